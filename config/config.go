@@ -0,0 +1,777 @@
+// package config は、アプリケーションの設定の読み込みと検証を提供します
+// 設定は既定値、設定ファイル（YAMLまたはTOML）、環境変数の順に上書きされ、起動時に一度だけ読み込みます
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/labstack/gommon/bytes"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultJWTSecret は、開発環境で使用するJWTの署名用の秘密鍵です
+// 本番環境でこの値のまま起動することはできません
+const DefaultJWTSecret = "your-secret-key-change-in-production"
+
+// minProductionJWTSecretLength は、本番環境で要求するJWTの秘密鍵の最小の長さです
+const minProductionJWTSecretLength = 32
+
+// 実行環境の種類です
+const (
+	EnvDevelopment = "development"
+	EnvTest        = "test"
+	EnvProduction  = "production"
+)
+
+// 設定ファイルのパスを指定する環境変数です
+const configFileEnv = "CONFIG_FILE"
+
+// Config は、アプリケーション全体の設定です
+type Config struct {
+	Env         string          `yaml:"env" toml:"env"`                   // 実行環境（development、test、production）
+	FrontendURL string          `yaml:"frontend_url" toml:"frontend_url"` // メール本文のリンク先となるフロントエンドのURL
+	Server      ServerConfig    `yaml:"server" toml:"server"`
+	Database    DatabaseConfig  `yaml:"database" toml:"database"`
+	Auth        AuthConfig      `yaml:"auth" toml:"auth"`
+	Account     AccountConfig   `yaml:"account" toml:"account"`
+	RateLimit   RateLimitConfig `yaml:"rate_limit" toml:"rate_limit"`
+	OIDC        OIDCConfig      `yaml:"oidc" toml:"oidc"`
+	Mail        MailConfig      `yaml:"mail" toml:"mail"`
+	Tracing     TracingConfig   `yaml:"tracing" toml:"tracing"`
+	Log         LogConfig       `yaml:"log" toml:"log"`
+}
+
+// ServerConfig は、HTTPサーバーの設定です
+type ServerConfig struct {
+	Port            int           `yaml:"port" toml:"port"`                         // 待ち受けるポート番号
+	ReadTimeout     time.Duration `yaml:"read_timeout" toml:"read_timeout"`         // リクエスト全体の読み込みのタイムアウト
+	WriteTimeout    time.Duration `yaml:"write_timeout" toml:"write_timeout"`       // レスポンスの書き込みのタイムアウト
+	IdleTimeout     time.Duration `yaml:"idle_timeout" toml:"idle_timeout"`         // Keep-Alive接続のアイドルタイムアウト
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"` // シャットダウン時に処理中のリクエストの完了を待つ時間
+	BodyLimit       string        `yaml:"body_limit" toml:"body_limit"`             // リクエストボディの最大サイズ（例: 1M）
+	TrustedProxies  []string      `yaml:"trusted_proxies" toml:"trusted_proxies"`   // X-Forwarded-Forを信頼するプロキシのアドレスの範囲（CIDR）。空の場合は接続元のアドレスをクライアントのIPアドレスとします
+}
+
+// DatabaseConfig は、データベースへの接続設定です
+type DatabaseConfig struct {
+	Host         string        `yaml:"host" toml:"host"`
+	Port         int           `yaml:"port" toml:"port"`
+	User         string        `yaml:"user" toml:"user"`
+	Password     string        `yaml:"password" toml:"password"`
+	Name         string        `yaml:"name" toml:"name"`
+	SSLMode      string        `yaml:"sslmode" toml:"sslmode"`
+	TimeZone     string        `yaml:"timezone" toml:"timezone"`
+	AutoMigrate  bool          `yaml:"auto_migrate" toml:"auto_migrate"`   // 起動時に未適用のマイグレーションを適用するかどうか
+	QueryTimeout time.Duration `yaml:"query_timeout" toml:"query_timeout"` // 1回のSQLの実行時間の上限
+}
+
+// AuthConfig は、認証の設定です
+type AuthConfig struct {
+	JWTSecret                string        `yaml:"jwt_secret" toml:"jwt_secret"`                                 // 2段階認証のチャレンジトークンの署名に使用する秘密鍵。jwt_signing_key_fileが空の場合はアクセストークンの署名にも使用します
+	JWTSigningKeyFile        string        `yaml:"jwt_signing_key_file" toml:"jwt_signing_key_file"`             // アクセストークンの署名に使用する秘密鍵（RSAまたはEd25519、PEM形式）のファイルのパス
+	JWTVerificationKeyFiles  []string      `yaml:"jwt_verification_key_files" toml:"jwt_verification_key_files"` // ローテーションのため検証にも使用し、JWKSで公開する鍵のファイルのパス
+	JWTIssuer                string        `yaml:"jwt_issuer" toml:"jwt_issuer"`                                 // アクセストークンのiss
+	JWTAudience              []string      `yaml:"jwt_audience" toml:"jwt_audience"`                             // アクセストークンのaud。先頭はこのAPI自身で、認証ミドルウェアで検証します
+	RequireEmailVerification bool          `yaml:"require_email_verification" toml:"require_email_verification"` // メールアドレスが未確認のユーザーのログインを拒否するかどうか
+	Lockout                  LockoutConfig `yaml:"lockout" toml:"lockout"`
+	MFAIssuer                string        `yaml:"mfa_issuer" toml:"mfa_issuer"`                 // 2段階認証の認証アプリに表示するサービス名
+	MFAEncryptionKey         string        `yaml:"mfa_encryption_key" toml:"mfa_encryption_key"` // 2段階認証の秘密鍵の暗号化に使用する秘密の値。空の場合はjwt_secretから導出します
+}
+
+// LockoutConfig は、パスワードの誤りが続いたアカウントを保護する設定です
+type LockoutConfig struct {
+	DelayAfter int           `yaml:"delay_after" toml:"delay_after"` // この回数連続で失敗すると、次の試行まで待機を求める
+	BaseDelay  time.Duration `yaml:"base_delay" toml:"base_delay"`   // 最初の待機時間。以降は失敗するたびに倍になります
+	MaxDelay   time.Duration `yaml:"max_delay" toml:"max_delay"`     // 待機時間の上限
+	Threshold  int           `yaml:"threshold" toml:"threshold"`     // この回数連続で失敗すると、アカウントをロックしてロック解除のメールを送信する
+	Duration   time.Duration `yaml:"duration" toml:"duration"`       // ロックする期間。この期間失敗がなければ失敗回数を数え直します
+}
+
+// AccountConfig は、アカウントの退会の設定です
+type AccountConfig struct {
+	DeletionGracePeriod time.Duration `yaml:"deletion_grace_period" toml:"deletion_grace_period"` // 退会後にログインで復元できる期間。経過後にデータを完全に削除します
+	PurgeInterval       time.Duration `yaml:"purge_interval" toml:"purge_interval"`               // 猶予期間を過ぎたユーザーと期限切れのトークンを削除する処理の実行間隔
+}
+
+// RateLimitConfig は、認証関連のエンドポイントの試行回数の制限の設定です
+type RateLimitConfig struct {
+	Backend string        `yaml:"backend" toml:"backend"` // memoryでインスタンスごとに記録、redisで複数のインスタンスで共有
+	Redis   RedisConfig   `yaml:"redis" toml:"redis"`
+	IP      RateLimitRule `yaml:"ip" toml:"ip"`       // IPアドレスとエンドポイントごとのリクエスト数
+	Login   RateLimitRule `yaml:"login" toml:"login"` // メールアドレスごとのログインの試行回数
+	Email   RateLimitRule `yaml:"email" toml:"email"` // メールアドレスごとのパスワードリセットと確認メールの送信回数
+}
+
+// RateLimitRule は、期間内に許可する回数です
+type RateLimitRule struct {
+	Limit  int           `yaml:"limit" toml:"limit"`   // 期間内に許可する回数。0の場合は制限しない
+	Window time.Duration `yaml:"window" toml:"window"` // 回数を数える期間
+}
+
+// RedisConfig は、Redis互換のサーバーへの接続設定です
+type RedisConfig struct {
+	Addr      string `yaml:"addr" toml:"addr"`         // サーバーのアドレス（例: localhost:6379）
+	Username  string `yaml:"username" toml:"username"` // ACLのユーザー名。空の場合はdefaultユーザーとして認証します
+	Password  string `yaml:"password" toml:"password"`
+	DB        int    `yaml:"db" toml:"db"`
+	TLS       bool   `yaml:"tls" toml:"tls"`                 // TLSで接続するかどうか
+	TLSCAFile string `yaml:"tls_ca_file" toml:"tls_ca_file"` // サーバーの証明書を検証するCA証明書（PEM形式）のファイルのパス。空の場合はシステムのCAを使用します
+}
+
+// 試行回数の記録先です
+const (
+	RateLimitBackendMemory = "memory"
+	RateLimitBackendRedis  = "redis"
+)
+
+// OIDCConfig は、外部のプロバイダーのアカウントでのログイン（OpenID Connect）の設定です
+// クライアントIDを設定したプロバイダーのみ有効になります
+type OIDCConfig struct {
+	RedirectURL string            `yaml:"redirect_url" toml:"redirect_url"` // 認可コードを受け取るURL。末尾にプロバイダー名を付けたURLをプロバイダーに登録します
+	Google      OIDCClientConfig  `yaml:"google" toml:"google"`
+	Apple       AppleOIDCConfig   `yaml:"apple" toml:"apple"`
+	Generic     GenericOIDCConfig `yaml:"generic" toml:"generic"` // ディスカバリーに対応した任意のプロバイダー
+}
+
+// OIDCClientConfig は、プロバイダーに登録したクライアントの設定です
+type OIDCClientConfig struct {
+	ClientID     string `yaml:"client_id" toml:"client_id"`
+	ClientSecret string `yaml:"client_secret" toml:"client_secret"`
+}
+
+// AppleOIDCConfig は、Sign in with Appleの設定です
+// client_secretは、Apple Developerで発行した秘密鍵で署名して作成します
+type AppleOIDCConfig struct {
+	ClientID       string `yaml:"client_id" toml:"client_id"`               // Services ID
+	TeamID         string `yaml:"team_id" toml:"team_id"`                   // Apple DeveloperのチームID
+	KeyID          string `yaml:"key_id" toml:"key_id"`                     // 秘密鍵のKey ID
+	PrivateKeyFile string `yaml:"private_key_file" toml:"private_key_file"` // 秘密鍵（.p8）のファイルのパス
+}
+
+// GenericOIDCConfig は、任意のOpenID Connectのプロバイダーの設定です
+type GenericOIDCConfig struct {
+	Name         string `yaml:"name" toml:"name"`     // URLとアカウントの連携に使用するプロバイダー名（例: keycloak）
+	Issuer       string `yaml:"issuer" toml:"issuer"` // プロバイダーのIssuer
+	ClientID     string `yaml:"client_id" toml:"client_id"`
+	ClientSecret string `yaml:"client_secret" toml:"client_secret"`
+}
+
+// 組み込みのプロバイダー名です
+const (
+	OIDCProviderGoogle = "google"
+	OIDCProviderApple  = "apple"
+)
+
+// oidcProviderNamePattern は、任意のプロバイダーの名前として使用できる文字列です
+var oidcProviderNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// MailConfig は、メール送信の設定です
+type MailConfig struct {
+	Mailer    string     `yaml:"mailer" toml:"mailer"`         // smtpでSMTP送信、outboxでファイルに書き出し
+	From      string     `yaml:"from" toml:"from"`             // 送信元メールアドレス
+	OutboxDir string     `yaml:"outbox_dir" toml:"outbox_dir"` // 書き出し先のディレクトリ（outbox使用時）
+	SMTP      SMTPConfig `yaml:"smtp" toml:"smtp"`
+}
+
+// SMTPConfig は、SMTPサーバーへの接続設定です
+type SMTPConfig struct {
+	Host     string `yaml:"host" toml:"host"`
+	Port     int    `yaml:"port" toml:"port"`
+	Username string `yaml:"username" toml:"username"` // 空の場合は認証しない
+	Password string `yaml:"password" toml:"password"`
+}
+
+// メール送信の方法です
+const (
+	MailerOutbox = "outbox"
+	MailerSMTP   = "smtp"
+)
+
+// TracingConfig は、OpenTelemetryによるトレースの設定です
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter" toml:"exporter"`         // noneで無効、otlpでOTLP/HTTP送信、stdoutで標準出力に書き出し
+	Endpoint    string  `yaml:"endpoint" toml:"endpoint"`         // OTLPの送信先（例: localhost:4318）。空の場合はOTEL_EXPORTER_OTLP_ENDPOINTまたは既定値を使用
+	Insecure    bool    `yaml:"insecure" toml:"insecure"`         // OTLPの送信にTLSを使用しないかどうか
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio"` // 記録するトレースの割合（0〜1）。上流でサンプリングされたトレースは常に記録します
+	ServiceName string  `yaml:"service_name" toml:"service_name"` // トレースに付与するサービス名
+}
+
+// トレースの送信方法です
+const (
+	TracingExporterNone   = "none"
+	TracingExporterOTLP   = "otlp"
+	TracingExporterStdout = "stdout"
+)
+
+// LogConfig は、ログ出力の設定です
+type LogConfig struct {
+	Level string `yaml:"level" toml:"level"` // 出力する最低のレベル（debug、info、warn、error）
+}
+
+// Default は、既定値の設定を返します
+func Default() Config {
+	return Config{
+		Env:         EnvDevelopment,
+		FrontendURL: "http://localhost:3000",
+		Server: ServerConfig{
+			Port:            8080,
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    30 * time.Second,
+			IdleTimeout:     60 * time.Second,
+			ShutdownTimeout: 20 * time.Second,
+			BodyLimit:       "1M",
+		},
+		Database: DatabaseConfig{
+			Host:         "localhost",
+			Port:         5432,
+			User:         "postgres",
+			Name:         "voice_link",
+			SSLMode:      "disable",
+			TimeZone:     "Asia/Tokyo",
+			AutoMigrate:  true,
+			QueryTimeout: 5 * time.Second,
+		},
+		Auth: AuthConfig{
+			JWTSecret:   DefaultJWTSecret,
+			JWTIssuer:   "voice-link",
+			JWTAudience: []string{"voice-link"},
+			MFAIssuer:   "Voice Link",
+			Lockout: LockoutConfig{
+				DelayAfter: 3,
+				BaseDelay:  time.Second,
+				MaxDelay:   time.Minute,
+				Threshold:  10,
+				Duration:   30 * time.Minute,
+			},
+		},
+		Account: AccountConfig{
+			DeletionGracePeriod: 30 * 24 * time.Hour,
+			PurgeInterval:       time.Hour,
+		},
+		RateLimit: RateLimitConfig{
+			Backend: RateLimitBackendMemory,
+			Redis: RedisConfig{
+				Addr: "localhost:6379",
+			},
+			IP:    RateLimitRule{Limit: 60, Window: time.Minute},
+			Login: RateLimitRule{Limit: 20, Window: 15 * time.Minute},
+			Email: RateLimitRule{Limit: 3, Window: time.Hour},
+		},
+		Mail: MailConfig{
+			Mailer:    MailerOutbox,
+			From:      "Voice Link <no-reply@voice-link.local>",
+			OutboxDir: "tmp/outbox",
+			SMTP: SMTPConfig{
+				Port: 587,
+			},
+		},
+		Tracing: TracingConfig{
+			Exporter:    TracingExporterNone,
+			SampleRatio: 1,
+			ServiceName: "voice-link",
+		},
+		Log: LogConfig{
+			Level: "info",
+		},
+	}
+}
+
+// Load は、既定値にCONFIG_FILE環境変数で指定された設定ファイルと環境変数を適用し、検証した設定を返します
+func Load() (*Config, error) {
+	return load(os.LookupEnv)
+}
+
+// load は、lookupEnvで環境変数を参照して設定を読み込みます
+func load(lookupEnv func(string) (string, bool)) (*Config, error) {
+	cfg := Default()
+
+	if path, ok := lookupEnv(configFileEnv); ok && path != "" {
+		if err := loadFile(path, &cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := applyEnv(&cfg, lookupEnv); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// loadFile は、拡張子に応じてYAMLまたはTOMLの設定ファイルを読み込みます
+// ファイルに記載されていない項目は現在の値を維持します
+func loadFile(path string, cfg *Config) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, cfg)
+	case ".toml":
+		err = toml.Unmarshal(content, cfg)
+	default:
+		return fmt.Errorf("unsupported config file format: %s (use .yaml, .yml or .toml)", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// applyEnv は、環境変数で指定された値で設定を上書きします
+// 値が空の環境変数は未設定として扱います
+func applyEnv(cfg *Config, lookupEnv func(string) (string, bool)) error {
+	e := envReader{lookup: lookupEnv}
+
+	e.string("APP_ENV", &cfg.Env)
+	e.string("FRONTEND_URL", &cfg.FrontendURL)
+
+	e.int("PORT", &cfg.Server.Port)
+	e.duration("SERVER_READ_TIMEOUT", &cfg.Server.ReadTimeout)
+	e.duration("SERVER_WRITE_TIMEOUT", &cfg.Server.WriteTimeout)
+	e.duration("SERVER_IDLE_TIMEOUT", &cfg.Server.IdleTimeout)
+	e.duration("SERVER_SHUTDOWN_TIMEOUT", &cfg.Server.ShutdownTimeout)
+	e.string("SERVER_BODY_LIMIT", &cfg.Server.BodyLimit)
+	e.list("SERVER_TRUSTED_PROXIES", &cfg.Server.TrustedProxies)
+
+	e.string("DB_HOST", &cfg.Database.Host)
+	e.int("DB_PORT", &cfg.Database.Port)
+	e.string("DB_USER", &cfg.Database.User)
+	e.string("DB_PASSWORD", &cfg.Database.Password)
+	e.string("DB_NAME", &cfg.Database.Name)
+	e.string("DB_SSLMODE", &cfg.Database.SSLMode)
+	e.string("DB_TIMEZONE", &cfg.Database.TimeZone)
+	e.bool("AUTO_MIGRATE", &cfg.Database.AutoMigrate)
+	e.duration("DB_QUERY_TIMEOUT", &cfg.Database.QueryTimeout)
+
+	e.string("JWT_SECRET", &cfg.Auth.JWTSecret)
+	e.string("JWT_SIGNING_KEY_FILE", &cfg.Auth.JWTSigningKeyFile)
+	e.list("JWT_VERIFICATION_KEY_FILES", &cfg.Auth.JWTVerificationKeyFiles)
+	e.string("JWT_ISSUER", &cfg.Auth.JWTIssuer)
+	e.list("JWT_AUDIENCE", &cfg.Auth.JWTAudience)
+	e.bool("REQUIRE_EMAIL_VERIFICATION", &cfg.Auth.RequireEmailVerification)
+	e.int("LOCKOUT_DELAY_AFTER", &cfg.Auth.Lockout.DelayAfter)
+	e.duration("LOCKOUT_BASE_DELAY", &cfg.Auth.Lockout.BaseDelay)
+	e.duration("LOCKOUT_MAX_DELAY", &cfg.Auth.Lockout.MaxDelay)
+	e.int("LOCKOUT_THRESHOLD", &cfg.Auth.Lockout.Threshold)
+	e.duration("LOCKOUT_DURATION", &cfg.Auth.Lockout.Duration)
+	e.string("MFA_ISSUER", &cfg.Auth.MFAIssuer)
+	e.string("MFA_ENCRYPTION_KEY", &cfg.Auth.MFAEncryptionKey)
+
+	e.duration("ACCOUNT_DELETION_GRACE_PERIOD", &cfg.Account.DeletionGracePeriod)
+	e.duration("ACCOUNT_PURGE_INTERVAL", &cfg.Account.PurgeInterval)
+
+	e.string("RATE_LIMIT_BACKEND", &cfg.RateLimit.Backend)
+	e.string("REDIS_ADDR", &cfg.RateLimit.Redis.Addr)
+	e.string("REDIS_USERNAME", &cfg.RateLimit.Redis.Username)
+	e.string("REDIS_PASSWORD", &cfg.RateLimit.Redis.Password)
+	e.int("REDIS_DB", &cfg.RateLimit.Redis.DB)
+	e.bool("REDIS_TLS", &cfg.RateLimit.Redis.TLS)
+	e.string("REDIS_TLS_CA_FILE", &cfg.RateLimit.Redis.TLSCAFile)
+	e.int("RATE_LIMIT_IP_LIMIT", &cfg.RateLimit.IP.Limit)
+	e.duration("RATE_LIMIT_IP_WINDOW", &cfg.RateLimit.IP.Window)
+	e.int("RATE_LIMIT_LOGIN_LIMIT", &cfg.RateLimit.Login.Limit)
+	e.duration("RATE_LIMIT_LOGIN_WINDOW", &cfg.RateLimit.Login.Window)
+	e.int("RATE_LIMIT_EMAIL_LIMIT", &cfg.RateLimit.Email.Limit)
+	e.duration("RATE_LIMIT_EMAIL_WINDOW", &cfg.RateLimit.Email.Window)
+
+	e.string("OIDC_REDIRECT_URL", &cfg.OIDC.RedirectURL)
+	e.string("OIDC_GOOGLE_CLIENT_ID", &cfg.OIDC.Google.ClientID)
+	e.string("OIDC_GOOGLE_CLIENT_SECRET", &cfg.OIDC.Google.ClientSecret)
+	e.string("OIDC_APPLE_CLIENT_ID", &cfg.OIDC.Apple.ClientID)
+	e.string("OIDC_APPLE_TEAM_ID", &cfg.OIDC.Apple.TeamID)
+	e.string("OIDC_APPLE_KEY_ID", &cfg.OIDC.Apple.KeyID)
+	e.string("OIDC_APPLE_PRIVATE_KEY_FILE", &cfg.OIDC.Apple.PrivateKeyFile)
+	e.string("OIDC_GENERIC_NAME", &cfg.OIDC.Generic.Name)
+	e.string("OIDC_GENERIC_ISSUER", &cfg.OIDC.Generic.Issuer)
+	e.string("OIDC_GENERIC_CLIENT_ID", &cfg.OIDC.Generic.ClientID)
+	e.string("OIDC_GENERIC_CLIENT_SECRET", &cfg.OIDC.Generic.ClientSecret)
+
+	e.string("MAILER", &cfg.Mail.Mailer)
+	e.string("MAIL_FROM", &cfg.Mail.From)
+	e.string("MAIL_OUTBOX_DIR", &cfg.Mail.OutboxDir)
+	e.string("SMTP_HOST", &cfg.Mail.SMTP.Host)
+	e.int("SMTP_PORT", &cfg.Mail.SMTP.Port)
+	e.string("SMTP_USERNAME", &cfg.Mail.SMTP.Username)
+	e.string("SMTP_PASSWORD", &cfg.Mail.SMTP.Password)
+
+	e.string("TRACING_EXPORTER", &cfg.Tracing.Exporter)
+	e.string("TRACING_ENDPOINT", &cfg.Tracing.Endpoint)
+	e.bool("TRACING_INSECURE", &cfg.Tracing.Insecure)
+	e.float("TRACING_SAMPLE_RATIO", &cfg.Tracing.SampleRatio)
+	e.string("TRACING_SERVICE_NAME", &cfg.Tracing.ServiceName)
+
+	e.string("LOG_LEVEL", &cfg.Log.Level)
+
+	return errors.Join(e.errs...)
+}
+
+// envReader は、環境変数を型に応じて変換し、変換できなかった変数のエラーを蓄積します
+type envReader struct {
+	lookup func(string) (string, bool)
+	errs   []error
+}
+
+func (e *envReader) value(key string) (string, bool) {
+	value, ok := e.lookup(key)
+	if !ok || value == "" {
+		return "", false
+	}
+	return value, true
+}
+
+func (e *envReader) string(key string, dst *string) {
+	if value, ok := e.value(key); ok {
+		*dst = value
+	}
+}
+
+// list は、カンマ区切りの値を前後の空白を除いて読み込みます
+func (e *envReader) list(key string, dst *[]string) {
+	value, ok := e.value(key)
+	if !ok {
+		return
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	*dst = items
+}
+
+func (e *envReader) int(key string, dst *int) {
+	value, ok := e.value(key)
+	if !ok {
+		return
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s must be an integer: %q", key, value))
+		return
+	}
+	*dst = n
+}
+
+func (e *envReader) bool(key string, dst *bool) {
+	value, ok := e.value(key)
+	if !ok {
+		return
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s must be true or false: %q", key, value))
+		return
+	}
+	*dst = b
+}
+
+func (e *envReader) float(key string, dst *float64) {
+	value, ok := e.value(key)
+	if !ok {
+		return
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s must be a number: %q", key, value))
+		return
+	}
+	*dst = f
+}
+
+func (e *envReader) duration(key string, dst *time.Duration) {
+	value, ok := e.value(key)
+	if !ok {
+		return
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s must be a duration such as 30s: %q", key, value))
+		return
+	}
+	*dst = d
+}
+
+// Validate は、設定値を検証し、問題のある項目をすべてまとめたエラーを返します
+func (c *Config) Validate() error {
+	var errs []error
+	addErr := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	switch c.Env {
+	case EnvDevelopment, EnvTest, EnvProduction:
+	default:
+		addErr("env must be one of %s, %s, %s: %q", EnvDevelopment, EnvTest, EnvProduction, c.Env)
+	}
+
+	if !isAbsoluteHTTPURL(c.FrontendURL) {
+		addErr("frontend_url must be an absolute http(s) URL: %q", c.FrontendURL)
+	}
+
+	if !isValidPort(c.Server.Port) {
+		addErr("server.port must be between 1 and 65535: %d", c.Server.Port)
+	}
+	for _, timeout := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"server.read_timeout", c.Server.ReadTimeout},
+		{"server.write_timeout", c.Server.WriteTimeout},
+		{"server.idle_timeout", c.Server.IdleTimeout},
+		{"server.shutdown_timeout", c.Server.ShutdownTimeout},
+		{"database.query_timeout", c.Database.QueryTimeout},
+		{"account.deletion_grace_period", c.Account.DeletionGracePeriod},
+		{"account.purge_interval", c.Account.PurgeInterval},
+		{"auth.lockout.base_delay", c.Auth.Lockout.BaseDelay},
+		{"auth.lockout.max_delay", c.Auth.Lockout.MaxDelay},
+		{"auth.lockout.duration", c.Auth.Lockout.Duration},
+	} {
+		if timeout.value <= 0 {
+			addErr("%s must be positive: %s", timeout.name, timeout.value)
+		}
+	}
+	if limit, err := bytes.Parse(c.Server.BodyLimit); err != nil || limit <= 0 {
+		addErr("server.body_limit must be a size such as 1M: %q", c.Server.BodyLimit)
+	}
+	if _, err := c.Server.TrustedProxyNetworks(); err != nil {
+		addErr("server.trusted_proxies %v", err)
+	}
+
+	if c.Database.Host == "" {
+		addErr("database.host is required")
+	}
+	if !isValidPort(c.Database.Port) {
+		addErr("database.port must be between 1 and 65535: %d", c.Database.Port)
+	}
+	if c.Database.User == "" {
+		addErr("database.user is required")
+	}
+	if c.Database.Name == "" {
+		addErr("database.name is required")
+	}
+	switch c.Database.SSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		addErr("database.sslmode is not a valid PostgreSQL sslmode: %q", c.Database.SSLMode)
+	}
+	if _, err := time.LoadLocation(c.Database.TimeZone); err != nil || c.Database.TimeZone == "" {
+		addErr("database.timezone is not a valid time zone: %q", c.Database.TimeZone)
+	}
+
+	if c.Auth.JWTSecret == "" {
+		addErr("auth.jwt_secret is required")
+	}
+	if c.IsProduction() {
+		// 既定の秘密鍵はリポジトリで公開されているため、本番環境では使用できない
+		if c.UsesDefaultJWTSecret() {
+			addErr("auth.jwt_secret must be changed from the default value in production")
+		} else if len(c.Auth.JWTSecret) < minProductionJWTSecretLength {
+			addErr("auth.jwt_secret must be at least %d characters in production", minProductionJWTSecretLength)
+		}
+	}
+
+	if len(c.Auth.JWTVerificationKeyFiles) > 0 && c.Auth.JWTSigningKeyFile == "" {
+		addErr("auth.jwt_verification_key_files requires auth.jwt_signing_key_file")
+	}
+	if c.Auth.JWTIssuer == "" {
+		addErr("auth.jwt_issuer is required")
+	}
+	if len(c.Auth.JWTAudience) == 0 {
+		addErr("auth.jwt_audience is required")
+	}
+	for _, audience := range c.Auth.JWTAudience {
+		if audience == "" {
+			addErr("auth.jwt_audience must not contain an empty value")
+			break
+		}
+	}
+
+	if c.Auth.MFAIssuer == "" {
+		addErr("auth.mfa_issuer is required")
+	}
+	if c.IsProduction() && c.Auth.MFAEncryptionKey != "" && len(c.Auth.MFAEncryptionKey) < minProductionJWTSecretLength {
+		addErr("auth.mfa_encryption_key must be at least %d characters in production", minProductionJWTSecretLength)
+	}
+	if c.Auth.Lockout.DelayAfter < 1 {
+		addErr("auth.lockout.delay_after must be at least 1: %d", c.Auth.Lockout.DelayAfter)
+	}
+	if c.Auth.Lockout.Threshold < 1 {
+		addErr("auth.lockout.threshold must be at least 1: %d", c.Auth.Lockout.Threshold)
+	}
+	if c.Auth.Lockout.MaxDelay < c.Auth.Lockout.BaseDelay {
+		addErr("auth.lockout.max_delay must not be less than auth.lockout.base_delay: %s", c.Auth.Lockout.MaxDelay)
+	}
+
+	switch c.RateLimit.Backend {
+	case RateLimitBackendMemory:
+	case RateLimitBackendRedis:
+		if c.RateLimit.Redis.Addr == "" {
+			addErr("rate_limit.redis.addr is required when rate_limit.backend is %s", RateLimitBackendRedis)
+		}
+		if c.RateLimit.Redis.DB < 0 {
+			addErr("rate_limit.redis.db must not be negative: %d", c.RateLimit.Redis.DB)
+		}
+		if c.RateLimit.Redis.TLSCAFile != "" && !c.RateLimit.Redis.TLS {
+			addErr("rate_limit.redis.tls_ca_file requires rate_limit.redis.tls")
+		}
+	default:
+		addErr("rate_limit.backend must be %s or %s: %q", RateLimitBackendMemory, RateLimitBackendRedis, c.RateLimit.Backend)
+	}
+	for _, rule := range []struct {
+		name  string
+		value RateLimitRule
+	}{
+		{"rate_limit.ip", c.RateLimit.IP},
+		{"rate_limit.login", c.RateLimit.Login},
+		{"rate_limit.email", c.RateLimit.Email},
+	} {
+		if rule.value.Limit < 0 {
+			addErr("%s.limit must not be negative: %d", rule.name, rule.value.Limit)
+		}
+		if rule.value.Limit > 0 && rule.value.Window <= 0 {
+			addErr("%s.window must be positive: %s", rule.name, rule.value.Window)
+		}
+	}
+
+	if c.OIDC.Enabled() {
+		if !isAbsoluteHTTPURL(c.OIDC.RedirectURL) {
+			addErr("oidc.redirect_url must be an absolute http(s) URL when a provider is configured: %q", c.OIDC.RedirectURL)
+		}
+	}
+	if c.OIDC.Google.ClientID != "" && c.OIDC.Google.ClientSecret == "" {
+		addErr("oidc.google.client_secret is required when oidc.google.client_id is set")
+	}
+	if c.OIDC.Apple.ClientID != "" {
+		if c.OIDC.Apple.TeamID == "" {
+			addErr("oidc.apple.team_id is required when oidc.apple.client_id is set")
+		}
+		if c.OIDC.Apple.KeyID == "" {
+			addErr("oidc.apple.key_id is required when oidc.apple.client_id is set")
+		}
+		if c.OIDC.Apple.PrivateKeyFile == "" {
+			addErr("oidc.apple.private_key_file is required when oidc.apple.client_id is set")
+		}
+	}
+	if c.OIDC.Generic.ClientID != "" {
+		switch name := c.OIDC.Generic.Name; {
+		case name == OIDCProviderGoogle || name == OIDCProviderApple:
+			addErr("oidc.generic.name must not be a built-in provider name: %q", name)
+		case !oidcProviderNamePattern.MatchString(name):
+			addErr("oidc.generic.name must consist of lowercase letters, digits and hyphens: %q", name)
+		}
+		if !isAbsoluteHTTPURL(c.OIDC.Generic.Issuer) {
+			addErr("oidc.generic.issuer must be an absolute http(s) URL: %q", c.OIDC.Generic.Issuer)
+		}
+	}
+
+	switch c.Mail.Mailer {
+	case MailerOutbox:
+		if c.Mail.OutboxDir == "" {
+			addErr("mail.outbox_dir is required when mail.mailer is %s", MailerOutbox)
+		}
+	case MailerSMTP:
+		if c.Mail.SMTP.Host == "" {
+			addErr("mail.smtp.host is required when mail.mailer is %s", MailerSMTP)
+		}
+		if !isValidPort(c.Mail.SMTP.Port) {
+			addErr("mail.smtp.port must be between 1 and 65535: %d", c.Mail.SMTP.Port)
+		}
+	default:
+		addErr("mail.mailer must be %s or %s: %q", MailerOutbox, MailerSMTP, c.Mail.Mailer)
+	}
+	if c.Mail.From == "" {
+		addErr("mail.from is required")
+	}
+
+	switch c.Tracing.Exporter {
+	case TracingExporterNone, TracingExporterOTLP, TracingExporterStdout:
+	default:
+		addErr("tracing.exporter must be one of %s, %s, %s: %q", TracingExporterNone, TracingExporterOTLP, TracingExporterStdout, c.Tracing.Exporter)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		addErr("tracing.sample_ratio must be between 0 and 1: %v", c.Tracing.SampleRatio)
+	}
+	if c.Tracing.Exporter != TracingExporterNone && c.Tracing.ServiceName == "" {
+		addErr("tracing.service_name is required when tracing is enabled")
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		addErr("log.level must be one of debug, info, warn, error: %q", c.Log.Level)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// IsProduction は、本番環境かどうかを判定します
+func (c *Config) IsProduction() bool {
+	return c.Env == EnvProduction
+}
+
+// UsesDefaultJWTSecret は、JWTの秘密鍵が既定値のままかどうかを判定します
+func (c *Config) UsesDefaultJWTSecret() bool {
+	return c.Auth.JWTSecret == DefaultJWTSecret
+}
+
+// DSN は、PostgreSQLへの接続文字列を返します
+func (c DatabaseConfig) DSN() string {
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=%s TimeZone=%s",
+		c.Host, c.User, c.Password, c.Name, c.Port, c.SSLMode, c.TimeZone)
+}
+
+// TrustedProxyNetworks は、X-Forwarded-Forを信頼するプロキシのアドレスの範囲を返します
+func (c ServerConfig) TrustedProxyNetworks() ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(c.TrustedProxies))
+	for _, cidr := range c.TrustedProxies {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("must be CIDR ranges such as 10.0.0.0/8: %q", cidr)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// Enabled は、いずれかのプロバイダーが設定されているかどうかを判定します
+func (c OIDCConfig) Enabled() bool {
+	return c.Google.ClientID != "" || c.Apple.ClientID != "" || c.Generic.ClientID != ""
+}
+
+// ProviderRedirectURL は、プロバイダーに登録するリダイレクト先のURLを返します
+func (c OIDCConfig) ProviderRedirectURL(provider string) string {
+	return strings.TrimSuffix(c.RedirectURL, "/") + "/" + provider
+}
+
+// isAbsoluteHTTPURL は、ホストを含むhttpまたはhttpsのURLかどうかを判定します
+func isAbsoluteHTTPURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// isValidPort は、TCPのポート番号として有効かどうかを判定します
+func isValidPort(port int) bool {
+	return port >= 1 && port <= 65535
+}
@@ -0,0 +1,450 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// envMap は、テスト用の環境変数を返すlookupEnvを作成します
+func envMap(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+}
+
+// writeConfigFile は、一時ディレクトリに設定ファイルを作成してパスを返します
+func writeConfigFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	cfg, err := load(envMap(nil))
+
+	assert.NoError(t, err)
+	assert.Equal(t, EnvDevelopment, cfg.Env)
+	assert.Equal(t, 8080, cfg.Server.Port)
+	assert.True(t, cfg.Database.AutoMigrate)
+	assert.True(t, cfg.UsesDefaultJWTSecret())
+	assert.Equal(t, "host=localhost user=postgres password= dbname=voice_link port=5432 sslmode=disable TimeZone=Asia/Tokyo", cfg.Database.DSN())
+}
+
+func TestLoad_Env(t *testing.T) {
+	cfg, err := load(envMap(map[string]string{
+		"PORT":                          "9090",
+		"SERVER_WRITE_TIMEOUT":          "1m",
+		"SERVER_BODY_LIMIT":             "512K",
+		"SERVER_TRUSTED_PROXIES":        "10.0.0.0/8, 192.168.0.0/16",
+		"DB_HOST":                       "db",
+		"DB_SSLMODE":                    "require",
+		"DB_TIMEZONE":                   "UTC",
+		"AUTO_MIGRATE":                  "false",
+		"DB_QUERY_TIMEOUT":              "2s",
+		"JWT_SECRET":                    "env-secret",
+		"JWT_SIGNING_KEY_FILE":          "/run/secrets/jwt-2.pem",
+		"JWT_VERIFICATION_KEY_FILES":    "/run/secrets/jwt-1.pem",
+		"JWT_AUDIENCE":                  "voice-link, media-relay",
+		"REQUIRE_EMAIL_VERIFICATION":    "true",
+		"LOCKOUT_THRESHOLD":             "5",
+		"LOCKOUT_DURATION":              "1h",
+		"MFA_ISSUER":                    "Voice Link (staging)",
+		"MFA_ENCRYPTION_KEY":            "env-mfa-key",
+		"ACCOUNT_DELETION_GRACE_PERIOD": "168h",
+		"ACCOUNT_PURGE_INTERVAL":        "10m",
+		"RATE_LIMIT_BACKEND":            "redis",
+		"REDIS_ADDR":                    "redis:6379",
+		"REDIS_DB":                      "2",
+		"REDIS_USERNAME":                "limiter",
+		"REDIS_TLS":                     "true",
+		"RATE_LIMIT_LOGIN_LIMIT":        "5",
+		"RATE_LIMIT_LOGIN_WINDOW":       "5m",
+		"OIDC_REDIRECT_URL":             "https://app.example.com/oauth/callback/",
+		"OIDC_GOOGLE_CLIENT_ID":         "google-client",
+		"OIDC_GOOGLE_CLIENT_SECRET":     "google-secret",
+		"OIDC_GENERIC_NAME":             "keycloak",
+		"OIDC_GENERIC_ISSUER":           "https://sso.example.com/realms/main",
+		"OIDC_GENERIC_CLIENT_ID":        "voice-link",
+		"MAILER":                        "smtp",
+		"SMTP_HOST":                     "smtp.example.com",
+		"SMTP_PORT":                     "",
+		"TRACING_EXPORTER":              "otlp",
+		"TRACING_ENDPOINT":              "collector:4318",
+		"TRACING_SAMPLE_RATIO":          "0.25",
+		"LOG_LEVEL":                     "debug",
+	}))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 9090, cfg.Server.Port)
+	assert.Equal(t, time.Minute, cfg.Server.WriteTimeout)
+	assert.Equal(t, "512K", cfg.Server.BodyLimit)
+	assert.Equal(t, []string{"10.0.0.0/8", "192.168.0.0/16"}, cfg.Server.TrustedProxies)
+	assert.Equal(t, "db", cfg.Database.Host)
+	assert.Equal(t, "require", cfg.Database.SSLMode)
+	assert.Equal(t, "UTC", cfg.Database.TimeZone)
+	assert.False(t, cfg.Database.AutoMigrate)
+	assert.Equal(t, 2*time.Second, cfg.Database.QueryTimeout)
+	assert.Equal(t, "env-secret", cfg.Auth.JWTSecret)
+	assert.Equal(t, "/run/secrets/jwt-2.pem", cfg.Auth.JWTSigningKeyFile)
+	assert.Equal(t, []string{"/run/secrets/jwt-1.pem"}, cfg.Auth.JWTVerificationKeyFiles)
+	assert.Equal(t, "voice-link", cfg.Auth.JWTIssuer)
+	assert.Equal(t, []string{"voice-link", "media-relay"}, cfg.Auth.JWTAudience)
+	assert.True(t, cfg.Auth.RequireEmailVerification)
+	assert.Equal(t, 5, cfg.Auth.Lockout.Threshold)
+	assert.Equal(t, time.Hour, cfg.Auth.Lockout.Duration)
+	assert.Equal(t, 3, cfg.Auth.Lockout.DelayAfter)
+	assert.Equal(t, "Voice Link (staging)", cfg.Auth.MFAIssuer)
+	assert.Equal(t, "env-mfa-key", cfg.Auth.MFAEncryptionKey)
+	assert.Equal(t, 7*24*time.Hour, cfg.Account.DeletionGracePeriod)
+	assert.Equal(t, 10*time.Minute, cfg.Account.PurgeInterval)
+	assert.Equal(t, RateLimitBackendRedis, cfg.RateLimit.Backend)
+	assert.Equal(t, RedisConfig{Addr: "redis:6379", Username: "limiter", DB: 2, TLS: true}, cfg.RateLimit.Redis)
+	assert.Equal(t, RateLimitRule{Limit: 5, Window: 5 * time.Minute}, cfg.RateLimit.Login)
+	assert.Equal(t, Default().RateLimit.IP, cfg.RateLimit.IP)
+	assert.True(t, cfg.OIDC.Enabled())
+	assert.Equal(t, OIDCClientConfig{ClientID: "google-client", ClientSecret: "google-secret"}, cfg.OIDC.Google)
+	assert.Equal(t, GenericOIDCConfig{Name: "keycloak", Issuer: "https://sso.example.com/realms/main", ClientID: "voice-link"}, cfg.OIDC.Generic)
+	assert.Equal(t, "https://app.example.com/oauth/callback/google", cfg.OIDC.ProviderRedirectURL(OIDCProviderGoogle))
+	assert.Equal(t, MailerSMTP, cfg.Mail.Mailer)
+	assert.Equal(t, "smtp.example.com", cfg.Mail.SMTP.Host)
+	// 空の環境変数は未設定として既定値を維持する
+	assert.Equal(t, 587, cfg.Mail.SMTP.Port)
+	assert.Equal(t, TracingExporterOTLP, cfg.Tracing.Exporter)
+	assert.Equal(t, "collector:4318", cfg.Tracing.Endpoint)
+	assert.Equal(t, 0.25, cfg.Tracing.SampleRatio)
+	assert.Equal(t, "debug", cfg.Log.Level)
+}
+
+func TestLoad_File(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{
+			name: "YAML",
+			file: "config.yaml",
+			content: `
+frontend_url: https://app.example.com
+server:
+  port: 9000
+  shutdown_timeout: 45s
+database:
+  host: db.internal
+auth:
+  jwt_secret: file-secret
+`,
+		},
+		{
+			name: "TOML",
+			file: "config.toml",
+			content: `
+frontend_url = "https://app.example.com"
+
+[server]
+port = 9000
+shutdown_timeout = "45s"
+
+[database]
+host = "db.internal"
+
+[auth]
+jwt_secret = "file-secret"
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfigFile(t, tt.file, tt.content)
+
+			cfg, err := load(envMap(map[string]string{
+				"CONFIG_FILE": path,
+				// 環境変数は設定ファイルより優先される
+				"JWT_SECRET": "env-secret",
+			}))
+
+			assert.NoError(t, err)
+			assert.Equal(t, "https://app.example.com", cfg.FrontendURL)
+			assert.Equal(t, 9000, cfg.Server.Port)
+			assert.Equal(t, 45*time.Second, cfg.Server.ShutdownTimeout)
+			assert.Equal(t, "db.internal", cfg.Database.Host)
+			assert.Equal(t, "env-secret", cfg.Auth.JWTSecret)
+			// ファイルに記載されていない項目は既定値を維持する
+			assert.Equal(t, 5432, cfg.Database.Port)
+			assert.Equal(t, "postgres", cfg.Database.User)
+		})
+	}
+}
+
+func TestLoad_FileErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		path func(t *testing.T) string
+	}{
+		{
+			name: "存在しないファイル",
+			path: func(t *testing.T) string { return filepath.Join(t.TempDir(), "missing.yaml") },
+		},
+		{
+			name: "対応していない形式",
+			path: func(t *testing.T) string { return writeConfigFile(t, "config.json", "{}") },
+		},
+		{
+			name: "不正なYAML",
+			path: func(t *testing.T) string { return writeConfigFile(t, "config.yaml", "server: [") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := load(envMap(map[string]string{"CONFIG_FILE": tt.path(t)}))
+
+			assert.Error(t, err)
+			assert.Nil(t, cfg)
+		})
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := []struct {
+		name          string
+		env           map[string]string
+		expectedError string
+	}{
+		{
+			name:          "数値でないポート番号",
+			env:           map[string]string{"PORT": "http"},
+			expectedError: `PORT must be an integer: "http"`,
+		},
+		{
+			name:          "真偽値でない値",
+			env:           map[string]string{"AUTO_MIGRATE": "sometimes"},
+			expectedError: `AUTO_MIGRATE must be true or false: "sometimes"`,
+		},
+		{
+			name:          "不正な時間の指定",
+			env:           map[string]string{"SERVER_READ_TIMEOUT": "10"},
+			expectedError: `SERVER_READ_TIMEOUT must be a duration such as 30s: "10"`,
+		},
+		{
+			name:          "負のタイムアウト",
+			env:           map[string]string{"SERVER_IDLE_TIMEOUT": "-1s"},
+			expectedError: "server.idle_timeout must be positive: -1s",
+		},
+		{
+			name:          "SQLのタイムアウトなし",
+			env:           map[string]string{"DB_QUERY_TIMEOUT": "0s"},
+			expectedError: "database.query_timeout must be positive: 0s",
+		},
+		{
+			name:          "退会の猶予期間なし",
+			env:           map[string]string{"ACCOUNT_DELETION_GRACE_PERIOD": "0s"},
+			expectedError: "account.deletion_grace_period must be positive: 0s",
+		},
+		{
+			name:          "不正なリクエストボディの上限",
+			env:           map[string]string{"SERVER_BODY_LIMIT": "lots"},
+			expectedError: `server.body_limit must be a size such as 1M: "lots"`,
+		},
+		{
+			name:          "CIDRでない信頼するプロキシ",
+			env:           map[string]string{"SERVER_TRUSTED_PROXIES": "10.0.0.1"},
+			expectedError: `server.trusted_proxies must be CIDR ranges such as 10.0.0.0/8: "10.0.0.1"`,
+		},
+		{
+			name:          "ロックしない失敗回数",
+			env:           map[string]string{"LOCKOUT_THRESHOLD": "0"},
+			expectedError: "auth.lockout.threshold must be at least 1: 0",
+		},
+		{
+			name:          "最初の待機時間より短い待機時間の上限",
+			env:           map[string]string{"LOCKOUT_BASE_DELAY": "10s", "LOCKOUT_MAX_DELAY": "5s"},
+			expectedError: "auth.lockout.max_delay must not be less than auth.lockout.base_delay: 5s",
+		},
+		{
+			name:          "不明な試行回数の記録先",
+			env:           map[string]string{"RATE_LIMIT_BACKEND": "memcached"},
+			expectedError: `rate_limit.backend must be memory or redis: "memcached"`,
+		},
+		{
+			name:          "TLSを使用しないCA証明書の指定",
+			env:           map[string]string{"RATE_LIMIT_BACKEND": "redis", "REDIS_TLS_CA_FILE": "/run/secrets/redis-ca.pem"},
+			expectedError: "rate_limit.redis.tls_ca_file requires rate_limit.redis.tls",
+		},
+		{
+			name:          "負の試行回数の上限",
+			env:           map[string]string{"RATE_LIMIT_EMAIL_LIMIT": "-1"},
+			expectedError: "rate_limit.email.limit must not be negative: -1",
+		},
+		{
+			name:          "試行回数を数える期間なし",
+			env:           map[string]string{"RATE_LIMIT_IP_WINDOW": "0s"},
+			expectedError: "rate_limit.ip.window must be positive: 0s",
+		},
+		{
+			name:          "範囲外のポート番号",
+			env:           map[string]string{"PORT": "70000"},
+			expectedError: "server.port must be between 1 and 65535: 70000",
+		},
+		{
+			name:          "不明な実行環境",
+			env:           map[string]string{"APP_ENV": "staging"},
+			expectedError: `env must be one of development, test, production: "staging"`,
+		},
+		{
+			name:          "不正なフロントエンドのURL",
+			env:           map[string]string{"FRONTEND_URL": "localhost:3000"},
+			expectedError: `frontend_url must be an absolute http(s) URL: "localhost:3000"`,
+		},
+		{
+			name:          "不正なsslmode",
+			env:           map[string]string{"DB_SSLMODE": "on"},
+			expectedError: `database.sslmode is not a valid PostgreSQL sslmode: "on"`,
+		},
+		{
+			name:          "署名の鍵なしで検証用の鍵を指定",
+			env:           map[string]string{"JWT_VERIFICATION_KEY_FILES": "/run/secrets/jwt-1.pem"},
+			expectedError: "auth.jwt_verification_key_files requires auth.jwt_signing_key_file",
+		},
+		{
+			name:          "audの指定なし",
+			env:           map[string]string{"JWT_AUDIENCE": ","},
+			expectedError: "auth.jwt_audience is required",
+		},
+		{
+			name:          "プロバイダーを設定してリダイレクト先なし",
+			env:           map[string]string{"OIDC_GOOGLE_CLIENT_ID": "google-client", "OIDC_GOOGLE_CLIENT_SECRET": "google-secret"},
+			expectedError: `oidc.redirect_url must be an absolute http(s) URL when a provider is configured: ""`,
+		},
+		{
+			name:          "Googleのクライアントシークレットなし",
+			env:           map[string]string{"OIDC_REDIRECT_URL": "https://app.example.com/callback", "OIDC_GOOGLE_CLIENT_ID": "google-client"},
+			expectedError: "oidc.google.client_secret is required when oidc.google.client_id is set",
+		},
+		{
+			name:          "Appleの秘密鍵なし",
+			env:           map[string]string{"OIDC_REDIRECT_URL": "https://app.example.com/callback", "OIDC_APPLE_CLIENT_ID": "com.example.web", "OIDC_APPLE_TEAM_ID": "TEAM", "OIDC_APPLE_KEY_ID": "KEY"},
+			expectedError: "oidc.apple.private_key_file is required when oidc.apple.client_id is set",
+		},
+		{
+			name:          "組み込みのプロバイダーと同じ名前",
+			env:           map[string]string{"OIDC_REDIRECT_URL": "https://app.example.com/callback", "OIDC_GENERIC_NAME": "google", "OIDC_GENERIC_ISSUER": "https://sso.example.com", "OIDC_GENERIC_CLIENT_ID": "voice-link"},
+			expectedError: `oidc.generic.name must not be a built-in provider name: "google"`,
+		},
+		{
+			name:          "URLに使用できないプロバイダー名",
+			env:           map[string]string{"OIDC_REDIRECT_URL": "https://app.example.com/callback", "OIDC_GENERIC_NAME": "My SSO", "OIDC_GENERIC_ISSUER": "https://sso.example.com", "OIDC_GENERIC_CLIENT_ID": "voice-link"},
+			expectedError: `oidc.generic.name must consist of lowercase letters, digits and hyphens: "My SSO"`,
+		},
+		{
+			name:          "任意のプロバイダーのIssuerなし",
+			env:           map[string]string{"OIDC_REDIRECT_URL": "https://app.example.com/callback", "OIDC_GENERIC_NAME": "keycloak", "OIDC_GENERIC_CLIENT_ID": "voice-link"},
+			expectedError: `oidc.generic.issuer must be an absolute http(s) URL: ""`,
+		},
+		{
+			name:          "SMTPサーバーの指定なし",
+			env:           map[string]string{"MAILER": "smtp"},
+			expectedError: "mail.smtp.host is required when mail.mailer is smtp",
+		},
+		{
+			name:          "不明なメール送信方法",
+			env:           map[string]string{"MAILER": "sendgrid"},
+			expectedError: `mail.mailer must be outbox or smtp: "sendgrid"`,
+		},
+		{
+			name:          "数値でないサンプリング率",
+			env:           map[string]string{"TRACING_SAMPLE_RATIO": "half"},
+			expectedError: `TRACING_SAMPLE_RATIO must be a number: "half"`,
+		},
+		{
+			name:          "範囲外のサンプリング率",
+			env:           map[string]string{"TRACING_SAMPLE_RATIO": "1.5"},
+			expectedError: "tracing.sample_ratio must be between 0 and 1: 1.5",
+		},
+		{
+			name:          "不明なトレースの送信方法",
+			env:           map[string]string{"TRACING_EXPORTER": "jaeger"},
+			expectedError: `tracing.exporter must be one of none, otlp, stdout: "jaeger"`,
+		},
+		{
+			name:          "不明なログレベル",
+			env:           map[string]string{"LOG_LEVEL": "verbose"},
+			expectedError: `log.level must be one of debug, info, warn, error: "verbose"`,
+		},
+		{
+			name:          "本番環境で既定の秘密鍵",
+			env:           map[string]string{"APP_ENV": "production"},
+			expectedError: "auth.jwt_secret must be changed from the default value in production",
+		},
+		{
+			name:          "本番環境で短い秘密鍵",
+			env:           map[string]string{"APP_ENV": "production", "JWT_SECRET": "short"},
+			expectedError: "auth.jwt_secret must be at least 32 characters in production",
+		},
+		{
+			name:          "本番環境で短い2段階認証の暗号化の鍵",
+			env:           map[string]string{"APP_ENV": "production", "JWT_SECRET": "0123456789abcdef0123456789abcdef", "MFA_ENCRYPTION_KEY": "short"},
+			expectedError: "auth.mfa_encryption_key must be at least 32 characters in production",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := load(envMap(tt.env))
+
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedError)
+			assert.Nil(t, cfg)
+		})
+	}
+}
+
+func TestLoad_Production(t *testing.T) {
+	cfg, err := load(envMap(map[string]string{
+		"APP_ENV":    "production",
+		"JWT_SECRET": "0123456789abcdef0123456789abcdef",
+	}))
+
+	assert.NoError(t, err)
+	assert.True(t, cfg.IsProduction())
+	assert.False(t, cfg.UsesDefaultJWTSecret())
+}
+
+func TestValidate_ReportsAllErrors(t *testing.T) {
+	cfg := Default()
+	cfg.Server.Port = 0
+	cfg.Database.Host = ""
+	cfg.Mail.From = ""
+	cfg.RateLimit.Backend = RateLimitBackendRedis
+	cfg.RateLimit.Redis.Addr = ""
+	cfg.Auth.MFAIssuer = ""
+
+	err := cfg.Validate()
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "server.port must be between 1 and 65535: 0")
+	assert.Contains(t, err.Error(), "database.host is required")
+	assert.Contains(t, err.Error(), "mail.from is required")
+	assert.Contains(t, err.Error(), "rate_limit.redis.addr is required when rate_limit.backend is redis")
+	assert.Contains(t, err.Error(), "auth.mfa_issuer is required")
+}
+
+func TestLoad_ExampleFile(t *testing.T) {
+	// リポジトリに含まれる設定ファイルの例が読み込めること
+	cfg, err := load(envMap(map[string]string{"CONFIG_FILE": "../config.example.yaml"}))
+
+	assert.NoError(t, err)
+	assert.Equal(t, Default().Mail, cfg.Mail)
+	assert.Equal(t, Default().Auth.Lockout, cfg.Auth.Lockout)
+	assert.Equal(t, Default().Auth.MFAIssuer, cfg.Auth.MFAIssuer)
+	assert.Equal(t, Default().Auth.JWTIssuer, cfg.Auth.JWTIssuer)
+	assert.Equal(t, Default().Auth.JWTAudience, cfg.Auth.JWTAudience)
+	assert.Equal(t, Default().RateLimit, cfg.RateLimit)
+	assert.Equal(t, Default().OIDC, cfg.OIDC)
+}
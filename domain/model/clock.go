@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// Clock は、現在時刻の取得元です
+// テストで時刻を進められるよう、時刻に依存する判定にはtime.Nowの代わりにClockを注入します
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock は、システムの時刻を返すClockです
+type systemClock struct{}
+
+// Now は、time.Now()を返します
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// SystemClock は、システムの時刻を返すClockです
+var SystemClock Clock = systemClock{}
@@ -0,0 +1,11 @@
+package model
+
+import "errors"
+
+// ErrNotFound は、リポジトリで対象のレコードが見つからなかった場合に返されるエラーです
+// 永続化の実装に依存せずに判定できるよう、各リポジトリはこのエラーに変換して返します
+var ErrNotFound = errors.New("record not found")
+
+// ErrDuplicateEmail は、他のユーザーが使用しているメールアドレスでユーザーを保存しようとした場合に返されるエラーです
+// 同時に同じメールアドレスで登録された場合など、事前の重複チェックをすり抜けた一意制約違反を表します
+var ErrDuplicateEmail = errors.New("duplicate email")
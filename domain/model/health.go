@@ -0,0 +1,17 @@
+package model
+
+import "context"
+
+// HealthChecker は、データベースやメールサーバーなどの依存先が利用可能かどうかを確認します
+// 利用できない場合は原因を表すエラーを返します
+type HealthChecker interface {
+	Check(ctx context.Context) error
+}
+
+// HealthCheckerFunc は、関数をHealthCheckerとして使用するためのアダプターです
+type HealthCheckerFunc func(ctx context.Context) error
+
+// Check は、f(ctx)を呼び出します
+func (f HealthCheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
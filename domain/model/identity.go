@@ -0,0 +1,89 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrDuplicateIdentity は、既に他のユーザーに連携されている外部アカウント、または連携済みのプロバイダーのアカウントを連携しようとした場合に返されるエラーです
+var ErrDuplicateIdentity = errors.New("duplicate identity")
+
+// ErrOIDCRejected は、認可コードやIDトークンがプロバイダーに受け付けられなかった、または検証に失敗した場合に返されるエラーです
+// 利用者の操作や改ざんによって起こり得るため、プロバイダーとの通信の障害とは区別します
+var ErrOIDCRejected = errors.New("oidc authorization rejected")
+
+// Identity は、ユーザーに連携したOpenID Connectのプロバイダーのアカウントを表します
+// 1人のユーザーはプロバイダーごとに1つのアカウントを連携できます
+type Identity struct {
+	ID     uint `json:"-" gorm:"primaryKey"`
+	UserID uint `json:"-" gorm:"not null;uniqueIndex:idx_user_identities_user_id_provider"`
+	// Provider は、プロバイダーの名前（google、appleなど）です
+	Provider string `json:"provider" gorm:"type:varchar(64);not null;uniqueIndex:idx_user_identities_provider_subject;uniqueIndex:idx_user_identities_user_id_provider"`
+	// Subject は、プロバイダーが発行したアカウントの識別子（IDトークンのsub）です
+	Subject   string    `json:"-" gorm:"not null;uniqueIndex:idx_user_identities_provider_subject"`
+	Email     string    `json:"email"` // 連携した時点のプロバイダーのメールアドレス
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName は、連携したアカウントを保存するテーブルの名前を返します
+func (Identity) TableName() string {
+	return "user_identities"
+}
+
+// IdentityRepository は、ユーザーに連携したアカウントの永続化を担当します
+type IdentityRepository interface {
+	// Create は、アカウントの連携を保存します
+	// 同じアカウントが連携済みの場合や、ユーザーが同じプロバイダーのアカウントを連携済みの場合はErrDuplicateIdentityを返します
+	Create(ctx context.Context, identity *Identity) error
+	FindByProviderSubject(ctx context.Context, provider, subject string) (*Identity, error)
+	// FindByUserID は、ユーザーに連携したアカウントを連携した順に返します
+	FindByUserID(ctx context.Context, userID uint) ([]Identity, error)
+	// Delete は、ユーザーに連携したプロバイダーのアカウントの連携を解除します。連携していない場合はErrNotFoundを返します
+	Delete(ctx context.Context, userID uint, provider string) error
+	DeleteAllByUserID(ctx context.Context, userID uint) error
+}
+
+// OIDCAuthRequest は、プロバイダーの認可画面へ移動してからコールバックを受け取るまでの認可リクエストを表します
+// stateはハッシュ化して保存し、コールバックで一度だけ使用できます
+type OIDCAuthRequest struct {
+	ID           uint      `gorm:"primaryKey"`
+	StateHash    string    `gorm:"uniqueIndex;not null"`
+	Provider     string    `gorm:"type:varchar(64);not null"`
+	Nonce        string    `gorm:"not null"` // IDトークンに含まれることを確認するnonce
+	CodeVerifier string    `gorm:"not null"` // PKCEのcode_verifier
+	UserID       *uint     // アカウントの連携の場合に、連携先のユーザーを設定
+	ExpiresAt    time.Time `gorm:"not null;index"`
+	CreatedAt    time.Time
+}
+
+// TableName は、認可リクエストを保存するテーブルの名前を返します
+func (OIDCAuthRequest) TableName() string {
+	return "oidc_auth_requests"
+}
+
+// OIDCAuthRequestRepository は、認可リクエストの永続化を担当します
+type OIDCAuthRequestRepository interface {
+	// Create は、認可リクエストを保存します。あわせて期限がnowより前の認可リクエストを削除します
+	Create(ctx context.Context, request *OIDCAuthRequest, now time.Time) error
+	// Consume は、stateのハッシュ値に一致する認可リクエストを削除して返します
+	// 同じstateが同時に使用された場合も一方のみ成功し、もう一方はErrNotFoundを返します
+	Consume(ctx context.Context, stateHash string) (*OIDCAuthRequest, error)
+}
+
+// ExternalIdentity は、プロバイダーが検証したアカウントの情報です
+type ExternalIdentity struct {
+	Subject       string // アカウントの識別子（IDトークンのsub）
+	Email         string
+	EmailVerified bool // プロバイダーがメールアドレスを確認済みかどうか
+	Name          string
+}
+
+// OIDCProvider は、OpenID Connectのプロバイダーとの認可コードフローを担当します
+type OIDCProvider interface {
+	// AuthCodeURL は、state、nonce、PKCEのcode_challenge（S256）を含む認可画面のURLを作成します
+	AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error)
+	// Exchange は、認可コードをトークンと交換し、IDトークンの署名とnonceを検証してアカウントの情報を返します
+	// 認可コードやIDトークンが受け付けられない場合はErrOIDCRejectedを返します
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (*ExternalIdentity, error)
+}
@@ -0,0 +1,41 @@
+package model
+
+// Language は、メッセージやメールの表示に使用する言語を表します
+type Language string
+
+const (
+	// LanguageJapanese は、日本語です
+	LanguageJapanese Language = "ja"
+	// LanguageEnglish は、英語です
+	LanguageEnglish Language = "en"
+)
+
+// DefaultLanguage は、言語が指定されていない場合に使用する言語です
+const DefaultLanguage = LanguageJapanese
+
+// supportedLanguages は、対応している言語の一覧です
+// 新しい言語に対応する場合は、ここに定義を追加してメッセージとメールテンプレートを用意します
+var supportedLanguages = []Language{LanguageJapanese, LanguageEnglish}
+
+// SupportedLanguages は、対応している言語の一覧を返します
+func SupportedLanguages() []Language {
+	return append([]Language(nil), supportedLanguages...)
+}
+
+// IsValid は、対応している言語かどうかを判定します
+func (l Language) IsValid() bool {
+	for _, supported := range supportedLanguages {
+		if l == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// OrDefault は、対応している言語の場合はそのまま、それ以外の場合は既定の言語を返します
+func (l Language) OrDefault() Language {
+	if l.IsValid() {
+		return l
+	}
+	return DefaultLanguage
+}
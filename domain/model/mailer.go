@@ -0,0 +1,17 @@
+package model
+
+import "context"
+
+// Mail は、送信するメールの内容を表します
+type Mail struct {
+	To       string // 宛先メールアドレス
+	Subject  string // 件名
+	TextBody string // テキスト形式の本文
+	HTMLBody string // HTML形式の本文
+}
+
+// Mailer は、メールの送信先です
+type Mailer interface {
+	// Send は、メールを送信します。ctxが取り消された場合は送信を中断します
+	Send(ctx context.Context, mail *Mail) error
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"context"
+	"time"
+)
+
+// RateLimit は、期間内に許可する試行の回数です
+type RateLimit struct {
+	Limit  int           // 期間内に許可する回数
+	Window time.Duration // 回数を数える期間
+}
+
+// RateLimitResult は、試行を記録した結果です
+type RateLimitResult struct {
+	Allowed    bool          // 制限内かどうか
+	RetryAfter time.Duration // 制限を超えた場合に、次に試行できるようになるまでの時間
+}
+
+// RateLimiter は、キーごとに期間内の試行の回数を数え、制限を超えたかどうかを判定します
+// 期間は最初の試行から始まり、期間が過ぎると回数はリセットされます
+// 複数のインスタンスで運用する場合は、インスタンス間で回数を共有する実装を使用します
+type RateLimiter interface {
+	// Allow は、キーの試行を1回記録し、limitの範囲内かどうかを返します
+	Allow(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error)
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"context"
+	"time"
+)
+
+// RecoveryCode は、認証アプリを使用できない場合に2段階認証のコードの代わりに一度だけ使用できるコードです
+// コードはハッシュ化して保存します
+type RecoveryCode struct {
+	ID        uint       `gorm:"primaryKey"`
+	UserID    uint       `gorm:"not null;index"`
+	CodeHash  string     `gorm:"not null"`
+	UsedAt    *time.Time // 使用済みの場合に設定
+	CreatedAt time.Time
+}
+
+// TableName は、リカバリーコードを保存するテーブルの名前を返します
+func (RecoveryCode) TableName() string {
+	return "mfa_recovery_codes"
+}
+
+// RecoveryCodeRepository は、2段階認証のリカバリーコードの永続化を担当します
+type RecoveryCodeRepository interface {
+	// ReplaceAll は、ユーザーのリカバリーコードをすべて削除し、codeHashesのコードを作成します
+	ReplaceAll(ctx context.Context, userID uint, codeHashes []string) error
+	// Use は、ユーザーの未使用のコードを使用済みにします
+	// 該当するコードがない場合（使用済みの場合を含む）はfalseを返します
+	Use(ctx context.Context, userID uint, codeHash string, usedAt time.Time) (bool, error)
+	// DeleteAllByUserID は、ユーザーのすべてのリカバリーコードを削除します
+	DeleteAllByUserID(ctx context.Context, userID uint) error
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"context"
+	"time"
+)
+
+// RefreshToken は、アクセストークンの再発行に使用するリフレッシュトークンを表します
+// トークン本体はハッシュ化して保存し、ローテーションごとに同じFamilyIDを引き継ぎます
+type RefreshToken struct {
+	ID        uint       `gorm:"primaryKey"`
+	UserID    uint       `gorm:"not null;index"`
+	TokenHash string     `gorm:"uniqueIndex;not null"`
+	FamilyID  string     `gorm:"index;not null"`
+	ExpiresAt time.Time  `gorm:"not null;index"`
+	UsedAt    *time.Time // ローテーション済みの場合に設定
+	RevokedAt *time.Time // 失効済みの場合に設定
+	CreatedAt time.Time
+}
+
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *RefreshToken) error
+	FindByTokenHash(ctx context.Context, tokenHash string) (*RefreshToken, error)
+	MarkUsed(ctx context.Context, id uint) (bool, error)
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeAllByUserID(ctx context.Context, userID uint) error
+	DeleteAllByUserID(ctx context.Context, userID uint) error
+	// DeleteExpired は、有効期限がnowより前のリフレッシュトークンを削除し、削除した件数を返します
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
@@ -0,0 +1,50 @@
+package model
+
+// Role は、ユーザーに割り当てられるロールを表します
+type Role string
+
+const (
+	// RoleUser は、一般ユーザーのロールです
+	RoleUser Role = "user"
+	// RoleAdmin は、管理者のロールです
+	RoleAdmin Role = "admin"
+)
+
+// Permission は、ロールに付与される操作権限を表します
+type Permission string
+
+const (
+	// PermissionUsersRead は、任意のユーザー情報を参照する権限です
+	PermissionUsersRead Permission = "users:read"
+	// PermissionUsersWrite は、任意のユーザー情報を更新する権限です
+	PermissionUsersWrite Permission = "users:write"
+	// PermissionUsersDelete は、任意のユーザーを削除する権限です
+	PermissionUsersDelete Permission = "users:delete"
+)
+
+// rolePermissions は、各ロールに付与されている権限の一覧です
+// 新しいロールや権限を追加する場合は、ここに定義を追加します
+var rolePermissions = map[Role][]Permission{
+	RoleUser: {},
+	RoleAdmin: {
+		PermissionUsersRead,
+		PermissionUsersWrite,
+		PermissionUsersDelete,
+	},
+}
+
+// IsValid は、定義済みのロールかどうかを判定します
+func (r Role) IsValid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// HasPermission は、ロールが指定された権限を持っているかどうかを判定します
+func (r Role) HasPermission(permission Permission) bool {
+	for _, p := range rolePermissions[r] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"context"
+	"time"
+)
+
+// Session は、1回のログインから始まる端末ごとのセッションを表します
+// IDはアクセストークンのsidとリフレッシュトークンのFamilyIDに使用し、トークンを更新しても変わりません
+type Session struct {
+	ID         string     `json:"id" gorm:"primaryKey;size:64"`
+	UserID     uint       `json:"-" gorm:"not null;index"`
+	DeviceName string     `json:"device_name"` // ログイン時にクライアントが指定した端末の名前
+	UserAgent  string     `json:"user_agent"`  // 最後にトークンを更新したときのUser-Agent
+	IPAddress  string     `json:"ip_address"`  // 最後にトークンを更新したときのIPアドレス
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at" gorm:"not null"`
+	ExpiresAt  time.Time  `json:"-" gorm:"not null;index"` // リフレッシュトークンの有効期限。トークンを更新するたびに延長します
+	RevokedAt  *time.Time `json:"-"`                       // ログアウトまたは失効させた場合に設定
+}
+
+// SessionRepository は、セッションの永続化を担当します
+// セッションの失効はアクセストークンの検証に影響するため、TokenRevocationStoreで行います
+type SessionRepository interface {
+	Create(ctx context.Context, session *Session) error
+	FindByID(ctx context.Context, id string) (*Session, error)
+	// FindActiveByUserID は、失効しておらず期限がnow以降のユーザーのセッションを最近使用した順に返します
+	FindActiveByUserID(ctx context.Context, userID uint, now time.Time) ([]Session, error)
+	// Touch は、トークンを更新した端末の情報と日時を記録し、セッションの期限を延長します
+	Touch(ctx context.Context, id, userAgent, ipAddress string, lastSeenAt, expiresAt time.Time) error
+	RevokeAllByUserID(ctx context.Context, userID uint) error
+	DeleteAllByUserID(ctx context.Context, userID uint) error
+	// DeleteExpired は、期限がnowより前のセッションを失効済みのものも含めて削除し、削除した件数を返します
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
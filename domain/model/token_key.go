@@ -0,0 +1,100 @@
+package model
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+)
+
+// アクセストークンの署名アルゴリズムです
+const (
+	TokenAlgorithmHS256 = "HS256" // 共有の秘密鍵によるHMAC。検証する全員が署名できるため、他のサービスには公開できません
+	TokenAlgorithmRS256 = "RS256"
+	TokenAlgorithmEdDSA = "EdDSA" // Ed25519
+)
+
+// TokenKey は、アクセストークンの署名または検証に使用する鍵です
+type TokenKey struct {
+	ID         string      // JWTヘッダーのkid。共有の秘密鍵の場合は空
+	Algorithm  string      // 署名アルゴリズム
+	PrivateKey interface{} // 署名に使用する鍵。検証のみに使用する鍵の場合はnil
+	PublicKey  interface{} // 検証に使用する鍵。共有の秘密鍵の場合は秘密鍵と同じ値
+}
+
+// TokenKeySet は、アクセストークンの署名と検証に使用する鍵の集合です
+// 鍵をローテーションする間は、署名に使用する鍵に加えて以前の鍵や次の鍵も検証に使用できます
+type TokenKeySet interface {
+	// SigningKey は、新しいトークンの署名に使用する鍵を返します
+	SigningKey() *TokenKey
+	// VerificationKey は、トークンのkidに対応する検証用の鍵を返します
+	VerificationKey(kid string) (*TokenKey, bool)
+	// PublicKeys は、他のサービスに公開する検証用の鍵を返します。共有の秘密鍵は含みません
+	PublicKeys() []*TokenKey
+}
+
+// hmacTokenKeySet は、共有の秘密鍵のみで署名と検証を行うTokenKeySetです
+type hmacTokenKeySet struct {
+	key *TokenKey
+}
+
+// NewHMACTokenKeySet は、共有の秘密鍵でHS256の署名と検証を行うTokenKeySetを作成します
+// 非対称鍵を設定していない場合の既定の鍵で、JWKSには何も公開しません
+func NewHMACTokenKeySet(secret string) TokenKeySet {
+	key := []byte(secret)
+	return &hmacTokenKeySet{key: &TokenKey{Algorithm: TokenAlgorithmHS256, PrivateKey: key, PublicKey: key}}
+}
+
+func (s *hmacTokenKeySet) SigningKey() *TokenKey {
+	return s.key
+}
+
+func (s *hmacTokenKeySet) VerificationKey(kid string) (*TokenKey, bool) {
+	if kid != "" {
+		return nil, false
+	}
+	return s.key, true
+}
+
+func (s *hmacTokenKeySet) PublicKeys() []*TokenKey {
+	return nil
+}
+
+// JSONWebKey は、JWKS（RFC 7517）で公開する公開鍵です
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n,omitempty"`   // RSAのmodulus
+	E   string `json:"e,omitempty"`   // RSAのexponent
+	Crv string `json:"crv,omitempty"` // OKPの曲線（Ed25519）
+	X   string `json:"x,omitempty"`   // OKPの公開鍵
+}
+
+// JWK は、公開鍵をJWKS（RFC 7517）の形式で返します
+// 共有の秘密鍵など、公開できない鍵の場合はfalseを返します
+func (k *TokenKey) JWK() (JSONWebKey, bool) {
+	switch key := k.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return JSONWebKey{
+			Kty: "RSA",
+			Kid: k.ID,
+			Use: "sig",
+			Alg: k.Algorithm,
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}, true
+	case ed25519.PublicKey:
+		return JSONWebKey{
+			Kty: "OKP",
+			Kid: k.ID,
+			Use: "sig",
+			Alg: k.Algorithm,
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(key),
+		}, true
+	default:
+		return JSONWebKey{}, false
+	}
+}
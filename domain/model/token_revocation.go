@@ -0,0 +1,38 @@
+package model
+
+import (
+	"context"
+	"time"
+)
+
+// RevokedToken は、個別に失効させたアクセストークンを表します
+type RevokedToken struct {
+	JTI       string    `gorm:"primaryKey;size:64"`
+	UserID    uint      `gorm:"not null;index"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	CreatedAt time.Time
+}
+
+// UserTokenVersion は、ユーザーごとのトークン世代を表します
+// 世代を進めると、それより前の世代で発行されたアクセストークンはすべて無効になります
+type UserTokenVersion struct {
+	UserID    uint `gorm:"primaryKey;autoIncrement:false"`
+	Version   uint `gorm:"not null;default:0"`
+	UpdatedAt time.Time
+}
+
+type TokenRevocationStore interface {
+	RevokeToken(ctx context.Context, jti string, userID uint, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+	RevokeAllUserTokens(ctx context.Context, userID uint) error
+	GetUserTokenVersion(ctx context.Context, userID uint) (uint, error)
+	// RevokeSession は、セッションを失効させ、そのセッションで発行したアクセストークンを無効にします
+	RevokeSession(ctx context.Context, sessionID string) error
+	IsSessionRevoked(ctx context.Context, sessionID string) (bool, error)
+	// DeleteExpired は、有効期限がnowより前の失効済みのアクセストークンを削除し、削除した件数を返します
+	// 有効期限を過ぎたトークンは署名の検証で拒否されるため、失効情報は不要です
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+	// DeleteAllByUserID は、ユーザーの失効済みのアクセストークンとトークン世代を削除します
+	// 退会したユーザーのデータを完全に削除する場合に使用します
+	DeleteAllByUserID(ctx context.Context, userID uint) error
+}
@@ -0,0 +1,12 @@
+package model
+
+import "context"
+
+// TransactionManager は、複数のリポジトリの操作を1つのトランザクションとして実行します
+type TransactionManager interface {
+	// WithinTransaction は、fnをトランザクション内で実行します
+	// fnに渡されたコンテキストで呼び出したリポジトリの操作は同じトランザクションで実行され、
+	// fnがエラーを返した場合はすべて取り消されます
+	// 既にトランザクション内で呼び出された場合は、そのトランザクションでfnを実行します
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
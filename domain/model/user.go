@@ -1,25 +1,92 @@
 package model
 
 import (
+	"context"
 	"time"
 )
 
 type User struct {
-	ID                   uint       `json:"id" gorm:"primaryKey"`
-	Name                 string     `json:"name" gorm:"not null"`
-	Email                string     `json:"email" gorm:"unique;not null"`
-	Password             string     `json:"-" gorm:"not null"`
-	PasswordResetToken   *string    `json:"-" gorm:"unique"`
-	PasswordResetExpires *time.Time `json:"-"`
-	CreatedAt            time.Time  `json:"created_at"`
-	UpdatedAt            time.Time  `json:"updated_at"`
+	ID                       uint       `json:"id" gorm:"primaryKey"`
+	Name                     string     `json:"name" gorm:"not null"`
+	Email                    string     `json:"email" gorm:"unique;not null"`
+	Password                 string     `json:"-" gorm:"not null"`
+	Role                     Role       `json:"role" gorm:"type:varchar(20);not null;default:user"`
+	PreferredLanguage        Language   `json:"preferred_language" gorm:"type:varchar(8);not null;default:ja"` // メッセージやメールに使用する言語
+	EmailVerifiedAt          *time.Time `json:"email_verified_at"`
+	PendingEmail             *string    `json:"pending_email,omitempty"` // 確認待ちの変更後メールアドレス
+	EmailVerificationToken   *string    `json:"-" gorm:"unique"`
+	EmailVerificationExpires *time.Time `json:"-"`
+	PasswordResetToken       *string    `json:"-" gorm:"unique"`
+	PasswordResetExpires     *time.Time `json:"-"`
+	FailedLoginAttempts      int        `json:"-" gorm:"not null;default:0"` // 連続したログインの失敗回数
+	LastFailedLoginAt        *time.Time `json:"-"`
+	LockedUntil              *time.Time `json:"-"` // ログインの失敗が続いたためにロックしている期限
+	UnlockToken              *string    `json:"-" gorm:"unique"`
+	UnlockTokenExpires       *time.Time `json:"-"`
+	MFASecret                *string    `json:"-"`                           // 2段階認証（TOTP）の秘密鍵をAES-GCMで暗号化した値。MFAEnabledAtがnilの場合は登録の確認待ち
+	MFAEnabledAt             *time.Time `json:"mfa_enabled_at"`              // 2段階認証を有効にした日時
+	MFALastUsedStep          int64      `json:"-" gorm:"not null;default:0"` // 最後に使用したTOTPの時間ステップ。同じコードの再利用を防ぐ
+	CreatedAt                time.Time  `json:"created_at"`
+	UpdatedAt                time.Time  `json:"updated_at"`
+	DeletedAt                *time.Time `json:"-"` // 退会日時（猶予期間の経過後に完全に削除される）
 }
 
+// IsDeleted は、ユーザーが退会済み（完全に削除される前の猶予期間中）かどうかを判定します
+func (u *User) IsDeleted() bool {
+	return u.DeletedAt != nil
+}
+
+// IsLocked は、ログインの失敗が続いたためにアカウントがnowの時点でロックされているかどうかを判定します
+func (u *User) IsLocked(now time.Time) bool {
+	return u.LockedUntil != nil && now.Before(*u.LockedUntil)
+}
+
+// IsMFAEnabled は、ユーザーが2段階認証を有効にしているかどうかを判定します
+func (u *User) IsMFAEnabled() bool {
+	return u.MFAEnabledAt != nil && u.MFASecret != nil
+}
+
+// UserRepository は、ユーザーの永続化を担当します
+// ctxはリクエストのトレース情報をデータベースの操作まで引き継ぐために使用します
+// FindByEmail以外の検索は、退会済みのユーザーを含みません
 type UserRepository interface {
-	Create(user *User) error
-	FindByID(id uint) (*User, error)
-	FindByEmail(email string) (*User, error)
-	FindByPasswordResetToken(token string) (*User, error)
-	Update(user *User) error
-	Delete(id uint) error
+	Create(ctx context.Context, user *User) error
+	FindByID(ctx context.Context, id uint) (*User, error)
+	// FindByIDIncludingDeleted は、退会済みのユーザーも含めて検索します
+	// 連携したアカウントでのログインで、猶予期間中のユーザーの退会を取り消すために使用します
+	FindByIDIncludingDeleted(ctx context.Context, id uint) (*User, error)
+	// FindByEmail は、退会済みのユーザーも含めて検索します
+	// メールアドレスは完全に削除されるまで退会済みのユーザーが使用しているものとして扱います
+	FindByEmail(ctx context.Context, email string) (*User, error)
+	FindByPasswordResetToken(ctx context.Context, token string) (*User, error)
+	FindByEmailVerificationToken(ctx context.Context, token string) (*User, error)
+	FindByUnlockToken(ctx context.Context, token string) (*User, error)
+	// FindAll は、条件に一致するユーザーをquery.SortByとquery.Orderの順に最大query.Limit件返します
+	FindAll(ctx context.Context, query UserListQuery) ([]User, error)
+	Update(ctx context.Context, user *User) error
+	// RecordLoginFailure は、ログインの失敗をatの時刻で記録し、連続した失敗回数を返します
+	// 前回の失敗がresetBeforeより前の場合は、連続した失敗とみなさず1回目として数えます
+	RecordLoginFailure(ctx context.Context, id uint, at, resetBefore time.Time) (int, error)
+	// Lock は、lockedUntilまでログインできないようにし、ロックを解除するためのトークンを設定します
+	Lock(ctx context.Context, id uint, lockedUntil time.Time, unlockToken string, unlockTokenExpires time.Time) error
+	// ResetLoginFailures は、ログインの失敗回数とロックを解除します
+	ResetLoginFailures(ctx context.Context, id uint) error
+	// SetMFASecret は、確認待ちの2段階認証の暗号化した秘密鍵を設定します。2段階認証を有効にしている場合はErrNotFoundを返します
+	SetMFASecret(ctx context.Context, id uint, secret string) error
+	// EnableMFA は、確認した秘密鍵で2段階認証を有効にし、確認に使用した時間ステップを記録します
+	EnableMFA(ctx context.Context, id uint, enabledAt time.Time, step int64) error
+	// UseMFAStep は、stepがこれまでに使用した時間ステップより新しい場合に記録してtrueを返します
+	// 既に使用した時間ステップの場合は、同じコードの再利用としてfalseを返します
+	UseMFAStep(ctx context.Context, id uint, step int64) (bool, error)
+	// DisableMFA は、2段階認証を無効にし、秘密鍵を削除します
+	DisableMFA(ctx context.Context, id uint) error
+	// Delete は、ユーザーをdeletedAtに退会済みにします。データはPurgeで完全に削除されるまで保持します
+	Delete(ctx context.Context, id uint, deletedAt time.Time) error
+	// Restore は、退会済みのユーザーを復元します。退会済みでない場合はErrNotFoundを返します
+	Restore(ctx context.Context, id uint) error
+	// FindDeletedBefore は、deletedBeforeより前に退会したユーザーのIDを最大limit件返します
+	FindDeletedBefore(ctx context.Context, deletedBefore time.Time, limit int) ([]uint, error)
+	// Purge は、deletedBeforeより前に退会したユーザーを完全に削除します
+	// 該当しない場合（退会を取り消した場合を含む）はErrNotFoundを返します
+	Purge(ctx context.Context, id uint, deletedBefore time.Time) error
 }
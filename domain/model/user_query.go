@@ -0,0 +1,68 @@
+package model
+
+import "time"
+
+// UserSortField は、ユーザー一覧の並び替えに使用する項目です
+type UserSortField string
+
+// ユーザー一覧で並び替えに使用できる項目です
+// 同じ値のユーザーはIDの順に並びます
+const (
+	UserSortByID        UserSortField = "id"
+	UserSortByCreatedAt UserSortField = "created_at"
+	UserSortByEmail     UserSortField = "email"
+	UserSortByName      UserSortField = "name"
+)
+
+// IsValid は、並び替えに使用できる項目かどうかを判定します
+func (f UserSortField) IsValid() bool {
+	switch f {
+	case UserSortByID, UserSortByCreatedAt, UserSortByEmail, UserSortByName:
+		return true
+	}
+	return false
+}
+
+// SortOrder は、並び順を表します
+type SortOrder string
+
+// 並び順の一覧です
+const (
+	SortAscending  SortOrder = "asc"
+	SortDescending SortOrder = "desc"
+)
+
+// IsValid は、対応している並び順かどうかを判定します
+func (o SortOrder) IsValid() bool {
+	return o == SortAscending || o == SortDescending
+}
+
+// UserFilter は、ユーザー一覧の絞り込み条件です
+// ゼロ値の項目は条件に含めません
+type UserFilter struct {
+	EmailPrefix string     // メールアドレスの前方一致（大文字と小文字を区別しない）
+	NamePrefix  string     // 名前の前方一致（大文字と小文字を区別しない）
+	CreatedFrom *time.Time // 作成日時の下限（この日時を含む）
+	CreatedTo   *time.Time // 作成日時の上限（この日時を含まない）
+	Role        Role       // ロール
+	Verified    *bool      // メールアドレスの確認が完了しているかどうか
+}
+
+// UserCursor は、ユーザー一覧のページの区切りとなるユーザーの並び替えキーです
+// 並び替えに使用している項目とIDのみが参照されます
+type UserCursor struct {
+	ID        uint
+	CreatedAt time.Time
+	Email     string
+	Name      string
+}
+
+// UserListQuery は、ユーザー一覧の取得条件です
+// Afterが指定されている場合は、並び順でそのユーザーより後のユーザーのみを返します
+type UserListQuery struct {
+	Filter UserFilter
+	SortBy UserSortField
+	Order  SortOrder
+	After  *UserCursor
+	Limit  int // 取得する最大件数
+}
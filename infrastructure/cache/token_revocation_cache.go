@@ -0,0 +1,210 @@
+// package cache は、インメモリキャッシュによるリポジトリのデコレーターを提供します
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+	"voice-link/domain/model"
+)
+
+// sweepThreshold は、期限切れエントリの掃除を行うキャッシュ件数の閾値です
+const sweepThreshold = 10000
+
+// sweepInterval は、期限切れエントリの掃除を行う最短の間隔です
+// 掃除はすべてのエントリを走査するため、キャッシュへの追加のたびには行いません
+const sweepInterval = time.Minute
+
+// cacheEntry は、有効期限付きのキャッシュエントリです
+type cacheEntry[T any] struct {
+	value     T
+	expiresAt time.Time
+}
+
+// tokenRevocationCache は、TokenRevocationStoreの問い合わせ結果をメモリ上にキャッシュする構造体です
+// 認証のたびにデータベースへ問い合わせることを避けるために使用します
+type tokenRevocationCache struct {
+	store    model.TokenRevocationStore // 永続化先のストア
+	ttl      time.Duration              // 問い合わせ結果をキャッシュする期間
+	now      func() time.Time
+	mu       sync.RWMutex
+	revoked  map[string]cacheEntry[bool]
+	versions map[uint]cacheEntry[uint]
+	sessions map[string]cacheEntry[bool]
+	// nextSweep は、次に掃除を行える日時です
+	nextSweep time.Time
+}
+
+// NewTokenRevocationCache は、キャッシュ付きのTokenRevocationStoreを作成します
+// 複数のインスタンスで運用する場合、他のインスタンスでの失効は最大でttlの間反映されません
+func NewTokenRevocationCache(store model.TokenRevocationStore, ttl time.Duration) model.TokenRevocationStore {
+	return &tokenRevocationCache{
+		store:    store,
+		ttl:      ttl,
+		now:      time.Now,
+		revoked:  make(map[string]cacheEntry[bool]),
+		versions: make(map[uint]cacheEntry[uint]),
+		sessions: make(map[string]cacheEntry[bool]),
+	}
+}
+
+// RevokeToken は、アクセストークンを失効させ、失効済みであることをキャッシュします
+func (c *tokenRevocationCache) RevokeToken(ctx context.Context, jti string, userID uint, expiresAt time.Time) error {
+	if err := c.store.RevokeToken(ctx, jti, userID, expiresAt); err != nil {
+		return err
+	}
+
+	// 失効は取り消されないため、トークンの有効期限までキャッシュしてよい
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.revoked[jti] = cacheEntry[bool]{value: true, expiresAt: expiresAt}
+	c.sweepLocked()
+
+	return nil
+}
+
+// IsTokenRevoked は、キャッシュを参照してアクセストークンが失効済みかどうかを判定します
+func (c *tokenRevocationCache) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	now := c.now()
+
+	c.mu.RLock()
+	entry, ok := c.revoked[jti]
+	c.mu.RUnlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.value, nil
+	}
+
+	revoked, err := c.store.IsTokenRevoked(ctx, jti)
+	if err != nil {
+		return false, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.revoked[jti] = cacheEntry[bool]{value: revoked, expiresAt: now.Add(c.ttl)}
+	c.sweepLocked()
+
+	return revoked, nil
+}
+
+// RevokeAllUserTokens は、ユーザーのトークン世代を進め、キャッシュ済みの世代を破棄します
+func (c *tokenRevocationCache) RevokeAllUserTokens(ctx context.Context, userID uint) error {
+	if err := c.store.RevokeAllUserTokens(ctx, userID); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.versions, userID)
+
+	return nil
+}
+
+// GetUserTokenVersion は、キャッシュを参照してユーザーの現在のトークン世代を取得します
+func (c *tokenRevocationCache) GetUserTokenVersion(ctx context.Context, userID uint) (uint, error) {
+	now := c.now()
+
+	c.mu.RLock()
+	entry, ok := c.versions[userID]
+	c.mu.RUnlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.value, nil
+	}
+
+	version, err := c.store.GetUserTokenVersion(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.versions[userID] = cacheEntry[uint]{value: version, expiresAt: now.Add(c.ttl)}
+	c.sweepLocked()
+
+	return version, nil
+}
+
+// RevokeSession は、セッションを失効させ、失効済みであることをキャッシュします
+func (c *tokenRevocationCache) RevokeSession(ctx context.Context, sessionID string) error {
+	if err := c.store.RevokeSession(ctx, sessionID); err != nil {
+		return err
+	}
+
+	// 失効は取り消されないため、期限が過ぎてもストアへの問い合わせで同じ結果になる
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sessions[sessionID] = cacheEntry[bool]{value: true, expiresAt: c.now().Add(c.ttl)}
+	c.sweepLocked()
+
+	return nil
+}
+
+// IsSessionRevoked は、キャッシュを参照してセッションが失効済みかどうかを判定します
+func (c *tokenRevocationCache) IsSessionRevoked(ctx context.Context, sessionID string) (bool, error) {
+	now := c.now()
+
+	c.mu.RLock()
+	entry, ok := c.sessions[sessionID]
+	c.mu.RUnlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.value, nil
+	}
+
+	revoked, err := c.store.IsSessionRevoked(ctx, sessionID)
+	if err != nil {
+		return false, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sessions[sessionID] = cacheEntry[bool]{value: revoked, expiresAt: now.Add(c.ttl)}
+	c.sweepLocked()
+
+	return revoked, nil
+}
+
+// DeleteExpired は、ストアから有効期限を過ぎた失効情報を削除します
+// 削除するのは検証で拒否されるトークンの失効情報のみのため、キャッシュはそのまま使用できます
+func (c *tokenRevocationCache) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	return c.store.DeleteExpired(ctx, now)
+}
+
+// DeleteAllByUserID は、ストアからユーザーの失効情報を削除し、ユーザーのトークン世代のキャッシュを破棄します
+func (c *tokenRevocationCache) DeleteAllByUserID(ctx context.Context, userID uint) error {
+	if err := c.store.DeleteAllByUserID(ctx, userID); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.versions, userID)
+
+	return nil
+}
+
+// sweepLocked は、キャッシュ件数が閾値を超えた場合に期限切れのエントリを削除します
+// 前回の掃除からsweepIntervalが経過していない場合は行いません
+// 呼び出し元でロックを取得している必要があります
+func (c *tokenRevocationCache) sweepLocked() {
+	now := c.now()
+	if len(c.revoked)+len(c.versions)+len(c.sessions) < sweepThreshold || now.Before(c.nextSweep) {
+		return
+	}
+	c.nextSweep = now.Add(sweepInterval)
+
+	for jti, entry := range c.revoked {
+		if !now.Before(entry.expiresAt) {
+			delete(c.revoked, jti)
+		}
+	}
+	for userID, entry := range c.versions {
+		if !now.Before(entry.expiresAt) {
+			delete(c.versions, userID)
+		}
+	}
+	for sessionID, entry := range c.sessions {
+		if !now.Before(entry.expiresAt) {
+			delete(c.sessions, sessionID)
+		}
+	}
+}
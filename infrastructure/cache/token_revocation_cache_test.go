@@ -0,0 +1,194 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingStore は、問い合わせ回数を記録するテスト用のTokenRevocationStoreです
+type countingStore struct {
+	revoked        map[string]bool
+	versions       map[uint]uint
+	sessions       map[string]bool
+	revokedCalls   int
+	versionCalls   int
+	revokeAllCalls int
+	sessionCalls   int
+}
+
+func newCountingStore() *countingStore {
+	return &countingStore{
+		revoked:  make(map[string]bool),
+		versions: make(map[uint]uint),
+		sessions: make(map[string]bool),
+	}
+}
+
+func (s *countingStore) RevokeToken(ctx context.Context, jti string, userID uint, expiresAt time.Time) error {
+	s.revoked[jti] = true
+	return nil
+}
+
+func (s *countingStore) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	s.revokedCalls++
+	return s.revoked[jti], nil
+}
+
+func (s *countingStore) RevokeAllUserTokens(ctx context.Context, userID uint) error {
+	s.revokeAllCalls++
+	s.versions[userID]++
+	return nil
+}
+
+func (s *countingStore) GetUserTokenVersion(ctx context.Context, userID uint) (uint, error) {
+	s.versionCalls++
+	return s.versions[userID], nil
+}
+
+func (s *countingStore) RevokeSession(ctx context.Context, sessionID string) error {
+	s.sessions[sessionID] = true
+	return nil
+}
+
+func (s *countingStore) IsSessionRevoked(ctx context.Context, sessionID string) (bool, error) {
+	s.sessionCalls++
+	return s.sessions[sessionID], nil
+}
+
+func (s *countingStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (s *countingStore) DeleteAllByUserID(ctx context.Context, userID uint) error {
+	delete(s.versions, userID)
+	return nil
+}
+
+func TestTokenRevocationCache_IsTokenRevoked(t *testing.T) {
+	ctx := context.Background()
+	store := newCountingStore()
+	c := NewTokenRevocationCache(store, time.Minute).(*tokenRevocationCache)
+
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	// 初回はストアに問い合わせ、以降はキャッシュを使用する
+	revoked, err := c.IsTokenRevoked(ctx, "jti-1")
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+	revoked, err = c.IsTokenRevoked(ctx, "jti-1")
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+	assert.Equal(t, 1, store.revokedCalls)
+
+	// 自インスタンスでの失効はキャッシュに即時反映される
+	assert.NoError(t, c.RevokeToken(ctx, "jti-1", 1, now.Add(time.Hour)))
+	revoked, err = c.IsTokenRevoked(ctx, "jti-1")
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+	assert.Equal(t, 1, store.revokedCalls)
+
+	// TTLを過ぎた未失効エントリは再度ストアに問い合わせる
+	store.revoked["jti-2"] = false
+	_, _ = c.IsTokenRevoked(ctx, "jti-2")
+	store.revoked["jti-2"] = true
+	now = now.Add(2 * time.Minute)
+	revoked, err = c.IsTokenRevoked(ctx, "jti-2")
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+	assert.Equal(t, 3, store.revokedCalls)
+}
+
+func TestTokenRevocationCache_UserTokenVersion(t *testing.T) {
+	ctx := context.Background()
+	store := newCountingStore()
+	c := NewTokenRevocationCache(store, time.Minute)
+
+	// 初回はストアに問い合わせ、以降はキャッシュを使用する
+	version, err := c.GetUserTokenVersion(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(0), version)
+	_, _ = c.GetUserTokenVersion(ctx, 1)
+	assert.Equal(t, 1, store.versionCalls)
+
+	// 一括失効するとキャッシュが破棄され、新しい世代が返される
+	assert.NoError(t, c.RevokeAllUserTokens(ctx, 1))
+	version, err = c.GetUserTokenVersion(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), version)
+	assert.Equal(t, 2, store.versionCalls)
+}
+
+func TestTokenRevocationCache_IsSessionRevoked(t *testing.T) {
+	ctx := context.Background()
+	store := newCountingStore()
+	c := NewTokenRevocationCache(store, time.Minute).(*tokenRevocationCache)
+
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	// 初回はストアに問い合わせ、以降はキャッシュを使用する
+	revoked, err := c.IsSessionRevoked(ctx, "session-1")
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+	_, _ = c.IsSessionRevoked(ctx, "session-1")
+	assert.Equal(t, 1, store.sessionCalls)
+
+	// 自インスタンスでの失効はキャッシュに即時反映される
+	assert.NoError(t, c.RevokeSession(ctx, "session-1"))
+	revoked, err = c.IsSessionRevoked(ctx, "session-1")
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+	assert.Equal(t, 1, store.sessionCalls)
+
+	// 他のインスタンスでの失効はTTLを過ぎると反映される
+	_, _ = c.IsSessionRevoked(ctx, "session-2")
+	store.sessions["session-2"] = true
+	revoked, err = c.IsSessionRevoked(ctx, "session-2")
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+	now = now.Add(2 * time.Minute)
+	revoked, err = c.IsSessionRevoked(ctx, "session-2")
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+	assert.Equal(t, 3, store.sessionCalls)
+}
+
+func TestTokenRevocationCache_SweepsExpiredEntries(t *testing.T) {
+	ctx := context.Background()
+	store := newCountingStore()
+	c := NewTokenRevocationCache(store, time.Minute).(*tokenRevocationCache)
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	for i := 0; i < sweepThreshold; i++ {
+		_, err := c.IsTokenRevoked(ctx, "jti-"+time.Duration(i).String())
+		assert.NoError(t, err)
+	}
+
+	// 閾値を超えた状態でエントリを追加すると、期限切れのエントリを削除する
+	now = now.Add(time.Minute)
+	_, err := c.IsTokenRevoked(ctx, "new-jti")
+	assert.NoError(t, err)
+	assert.Len(t, c.revoked, 1)
+
+	for i := 0; i < sweepThreshold; i++ {
+		assert.NoError(t, c.RevokeToken(ctx, "revoked-"+time.Duration(i).String(), 1, now.Add(time.Second)))
+	}
+
+	// 前回の掃除からsweepIntervalが経過するまでは、期限切れのエントリがあっても掃除しない
+	now = now.Add(sweepInterval / 2)
+	_, err = c.IsTokenRevoked(ctx, "another-jti")
+	assert.NoError(t, err)
+	assert.Len(t, c.revoked, sweepThreshold+2)
+
+	now = now.Add(sweepInterval / 2)
+	_, err = c.IsTokenRevoked(ctx, "last-jti")
+	assert.NoError(t, err)
+	// 期限が残っているanother-jtiとlast-jtiのみ残る
+	assert.Len(t, c.revoked, 2)
+}
@@ -0,0 +1,307 @@
+package mail
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	netmail "net/mail"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"voice-link/domain/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testMail は、テストで送信するメールです
+var testMail = &model.Mail{
+	To:       "test@example.com",
+	Subject:  "【Voice Link】パスワード再設定のご案内",
+	TextBody: "テキスト本文 https://example.com/reset-password?token=abc",
+	HTMLBody: "<p>HTML本文</p>",
+}
+
+// parseMessage は、作成されたメッセージを解析して件名と各パートの本文を返します
+func parseMessage(t *testing.T, raw []byte) (string, map[string]string) {
+	msg, err := netmail.ReadMessage(strings.NewReader(string(raw)))
+	assert.NoError(t, err)
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	assert.NoError(t, err)
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	assert.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+
+	bodies := make(map[string]string)
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+
+		// quoted-printableはmultipart.Readerによって自動的にデコードされる
+		body, err := io.ReadAll(part)
+		assert.NoError(t, err)
+		contentType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		bodies[contentType] = string(body)
+	}
+
+	return subject, bodies
+}
+
+func TestBuildMessage(t *testing.T) {
+	raw, err := buildMessage("no-reply@example.com", testMail, time.Now())
+	assert.NoError(t, err)
+
+	subject, bodies := parseMessage(t, raw)
+	assert.Equal(t, testMail.Subject, subject)
+	assert.Equal(t, testMail.TextBody, bodies["text/plain"])
+	assert.Equal(t, testMail.HTMLBody, bodies["text/html"])
+}
+
+// fakeSMTPServer は、メールの送信に必要なコマンドのみに応答するテスト用のSMTPサーバーです
+type fakeSMTPServer struct {
+	listener net.Listener
+
+	mu       sync.Mutex
+	commands []string
+	data     string
+}
+
+// newFakeSMTPServer は、ローカルのポートで待ち受けるテスト用のサーバーを起動します
+// stallがtrueの場合は、接続を受け付けたまま応答しません
+func newFakeSMTPServer(t *testing.T, stall bool) *fakeSMTPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	s := &fakeSMTPServer{listener: listener}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			if stall {
+				t.Cleanup(func() { conn.Close() })
+				continue
+			}
+			go s.serve(conn)
+		}
+	}()
+
+	return s
+}
+
+// config は、サーバーに接続する設定を返します
+func (s *fakeSMTPServer) config() SMTPConfig {
+	addr := s.listener.Addr().(*net.TCPAddr)
+	return SMTPConfig{Host: "127.0.0.1", Port: addr.Port, Username: "user", Password: "pass", From: "Voice Link <no-reply@example.com>"}
+}
+
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	text.PrintfLine("220 localhost ESMTP")
+
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		s.mu.Lock()
+		s.commands = append(s.commands, line)
+		s.mu.Unlock()
+
+		switch command {
+		case "EHLO":
+			text.PrintfLine("250-localhost")
+			text.PrintfLine("250 AUTH PLAIN")
+		case "AUTH":
+			text.PrintfLine("235 Authentication successful")
+		case "MAIL", "RCPT":
+			text.PrintfLine("250 OK")
+		case "DATA":
+			text.PrintfLine("354 Start mail input")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.data = string(data)
+			s.mu.Unlock()
+			text.PrintfLine("250 OK")
+		case "QUIT":
+			text.PrintfLine("221 Bye")
+			return
+		default:
+			text.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+func TestSMTPMailer_Send(t *testing.T) {
+	server := newFakeSMTPServer(t, false)
+	mailer := NewSMTPMailer(server.config())
+
+	err := mailer.Send(context.Background(), testMail)
+
+	assert.NoError(t, err)
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	assert.Equal(t, "EHLO localhost", server.commands[0])
+	assert.True(t, strings.HasPrefix(server.commands[1], "AUTH PLAIN "))
+	// MAIL FROMには表示名を除いたアドレスを使用する
+	assert.Equal(t, "MAIL FROM:<no-reply@example.com>", server.commands[2])
+	assert.Equal(t, "RCPT TO:<test@example.com>", server.commands[3])
+	assert.Equal(t, []string{"DATA", "QUIT"}, server.commands[4:])
+
+	subject, _ := parseMessage(t, []byte(server.data))
+	assert.Equal(t, testMail.Subject, subject)
+}
+
+func TestSMTPMailer_SendTimeout(t *testing.T) {
+	t.Run("サーバーが応答しない場合は期限で打ち切る", func(t *testing.T) {
+		server := newFakeSMTPServer(t, true)
+		mailer := NewSMTPMailer(server.config()).(*smtpMailer)
+		mailer.timeout = 50 * time.Millisecond
+
+		err := mailer.Send(context.Background(), testMail)
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("コンテキストが取り消された場合は中断する", func(t *testing.T) {
+		server := newFakeSMTPServer(t, true)
+		mailer := NewSMTPMailer(server.config())
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		err := mailer.Send(ctx, testMail)
+
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("サーバーに接続できない場合", func(t *testing.T) {
+		server := newFakeSMTPServer(t, false)
+		server.listener.Close()
+		mailer := NewSMTPMailer(server.config())
+
+		err := mailer.Send(context.Background(), testMail)
+
+		assert.ErrorContains(t, err, "failed to connect to smtp server")
+	})
+}
+
+func TestOutboxMailer_Send(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+	mailer := NewOutboxMailer(dir, "no-reply@example.com")
+
+	err := mailer.Send(context.Background(), testMail)
+	assert.NoError(t, err)
+
+	// 宛先を含むファイル名で書き出されること
+	files, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+	assert.True(t, strings.HasSuffix(files[0].Name(), "-test_example.com.eml"))
+
+	raw, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	assert.NoError(t, err)
+	_, bodies := parseMessage(t, raw)
+	assert.Equal(t, testMail.TextBody, bodies["text/plain"])
+}
+
+func TestSMTPMailer_Check(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	addr := listener.Addr().(*net.TCPAddr)
+
+	mailer := NewSMTPMailer(SMTPConfig{Host: "127.0.0.1", Port: addr.Port}).(*smtpMailer)
+
+	// SMTPサーバーが接続を受け付ける場合
+	assert.NoError(t, mailer.Check(context.Background()))
+
+	// SMTPサーバーが停止している場合
+	listener.Close()
+	assert.Error(t, mailer.Check(context.Background()))
+}
+
+func TestOutboxMailer_Check(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+	mailer := NewOutboxMailer(dir, "no-reply@example.com").(*outboxMailer)
+
+	assert.NoError(t, mailer.Check(context.Background()))
+
+	// 確認用のファイルは残らない
+	files, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+// recordingMailer は、送信したメールを記録するテスト用のMailerです
+type recordingMailer struct {
+	sent    chan *model.Mail
+	release chan struct{} // nilでない場合は、閉じられるまで送信を完了しない
+}
+
+func (m *recordingMailer) Send(ctx context.Context, mail *model.Mail) error {
+	if m.release != nil {
+		<-m.release
+	}
+	m.sent <- mail
+	return nil
+}
+
+func TestQueuedMailer(t *testing.T) {
+	t.Run("送信を待たずに戻り、バックグラウンドで送信する", func(t *testing.T) {
+		inner := &recordingMailer{sent: make(chan *model.Mail, 1), release: make(chan struct{})}
+		mailer := NewQueuedMailer(inner, 1)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go mailer.Run(ctx)
+
+		// リクエストのコンテキストが取り消されても送信する
+		requestCtx, cancelRequest := context.WithCancel(context.Background())
+		assert.NoError(t, mailer.Send(requestCtx, testMail))
+		cancelRequest()
+
+		close(inner.release)
+		select {
+		case sent := <-inner.sent:
+			assert.Equal(t, testMail, sent)
+		case <-time.After(time.Second):
+			t.Fatal("mail was not sent")
+		}
+	})
+
+	t.Run("キューが上限に達した場合は受け付けない", func(t *testing.T) {
+		mailer := NewQueuedMailer(&recordingMailer{sent: make(chan *model.Mail, 1)}, 1)
+
+		assert.NoError(t, mailer.Send(context.Background(), testMail))
+		assert.ErrorIs(t, mailer.Send(context.Background(), testMail), ErrQueueFull)
+	})
+
+	t.Run("停止時はキューに残ったメールを送信してから終了する", func(t *testing.T) {
+		inner := &recordingMailer{sent: make(chan *model.Mail, 2)}
+		mailer := NewQueuedMailer(inner, 2)
+		assert.NoError(t, mailer.Send(context.Background(), testMail))
+		assert.NoError(t, mailer.Send(context.Background(), testMail))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		mailer.Run(ctx)
+
+		assert.Len(t, inner.sent, 2)
+	})
+}
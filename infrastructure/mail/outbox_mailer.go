@@ -0,0 +1,69 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+	"voice-link/domain/model"
+)
+
+// unsafeFileNameChars は、ファイル名に使用しない文字にマッチします
+var unsafeFileNameChars = regexp.MustCompile(`[^a-zA-Z0-9._-]`)
+
+// outboxMailer は、メールを実際には送信せずにファイルとして書き出す構造体です
+// ローカル開発やテストでの送信内容の確認に使用します
+type outboxMailer struct {
+	dir  string // 書き出し先のディレクトリ
+	from string // 送信元メールアドレス
+	now  func() time.Time
+}
+
+// NewOutboxMailer は、メールを指定されたディレクトリに.emlファイルとして書き出すMailerを作成します
+func NewOutboxMailer(dir, from string) model.Mailer {
+	return &outboxMailer{
+		dir:  dir,
+		from: from,
+		now:  time.Now,
+	}
+}
+
+// Send は、メールを.emlファイルとして書き出します
+func (m *outboxMailer) Send(ctx context.Context, mail *model.Mail) error {
+	now := m.now()
+	msg, err := buildMessage(m.from, mail, now)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create outbox directory: %w", err)
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", now.Format("20060102T150405.000000000"), unsafeFileNameChars.ReplaceAllString(mail.To, "_"))
+	path := filepath.Join(m.dir, name)
+	if err := os.WriteFile(path, msg, 0o600); err != nil {
+		return fmt.Errorf("failed to write mail to outbox: %w", err)
+	}
+
+	// ファイル名には宛先のアドレスが含まれるため、ログにはディレクトリのみ記録する
+	slog.InfoContext(ctx, "mail written to outbox", "subject", mail.Subject, "dir", m.dir)
+	return nil
+}
+
+// Check は、書き出し先のディレクトリにファイルを作成できることを確認します
+func (m *outboxMailer) Check(ctx context.Context) error {
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create outbox directory: %w", err)
+	}
+
+	f, err := os.CreateTemp(m.dir, ".healthcheck-*")
+	if err != nil {
+		return fmt.Errorf("outbox directory is not writable: %w", err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
@@ -0,0 +1,80 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"voice-link/domain/model"
+)
+
+// ErrQueueFull は、送信待ちのメールが上限に達したため、メールを受け付けられなかったことを示します
+var ErrQueueFull = errors.New("mail queue is full")
+
+// queuedMail は、送信待ちのメールです
+type queuedMail struct {
+	ctx  context.Context // ログとトレースに使用する、送信を依頼したときのコンテキスト
+	mail *model.Mail
+}
+
+// QueuedMailer は、メールをキューに追加してすぐに戻り、Runで起動した処理がバックグラウンドで送信するMailerです
+// リクエストの処理時間がメールサーバーの応答に左右されず、メールを送信したかどうかを応答時間から判別できないようにします
+type QueuedMailer struct {
+	mailer model.Mailer
+	queue  chan queuedMail
+}
+
+// NewQueuedMailer は、mailerで送信するメールをsize件までキューに保持するQueuedMailerを作成します
+func NewQueuedMailer(mailer model.Mailer, size int) *QueuedMailer {
+	return &QueuedMailer{
+		mailer: mailer,
+		queue:  make(chan queuedMail, size),
+	}
+}
+
+// Send は、メールを送信待ちのキューに追加します
+// キューが上限に達している場合は待機せずにErrQueueFullを返します
+func (m *QueuedMailer) Send(ctx context.Context, mail *model.Mail) error {
+	// リクエストの終了後に送信するため、取り消しは引き継がない
+	item := queuedMail{ctx: context.WithoutCancel(ctx), mail: mail}
+	select {
+	case m.queue <- item:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Run は、キューに追加されたメールを順に送信します
+// ctxが取り消された場合は、キューに残っているメールを送信してから終了します
+func (m *QueuedMailer) Run(ctx context.Context) {
+	for {
+		select {
+		case item := <-m.queue:
+			m.deliver(item)
+		case <-ctx.Done():
+			for {
+				select {
+				case item := <-m.queue:
+					m.deliver(item)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// deliver は、1件のメールを送信し、失敗した場合はログに記録します
+func (m *QueuedMailer) deliver(item queuedMail) {
+	if err := m.mailer.Send(item.ctx, item.mail); err != nil {
+		slog.ErrorContext(item.ctx, "failed to send queued mail", "subject", item.mail.Subject, "error", err)
+	}
+}
+
+// Check は、送信に使用するMailerの状態を確認します
+func (m *QueuedMailer) Check(ctx context.Context) error {
+	if checker, ok := m.mailer.(model.HealthChecker); ok {
+		return checker.Check(ctx)
+	}
+	return nil
+}
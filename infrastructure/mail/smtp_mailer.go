@@ -0,0 +1,145 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"time"
+	"voice-link/domain/model"
+)
+
+// defaultSMTPTimeout は、SMTPサーバーへの接続から送信の完了までにかける時間の上限です
+// サーバーが応答しない場合に送信処理が止まり続けないよう、接続に期限を設定します
+const defaultSMTPTimeout = 30 * time.Second
+
+// SMTPConfig は、SMTPサーバーへの接続設定を定義します
+type SMTPConfig struct {
+	Host     string // SMTPサーバーのホスト名
+	Port     int    // SMTPサーバーのポート番号
+	Username string // 認証ユーザー名（空の場合は認証しない）
+	Password string // 認証パスワード
+	From     string // 送信元メールアドレス
+}
+
+// smtpMailer は、SMTPサーバー経由でメールを送信する構造体です
+type smtpMailer struct {
+	config  SMTPConfig
+	dialer  net.Dialer
+	timeout time.Duration
+}
+
+// NewSMTPMailer は、SMTPサーバー経由でメールを送信するMailerを作成します
+// サーバーが対応している場合はSTARTTLSで暗号化して送信します
+func NewSMTPMailer(config SMTPConfig) model.Mailer {
+	return &smtpMailer{
+		config:  config,
+		timeout: defaultSMTPTimeout,
+	}
+}
+
+// Send は、SMTPサーバーにメールを送信します
+// 送信はtimeoutまたはctxの期限のうち早い方で打ち切り、ctxが取り消された場合も中断します
+func (m *smtpMailer) Send(ctx context.Context, mail *model.Mail) error {
+	msg, err := buildMessage(m.config.From, mail, time.Now())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+
+	conn, err := m.dialer.DialContext(ctx, "tcp", m.addr())
+	if err != nil {
+		return fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+	defer conn.Close()
+
+	// 応答しないサーバーとの送受信が期限を過ぎて続かないようにする
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return fmt.Errorf("failed to set smtp deadline: %w", err)
+	}
+	// 期限の前にctxが取り消された場合も、送受信を中断させる
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	if err := m.send(conn, mail.To, msg); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return fmt.Errorf("failed to send mail via smtp: %w", errors.Join(ctxErr, err))
+		}
+		return fmt.Errorf("failed to send mail via smtp: %w", err)
+	}
+
+	return nil
+}
+
+// send は、接続済みのSMTPサーバーとのセッションでメールを送信します
+// net/smtpのSendMailと同じく、対応している場合はSTARTTLSで暗号化し、認証情報がある場合は認証します
+func (m *smtpMailer) send(conn net.Conn, to string, msg []byte) error {
+	client, err := smtp.NewClient(conn, m.config.Host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.config.Host, MinVersion: tls.VersionTLS12}); err != nil {
+			return err
+		}
+	}
+	if m.config.Username != "" {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("smtp server does not support AUTH")
+		}
+		if err := client.Auth(smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(envelopeAddress(m.config.From)); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// envelopeAddress は、表示名を含む送信元（例: Voice Link <no-reply@example.com>）からMAIL FROMに使用するアドレスを取り出します
+// 解析できない場合はそのまま返します
+func envelopeAddress(from string) string {
+	addr, err := netmail.ParseAddress(from)
+	if err != nil {
+		return from
+	}
+	return addr.Address
+}
+
+// Check は、SMTPサーバーにTCPで接続できることを確認します
+// ヘルスチェックのたびにメールサーバーのセッションを開始しないよう、接続のみを確認します
+func (m *smtpMailer) Check(ctx context.Context) error {
+	conn, err := m.dialer.DialContext(ctx, "tcp", m.addr())
+	if err != nil {
+		return fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+	return conn.Close()
+}
+
+// addr は、SMTPサーバーのアドレスを返します
+func (m *smtpMailer) addr() string {
+	return net.JoinHostPort(m.config.Host, fmt.Sprint(m.config.Port))
+}
@@ -0,0 +1,157 @@
+// package metrics は、Prometheus形式のメトリクスの収集と公開を提供します
+// ラベルにはルートのテンプレートやエラーコードなど値の種類が限られるもののみを使用し、
+// ユーザーIDやパスなどの値によって系列が増え続けないようにします
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace は、このアプリケーションのメトリクス名の接頭辞です
+const namespace = "voice_link"
+
+// Metrics は、アプリケーションのメトリクスを保持し、/metricsで公開します
+// usecase.Metrics、middleware.RequestMetrics、middleware.TokenMetricsを実装します
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests        *prometheus.CounterVec
+	httpRequestDuration *prometheus.HistogramVec
+	logins              *prometheus.CounterVec
+	registrations       prometheus.Counter
+	passwordResets      *prometheus.CounterVec
+	tokenRejections     *prometheus.CounterVec
+}
+
+// New は、メトリクスを登録したMetricsを作成します
+// Goランタイムとプロセスのメトリクスも合わせて公開します
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Number of HTTP requests by method, route template and status code.",
+		}, []string{"method", "route", "status"}),
+		httpRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency by method, route template and status code.",
+			// bcryptによるパスワードの検証（数百ミリ秒）を区別できるよう、既定より細かく区切る
+			Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 0.75, 1, 2.5, 5, 10},
+		}, []string{"method", "route", "status"}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "auth",
+			Name:      "logins_total",
+			Help:      "Number of login attempts by result and failure reason.",
+		}, []string{"result", "reason"}),
+		registrations: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "auth",
+			Name:      "registrations_total",
+			Help:      "Number of registered users.",
+		}),
+		passwordResets: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "auth",
+			Name:      "password_resets_total",
+			Help:      "Number of password reset requests and completed resets.",
+		}, []string{"stage"}),
+		tokenRejections: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "auth",
+			Name:      "token_rejections_total",
+			Help:      "Number of access tokens rejected by the auth middleware by reason.",
+		}, []string{"reason"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpRequestDuration,
+		m.logins,
+		m.registrations,
+		m.passwordResets,
+		m.tokenRejections,
+	)
+
+	return m
+}
+
+// RegisterDB は、データベースのコネクションプールの状態（使用中、待機中の接続数など）を公開します
+func (m *Metrics) RegisterDB(db *sql.DB, dbName string) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, dbName))
+}
+
+// Handler は、メトリクスをPrometheus形式で返すHTTPハンドラーを返します
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// otherMethod は、標準のメソッド以外のリクエストを記録する際のメソッド名です
+const otherMethod = "OTHER"
+
+// standardMethods は、メソッド名をそのままラベルに使用する標準のHTTPメソッドです
+var standardMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodConnect: true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+// ObserveRequest は、HTTPリクエストの件数と処理時間を記録します
+// クライアントが任意のメソッド名で系列を増やせないよう、標準以外のメソッドはOTHERにまとめます
+func (m *Metrics) ObserveRequest(method, route string, status int, duration time.Duration) {
+	if !standardMethods[method] {
+		method = otherMethod
+	}
+	code := strconv.Itoa(status)
+	m.httpRequests.WithLabelValues(method, route, code).Inc()
+	m.httpRequestDuration.WithLabelValues(method, route, code).Observe(duration.Seconds())
+}
+
+// LoginSucceeded は、ログインの成功を記録します
+func (m *Metrics) LoginSucceeded() {
+	m.logins.WithLabelValues("success", "").Inc()
+}
+
+// LoginFailed は、ログインの失敗を理由ごとに記録します
+func (m *Metrics) LoginFailed(reason string) {
+	m.logins.WithLabelValues("failure", reason).Inc()
+}
+
+// UserRegistered は、ユーザーの登録を記録します
+func (m *Metrics) UserRegistered() {
+	m.registrations.Inc()
+}
+
+// PasswordResetRequested は、パスワードリセットのリクエストを記録します
+func (m *Metrics) PasswordResetRequested() {
+	m.passwordResets.WithLabelValues("requested").Inc()
+}
+
+// PasswordResetCompleted は、パスワードリセットの完了を記録します
+func (m *Metrics) PasswordResetCompleted() {
+	m.passwordResets.WithLabelValues("completed").Inc()
+}
+
+// TokenRejected は、AuthMiddlewareでトークンを拒否したことを理由ごとに記録します
+func (m *Metrics) TokenRejected(reason string) {
+	m.tokenRejections.WithLabelValues(reason).Inc()
+}
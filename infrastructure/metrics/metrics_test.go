@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics_Counters(t *testing.T) {
+	m := New()
+
+	m.LoginSucceeded()
+	m.LoginFailed("invalid_credentials")
+	m.LoginFailed("invalid_credentials")
+	m.UserRegistered()
+	m.PasswordResetRequested()
+	m.PasswordResetCompleted()
+	m.TokenRejected("token_revoked")
+	m.ObserveRequest(http.MethodGet, "/api/v1/users/:id", http.StatusOK, 30*time.Millisecond)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.logins.WithLabelValues("success", "")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.logins.WithLabelValues("failure", "invalid_credentials")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.registrations))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.passwordResets.WithLabelValues("requested")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.passwordResets.WithLabelValues("completed")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.tokenRejections.WithLabelValues("token_revoked")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.httpRequests.WithLabelValues(http.MethodGet, "/api/v1/users/:id", "200")))
+}
+
+func TestMetrics_ObserveRequestNonStandardMethod(t *testing.T) {
+	m := New()
+
+	// 標準以外のメソッドはメソッド名ごとに系列を作らず、OTHERにまとめる
+	m.ObserveRequest("FOO", "unmatched", http.StatusMethodNotAllowed, time.Millisecond)
+	m.ObserveRequest("BAR", "unmatched", http.StatusMethodNotAllowed, time.Millisecond)
+	m.ObserveRequest(http.MethodPatch, "unmatched", http.StatusNotFound, time.Millisecond)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.httpRequests.WithLabelValues("OTHER", "unmatched", "405")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.httpRequests.WithLabelValues(http.MethodPatch, "unmatched", "404")))
+	assert.Equal(t, 2, testutil.CollectAndCount(m.httpRequests))
+}
+
+func TestMetrics_Handler(t *testing.T) {
+	m := New()
+	db, err := sql.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+	m.RegisterDB(db, "voice_link")
+	m.LoginSucceeded()
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	body, _ := io.ReadAll(rec.Body)
+	assert.Contains(t, string(body), `voice_link_auth_logins_total{reason="",result="success"} 1`)
+	// コネクションプールの状態
+	assert.Contains(t, string(body), `go_sql_open_connections{db_name="voice_link"}`)
+	assert.Contains(t, string(body), "go_goroutines")
+}
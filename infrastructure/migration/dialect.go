@@ -0,0 +1,70 @@
+package migration
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// advisoryLockKey は、PostgreSQLのアドバイザリロックでマイグレーションを識別するキーです
+// 任意の値ですが、アプリケーション内の他のロックと重複しないようにします
+const advisoryLockKey int64 = 7_104_020_241
+
+// dialect は、データベースの種類ごとに異なるマイグレーションの処理を定義します
+type dialect interface {
+	// name は、SQLファイルを配置するディレクトリ名です
+	name() string
+	// lock は、他のプロセスが同時にマイグレーションを実行しないようにロックを取得します
+	lock(conn *gorm.DB) error
+	// unlock は、lockで取得したロックを解放します
+	unlock(conn *gorm.DB) error
+	// hasTable は、テーブルが存在するかどうかを読み取りのみで判定します
+	hasTable(db *gorm.DB, table string) (bool, error)
+}
+
+// dialectOf は、接続先のデータベースの種類に対応するdialectを返します
+func dialectOf(db *gorm.DB) (dialect, error) {
+	switch db.Dialector.Name() {
+	case "postgres":
+		return postgresDialect{}, nil
+	case "sqlite":
+		return sqliteDialect{}, nil
+	}
+	return nil, fmt.Errorf("unsupported database for migrations: %s", db.Dialector.Name())
+}
+
+// postgresDialect は、PostgreSQLのマイグレーションの処理です
+// セッション単位のアドバイザリロックで、複数のレプリカが同時に起動した場合の競合を防ぎます
+type postgresDialect struct{}
+
+func (postgresDialect) name() string { return "postgres" }
+
+func (postgresDialect) lock(conn *gorm.DB) error {
+	return conn.Exec("SELECT pg_advisory_lock(?)", advisoryLockKey).Error
+}
+
+func (postgresDialect) unlock(conn *gorm.DB) error {
+	return conn.Exec("SELECT pg_advisory_unlock(?)", advisoryLockKey).Error
+}
+
+func (postgresDialect) hasTable(db *gorm.DB, table string) (bool, error) {
+	var exists bool
+	err := db.Raw("SELECT to_regclass(?) IS NOT NULL", table).Row().Scan(&exists)
+	return exists, err
+}
+
+// sqliteDialect は、SQLiteのマイグレーションの処理です
+// SQLiteは書き込みをデータベースファイル単位でロックするため、追加のロックは取得しません
+type sqliteDialect struct{}
+
+func (sqliteDialect) name() string { return "sqlite" }
+
+func (sqliteDialect) lock(conn *gorm.DB) error { return nil }
+
+func (sqliteDialect) unlock(conn *gorm.DB) error { return nil }
+
+func (sqliteDialect) hasTable(db *gorm.DB, table string) (bool, error) {
+	var exists bool
+	err := db.Raw("SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = ?)", table).Row().Scan(&exists)
+	return exists, err
+}
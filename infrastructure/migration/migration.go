@@ -0,0 +1,341 @@
+// package migration は、SQLファイルによるバージョン管理されたデータベースマイグレーションを提供します
+// マイグレーションはsql/{データベースの種類}/に{バージョン}_{名前}.up.sqlと.down.sqlの組で配置し、
+// バイナリに埋め込まれます。適用済みのバージョンはschema_migrationsテーブルに記録されます
+package migration
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed sql
+var files embed.FS
+
+// migrationTable は、適用済みのマイグレーションを記録するテーブルです
+const migrationTable = "schema_migrations"
+
+// fileNamePattern は、マイグレーションファイルの名前の形式です（例: 000001_create_users.up.sql）
+var fileNamePattern = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration は、1つのバージョンのマイグレーションを表します
+type Migration struct {
+	Version uint64 // バージョン（適用順）
+	Name    string // マイグレーションの名前
+	Up      string // 適用するSQL
+	Down    string // 取り消すSQL
+}
+
+// Status は、マイグレーションの適用状況を表します
+type Status struct {
+	Version   uint64
+	Name      string
+	AppliedAt *time.Time // 未適用の場合はnil
+	Missing   bool       // 適用済みだが、このバイナリにSQLファイルが含まれていない場合はtrue
+}
+
+// appliedMigration は、schema_migrationsテーブルの行です
+type appliedMigration struct {
+	Version   uint64
+	Name      string
+	AppliedAt time.Time
+}
+
+// Migrator は、マイグレーションの適用と取り消しを行います
+type Migrator struct {
+	db         *gorm.DB
+	dialect    dialect
+	migrations []Migration
+}
+
+// New は、接続先のデータベースの種類に対応するマイグレーションを読み込んだMigratorを作成します
+func New(db *gorm.DB) (*Migrator, error) {
+	d, err := dialectOf(db)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations, err := Load(d.name())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, dialect: d, migrations: migrations}, nil
+}
+
+// Load は、指定したデータベースの種類のマイグレーションをバージョン順に読み込みます
+func Load(dialectName string) ([]Migration, error) {
+	dir := path.Join("sql", dialectName)
+	entries, err := fs.ReadDir(files, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations for %s: %w", dialectName, err)
+	}
+
+	byVersion := make(map[uint64]*Migration)
+	for _, entry := range entries {
+		matches := fileNamePattern.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			return nil, fmt.Errorf("invalid migration file name: %s", entry.Name())
+		}
+
+		version, err := strconv.ParseUint(matches[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version: %s", entry.Name())
+		}
+
+		content, err := fs.ReadFile(files, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: matches[2]}
+			byVersion[version] = m
+		}
+		if m.Name != matches[2] {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", version, m.Name, matches[2])
+		}
+
+		if matches[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		// 取り消せないマイグレーションを防ぐため、upとdownの両方を必須とする
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s must have both up and down files", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Up は、未適用のマイグレーションをすべて適用し、適用した件数を返します
+// 複数のプロセスが同時に起動した場合も、ロックにより1つのプロセスのみが適用します
+func (m *Migrator) Up() (int, error) {
+	count := 0
+	err := m.withLock(func(conn *gorm.DB) error {
+		applied, err := m.applied(conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(migration.Up).Error; err != nil {
+					return err
+				}
+				return tx.Table(migrationTable).Create(&appliedMigration{
+					Version:   migration.Version,
+					Name:      migration.Name,
+					AppliedAt: time.Now(),
+				}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("failed to apply migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			slog.Info("applied migration", "version", migration.Version, "name", migration.Name)
+			count++
+		}
+		return nil
+	})
+
+	return count, err
+}
+
+// Down は、適用済みのマイグレーションを新しいものから指定した件数だけ取り消し、取り消した件数を返します
+func (m *Migrator) Down(steps int) (int, error) {
+	if steps < 1 {
+		return 0, errors.New("steps must be at least 1")
+	}
+
+	count := 0
+	err := m.withLock(func(conn *gorm.DB) error {
+		var applied []appliedMigration
+		if err := conn.Table(migrationTable).Order("version DESC").Limit(steps).Find(&applied).Error; err != nil {
+			return err
+		}
+
+		for _, record := range applied {
+			migration, ok := m.find(record.Version)
+			if !ok {
+				return fmt.Errorf("migration %d_%s is applied but not found in this binary", record.Version, record.Name)
+			}
+
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(migration.Down).Error; err != nil {
+					return err
+				}
+				return tx.Table(migrationTable).Where("version = ?", migration.Version).Delete(&appliedMigration{}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("failed to roll back migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			slog.Info("rolled back migration", "version", migration.Version, "name", migration.Name)
+			count++
+		}
+		return nil
+	})
+
+	return count, err
+}
+
+// Status は、すべてのマイグレーションの適用状況をバージョン順に返します
+func (m *Migrator) Status() ([]Status, error) {
+	if err := m.ensureTable(m.db); err != nil {
+		return nil, err
+	}
+	return m.status(m.db)
+}
+
+// status は、schema_migrationsテーブルからすべてのマイグレーションの適用状況を読み取ります
+func (m *Migrator) status(db *gorm.DB) ([]Status, error) {
+	applied, err := m.applied(db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if record, ok := applied[migration.Version]; ok {
+			appliedAt := record.AppliedAt
+			status.AppliedAt = &appliedAt
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+
+	// 新しいバージョンで適用され、このバイナリが知らないマイグレーション
+	for _, record := range applied {
+		appliedAt := record.AppliedAt
+		statuses = append(statuses, Status{Version: record.Version, Name: record.Name, AppliedAt: &appliedAt, Missing: true})
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+
+	return statuses, nil
+}
+
+// Pending は、未適用のマイグレーションの件数を返します
+func (m *Migrator) Pending() (int, error) {
+	statuses, err := m.Status()
+	if err != nil {
+		return 0, err
+	}
+	return countPending(statuses), nil
+}
+
+// Check は、未適用のマイグレーションがないことを確認します
+// 未適用のマイグレーションがある場合、スキーマがこのバイナリの想定と異なるためエラーを返します
+// レディネスチェックのたびにDDLを実行しないよう、schema_migrationsテーブルは作成せず、存在しない場合はすべて未適用とみなします
+func (m *Migrator) Check(ctx context.Context) error {
+	db := m.db.WithContext(ctx)
+	exists, err := m.dialect.hasTable(db, migrationTable)
+	if err != nil {
+		return err
+	}
+
+	pending := len(m.migrations)
+	if exists {
+		statuses, err := m.status(db)
+		if err != nil {
+			return err
+		}
+		pending = countPending(statuses)
+	}
+	if pending > 0 {
+		return fmt.Errorf("%d pending migration(s)", pending)
+	}
+	return nil
+}
+
+// countPending は、未適用のマイグレーションの件数を返します
+func countPending(statuses []Status) int {
+	pending := 0
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			pending++
+		}
+	}
+	return pending
+}
+
+// withLock は、マイグレーション用のロックを取得した1つの接続でfnを実行します
+// ロックはセッション単位のため、取得から解放まで同じ接続を使用します
+func (m *Migrator) withLock(fn func(conn *gorm.DB) error) error {
+	return m.db.Connection(func(conn *gorm.DB) error {
+		if err := m.dialect.lock(conn); err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		defer func() {
+			if err := m.dialect.unlock(conn); err != nil {
+				slog.Error("failed to release migration lock", "error", err)
+			}
+		}()
+
+		if err := m.ensureTable(conn); err != nil {
+			return err
+		}
+		return fn(conn)
+	})
+}
+
+// ensureTable は、schema_migrationsテーブルが存在しない場合に作成します
+func (m *Migrator) ensureTable(db *gorm.DB) error {
+	return db.Exec(`CREATE TABLE IF NOT EXISTS ` + migrationTable + ` (
+		version BIGINT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`).Error
+}
+
+// applied は、適用済みのマイグレーションをバージョンごとに返します
+func (m *Migrator) applied(db *gorm.DB) (map[uint64]appliedMigration, error) {
+	var records []appliedMigration
+	if err := db.Table(migrationTable).Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	applied := make(map[uint64]appliedMigration, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// find は、指定したバージョンのマイグレーションを返します
+func (m *Migrator) find(version uint64) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
@@ -0,0 +1,271 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+	"voice-link/domain/model"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// models は、マイグレーションで作成されるテーブルに対応するモデルです
+var models = []interface{}{&model.User{}, &model.RefreshToken{}, &model.RevokedToken{}, &model.UserTokenVersion{}, &model.RecoveryCode{}, &model.Identity{}, &model.OIDCAuthRequest{}, &model.Session{}}
+
+// setupSQLite は、テスト用のSQLiteデータベースを作成します
+func setupSQLite(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	assert.NoError(t, err)
+
+	// インメモリデータベースは接続ごとに別のデータベースになるため、接続を1つに制限する
+	sqlDB, err := db.DB()
+	assert.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
+	return db
+}
+
+// setupPostgres は、TEST_POSTGRES_DSNで指定されたPostgreSQLに接続します
+// 未設定の場合はテストをスキップします
+func setupPostgres(t *testing.T) *gorm.DB {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	assert.NoError(t, err)
+
+	// 前回のテストの状態を取り除く
+	for _, table := range []string{"sessions", "oidc_auth_requests", "user_identities", "mfa_recovery_codes", "user_token_versions", "revoked_tokens", "refresh_tokens", "users", migrationTable} {
+		assert.NoError(t, db.Exec("DROP TABLE IF EXISTS "+table).Error)
+	}
+
+	return db
+}
+
+// assertSchemaMatchesModels は、マイグレーション後のスキーマにモデルのすべてのカラムが存在することを確認します
+func assertSchemaMatchesModels(t *testing.T, db *gorm.DB) {
+	for _, m := range models {
+		stmt := &gorm.Statement{DB: db}
+		assert.NoError(t, stmt.Parse(m))
+
+		assert.True(t, db.Migrator().HasTable(m), "table %s should exist", stmt.Schema.Table)
+		for _, column := range stmt.Schema.DBNames {
+			assert.True(t, db.Migrator().HasColumn(m, column), "column %s.%s should exist", stmt.Schema.Table, column)
+		}
+	}
+}
+
+// assertNoTables は、マイグレーションで作成されるテーブルが存在しないことを確認します
+func assertNoTables(t *testing.T, db *gorm.DB) {
+	for _, m := range models {
+		assert.False(t, db.Migrator().HasTable(m))
+	}
+}
+
+func TestLoad(t *testing.T) {
+	sqliteMigrations, err := Load("sqlite")
+	assert.NoError(t, err)
+	postgresMigrations, err := Load("postgres")
+	assert.NoError(t, err)
+
+	// どのデータベースでも同じバージョンのマイグレーションが存在すること
+	if !assert.Len(t, postgresMigrations, len(sqliteMigrations)) {
+		return
+	}
+	for i := range sqliteMigrations {
+		assert.Equal(t, sqliteMigrations[i].Version, postgresMigrations[i].Version)
+		assert.Equal(t, sqliteMigrations[i].Name, postgresMigrations[i].Name)
+	}
+
+	// バージョンは昇順で重複しないこと
+	for i := 1; i < len(sqliteMigrations); i++ {
+		assert.Less(t, sqliteMigrations[i-1].Version, sqliteMigrations[i].Version)
+	}
+
+	_, err = Load("mysql")
+	assert.Error(t, err)
+}
+
+// testUpDown は、すべてのマイグレーションの適用と取り消しを往復して確認します
+func testUpDown(t *testing.T, db *gorm.DB) {
+	migrator, err := New(db)
+	assert.NoError(t, err)
+	total := len(migrator.migrations)
+
+	// すべて適用
+	count, err := migrator.Up()
+	assert.NoError(t, err)
+	assert.Equal(t, total, count)
+	assertSchemaMatchesModels(t, db)
+
+	statuses, err := migrator.Status()
+	assert.NoError(t, err)
+	if !assert.Len(t, statuses, total) {
+		return
+	}
+	for _, status := range statuses {
+		assert.NotNil(t, status.AppliedAt)
+		assert.False(t, status.Missing)
+	}
+
+	assert.NoError(t, migrator.Check(context.Background()))
+
+	// 適用済みの場合は何もしない
+	count, err = migrator.Up()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	// 最新のマイグレーションを1件取り消す
+	count, err = migrator.Down(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	pending, err := migrator.Pending()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, pending)
+	assert.Error(t, migrator.Check(context.Background()))
+
+	// 残りをすべて取り消す
+	count, err = migrator.Down(total)
+	assert.NoError(t, err)
+	assert.Equal(t, total-1, count)
+	assertNoTables(t, db)
+
+	// 取り消した後に再度適用できること
+	count, err = migrator.Up()
+	assert.NoError(t, err)
+	assert.Equal(t, total, count)
+	assertSchemaMatchesModels(t, db)
+}
+
+func TestMigrator_SQLite(t *testing.T) {
+	testUpDown(t, setupSQLite(t))
+}
+
+func TestMigrator_Postgres(t *testing.T) {
+	testUpDown(t, setupPostgres(t))
+}
+
+func TestMigrator_PostgresConcurrentUp(t *testing.T) {
+	db := setupPostgres(t)
+
+	// 複数のレプリカが同時に起動した場合も、マイグレーションは一度だけ適用されること
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		total int
+	)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			migrator, err := New(db)
+			assert.NoError(t, err)
+			count, err := migrator.Up()
+			assert.NoError(t, err)
+
+			mu.Lock()
+			total += count
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	migrations, err := Load("postgres")
+	assert.NoError(t, err)
+	assert.Equal(t, len(migrations), total)
+}
+
+// autoMigratedUser は、AutoMigrateを使用していたバージョンのユーザーのモデルです
+// それ以降に追加したカラムはマイグレーションで追加されます
+type autoMigratedUser struct {
+	ID                       uint   `gorm:"primaryKey"`
+	Name                     string `gorm:"not null"`
+	Email                    string `gorm:"unique;not null"`
+	Password                 string `gorm:"not null"`
+	Role                     string `gorm:"type:varchar(20);not null;default:user"`
+	PreferredLanguage        string `gorm:"type:varchar(8);not null;default:ja"`
+	EmailVerifiedAt          *time.Time
+	PendingEmail             *string
+	EmailVerificationToken   *string `gorm:"unique"`
+	EmailVerificationExpires *time.Time
+	PasswordResetToken       *string `gorm:"unique"`
+	PasswordResetExpires     *time.Time
+	CreatedAt                time.Time
+	UpdatedAt                time.Time
+}
+
+// TableName は、ユーザーと同じテーブル名を返します
+func (autoMigratedUser) TableName() string {
+	return "users"
+}
+
+func TestMigrator_ExistingAutoMigratedDatabase(t *testing.T) {
+	db := setupSQLite(t)
+
+	// 以前のバージョンで起動時のAutoMigrateによって作成されたデータベース
+	// usersテーブルはAutoMigrateを使用していたバージョンのモデルで作成する
+	assert.NoError(t, db.AutoMigrate(&autoMigratedUser{}, &model.RefreshToken{}, &model.RevokedToken{}, &model.UserTokenVersion{}))
+	assert.NoError(t, db.Create(&autoMigratedUser{Name: "既存ユーザー", Email: "existing@example.com", Password: "hashed"}).Error)
+
+	migrator, err := New(db)
+	assert.NoError(t, err)
+
+	// 既存のテーブルとデータを保ったまま適用済みとして記録されること
+	_, err = migrator.Up()
+	assert.NoError(t, err)
+
+	pending, err := migrator.Pending()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, pending)
+
+	var count int64
+	db.Model(&model.User{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestMigrator_UnknownAppliedMigration(t *testing.T) {
+	db := setupSQLite(t)
+	migrator, err := New(db)
+	assert.NoError(t, err)
+	_, err = migrator.Up()
+	assert.NoError(t, err)
+
+	// 新しいバージョンのアプリケーションで適用されたマイグレーション
+	assert.NoError(t, db.Table(migrationTable).Create(&appliedMigration{Version: 999999, Name: "from_newer_release"}).Error)
+
+	statuses, err := migrator.Status()
+	assert.NoError(t, err)
+	last := statuses[len(statuses)-1]
+	assert.Equal(t, uint64(999999), last.Version)
+	assert.True(t, last.Missing)
+
+	// SQLファイルがないマイグレーションは取り消せない
+	_, err = migrator.Down(1)
+	assert.Error(t, err)
+}
+
+func TestMigrator_Check(t *testing.T) {
+	db := setupSQLite(t)
+	migrator, err := New(db)
+	assert.NoError(t, err)
+
+	// schema_migrationsテーブルがない場合はすべて未適用とみなし、テーブルは作成しない
+	err = migrator.Check(context.Background())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), fmt.Sprintf("%d pending migration(s)", len(migrator.migrations)))
+	assert.False(t, db.Migrator().HasTable(migrationTable))
+
+	_, err = migrator.Up()
+	assert.NoError(t, err)
+	assert.NoError(t, migrator.Check(context.Background()))
+}
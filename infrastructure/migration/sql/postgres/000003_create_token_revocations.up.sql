@@ -0,0 +1,19 @@
+-- 個別に失効したアクセストークン
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    jti varchar(64) NOT NULL,
+    user_id bigint NOT NULL,
+    expires_at timestamptz NOT NULL,
+    created_at timestamptz,
+    PRIMARY KEY (jti)
+);
+
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_user_id ON revoked_tokens (user_id);
+
+-- ユーザー単位で一括失効するためのトークン世代
+CREATE TABLE IF NOT EXISTS user_token_versions (
+    user_id bigint NOT NULL,
+    version bigint NOT NULL DEFAULT 0,
+    updated_at timestamptz,
+    PRIMARY KEY (user_id)
+);
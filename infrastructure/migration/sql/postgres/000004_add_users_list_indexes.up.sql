@@ -0,0 +1,4 @@
+-- 管理者用のユーザー一覧の並び替えとキーセットページネーション
+-- 同じ値のユーザーはIDの順に並べるため、IDを含めた複合インデックスにする
+CREATE INDEX IF NOT EXISTS idx_users_created_at_id ON users (created_at, id);
+CREATE INDEX IF NOT EXISTS idx_users_name_id ON users (name, id);
@@ -0,0 +1,8 @@
+-- ログインの連続した失敗回数とロックの状態
+ALTER TABLE users ADD COLUMN IF NOT EXISTS failed_login_attempts integer NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_failed_login_at timestamptz;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locked_until timestamptz;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS unlock_token text;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS unlock_token_expires timestamptz;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uni_users_unlock_token ON users (unlock_token);
@@ -0,0 +1,15 @@
+-- 2段階認証（TOTP）の秘密鍵と状態
+ALTER TABLE users ADD COLUMN IF NOT EXISTS mfa_secret text;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS mfa_enabled_at timestamptz;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS mfa_last_used_step bigint NOT NULL DEFAULT 0;
+
+-- 2段階認証のリカバリーコード（ハッシュ値のみ保存）
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL,
+    code_hash text NOT NULL,
+    used_at timestamptz,
+    created_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_mfa_recovery_codes_user_id ON mfa_recovery_codes (user_id);
@@ -0,0 +1,15 @@
+-- ログインごとのセッション（idはアクセストークンのsidとリフレッシュトークンのfamily_id）
+CREATE TABLE IF NOT EXISTS sessions (
+    id varchar(64) NOT NULL,
+    user_id bigint NOT NULL,
+    device_name text,
+    user_agent text,
+    ip_address text,
+    created_at timestamptz,
+    last_seen_at timestamptz NOT NULL,
+    expires_at timestamptz NOT NULL,
+    revoked_at timestamptz,
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions (user_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_sessions_expires_at;
+DROP INDEX IF EXISTS idx_refresh_tokens_expires_at;
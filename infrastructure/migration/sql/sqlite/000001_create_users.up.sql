@@ -0,0 +1,21 @@
+-- ユーザー
+-- AutoMigrateで作成済みのデータベースにも適用できるよう、IF NOT EXISTSを指定する
+CREATE TABLE IF NOT EXISTS users (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    name text NOT NULL,
+    email text NOT NULL,
+    password text NOT NULL,
+    role varchar(20) NOT NULL DEFAULT 'user',
+    preferred_language varchar(8) NOT NULL DEFAULT 'ja',
+    email_verified_at datetime,
+    pending_email text,
+    email_verification_token text,
+    email_verification_expires datetime,
+    password_reset_token text,
+    password_reset_expires datetime,
+    created_at datetime,
+    updated_at datetime,
+    CONSTRAINT uni_users_email UNIQUE (email),
+    CONSTRAINT uni_users_email_verification_token UNIQUE (email_verification_token),
+    CONSTRAINT uni_users_password_reset_token UNIQUE (password_reset_token)
+);
@@ -0,0 +1,15 @@
+-- リフレッシュトークン
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    user_id integer NOT NULL,
+    token_hash text NOT NULL,
+    family_id text NOT NULL,
+    expires_at datetime NOT NULL,
+    used_at datetime,
+    revoked_at datetime,
+    created_at datetime
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refresh_tokens_token_hash ON refresh_tokens (token_hash);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens (user_id);
@@ -0,0 +1,19 @@
+-- 個別に失効したアクセストークン
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    jti varchar(64) NOT NULL,
+    user_id integer NOT NULL,
+    expires_at datetime NOT NULL,
+    created_at datetime,
+    PRIMARY KEY (jti)
+);
+
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_user_id ON revoked_tokens (user_id);
+
+-- ユーザー単位で一括失効するためのトークン世代
+CREATE TABLE IF NOT EXISTS user_token_versions (
+    user_id integer NOT NULL,
+    version integer NOT NULL DEFAULT 0,
+    updated_at datetime,
+    PRIMARY KEY (user_id)
+);
@@ -0,0 +1,5 @@
+-- 退会日時（猶予期間の経過後に完全に削除する）
+ALTER TABLE users ADD COLUMN deleted_at datetime;
+
+-- 完全に削除する対象の検索に使用する
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
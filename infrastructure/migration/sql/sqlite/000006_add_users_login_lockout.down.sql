@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS uni_users_unlock_token;
+ALTER TABLE users DROP COLUMN unlock_token_expires;
+ALTER TABLE users DROP COLUMN unlock_token;
+ALTER TABLE users DROP COLUMN locked_until;
+ALTER TABLE users DROP COLUMN last_failed_login_at;
+ALTER TABLE users DROP COLUMN failed_login_attempts;
@@ -0,0 +1,9 @@
+-- ログインの連続した失敗回数とロックの状態
+ALTER TABLE users ADD COLUMN failed_login_attempts integer NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN last_failed_login_at datetime;
+ALTER TABLE users ADD COLUMN locked_until datetime;
+ALTER TABLE users ADD COLUMN unlock_token text;
+ALTER TABLE users ADD COLUMN unlock_token_expires datetime;
+
+-- SQLiteではALTER TABLEで一意制約を追加できないため、一意インデックスを作成する
+CREATE UNIQUE INDEX IF NOT EXISTS uni_users_unlock_token ON users (unlock_token);
@@ -0,0 +1,15 @@
+-- 2段階認証（TOTP）の秘密鍵と状態
+ALTER TABLE users ADD COLUMN mfa_secret text;
+ALTER TABLE users ADD COLUMN mfa_enabled_at datetime;
+ALTER TABLE users ADD COLUMN mfa_last_used_step integer NOT NULL DEFAULT 0;
+
+-- 2段階認証のリカバリーコード（ハッシュ値のみ保存）
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    user_id integer NOT NULL,
+    code_hash text NOT NULL,
+    used_at datetime,
+    created_at datetime
+);
+
+CREATE INDEX IF NOT EXISTS idx_mfa_recovery_codes_user_id ON mfa_recovery_codes (user_id);
@@ -0,0 +1,27 @@
+-- ユーザーに連携したOpenID Connectのプロバイダーのアカウント
+CREATE TABLE IF NOT EXISTS user_identities (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    user_id integer NOT NULL,
+    provider varchar(64) NOT NULL,
+    subject text NOT NULL,
+    email text,
+    created_at datetime
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_identities_provider_subject ON user_identities (provider, subject);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_identities_user_id_provider ON user_identities (user_id, provider);
+
+-- 認可画面へ移動してからコールバックを受け取るまでの認可リクエスト（stateはハッシュ値のみ保存）
+CREATE TABLE IF NOT EXISTS oidc_auth_requests (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    state_hash text NOT NULL,
+    provider varchar(64) NOT NULL,
+    nonce text NOT NULL,
+    code_verifier text NOT NULL,
+    user_id integer,
+    expires_at datetime NOT NULL,
+    created_at datetime
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_oidc_auth_requests_state_hash ON oidc_auth_requests (state_hash);
+CREATE INDEX IF NOT EXISTS idx_oidc_auth_requests_expires_at ON oidc_auth_requests (expires_at);
@@ -0,0 +1,15 @@
+-- ログインごとのセッション（idはアクセストークンのsidとリフレッシュトークンのfamily_id）
+CREATE TABLE IF NOT EXISTS sessions (
+    id varchar(64) NOT NULL,
+    user_id integer NOT NULL,
+    device_name text,
+    user_agent text,
+    ip_address text,
+    created_at datetime,
+    last_seen_at datetime NOT NULL,
+    expires_at datetime NOT NULL,
+    revoked_at datetime,
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions (user_id);
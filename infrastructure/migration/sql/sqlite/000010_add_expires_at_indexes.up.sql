@@ -0,0 +1,3 @@
+-- 期限切れのリフレッシュトークンとセッションを定期的に削除するためのインデックス
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_expires_at ON refresh_tokens (expires_at);
+CREATE INDEX IF NOT EXISTS idx_sessions_expires_at ON sessions (expires_at);
@@ -0,0 +1,28 @@
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// appleClientSecretTTL は、Appleのclient_secretの有効期間です
+// トークンの交換ごとに作成するため、短い期間とします
+const appleClientSecretTTL = 5 * time.Minute
+
+// AppleClientSecret は、Appleのトークンエンドポイントに送信するclient_secretを作成する関数を返します
+// Appleはclient_secretとして、Apple Developerで発行した秘密鍵（ES256）で署名したJWTを要求します
+func AppleClientSecret(teamID, keyID, clientID string, key *ecdsa.PrivateKey) func(now time.Time) (string, error) {
+	return func(now time.Time) (string, error) {
+		token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.RegisteredClaims{
+			Issuer:    teamID,
+			Subject:   clientID,
+			Audience:  jwt.ClaimStrings{AppleIssuer},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(appleClientSecretTTL)),
+		})
+		token.Header["kid"] = keyID
+		return token.SignedString(key)
+	}
+}
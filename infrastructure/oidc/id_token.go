@@ -0,0 +1,226 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+	"voice-link/domain/model"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// idTokenLeeway は、IDトークンの有効期限と発行日時の検証で許容する時刻のずれです
+const idTokenLeeway = time.Minute
+
+// minKeyRefreshInterval は、未知のkidのIDトークンを受け取った場合に公開鍵を取得し直す最短の間隔です
+// プロバイダーの鍵のローテーションに追従しつつ、JWKSを繰り返し取得しないようにします
+const minKeyRefreshInterval = time.Minute
+
+// idTokenSigningMethods は、IDトークンの署名として受け付けるアルゴリズムです
+var idTokenSigningMethods = []string{"RS256", "ES256"}
+
+// errUnknownKey は、IDトークンの署名の公開鍵がJWKSに存在しない場合のエラーです
+var errUnknownKey = errors.New("signing key not found in jwks")
+
+// idTokenClaims は、IDトークンのクレームのうち、検証とアカウントの情報に使用する項目です
+type idTokenClaims struct {
+	Nonce           string       `json:"nonce"`
+	Email           string       `json:"email"`
+	EmailVerified   flexibleBool `json:"email_verified"`
+	Name            string       `json:"name"`
+	AuthorizedParty string       `json:"azp"`
+	jwt.RegisteredClaims
+}
+
+// flexibleBool は、真偽値または文字列の"true"、"false"で表された真偽値です
+// Appleはemail_verifiedを文字列で返します
+type flexibleBool bool
+
+// UnmarshalJSON は、真偽値と文字列のどちらの表記も読み込みます
+func (b *flexibleBool) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case bool:
+		*b = flexibleBool(v)
+	case string:
+		*b = v == "true"
+	default:
+		*b = false
+	}
+	return nil
+}
+
+// verifyIDToken は、IDトークンの署名、iss、aud、有効期限、nonceを検証してアカウントの情報を返します
+func (p *Provider) verifyIDToken(ctx context.Context, metadata *providerMetadata, rawIDToken, nonce string) (*model.ExternalIdentity, error) {
+	// 公開鍵の取得に失敗した場合はIDトークンの誤りではないため、拒否と区別して返す
+	var fetchErr error
+	claims := &idTokenClaims{}
+	_, err := jwt.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, err := p.publicKey(ctx, metadata.JWKSURI, kid)
+		if err != nil && !errors.Is(err, errUnknownKey) {
+			fetchErr = err
+		}
+		return key, err
+	},
+		jwt.WithValidMethods(idTokenSigningMethods),
+		jwt.WithIssuer(p.config.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(idTokenLeeway),
+	)
+	if fetchErr != nil {
+		return nil, fmt.Errorf("failed to fetch jwks: %w", fetchErr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid id_token: %v", model.ErrOIDCRejected, err)
+	}
+
+	// 認可リクエストで指定したnonceと一致しないIDトークンは、別の認可リクエストのものとして拒否する
+	if nonce == "" || subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return nil, fmt.Errorf("%w: id_token nonce mismatch", model.ErrOIDCRejected)
+	}
+	// 複数のクライアントに発行されたIDトークンは、このクライアントが要求したものに限る（OpenID Connect Core 3.1.3.7）
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.config.ClientID {
+		return nil, fmt.Errorf("%w: id_token azp %q does not match client id", model.ErrOIDCRejected, claims.AuthorizedParty)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: id_token has no subject", model.ErrOIDCRejected)
+	}
+
+	return &model.ExternalIdentity{
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+		Name:          claims.Name,
+	}, nil
+}
+
+// keySet は、JWKSから取得した署名の公開鍵です
+type keySet struct {
+	keys      map[string]interface{} // kidごとの公開鍵
+	fetchedAt time.Time
+}
+
+// publicKey は、kidの公開鍵を返します
+// キャッシュにない場合は、プロバイダーが鍵をローテーションした可能性があるためJWKSを取得し直します
+func (p *Provider) publicKey(ctx context.Context, jwksURI, kid string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys.lookup(kid); ok {
+		return key, nil
+	}
+	if p.keys != nil && time.Since(p.keys.fetchedAt) < p.keyRefreshInterval {
+		return nil, errUnknownKey
+	}
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.getJSON(ctx, jwksURI, &jwks); err != nil {
+		return nil, err
+	}
+
+	keys := &keySet{keys: make(map[string]interface{}, len(jwks.Keys)), fetchedAt: time.Now()}
+	for _, jwk := range jwks.Keys {
+		// 暗号化用の鍵や対応していない種類の鍵は無視する
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			continue
+		}
+		keys.keys[jwk.Kid] = key
+	}
+	p.keys = keys
+
+	if key, ok := p.keys.lookup(kid); ok {
+		return key, nil
+	}
+	return nil, errUnknownKey
+}
+
+// lookup は、kidの公開鍵を返します
+// kidが指定されていない場合は、鍵が1つのみであればその鍵を返します
+func (s *keySet) lookup(kid string) (interface{}, bool) {
+	if s == nil {
+		return nil, false
+	}
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+// jsonWebKey は、JWKS（RFC 7517）の公開鍵です
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKey は、RSAまたは楕円曲線の公開鍵を作成します
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid rsa exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve: %s", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != 32 || len(y) != 32 {
+			return nil, errors.New("invalid ec point")
+		}
+		// 曲線上の点であることを確認する
+		point := append(append([]byte{4}, x...), y...)
+		if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type: %s", k.Kty)
+	}
+}
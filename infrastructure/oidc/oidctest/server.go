@@ -0,0 +1,270 @@
+// package oidctest は、テストで使用するOpenID Connectのプロバイダーのモックを提供します
+// 認可画面は表示せず、設定したアカウントでログインしたものとして直ちにリダイレクトします
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// idTokenTTL は、発行するIDトークンの有効期間です
+const idTokenTTL = 10 * time.Minute
+
+// Account は、認可画面でログインしたものとして扱うプロバイダーのアカウントです
+type Account struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// authorization は、発行した認可コードに対応する認可リクエストです
+type authorization struct {
+	clientID      string
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+	account       Account
+}
+
+// Server は、ディスカバリー、JWKS、認可エンドポイント、トークンエンドポイントを提供するプロバイダーのモックです
+// トークンエンドポイントはPKCEのcode_verifierを検証し、RS256で署名したIDトークンを発行します
+type Server struct {
+	*httptest.Server
+	ClientID     string
+	ClientSecret string
+
+	mu      sync.Mutex
+	key     *rsa.PrivateKey
+	keyID   string
+	account Account
+	codes   map[string]authorization
+}
+
+// NewServer は、クライアントIDとクライアントシークレットを登録したプロバイダーのモックを起動します
+// 使用後はCloseで停止します
+func NewServer(clientID, clientSecret string) *Server {
+	s := &Server{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		account:      Account{Subject: "mock-user", Email: "mock-user@example.com", EmailVerified: true, Name: "Mock User"},
+		codes:        make(map[string]authorization),
+	}
+	s.RotateKey()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", s.handleDiscovery)
+	mux.HandleFunc("GET /jwks", s.handleJWKS)
+	mux.HandleFunc("GET /authorize", s.handleAuthorize)
+	mux.HandleFunc("POST /token", s.handleToken)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Issuer は、プロバイダーのIssuerを返します
+func (s *Server) Issuer() string {
+	return s.URL
+}
+
+// SetAccount は、以降の認可リクエストでログインしたものとして扱うアカウントを設定します
+func (s *Server) SetAccount(account Account) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.account = account
+}
+
+// RotateKey は、IDトークンの署名に使用する鍵を新しい鍵に置き換えます
+// 古い鍵はJWKSから削除されます
+func (s *Server) RotateKey() {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(fmt.Sprintf("oidctest: failed to generate key: %v", err))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.key = key
+	s.keyID = randomString()
+}
+
+// Authorize は、認可画面のURLにアクセスしてリダイレクト先に渡される認可コードとstateを返します
+// ブラウザでログインしてリダイレクトされるまでの操作の代わりに使用します
+func (s *Server) Authorize(authURL string) (code, state string, err error) {
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusFound {
+		return "", "", fmt.Errorf("authorize returned status %d", resp.StatusCode)
+	}
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return "", "", err
+	}
+	return location.Query().Get("code"), location.Query().Get("state"), nil
+}
+
+func (s *Server) handleDiscovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                s.URL,
+		"authorization_endpoint":                s.URL + "/authorize",
+		"token_endpoint":                        s.URL + "/token",
+		"jwks_uri":                              s.URL + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (s *Server) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	key, keyID := s.key, s.keyID
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": keyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	})
+}
+
+// handleAuthorize は、認可リクエストを検証し、設定したアカウントの認可コードを付けてリダイレクトします
+func (s *Server) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("client_id") != s.ClientID {
+		http.Error(w, "unknown client_id", http.StatusBadRequest)
+		return
+	}
+	if query.Get("response_type") != "code" || query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		http.Error(w, "authorization code flow with PKCE (S256) is required", http.StatusBadRequest)
+		return
+	}
+	redirectURI, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || !redirectURI.IsAbs() {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	code := randomString()
+	s.mu.Lock()
+	s.codes[code] = authorization{
+		clientID:      s.ClientID,
+		redirectURI:   redirectURI.String(),
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+		account:       s.account,
+	}
+	s.mu.Unlock()
+
+	params := redirectURI.Query()
+	params.Set("code", code)
+	params.Set("state", query.Get("state"))
+	redirectURI.RawQuery = params.Encode()
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+// handleToken は、認可コードとcode_verifierを検証してIDトークンを発行します
+// 認可コードは一度だけ使用できます
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		tokenError(w, "invalid_request")
+		return
+	}
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != s.ClientID || clientSecret != s.ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		tokenError(w, "unsupported_grant_type")
+		return
+	}
+
+	s.mu.Lock()
+	code := r.PostForm.Get("code")
+	auth, ok := s.codes[code]
+	delete(s.codes, code)
+	key, keyID := s.key, s.keyID
+	s.mu.Unlock()
+
+	if !ok || auth.redirectURI != r.PostForm.Get("redirect_uri") {
+		tokenError(w, "invalid_grant")
+		return
+	}
+	verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(verifier[:]) != auth.codeChallenge {
+		tokenError(w, "invalid_grant")
+		return
+	}
+
+	now := time.Now()
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            s.URL,
+		"sub":            auth.account.Subject,
+		"aud":            auth.clientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(idTokenTTL).Unix(),
+		"nonce":          auth.nonce,
+		"email":          auth.account.Email,
+		"email_verified": auth.account.EmailVerified,
+		"name":           auth.account.Name,
+	})
+	idToken.Header["kid"] = keyID
+	signed, err := idToken.SignedString(key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   int(idTokenTTL.Seconds()),
+		"id_token":     signed,
+	})
+}
+
+func tokenError(w http.ResponseWriter, code string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{"error": code})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func randomString() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("oidctest: failed to generate random value: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,226 @@
+// package oidc は、OpenID Connectのプロバイダーとの認可コードフロー（PKCE）とIDトークンの検証を提供します
+// プロバイダーの設定はディスカバリー（/.well-known/openid-configuration）から取得します
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"voice-link/domain/model"
+)
+
+// 主要なプロバイダーのIssuerです
+const (
+	GoogleIssuer = "https://accounts.google.com"
+	AppleIssuer  = "https://appleid.apple.com"
+)
+
+// ResponseModeFormPost は、認可コードをリダイレクト先にPOSTで送信させるresponse_modeです
+// Appleでメールアドレスを要求する場合に必要です
+const ResponseModeFormPost = "form_post"
+
+// defaultScopes は、Scopesを指定しない場合に要求するスコープです
+var defaultScopes = []string{"openid", "email", "profile"}
+
+// defaultHTTPTimeout は、HTTPClientを指定しない場合のプロバイダーとの通信のタイムアウトです
+const defaultHTTPTimeout = 10 * time.Second
+
+// maxResponseSize は、プロバイダーから読み込むレスポンスの最大サイズです
+const maxResponseSize = 1 << 20
+
+// Config は、プロバイダーに登録したクライアントの設定です
+type Config struct {
+	Issuer       string   // プロバイダーのIssuer。ディスカバリーの取得元とIDトークンのissの検証に使用します
+	ClientID     string   // クライアントID。IDトークンのaudの検証にも使用します
+	ClientSecret string   // クライアントシークレット
+	RedirectURL  string   // プロバイダーに登録したリダイレクト先のURL
+	Scopes       []string // 要求するスコープ。空の場合はopenid、email、profile
+	ResponseMode string   // 認可コードの受け取り方。空の場合はクエリパラメーター
+
+	// ClientSecretFunc は、トークンエンドポイントに送信するclient_secretを作成します
+	// Appleのように署名したJWTを使用するプロバイダーの場合に指定し、指定した場合はClientSecretより優先します
+	ClientSecretFunc func(now time.Time) (string, error)
+	// HTTPClient は、プロバイダーとの通信に使用するクライアントです。nilの場合は10秒でタイムアウトするクライアントを使用します
+	HTTPClient *http.Client
+}
+
+// Provider は、model.OIDCProviderを実装するOpenID Connectのクライアントです
+// ディスカバリーと署名の公開鍵は初回の使用時に取得してキャッシュします
+type Provider struct {
+	config Config
+	client *http.Client
+
+	// keyRefreshInterval は、未知のkidのIDトークンを受け取った場合に公開鍵を取得し直す最短の間隔です
+	keyRefreshInterval time.Duration
+
+	mu       sync.Mutex
+	metadata *providerMetadata
+	keys     *keySet
+}
+
+// providerMetadata は、ディスカバリーで取得するプロバイダーの設定のうち、使用する項目です
+type providerMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// NewProvider は、設定に従ってプロバイダーとの認可コードフローを行うProviderを作成します
+func NewProvider(config Config) *Provider {
+	if len(config.Scopes) == 0 {
+		config.Scopes = defaultScopes
+	}
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: defaultHTTPTimeout}
+	}
+	return &Provider{config: config, client: client, keyRefreshInterval: minKeyRefreshInterval}
+}
+
+var _ model.OIDCProvider = (*Provider)(nil)
+
+// AuthCodeURL は、state、nonce、PKCEのcode_challenge（S256）を含む認可画面のURLを作成します
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := url.Parse(metadata.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid authorization endpoint: %w", err)
+	}
+
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.config.ClientID)
+	query.Set("redirect_uri", p.config.RedirectURL)
+	query.Set("scope", strings.Join(p.config.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+	if p.config.ResponseMode != "" {
+		query.Set("response_mode", p.config.ResponseMode)
+	}
+	authURL.RawQuery = query.Encode()
+
+	return authURL.String(), nil
+}
+
+// tokenResponse は、トークンエンドポイントのレスポンスです
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Exchange は、認可コードをトークンと交換し、IDトークンを検証してアカウントの情報を返します
+// プロバイダーが認可コードを拒否した場合や、IDトークンの検証に失敗した場合はmodel.ErrOIDCRejectedを返します
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*model.ExternalIdentity, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	clientSecret := p.config.ClientSecret
+	if p.config.ClientSecretFunc != nil {
+		if clientSecret, err = p.config.ClientSecretFunc(time.Now()); err != nil {
+			return nil, fmt.Errorf("failed to create client secret: %w", err)
+		}
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectURL)
+	form.Set("client_id", p.config.ClientID)
+	form.Set("client_secret", clientSecret)
+	form.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var token tokenResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&token); err != nil {
+		return nil, fmt.Errorf("failed to decode token response (status %d): %w", resp.StatusCode, err)
+	}
+	// 認可コードの誤りや期限切れ、code_verifierの不一致は400で返される
+	if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("%w: token endpoint returned %s: %s", model.ErrOIDCRejected, token.Error, token.ErrorDescription)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint returned status %d", resp.StatusCode)
+	}
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("%w: token response has no id_token", model.ErrOIDCRejected)
+	}
+
+	return p.verifyIDToken(ctx, metadata, token.IDToken, nonce)
+}
+
+// discover は、プロバイダーの設定を返します。未取得の場合はディスカバリーで取得します
+// 取得に失敗した場合はキャッシュせず、次の呼び出しで再度取得します
+func (p *Provider) discover(ctx context.Context) (*providerMetadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	discoveryURL := strings.TrimSuffix(p.config.Issuer, "/") + "/.well-known/openid-configuration"
+	var metadata providerMetadata
+	if err := p.getJSON(ctx, discoveryURL, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to fetch provider metadata: %w", err)
+	}
+
+	// 他のプロバイダーの設定を取得していないことを確認する（OpenID Connect Discovery 4.3）
+	if metadata.Issuer != p.config.Issuer {
+		return nil, fmt.Errorf("issuer in provider metadata %q does not match %q", metadata.Issuer, p.config.Issuer)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, errors.New("provider metadata is missing required endpoints")
+	}
+
+	p.metadata = &metadata
+	return p.metadata, nil
+}
+
+// getJSON は、URLからJSONを取得してdstに読み込みます
+func (p *Provider) getJSON(ctx context.Context, target string, dst interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned status %d", target, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(dst)
+}
@@ -0,0 +1,203 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+	"voice-link/domain/model"
+	"voice-link/infrastructure/oidc/oidctest"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+const testRedirectURL = "http://localhost:3000/oauth/callback/mock"
+
+// newTestProvider は、プロバイダーのモックと、モックに登録したクライアントのProviderを作成します
+func newTestProvider(t *testing.T) (*oidctest.Server, *Provider) {
+	server := oidctest.NewServer("client-id", "client-secret")
+	t.Cleanup(server.Close)
+
+	provider := NewProvider(Config{
+		Issuer:       server.Issuer(),
+		ClientID:     server.ClientID,
+		ClientSecret: server.ClientSecret,
+		RedirectURL:  testRedirectURL,
+	})
+	return server, provider
+}
+
+// codeChallenge は、code_verifierからS256のcode_challengeを作成します
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// authorize は、認可画面のURLを作成してモックで認可し、認可コードを返します
+func authorize(t *testing.T, server *oidctest.Server, provider *Provider, nonce, verifier string) string {
+	authURL, err := provider.AuthCodeURL(context.Background(), "state", nonce, codeChallenge(verifier))
+	assert.NoError(t, err)
+
+	code, state, err := server.Authorize(authURL)
+	assert.NoError(t, err)
+	assert.Equal(t, "state", state)
+	return code
+}
+
+func TestProvider_AuthCodeURL(t *testing.T) {
+	server, provider := newTestProvider(t)
+	provider.config.ResponseMode = ResponseModeFormPost
+
+	authURL, err := provider.AuthCodeURL(context.Background(), "state", "nonce", "challenge")
+	assert.NoError(t, err)
+
+	parsed, err := url.Parse(authURL)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, server.URL+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+	query := parsed.Query()
+	assert.Equal(t, "code", query.Get("response_type"))
+	assert.Equal(t, "client-id", query.Get("client_id"))
+	assert.Equal(t, testRedirectURL, query.Get("redirect_uri"))
+	assert.Equal(t, "openid email profile", query.Get("scope"))
+	assert.Equal(t, "state", query.Get("state"))
+	assert.Equal(t, "nonce", query.Get("nonce"))
+	assert.Equal(t, "challenge", query.Get("code_challenge"))
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+	assert.Equal(t, ResponseModeFormPost, query.Get("response_mode"))
+}
+
+func TestProvider_Exchange(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("IDトークンを検証してアカウントの情報を返す", func(t *testing.T) {
+		server, provider := newTestProvider(t)
+		server.SetAccount(oidctest.Account{Subject: "subject-1", Email: "user@example.com", EmailVerified: true, Name: "User"})
+		code := authorize(t, server, provider, "nonce", "verifier-verifier-verifier-verifier-verifier")
+
+		identity, err := provider.Exchange(ctx, code, "verifier-verifier-verifier-verifier-verifier", "nonce")
+
+		assert.NoError(t, err)
+		assert.Equal(t, &model.ExternalIdentity{Subject: "subject-1", Email: "user@example.com", EmailVerified: true, Name: "User"}, identity)
+	})
+
+	t.Run("code_verifierが一致しない場合は拒否する", func(t *testing.T) {
+		server, provider := newTestProvider(t)
+		code := authorize(t, server, provider, "nonce", "verifier")
+
+		_, err := provider.Exchange(ctx, code, "other-verifier", "nonce")
+
+		assert.ErrorIs(t, err, model.ErrOIDCRejected)
+	})
+
+	t.Run("nonceが一致しない場合は拒否する", func(t *testing.T) {
+		server, provider := newTestProvider(t)
+		code := authorize(t, server, provider, "nonce", "verifier")
+
+		_, err := provider.Exchange(ctx, code, "verifier", "other-nonce")
+
+		assert.ErrorIs(t, err, model.ErrOIDCRejected)
+	})
+
+	t.Run("認可コードは一度だけ使用できる", func(t *testing.T) {
+		server, provider := newTestProvider(t)
+		code := authorize(t, server, provider, "nonce", "verifier")
+		_, err := provider.Exchange(ctx, code, "verifier", "nonce")
+		assert.NoError(t, err)
+
+		_, err = provider.Exchange(ctx, code, "verifier", "nonce")
+
+		assert.ErrorIs(t, err, model.ErrOIDCRejected)
+	})
+
+	t.Run("他のクライアントに発行されたIDトークンは拒否する", func(t *testing.T) {
+		server, provider := newTestProvider(t)
+		code := authorize(t, server, provider, "nonce", "verifier")
+		// クライアントの認証は通るがaudが一致しない
+		provider.config.ClientID = "other-client"
+		server.ClientID = "other-client"
+
+		_, err := provider.Exchange(ctx, code, "verifier", "nonce")
+
+		assert.ErrorIs(t, err, model.ErrOIDCRejected)
+	})
+
+	t.Run("鍵のローテーション後はJWKSを取得し直す", func(t *testing.T) {
+		server, provider := newTestProvider(t)
+		provider.keyRefreshInterval = 0
+		code := authorize(t, server, provider, "nonce", "verifier")
+		_, err := provider.Exchange(ctx, code, "verifier", "nonce")
+		assert.NoError(t, err)
+
+		server.RotateKey()
+		code = authorize(t, server, provider, "nonce", "verifier")
+		_, err = provider.Exchange(ctx, code, "verifier", "nonce")
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("取得し直す間隔が経過していない場合は未知の鍵を拒否する", func(t *testing.T) {
+		server, provider := newTestProvider(t)
+		code := authorize(t, server, provider, "nonce", "verifier")
+		_, err := provider.Exchange(ctx, code, "verifier", "nonce")
+		assert.NoError(t, err)
+
+		server.RotateKey()
+		code = authorize(t, server, provider, "nonce", "verifier")
+		_, err = provider.Exchange(ctx, code, "verifier", "nonce")
+
+		assert.ErrorIs(t, err, model.ErrOIDCRejected)
+	})
+}
+
+func TestProvider_DiscoveryIssuerMismatch(t *testing.T) {
+	server, _ := newTestProvider(t)
+	provider := NewProvider(Config{Issuer: server.Issuer() + "/", ClientID: "client-id", RedirectURL: testRedirectURL})
+
+	_, err := provider.AuthCodeURL(context.Background(), "state", "nonce", "challenge")
+
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, model.ErrOIDCRejected)
+}
+
+func TestFlexibleBool(t *testing.T) {
+	for input, expected := range map[string]bool{
+		`true`:    true,
+		`false`:   false,
+		`"true"`:  true,
+		`"false"`: false,
+		`null`:    false,
+	} {
+		var b flexibleBool
+		assert.NoError(t, json.Unmarshal([]byte(input), &b), input)
+		assert.Equal(t, expected, bool(b), input)
+	}
+}
+
+func TestAppleClientSecret(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	now := time.Now()
+
+	secret, err := AppleClientSecret("TEAMID", "KEYID", "com.example.app", key)(now)
+	assert.NoError(t, err)
+
+	claims := &jwt.RegisteredClaims{}
+	token, err := jwt.ParseWithClaims(secret, claims, func(*jwt.Token) (interface{}, error) {
+		return &key.PublicKey, nil
+	}, jwt.WithValidMethods([]string{"ES256"}), jwt.WithAudience(AppleIssuer), jwt.WithIssuer("TEAMID"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "KEYID", token.Header["kid"])
+	assert.Equal(t, "com.example.app", claims.Subject)
+	assert.Equal(t, now.Add(appleClientSecretTTL).Unix(), claims.ExpiresAt.Unix())
+}
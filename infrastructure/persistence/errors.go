@@ -0,0 +1,67 @@
+package persistence
+
+import (
+	"errors"
+	"strings"
+	"voice-link/domain/model"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// pgUniqueViolation は、PostgreSQLの一意制約違反のエラーコードです
+const pgUniqueViolation = "23505"
+
+// uniqueConstraint は、一意制約と違反した場合に返すドメイン層のエラーの対応を表します
+type uniqueConstraint struct {
+	name   string // 制約名（PostgreSQLのエラーに含まれる）
+	column string // テーブル名と列名（SQLiteのエラーに含まれる）
+	err    error
+}
+
+// uniqueConstraints は、ドメイン層のエラーに変換する一意制約です
+// 制約名はマイグレーションで定義した名前と一致させます
+var uniqueConstraints = []uniqueConstraint{
+	{name: "uni_users_email", column: "users.email", err: model.ErrDuplicateEmail},
+	{name: "idx_user_identities_provider_subject", column: "user_identities.provider, user_identities.subject", err: model.ErrDuplicateIdentity},
+	{name: "idx_user_identities_user_id_provider", column: "user_identities.user_id, user_identities.provider", err: model.ErrDuplicateIdentity},
+}
+
+// translateError は、GORMのエラーをドメイン層のエラーに変換します
+func translateError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.ErrNotFound
+	}
+	if c, ok := violatedUniqueConstraint(err); ok {
+		return c.err
+	}
+	return err
+}
+
+// violatedUniqueConstraint は、エラーが既知の一意制約の違反であればその制約を返します
+func violatedUniqueConstraint(err error) (uniqueConstraint, bool) {
+	if err == nil {
+		return uniqueConstraint{}, false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		if pgErr.Code != pgUniqueViolation {
+			return uniqueConstraint{}, false
+		}
+		for _, c := range uniqueConstraints {
+			if pgErr.ConstraintName == c.name {
+				return c, true
+			}
+		}
+		return uniqueConstraint{}, false
+	}
+
+	// SQLiteのドライバーはテスト用に限られるため、型に依存せずメッセージで判定する
+	for _, c := range uniqueConstraints {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed: "+c.column) {
+			return c, true
+		}
+	}
+	return uniqueConstraint{}, false
+}
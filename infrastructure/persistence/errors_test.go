@@ -0,0 +1,70 @@
+package persistence
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"voice-link/domain/model"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestTranslateError(t *testing.T) {
+	otherErr := errors.New("connection refused")
+	otherUnique := &pgconn.PgError{Code: pgUniqueViolation, ConstraintName: "uni_users_password_reset_token"}
+
+	tests := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{
+			name:     "レコードなし",
+			err:      gorm.ErrRecordNotFound,
+			expected: model.ErrNotFound,
+		},
+		{
+			name:     "PostgreSQLのメールアドレスの一意制約違反",
+			err:      fmt.Errorf("insert: %w", &pgconn.PgError{Code: pgUniqueViolation, ConstraintName: "uni_users_email"}),
+			expected: model.ErrDuplicateEmail,
+		},
+		{
+			name:     "PostgreSQLのその他の一意制約違反",
+			err:      otherUnique,
+			expected: otherUnique,
+		},
+		{
+			name:     "SQLiteのメールアドレスの一意制約違反",
+			err:      errors.New("UNIQUE constraint failed: users.email"),
+			expected: model.ErrDuplicateEmail,
+		},
+		{
+			name:     "PostgreSQLの連携済みのアカウントの一意制約違反",
+			err:      &pgconn.PgError{Code: pgUniqueViolation, ConstraintName: "idx_user_identities_user_id_provider"},
+			expected: model.ErrDuplicateIdentity,
+		},
+		{
+			name:     "SQLiteの連携済みのアカウントの一意制約違反",
+			err:      errors.New("UNIQUE constraint failed: user_identities.provider, user_identities.subject"),
+			expected: model.ErrDuplicateIdentity,
+		},
+		{
+			name:     "その他のエラー",
+			err:      otherErr,
+			expected: otherErr,
+		},
+		{
+			name:     "エラーなし",
+			err:      nil,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, translateError(tt.err))
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package persistence
+
+import (
+	"context"
+	"voice-link/domain/model"
+
+	"gorm.io/gorm"
+)
+
+// databaseHealthChecker は、データベースへの接続を確認する構造体です
+type databaseHealthChecker struct {
+	db *gorm.DB // データベースコネクション
+}
+
+// NewDatabaseHealthChecker は、データベースに接続できるかどうかを確認するHealthCheckerを作成します
+func NewDatabaseHealthChecker(db *gorm.DB) model.HealthChecker {
+	return &databaseHealthChecker{db}
+}
+
+// Check は、コネクションプールの接続でデータベースにpingを送信します
+func (c *databaseHealthChecker) Check(ctx context.Context) error {
+	sqlDB, err := c.db.DB()
+	if err != nil {
+		return err
+	}
+
+	return sqlDB.PingContext(ctx)
+}
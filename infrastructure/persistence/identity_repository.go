@@ -0,0 +1,78 @@
+package persistence
+
+import (
+	"context"
+	"voice-link/domain/model"
+
+	"gorm.io/gorm"
+)
+
+// identityRepository は、ユーザーに連携したアカウントのデータベース操作を担当する構造体です
+type identityRepository struct {
+	db *gorm.DB // データベースコネクション
+}
+
+// NewIdentityRepository は、IdentityRepositoryインターフェースの新しいインスタンスを作成します
+func NewIdentityRepository(db *gorm.DB) model.IdentityRepository {
+	return &identityRepository{db}
+}
+
+// Create は、アカウントの連携をデータベースに作成します
+// 一意制約に違反した場合はErrDuplicateIdentityを返します
+func (r *identityRepository) Create(ctx context.Context, identity *model.Identity) (err error) {
+	ctx, span := startSpan(ctx, "IdentityRepository.Create")
+	defer func() { endSpan(span, err) }()
+
+	return translateError(conn(ctx, r.db).Create(identity).Error)
+}
+
+// FindByProviderSubject は、指定されたプロバイダーとアカウントの識別子の連携をデータベースから検索します
+func (r *identityRepository) FindByProviderSubject(ctx context.Context, provider, subject string) (_ *model.Identity, err error) {
+	ctx, span := startSpan(ctx, "IdentityRepository.FindByProviderSubject")
+	defer func() { endSpan(span, err) }()
+
+	var identity model.Identity
+	if err := conn(ctx, r.db).Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error; err != nil {
+		return nil, translateError(err)
+	}
+
+	return &identity, nil
+}
+
+// FindByUserID は、指定されたユーザーに連携したアカウントを連携した順に返します
+func (r *identityRepository) FindByUserID(ctx context.Context, userID uint) (_ []model.Identity, err error) {
+	ctx, span := startSpan(ctx, "IdentityRepository.FindByUserID")
+	defer func() { endSpan(span, err) }()
+
+	var identities []model.Identity
+	if err := conn(ctx, r.db).Where("user_id = ?", userID).Order("id").Find(&identities).Error; err != nil {
+		return nil, err
+	}
+
+	return identities, nil
+}
+
+// Delete は、指定されたユーザーに連携したプロバイダーのアカウントの連携を解除します
+func (r *identityRepository) Delete(ctx context.Context, userID uint, provider string) (err error) {
+	ctx, span := startSpan(ctx, "IdentityRepository.Delete")
+	defer func() { endSpan(span, err) }()
+
+	result := conn(ctx, r.db).Where("user_id = ? AND provider = ?", userID, provider).Delete(&model.Identity{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return model.ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteAllByUserID は、指定されたユーザーのすべてのアカウントの連携を削除します
+// 退会したユーザーのデータを完全に削除する場合に使用します
+func (r *identityRepository) DeleteAllByUserID(ctx context.Context, userID uint) (err error) {
+	ctx, span := startSpan(ctx, "IdentityRepository.DeleteAllByUserID")
+	defer func() { endSpan(span, err) }()
+
+	return conn(ctx, r.db).Where("user_id = ?", userID).Delete(&model.Identity{}).Error
+}
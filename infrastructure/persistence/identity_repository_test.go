@@ -0,0 +1,73 @@
+package persistence
+
+import (
+	"context"
+	"testing"
+	"time"
+	"voice-link/domain/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdentityRepository(t *testing.T) {
+	db := setupTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&model.Identity{}))
+	repo := NewIdentityRepository(db)
+	ctx := context.Background()
+
+	assert.NoError(t, repo.Create(ctx, &model.Identity{UserID: 1, Provider: "google", Subject: "google-1", Email: "test@example.com"}))
+	assert.NoError(t, repo.Create(ctx, &model.Identity{UserID: 1, Provider: "apple", Subject: "apple-1"}))
+
+	// 同じアカウントは他のユーザーに連携できない
+	err := repo.Create(ctx, &model.Identity{UserID: 2, Provider: "google", Subject: "google-1"})
+	assert.ErrorIs(t, err, model.ErrDuplicateIdentity)
+	// 同じプロバイダーのアカウントは1つのみ連携できる
+	err = repo.Create(ctx, &model.Identity{UserID: 1, Provider: "google", Subject: "google-2"})
+	assert.ErrorIs(t, err, model.ErrDuplicateIdentity)
+
+	found, err := repo.FindByProviderSubject(ctx, "google", "google-1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), found.UserID)
+	_, err = repo.FindByProviderSubject(ctx, "apple", "google-1")
+	assert.ErrorIs(t, err, model.ErrNotFound)
+
+	identities, err := repo.FindByUserID(ctx, 1)
+	assert.NoError(t, err)
+	if assert.Len(t, identities, 2) {
+		assert.Equal(t, "google", identities[0].Provider)
+		assert.Equal(t, "apple", identities[1].Provider)
+	}
+
+	assert.NoError(t, repo.Delete(ctx, 1, "google"))
+	assert.ErrorIs(t, repo.Delete(ctx, 1, "google"), model.ErrNotFound)
+
+	assert.NoError(t, repo.DeleteAllByUserID(ctx, 1))
+	identities, err = repo.FindByUserID(ctx, 1)
+	assert.NoError(t, err)
+	assert.Empty(t, identities)
+}
+
+func TestOIDCAuthRequestRepository(t *testing.T) {
+	db := setupTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&model.OIDCAuthRequest{}))
+	repo := NewOIDCAuthRequestRepository(db)
+	ctx := context.Background()
+	now := time.Now()
+	userID := uint(1)
+
+	assert.NoError(t, repo.Create(ctx, &model.OIDCAuthRequest{StateHash: "expired", Provider: "google", Nonce: "n", CodeVerifier: "v", ExpiresAt: now.Add(-time.Minute)}, now.Add(-time.Hour)))
+	assert.NoError(t, repo.Create(ctx, &model.OIDCAuthRequest{StateHash: "state", Provider: "google", Nonce: "nonce", CodeVerifier: "verifier", UserID: &userID, ExpiresAt: now.Add(10 * time.Minute)}, now))
+
+	// 期限切れの認可リクエストは作成時に削除される
+	_, err := repo.Consume(ctx, "expired")
+	assert.ErrorIs(t, err, model.ErrNotFound)
+
+	// 認可リクエストは一度だけ使用できる
+	request, err := repo.Consume(ctx, "state")
+	assert.NoError(t, err)
+	assert.Equal(t, "nonce", request.Nonce)
+	assert.Equal(t, "verifier", request.CodeVerifier)
+	assert.Equal(t, &userID, request.UserID)
+	_, err = repo.Consume(ctx, "state")
+	assert.ErrorIs(t, err, model.ErrNotFound)
+}
@@ -0,0 +1,92 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// logger は、GORMのログをslogに出力するロガーです
+// SQLはプレースホルダーのまま出力し、メールアドレスやトークンなどのバインド値は出力しません
+type logger struct {
+	logger        *slog.Logger
+	level         gormlogger.LogLevel
+	slowThreshold time.Duration
+}
+
+// NewLogger は、GORMのログをslogに出力するロガーを作成します
+// エラーになったSQLとslowThresholdより時間がかかったSQLを出力し、それ以外はデバッグレベルで出力します
+func NewLogger(l *slog.Logger, slowThreshold time.Duration) gormlogger.Interface {
+	return &logger{logger: l, level: gormlogger.Warn, slowThreshold: slowThreshold}
+}
+
+// LogMode は、指定されたレベルのロガーを返します
+func (l *logger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	clone := *l
+	clone.level = level
+	return &clone
+}
+
+// Info は、情報レベルのログを出力します
+func (l *logger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Info {
+		l.logger.InfoContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+// Warn は、警告レベルのログを出力します
+func (l *logger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Warn {
+		l.logger.WarnContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+// Error は、エラーレベルのログを出力します
+func (l *logger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Error {
+		l.logger.ErrorContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+// Trace は、実行したSQLを結果に応じたレベルで出力します
+// 該当するレコードがないことは想定された結果のため、エラーとして扱いません
+func (l *logger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	level := slog.LevelDebug
+	msg := "sql executed"
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && l.level >= gormlogger.Error:
+		level, msg = slog.LevelError, "sql failed"
+	case l.slowThreshold > 0 && elapsed > l.slowThreshold && l.level >= gormlogger.Warn:
+		level, msg = slog.LevelWarn, "slow sql"
+	}
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+
+	sql, rows := fc()
+	attrs := []slog.Attr{
+		slog.String("sql", sql),
+		slog.Int64("rows", rows),
+		slog.Float64("elapsed_ms", float64(elapsed.Microseconds())/1000),
+	}
+	if level == slog.LevelError {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	l.logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// ParamsFilter は、SQLのバインド値を取り除きます
+// GORMはこのメソッドを実装したロガーに対して、値を埋め込まずにSQLを渡します
+func (l *logger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
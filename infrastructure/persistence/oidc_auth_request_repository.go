@@ -0,0 +1,51 @@
+package persistence
+
+import (
+	"context"
+	"time"
+	"voice-link/domain/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// oidcAuthRequestRepository は、OpenID Connectの認可リクエストのデータベース操作を担当する構造体です
+type oidcAuthRequestRepository struct {
+	db *gorm.DB // データベースコネクション
+}
+
+// NewOIDCAuthRequestRepository は、OIDCAuthRequestRepositoryインターフェースの新しいインスタンスを作成します
+func NewOIDCAuthRequestRepository(db *gorm.DB) model.OIDCAuthRequestRepository {
+	return &oidcAuthRequestRepository{db}
+}
+
+// Create は、認可リクエストをデータベースに作成します
+// コールバックを受け取らなかった認可リクエストが残り続けないよう、期限切れの認可リクエストをあわせて削除します
+func (r *oidcAuthRequestRepository) Create(ctx context.Context, request *model.OIDCAuthRequest, now time.Time) (err error) {
+	ctx, span := startSpan(ctx, "OIDCAuthRequestRepository.Create")
+	defer func() { endSpan(span, err) }()
+
+	db := conn(ctx, r.db)
+	if err := db.Where("expires_at < ?", now).Delete(&model.OIDCAuthRequest{}).Error; err != nil {
+		return err
+	}
+	return db.Create(request).Error
+}
+
+// Consume は、指定されたstateのハッシュ値の認可リクエストを削除して返します
+// 削除できた場合のみ返すため、同じstateを同時に使用しても一方のみ成功します
+func (r *oidcAuthRequestRepository) Consume(ctx context.Context, stateHash string) (_ *model.OIDCAuthRequest, err error) {
+	ctx, span := startSpan(ctx, "OIDCAuthRequestRepository.Consume")
+	defer func() { endSpan(span, err) }()
+
+	var requests []model.OIDCAuthRequest
+	result := conn(ctx, r.db).Clauses(clause.Returning{}).Where("state_hash = ?", stateHash).Delete(&requests)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 || len(requests) == 0 {
+		return nil, model.ErrNotFound
+	}
+
+	return &requests[0], nil
+}
@@ -0,0 +1,64 @@
+package persistence
+
+import (
+	"context"
+	"time"
+	"voice-link/domain/model"
+
+	"gorm.io/gorm"
+)
+
+// recoveryCodeRepository は、2段階認証のリカバリーコードのデータベース操作を担当する構造体です
+type recoveryCodeRepository struct {
+	db *gorm.DB // データベースコネクション
+}
+
+// NewRecoveryCodeRepository は、RecoveryCodeRepositoryインターフェースの新しいインスタンスを作成します
+func NewRecoveryCodeRepository(db *gorm.DB) model.RecoveryCodeRepository {
+	return &recoveryCodeRepository{db}
+}
+
+// ReplaceAll は、指定されたユーザーのリカバリーコードをすべて削除し、codeHashesのコードを作成します
+// 古いコードが残らないよう、呼び出し側のトランザクション内で実行します
+func (r *recoveryCodeRepository) ReplaceAll(ctx context.Context, userID uint, codeHashes []string) (err error) {
+	ctx, span := startSpan(ctx, "RecoveryCodeRepository.ReplaceAll")
+	defer func() { endSpan(span, err) }()
+
+	db := conn(ctx, r.db)
+	if err := db.Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error; err != nil {
+		return err
+	}
+	if len(codeHashes) == 0 {
+		return nil
+	}
+
+	codes := make([]model.RecoveryCode, len(codeHashes))
+	for i, codeHash := range codeHashes {
+		codes[i] = model.RecoveryCode{UserID: userID, CodeHash: codeHash}
+	}
+	return db.Create(&codes).Error
+}
+
+// Use は、指定されたユーザーの未使用のリカバリーコードを使用済みにします
+// 同時に同じコードが使用された場合も一方のみ成功します
+func (r *recoveryCodeRepository) Use(ctx context.Context, userID uint, codeHash string, usedAt time.Time) (_ bool, err error) {
+	ctx, span := startSpan(ctx, "RecoveryCodeRepository.Use")
+	defer func() { endSpan(span, err) }()
+
+	result := conn(ctx, r.db).Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", usedAt)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// DeleteAllByUserID は、指定されたユーザーのすべてのリカバリーコードを削除します
+func (r *recoveryCodeRepository) DeleteAllByUserID(ctx context.Context, userID uint) (err error) {
+	ctx, span := startSpan(ctx, "RecoveryCodeRepository.DeleteAllByUserID")
+	defer func() { endSpan(span, err) }()
+
+	return conn(ctx, r.db).Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error
+}
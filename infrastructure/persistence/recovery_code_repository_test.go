@@ -0,0 +1,48 @@
+package persistence
+
+import (
+	"context"
+	"testing"
+	"time"
+	"voice-link/domain/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoveryCodeRepository(t *testing.T) {
+	db := setupTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&model.RecoveryCode{}))
+	repo := NewRecoveryCodeRepository(db)
+	ctx := context.Background()
+	now := time.Now()
+
+	assert.NoError(t, repo.ReplaceAll(ctx, 1, []string{"hash-a", "hash-b"}))
+	assert.NoError(t, repo.ReplaceAll(ctx, 2, []string{"hash-a"}))
+
+	// コードは一度だけ使用できる
+	used, err := repo.Use(ctx, 1, "hash-a", now)
+	assert.NoError(t, err)
+	assert.True(t, used)
+	used, err = repo.Use(ctx, 1, "hash-a", now)
+	assert.NoError(t, err)
+	assert.False(t, used)
+
+	// 他のユーザーのコードは使用できない
+	used, err = repo.Use(ctx, 1, "hash-c", now)
+	assert.NoError(t, err)
+	assert.False(t, used)
+	used, err = repo.Use(ctx, 2, "hash-a", now)
+	assert.NoError(t, err)
+	assert.True(t, used)
+
+	// 発行し直すと古いコードは使用できなくなる
+	assert.NoError(t, repo.ReplaceAll(ctx, 1, []string{"hash-c"}))
+	used, err = repo.Use(ctx, 1, "hash-b", now)
+	assert.NoError(t, err)
+	assert.False(t, used)
+
+	assert.NoError(t, repo.DeleteAllByUserID(ctx, 1))
+	used, err = repo.Use(ctx, 1, "hash-c", now)
+	assert.NoError(t, err)
+	assert.False(t, used)
+}
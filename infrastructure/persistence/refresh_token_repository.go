@@ -0,0 +1,94 @@
+package persistence
+
+import (
+	"context"
+	"time"
+	"voice-link/domain/model"
+
+	"gorm.io/gorm"
+)
+
+// refreshTokenRepository は、リフレッシュトークンのデータベース操作を担当する構造体です
+type refreshTokenRepository struct {
+	db *gorm.DB // データベースコネクション
+}
+
+// NewRefreshTokenRepository は、RefreshTokenRepositoryインターフェースの新しいインスタンスを作成します
+func NewRefreshTokenRepository(db *gorm.DB) model.RefreshTokenRepository {
+	return &refreshTokenRepository{db}
+}
+
+// Create は、新しいリフレッシュトークンをデータベースに作成します
+func (r *refreshTokenRepository) Create(ctx context.Context, token *model.RefreshToken) (err error) {
+	ctx, span := startSpan(ctx, "RefreshTokenRepository.Create")
+	defer func() { endSpan(span, err) }()
+
+	return conn(ctx, r.db).Create(token).Error
+}
+
+// FindByTokenHash は、指定されたハッシュ値のリフレッシュトークンをデータベースから検索します
+func (r *refreshTokenRepository) FindByTokenHash(ctx context.Context, tokenHash string) (_ *model.RefreshToken, err error) {
+	ctx, span := startSpan(ctx, "RefreshTokenRepository.FindByTokenHash")
+	defer func() { endSpan(span, err) }()
+
+	var token model.RefreshToken
+	if err := conn(ctx, r.db).Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		return nil, translateError(err)
+	}
+
+	return &token, nil
+}
+
+// MarkUsed は、リフレッシュトークンを使用済みにします
+// 既に使用済みまたは失効済みで更新されなかった場合はfalseを返します
+func (r *refreshTokenRepository) MarkUsed(ctx context.Context, id uint) (_ bool, err error) {
+	ctx, span := startSpan(ctx, "RefreshTokenRepository.MarkUsed")
+	defer func() { endSpan(span, err) }()
+
+	result := conn(ctx, r.db).Model(&model.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// RevokeFamily は、同じファミリーに属するすべてのリフレッシュトークンを失効させます
+func (r *refreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) (err error) {
+	ctx, span := startSpan(ctx, "RefreshTokenRepository.RevokeFamily")
+	defer func() { endSpan(span, err) }()
+
+	return conn(ctx, r.db).Model(&model.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeAllByUserID は、指定されたユーザーのすべてのリフレッシュトークンを失効させます
+func (r *refreshTokenRepository) RevokeAllByUserID(ctx context.Context, userID uint) (err error) {
+	ctx, span := startSpan(ctx, "RefreshTokenRepository.RevokeAllByUserID")
+	defer func() { endSpan(span, err) }()
+
+	return conn(ctx, r.db).Model(&model.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// DeleteAllByUserID は、指定されたユーザーのすべてのリフレッシュトークンを削除します
+// 退会したユーザーのデータを完全に削除する場合に使用します
+func (r *refreshTokenRepository) DeleteAllByUserID(ctx context.Context, userID uint) (err error) {
+	ctx, span := startSpan(ctx, "RefreshTokenRepository.DeleteAllByUserID")
+	defer func() { endSpan(span, err) }()
+
+	return conn(ctx, r.db).Where("user_id = ?", userID).Delete(&model.RefreshToken{}).Error
+}
+
+// DeleteExpired は、有効期限がnowより前のリフレッシュトークンを削除し、削除した件数を返します
+func (r *refreshTokenRepository) DeleteExpired(ctx context.Context, now time.Time) (_ int64, err error) {
+	ctx, span := startSpan(ctx, "RefreshTokenRepository.DeleteExpired")
+	defer func() { endSpan(span, err) }()
+
+	result := conn(ctx, r.db).Where("expires_at < ?", now).Delete(&model.RefreshToken{})
+	return result.RowsAffected, result.Error
+}
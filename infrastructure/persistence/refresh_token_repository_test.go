@@ -0,0 +1,30 @@
+package persistence
+
+import (
+	"context"
+	"testing"
+	"time"
+	"voice-link/domain/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshTokenRepository_DeleteExpired(t *testing.T) {
+	db := setupTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&model.RefreshToken{}))
+	repo := NewRefreshTokenRepository(db)
+	ctx := context.Background()
+	now := time.Now()
+
+	assert.NoError(t, repo.Create(ctx, &model.RefreshToken{UserID: 1, TokenHash: "expired", FamilyID: "family", ExpiresAt: now.Add(-time.Minute)}))
+	assert.NoError(t, repo.Create(ctx, &model.RefreshToken{UserID: 1, TokenHash: "valid", FamilyID: "family", ExpiresAt: now.Add(time.Minute)}))
+
+	deleted, err := repo.DeleteExpired(ctx, now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	_, err = repo.FindByTokenHash(ctx, "expired")
+	assert.ErrorIs(t, err, model.ErrNotFound)
+	_, err = repo.FindByTokenHash(ctx, "valid")
+	assert.NoError(t, err)
+}
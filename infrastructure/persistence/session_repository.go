@@ -0,0 +1,101 @@
+package persistence
+
+import (
+	"context"
+	"time"
+	"voice-link/domain/model"
+
+	"gorm.io/gorm"
+)
+
+// sessionRepository は、セッションのデータベース操作を担当する構造体です
+type sessionRepository struct {
+	db *gorm.DB // データベースコネクション
+}
+
+// NewSessionRepository は、SessionRepositoryインターフェースの新しいインスタンスを作成します
+func NewSessionRepository(db *gorm.DB) model.SessionRepository {
+	return &sessionRepository{db}
+}
+
+// Create は、新しいセッションをデータベースに作成します
+func (r *sessionRepository) Create(ctx context.Context, session *model.Session) (err error) {
+	ctx, span := startSpan(ctx, "SessionRepository.Create")
+	defer func() { endSpan(span, err) }()
+
+	return conn(ctx, r.db).Create(session).Error
+}
+
+// FindByID は、指定されたIDのセッションをデータベースから検索します
+func (r *sessionRepository) FindByID(ctx context.Context, id string) (_ *model.Session, err error) {
+	ctx, span := startSpan(ctx, "SessionRepository.FindByID")
+	defer func() { endSpan(span, err) }()
+
+	var session model.Session
+	if err := conn(ctx, r.db).Where("id = ?", id).First(&session).Error; err != nil {
+		return nil, translateError(err)
+	}
+
+	return &session, nil
+}
+
+// FindActiveByUserID は、失効しておらず期限が切れていないユーザーのセッションを最近使用した順に返します
+func (r *sessionRepository) FindActiveByUserID(ctx context.Context, userID uint, now time.Time) (_ []model.Session, err error) {
+	ctx, span := startSpan(ctx, "SessionRepository.FindActiveByUserID")
+	defer func() { endSpan(span, err) }()
+
+	var sessions []model.Session
+	if err := conn(ctx, r.db).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Order("last_seen_at DESC").Order("id").
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// Touch は、トークンを更新した端末の情報と日時を記録し、セッションの期限を延長します
+// 失効したセッションは更新しません
+func (r *sessionRepository) Touch(ctx context.Context, id, userAgent, ipAddress string, lastSeenAt, expiresAt time.Time) (err error) {
+	ctx, span := startSpan(ctx, "SessionRepository.Touch")
+	defer func() { endSpan(span, err) }()
+
+	return conn(ctx, r.db).Model(&model.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"user_agent":   userAgent,
+			"ip_address":   ipAddress,
+			"last_seen_at": lastSeenAt,
+			"expires_at":   expiresAt,
+		}).Error
+}
+
+// RevokeAllByUserID は、指定されたユーザーのすべてのセッションを失効させます
+func (r *sessionRepository) RevokeAllByUserID(ctx context.Context, userID uint) (err error) {
+	ctx, span := startSpan(ctx, "SessionRepository.RevokeAllByUserID")
+	defer func() { endSpan(span, err) }()
+
+	return conn(ctx, r.db).Model(&model.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// DeleteAllByUserID は、指定されたユーザーのすべてのセッションを削除します
+// 退会したユーザーのデータを完全に削除する場合に使用します
+func (r *sessionRepository) DeleteAllByUserID(ctx context.Context, userID uint) (err error) {
+	ctx, span := startSpan(ctx, "SessionRepository.DeleteAllByUserID")
+	defer func() { endSpan(span, err) }()
+
+	return conn(ctx, r.db).Where("user_id = ?", userID).Delete(&model.Session{}).Error
+}
+
+// DeleteExpired は、期限がnowより前のセッションを削除し、削除した件数を返します
+// 期限切れのセッションのアクセストークンは有効期限を過ぎているため、失効済みのセッションも削除できます
+func (r *sessionRepository) DeleteExpired(ctx context.Context, now time.Time) (_ int64, err error) {
+	ctx, span := startSpan(ctx, "SessionRepository.DeleteExpired")
+	defer func() { endSpan(span, err) }()
+
+	result := conn(ctx, r.db).Where("expires_at < ?", now).Delete(&model.Session{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,103 @@
+package persistence
+
+import (
+	"context"
+	"testing"
+	"time"
+	"voice-link/domain/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionRepository(t *testing.T) {
+	db := setupTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&model.Session{}))
+	repo := NewSessionRepository(db)
+	revocations := NewTokenRevocationRepository(db)
+	ctx := context.Background()
+	now := time.Now()
+
+	assert.NoError(t, repo.Create(ctx, &model.Session{ID: "phone", UserID: 1, DeviceName: "iPhone", UserAgent: "VoiceLink/1.0 (iOS)", IPAddress: "192.0.2.1", LastSeenAt: now.Add(-time.Hour), ExpiresAt: now.Add(time.Hour)}))
+	assert.NoError(t, repo.Create(ctx, &model.Session{ID: "laptop", UserID: 1, LastSeenAt: now.Add(-2 * time.Hour), ExpiresAt: now.Add(time.Hour)}))
+	assert.NoError(t, repo.Create(ctx, &model.Session{ID: "expired", UserID: 1, LastSeenAt: now.Add(-48 * time.Hour), ExpiresAt: now.Add(-time.Hour)}))
+	assert.NoError(t, repo.Create(ctx, &model.Session{ID: "other", UserID: 2, LastSeenAt: now, ExpiresAt: now.Add(time.Hour)}))
+
+	found, err := repo.FindByID(ctx, "phone")
+	assert.NoError(t, err)
+	assert.Equal(t, "iPhone", found.DeviceName)
+	_, err = repo.FindByID(ctx, "unknown")
+	assert.ErrorIs(t, err, model.ErrNotFound)
+
+	// 期限切れのセッションと他のユーザーのセッションは含まない
+	sessions, err := repo.FindActiveByUserID(ctx, 1, now)
+	assert.NoError(t, err)
+	if assert.Len(t, sessions, 2) {
+		assert.Equal(t, "phone", sessions[0].ID)
+		assert.Equal(t, "laptop", sessions[1].ID)
+	}
+
+	// トークンを更新すると最近使用したセッションになる
+	assert.NoError(t, repo.Touch(ctx, "laptop", "Mozilla/5.0", "198.51.100.1", now, now.Add(30*24*time.Hour)))
+	sessions, err = repo.FindActiveByUserID(ctx, 1, now)
+	assert.NoError(t, err)
+	if assert.Len(t, sessions, 2) {
+		assert.Equal(t, "laptop", sessions[0].ID)
+		assert.Equal(t, "Mozilla/5.0", sessions[0].UserAgent)
+		assert.Equal(t, "198.51.100.1", sessions[0].IPAddress)
+	}
+
+	// 失効したセッションは一覧に含まず、アクセストークンも無効になる
+	revoked, err := revocations.IsSessionRevoked(ctx, "phone")
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+	assert.NoError(t, revocations.RevokeSession(ctx, "phone"))
+	revoked, err = revocations.IsSessionRevoked(ctx, "phone")
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+	// 記録されていないセッションは失効していない
+	revoked, err = revocations.IsSessionRevoked(ctx, "unknown")
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+	sessions, err = repo.FindActiveByUserID(ctx, 1, now)
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 1)
+
+	assert.NoError(t, repo.RevokeAllByUserID(ctx, 1))
+	sessions, err = repo.FindActiveByUserID(ctx, 1, now)
+	assert.NoError(t, err)
+	assert.Empty(t, sessions)
+	revoked, err = revocations.IsSessionRevoked(ctx, "laptop")
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	assert.NoError(t, repo.DeleteAllByUserID(ctx, 1))
+	_, err = repo.FindByID(ctx, "laptop")
+	assert.ErrorIs(t, err, model.ErrNotFound)
+	_, err = repo.FindByID(ctx, "other")
+	assert.NoError(t, err)
+}
+
+func TestSessionRepository_DeleteExpired(t *testing.T) {
+	db := setupTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&model.Session{}))
+	repo := NewSessionRepository(db)
+	ctx := context.Background()
+	now := time.Now()
+
+	revokedAt := now.Add(-time.Hour)
+	assert.NoError(t, repo.Create(ctx, &model.Session{ID: "active", UserID: 1, LastSeenAt: now, ExpiresAt: now.Add(time.Hour)}))
+	assert.NoError(t, repo.Create(ctx, &model.Session{ID: "revoked", UserID: 1, LastSeenAt: now, ExpiresAt: now.Add(time.Hour), RevokedAt: &revokedAt}))
+	assert.NoError(t, repo.Create(ctx, &model.Session{ID: "expired", UserID: 1, LastSeenAt: now.Add(-48 * time.Hour), ExpiresAt: now.Add(-time.Hour)}))
+
+	deleted, err := repo.DeleteExpired(ctx, now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	_, err = repo.FindByID(ctx, "expired")
+	assert.ErrorIs(t, err, model.ErrNotFound)
+	// 期限内の失効済みのセッションはアクセストークンの失効の判定に使用するため残す
+	_, err = repo.FindByID(ctx, "revoked")
+	assert.NoError(t, err)
+	_, err = repo.FindByID(ctx, "active")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,82 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// timeoutParentContextKey は、タイムアウトを設定する前のコンテキストを保持するキーです
+const timeoutParentContextKey = "timeout:parent_context"
+
+// timeoutCancelKey は、タイムアウトを設定したコンテキストの解放関数を保持するキーです
+const timeoutCancelKey = "timeout:cancel"
+
+// queryTimeoutPlugin は、GORMが実行するSQLごとにタイムアウトを設定するプラグインです
+type queryTimeoutPlugin struct {
+	timeout time.Duration
+}
+
+// NewQueryTimeoutPlugin は、SQLの実行時間をtimeoutまでに制限するGORMのプラグインを作成します
+// db.WithContextで渡されたコンテキストにより早い期限がある場合はその期限を優先し、
+// クライアントの切断などでコンテキストがキャンセルされた場合は実行中のSQLも中断します
+func NewQueryTimeoutPlugin(timeout time.Duration) gorm.Plugin {
+	return queryTimeoutPlugin{timeout: timeout}
+}
+
+// Name は、プラグインの名前を返します
+func (queryTimeoutPlugin) Name() string {
+	return "voice-link:query_timeout"
+}
+
+// Initialize は、各操作の前後にタイムアウトを設定、解除するコールバックを登録します
+// トレースなど他のプラグインのコールバックより外側で動作するよう、最初と最後に実行します
+// Rowsは結果の読み込みがコールバックの後に行われるため対象外です
+func (p queryTimeoutPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("*").Register("timeout:before_create", p.before),
+		cb.Create().After("*").Register("timeout:after_create", p.after),
+		cb.Query().Before("*").Register("timeout:before_query", p.before),
+		cb.Query().After("*").Register("timeout:after_query", p.after),
+		cb.Update().Before("*").Register("timeout:before_update", p.before),
+		cb.Update().After("*").Register("timeout:after_update", p.after),
+		cb.Delete().Before("*").Register("timeout:before_delete", p.before),
+		cb.Delete().After("*").Register("timeout:after_delete", p.after),
+		cb.Raw().Before("*").Register("timeout:before_raw", p.before),
+		cb.Raw().After("*").Register("timeout:after_raw", p.after),
+	)
+}
+
+// before は、ステートメントのコンテキストにタイムアウトを設定します
+func (p queryTimeoutPlugin) before(db *gorm.DB) {
+	if p.timeout <= 0 {
+		return
+	}
+
+	parent := db.Statement.Context
+	if parent == nil {
+		parent = context.Background()
+	}
+
+	ctx, cancel := context.WithTimeout(parent, p.timeout)
+	// Saveのように同じステートメントで続けて操作する場合に備え、終了時に元のコンテキストに戻す
+	db.InstanceSet(timeoutParentContextKey, parent)
+	db.InstanceSet(timeoutCancelKey, cancel)
+	db.Statement.Context = ctx
+}
+
+// after は、タイムアウトを設定したコンテキストを解放し、元のコンテキストに戻します
+func (queryTimeoutPlugin) after(db *gorm.DB) {
+	parent, ok := db.InstanceGet(timeoutParentContextKey)
+	if !ok {
+		return
+	}
+	if cancel, ok := db.InstanceGet(timeoutCancelKey); ok {
+		cancel.(context.CancelFunc)()
+	}
+
+	db.Statement.Context = parent.(context.Context)
+}
@@ -0,0 +1,56 @@
+package persistence
+
+import (
+	"context"
+	"testing"
+	"time"
+	"voice-link/domain/model"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestQueryTimeoutPlugin(t *testing.T) {
+	db := setupTestDB(t)
+	assert.NoError(t, db.Use(NewTracingPlugin()))
+	assert.NoError(t, db.Use(NewQueryTimeoutPlugin(time.Minute)))
+
+	// SQLの実行中のコンテキストに期限を記録する
+	var deadlines []time.Time
+	assert.NoError(t, db.Callback().Query().Before("gorm:query").Register("test:deadline", func(db *gorm.DB) {
+		deadline, _ := db.Statement.Context.Deadline()
+		deadlines = append(deadlines, deadline)
+	}))
+
+	repo := NewUserRepository(db)
+	user := &model.User{Name: "テストユーザー", Email: "test@example.com", Password: "hashed"}
+	assert.NoError(t, repo.Create(context.Background(), user))
+
+	// 期限のないコンテキストにはタイムアウトが設定される
+	_, err := repo.FindByID(context.Background(), user.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, deadlines, 1) {
+		assert.WithinDuration(t, time.Now().Add(time.Minute), deadlines[0], 5*time.Second)
+	}
+
+	// より早い期限がある場合はその期限を優先する
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	expected, _ := ctx.Deadline()
+	_, err = repo.FindByID(ctx, user.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, deadlines, 2) {
+		assert.Equal(t, expected, deadlines[1])
+	}
+
+	// 同じステートメントで続けて操作しても、解放済みのコンテキストは引き継がない
+	user.Name = "更新されたユーザー"
+	assert.NoError(t, repo.Update(context.Background(), user))
+	assert.NoError(t, repo.Update(context.Background(), &model.User{ID: 100, Name: "新しいユーザー", Email: "new@example.com", Password: "hashed"}))
+
+	// キャンセルされたコンテキストではSQLを実行しない
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = repo.FindByID(canceled, user.ID)
+	assert.ErrorIs(t, err, context.Canceled)
+}
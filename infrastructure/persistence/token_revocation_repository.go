@@ -0,0 +1,127 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"time"
+	"voice-link/domain/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// tokenRevocationRepository は、アクセストークンの失効情報のデータベース操作を担当する構造体です
+type tokenRevocationRepository struct {
+	db *gorm.DB // データベースコネクション
+}
+
+// NewTokenRevocationRepository は、TokenRevocationStoreインターフェースの新しいインスタンスを作成します
+func NewTokenRevocationRepository(db *gorm.DB) model.TokenRevocationStore {
+	return &tokenRevocationRepository{db}
+}
+
+// RevokeToken は、指定されたjtiのアクセストークンを失効させます
+func (r *tokenRevocationRepository) RevokeToken(ctx context.Context, jti string, userID uint, expiresAt time.Time) (err error) {
+	ctx, span := startSpan(ctx, "TokenRevocationRepository.RevokeToken")
+	defer func() { endSpan(span, err) }()
+
+	return conn(ctx, r.db).Clauses(clause.OnConflict{DoNothing: true}).Create(&model.RevokedToken{
+		JTI:       jti,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}).Error
+}
+
+// IsTokenRevoked は、指定されたjtiのアクセストークンが失効済みかどうかを判定します
+func (r *tokenRevocationRepository) IsTokenRevoked(ctx context.Context, jti string) (_ bool, err error) {
+	ctx, span := startSpan(ctx, "TokenRevocationRepository.IsTokenRevoked")
+	defer func() { endSpan(span, err) }()
+
+	var count int64
+	if err := conn(ctx, r.db).Model(&model.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// RevokeAllUserTokens は、ユーザーのトークン世代を進めて発行済みのアクセストークンをすべて失効させます
+func (r *tokenRevocationRepository) RevokeAllUserTokens(ctx context.Context, userID uint) (err error) {
+	ctx, span := startSpan(ctx, "TokenRevocationRepository.RevokeAllUserTokens")
+	defer func() { endSpan(span, err) }()
+
+	return conn(ctx, r.db).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"version":    gorm.Expr("user_token_versions.version + 1"),
+			"updated_at": time.Now(),
+		}),
+	}).Create(&model.UserTokenVersion{
+		UserID:  userID,
+		Version: 1,
+	}).Error
+}
+
+// GetUserTokenVersion は、ユーザーの現在のトークン世代を取得します
+// 一度も一括失効していないユーザーの世代は0です
+func (r *tokenRevocationRepository) GetUserTokenVersion(ctx context.Context, userID uint) (_ uint, err error) {
+	ctx, span := startSpan(ctx, "TokenRevocationRepository.GetUserTokenVersion")
+	defer func() { endSpan(span, err) }()
+
+	var version model.UserTokenVersion
+	if err := conn(ctx, r.db).Where("user_id = ?", userID).First(&version).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	return version.Version, nil
+}
+
+// RevokeSession は、指定されたセッションを失効させます
+// 失効したセッションのsidを持つアクセストークンは、有効期限内でも無効になります
+func (r *tokenRevocationRepository) RevokeSession(ctx context.Context, sessionID string) (err error) {
+	ctx, span := startSpan(ctx, "TokenRevocationRepository.RevokeSession")
+	defer func() { endSpan(span, err) }()
+
+	return conn(ctx, r.db).Model(&model.Session{}).
+		Where("id = ? AND revoked_at IS NULL", sessionID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// IsSessionRevoked は、指定されたセッションが失効済みかどうかを判定します
+// セッションが記録されていない場合は失効していないものとして扱います
+func (r *tokenRevocationRepository) IsSessionRevoked(ctx context.Context, sessionID string) (_ bool, err error) {
+	ctx, span := startSpan(ctx, "TokenRevocationRepository.IsSessionRevoked")
+	defer func() { endSpan(span, err) }()
+
+	var count int64
+	if err := conn(ctx, r.db).Model(&model.Session{}).Where("id = ? AND revoked_at IS NOT NULL", sessionID).Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// DeleteExpired は、有効期限がnowより前の失効済みのアクセストークンを削除し、削除した件数を返します
+func (r *tokenRevocationRepository) DeleteExpired(ctx context.Context, now time.Time) (_ int64, err error) {
+	ctx, span := startSpan(ctx, "TokenRevocationRepository.DeleteExpired")
+	defer func() { endSpan(span, err) }()
+
+	result := conn(ctx, r.db).Where("expires_at < ?", now).Delete(&model.RevokedToken{})
+	return result.RowsAffected, result.Error
+}
+
+// DeleteAllByUserID は、指定されたユーザーの失効済みのアクセストークンとトークン世代を削除します
+// 退会したユーザーのデータを完全に削除する場合に使用します
+func (r *tokenRevocationRepository) DeleteAllByUserID(ctx context.Context, userID uint) (err error) {
+	ctx, span := startSpan(ctx, "TokenRevocationRepository.DeleteAllByUserID")
+	defer func() { endSpan(span, err) }()
+
+	db := conn(ctx, r.db)
+	if err := db.Where("user_id = ?", userID).Delete(&model.RevokedToken{}).Error; err != nil {
+		return err
+	}
+	return db.Where("user_id = ?", userID).Delete(&model.UserTokenVersion{}).Error
+}
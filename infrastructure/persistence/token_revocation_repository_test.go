@@ -0,0 +1,60 @@
+package persistence
+
+import (
+	"context"
+	"testing"
+	"time"
+	"voice-link/domain/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenRevocationRepository_DeleteExpired(t *testing.T) {
+	db := setupTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&model.RevokedToken{}))
+	repo := NewTokenRevocationRepository(db)
+	ctx := context.Background()
+	now := time.Now()
+
+	assert.NoError(t, repo.RevokeToken(ctx, "expired", 1, now.Add(-time.Minute)))
+	assert.NoError(t, repo.RevokeToken(ctx, "valid", 1, now.Add(time.Minute)))
+
+	deleted, err := repo.DeleteExpired(ctx, now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	revoked, err := repo.IsTokenRevoked(ctx, "expired")
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+	revoked, err = repo.IsTokenRevoked(ctx, "valid")
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+}
+
+func TestTokenRevocationRepository_DeleteAllByUserID(t *testing.T) {
+	db := setupTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&model.RevokedToken{}, &model.UserTokenVersion{}))
+	repo := NewTokenRevocationRepository(db)
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Minute)
+
+	assert.NoError(t, repo.RevokeToken(ctx, "jti-1", 1, expiresAt))
+	assert.NoError(t, repo.RevokeToken(ctx, "jti-2", 2, expiresAt))
+	assert.NoError(t, repo.RevokeAllUserTokens(ctx, 1))
+	assert.NoError(t, repo.RevokeAllUserTokens(ctx, 2))
+
+	assert.NoError(t, repo.DeleteAllByUserID(ctx, 1))
+
+	var count int64
+	db.Model(&model.RevokedToken{}).Where("user_id = ?", 1).Count(&count)
+	assert.Zero(t, count)
+	db.Model(&model.UserTokenVersion{}).Where("user_id = ?", 1).Count(&count)
+	assert.Zero(t, count)
+	// 他のユーザーの失効情報は残す
+	revoked, err := repo.IsTokenRevoked(ctx, "jti-2")
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+	version, err := repo.GetUserTokenVersion(ctx, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), version)
+}
@@ -0,0 +1,108 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"voice-link/domain/model"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// tracerName は、永続化層のスパンを記録するトレーサーの名前です
+const tracerName = "voice-link/infrastructure/persistence"
+
+// parentContextKey は、SQLのスパンを開始する前のコンテキストを保持するキーです
+const parentContextKey = "tracing:parent_context"
+
+// startSpan は、リポジトリのメソッドのスパンを開始します
+func startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name)
+}
+
+// endSpan は、エラーを記録してスパンを終了します
+// 該当するレコードがないことは想定された結果のため、エラーとして扱いません
+func endSpan(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, model.ErrNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// tracingPlugin は、GORMが実行するSQLごとにスパンを記録するプラグインです
+// SQLはプレースホルダーのまま記録し、メールアドレスやトークンなどのバインド値は記録しません
+type tracingPlugin struct{}
+
+// NewTracingPlugin は、SQLのスパンを記録するGORMのプラグインを作成します
+// db.WithContextで渡されたコンテキストのスパンの子として記録します
+func NewTracingPlugin() gorm.Plugin {
+	return tracingPlugin{}
+}
+
+// Name は、プラグインの名前を返します
+func (tracingPlugin) Name() string {
+	return "voice-link:tracing"
+}
+
+// Initialize は、各操作の前後にスパンを開始、終了するコールバックを登録します
+func (p tracingPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("tracing:before_create", p.before("gorm.Create")),
+		cb.Create().After("gorm:create").Register("tracing:after_create", p.after),
+		cb.Query().Before("gorm:query").Register("tracing:before_query", p.before("gorm.Query")),
+		cb.Query().After("gorm:query").Register("tracing:after_query", p.after),
+		cb.Update().Before("gorm:update").Register("tracing:before_update", p.before("gorm.Update")),
+		cb.Update().After("gorm:update").Register("tracing:after_update", p.after),
+		cb.Delete().Before("gorm:delete").Register("tracing:before_delete", p.before("gorm.Delete")),
+		cb.Delete().After("gorm:delete").Register("tracing:after_delete", p.after),
+		cb.Row().Before("gorm:row").Register("tracing:before_row", p.before("gorm.Row")),
+		cb.Row().After("gorm:row").Register("tracing:after_row", p.after),
+		cb.Raw().Before("gorm:raw").Register("tracing:before_raw", p.before("gorm.Raw")),
+		cb.Raw().After("gorm:raw").Register("tracing:after_raw", p.after),
+	)
+}
+
+// before は、SQLのスパンを開始するコールバックを返します
+func (tracingPlugin) before(name string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		parent := db.Statement.Context
+		if parent == nil {
+			parent = context.Background()
+		}
+
+		ctx, _ := otel.Tracer(tracerName).Start(parent, name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attribute.String("db.system", db.Dialector.Name())),
+		)
+		// Saveのように同じステートメントで続けて操作する場合に備え、終了時に元のコンテキストに戻す
+		db.InstanceSet(parentContextKey, parent)
+		db.Statement.Context = ctx
+	}
+}
+
+// after は、実行したSQLと結果を記録してスパンを終了します
+func (tracingPlugin) after(db *gorm.DB) {
+	parent, ok := db.InstanceGet(parentContextKey)
+	if !ok {
+		return
+	}
+
+	span := trace.SpanFromContext(db.Statement.Context)
+	span.SetAttributes(
+		attribute.String("db.sql.table", db.Statement.Table),
+		attribute.String("db.statement", db.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+	)
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+	span.End()
+
+	db.Statement.Context = parent.(context.Context)
+}
@@ -0,0 +1,45 @@
+package persistence
+
+import (
+	"context"
+	"voice-link/domain/model"
+
+	"gorm.io/gorm"
+)
+
+// txContextKey は、実行中のトランザクションをコンテキストに保持するキーです
+type txContextKey struct{}
+
+// transactionManager は、GORMのトランザクションでリポジトリの操作をまとめて実行する構造体です
+type transactionManager struct {
+	db *gorm.DB // データベースコネクション
+}
+
+// NewTransactionManager は、TransactionManagerインターフェースの新しいインスタンスを作成します
+func NewTransactionManager(db *gorm.DB) model.TransactionManager {
+	return &transactionManager{db}
+}
+
+// WithinTransaction は、fnをトランザクション内で実行し、エラーがなければコミットします
+// fnがエラーを返した場合やパニックした場合はロールバックします
+func (m *transactionManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if _, ok := ctx.Value(txContextKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+
+	ctx, span := startSpan(ctx, "TransactionManager.WithinTransaction")
+	defer func() { endSpan(span, err) }()
+
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txContextKey{}, tx))
+	})
+}
+
+// conn は、コンテキストのトランザクション内であればそのトランザクションを、そうでなければdbを返します
+// 返されるコネクションはctxで実行されます
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txContextKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
@@ -0,0 +1,78 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"voice-link/domain/model"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupTestDB は、ユーザーのテーブルを作成したテスト用のデータベースを設定します
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	// インメモリのデータベースは接続ごとに作成されるため、接続を1つに限る
+	sqlDB, err := db.DB()
+	assert.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	assert.NoError(t, db.AutoMigrate(&model.User{}))
+
+	return db
+}
+
+func TestTransactionManager(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewUserRepository(db)
+	transactions := NewTransactionManager(db)
+	ctx := context.Background()
+
+	// fnがエラーを返した場合は、トランザクション内の操作がすべて取り消される
+	errAbort := errors.New("abort")
+	err := transactions.WithinTransaction(ctx, func(ctx context.Context) error {
+		assert.NoError(t, repo.Create(ctx, &model.User{Name: "取り消されるユーザー", Email: "rollback@example.com", Password: "hashed"}))
+		// トランザクション内では作成したユーザーを参照できる
+		_, err := repo.FindByEmail(ctx, "rollback@example.com")
+		assert.NoError(t, err)
+		return errAbort
+	})
+	assert.ErrorIs(t, err, errAbort)
+	_, err = repo.FindByEmail(ctx, "rollback@example.com")
+	assert.ErrorIs(t, err, model.ErrNotFound)
+
+	// 入れ子で呼び出した場合は外側のトランザクションで実行され、まとめてコミットされる
+	err = transactions.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := repo.Create(ctx, &model.User{Name: "外側のユーザー", Email: "outer@example.com", Password: "hashed"}); err != nil {
+			return err
+		}
+		return transactions.WithinTransaction(ctx, func(ctx context.Context) error {
+			return repo.Create(ctx, &model.User{Name: "内側のユーザー", Email: "inner@example.com", Password: "hashed"})
+		})
+	})
+	assert.NoError(t, err)
+	for _, email := range []string{"outer@example.com", "inner@example.com"} {
+		_, err = repo.FindByEmail(ctx, email)
+		assert.NoError(t, err, email)
+	}
+}
+
+func TestUserRepository_DuplicateEmail(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	assert.NoError(t, repo.Create(ctx, &model.User{Name: "既存ユーザー", Email: "taken@example.com", Password: "hashed"}))
+
+	// 作成時の重複
+	err := repo.Create(ctx, &model.User{Name: "テストユーザー", Email: "taken@example.com", Password: "hashed"})
+	assert.ErrorIs(t, err, model.ErrDuplicateEmail)
+
+	// 更新時の重複
+	user := &model.User{Name: "テストユーザー", Email: "free@example.com", Password: "hashed"}
+	assert.NoError(t, repo.Create(ctx, user))
+	user.Email = "taken@example.com"
+	assert.ErrorIs(t, repo.Update(ctx, user), model.ErrDuplicateEmail)
+}
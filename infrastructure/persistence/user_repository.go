@@ -2,6 +2,10 @@
 package persistence
 
 import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
 	"voice-link/domain/model"
 
 	"gorm.io/gorm"
@@ -18,46 +22,444 @@ func NewUserRepository(db *gorm.DB) model.UserRepository {
 }
 
 // Create は、新しいユーザーをデータベースに作成します
-func (r *userRepository) Create(user *model.User) error {
-	return r.db.Create(user).Error
+// メールアドレスが他のユーザーと重複する場合はmodel.ErrDuplicateEmailを返します
+func (r *userRepository) Create(ctx context.Context, user *model.User) (err error) {
+	ctx, span := startSpan(ctx, "UserRepository.Create")
+	defer func() { endSpan(span, err) }()
+
+	return translateError(conn(ctx, r.db).Create(user).Error)
 }
 
+// notDeleted は、退会済みのユーザーを除く条件です
+const notDeleted = "deleted_at IS NULL"
+
 // FindByID は、指定されたIDのユーザーをデータベースから検索します
-func (r *userRepository) FindByID(id uint) (*model.User, error) {
+func (r *userRepository) FindByID(ctx context.Context, id uint) (_ *model.User, err error) {
+	ctx, span := startSpan(ctx, "UserRepository.FindByID")
+	defer func() { endSpan(span, err) }()
+
 	var user model.User
-	if err := r.db.First(&user, id).Error; err != nil {
-		return nil, err
+	if err := conn(ctx, r.db).Where(notDeleted).First(&user, id).Error; err != nil {
+		return nil, translateError(err)
+	}
+
+	return &user, nil
+}
+
+// FindByIDIncludingDeleted は、退会済みのユーザーも含めて指定されたIDのユーザーをデータベースから検索します
+func (r *userRepository) FindByIDIncludingDeleted(ctx context.Context, id uint) (_ *model.User, err error) {
+	ctx, span := startSpan(ctx, "UserRepository.FindByIDIncludingDeleted")
+	defer func() { endSpan(span, err) }()
+
+	var user model.User
+	if err := conn(ctx, r.db).First(&user, id).Error; err != nil {
+		return nil, translateError(err)
 	}
 
 	return &user, nil
 }
 
 // FindByEmail は、指定されたメールアドレスのユーザーをデータベースから検索します
-func (r *userRepository) FindByEmail(email string) (*model.User, error) {
+// メールアドレスは完全に削除されるまで再利用できないため、退会済みのユーザーも含めて検索します
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (_ *model.User, err error) {
+	ctx, span := startSpan(ctx, "UserRepository.FindByEmail")
+	defer func() { endSpan(span, err) }()
+
 	var user model.User
-	if err := r.db.Where("email = ?", email).First(&user).Error; err != nil {
-		return nil, err
+	if err := conn(ctx, r.db).Where("email = ?", email).First(&user).Error; err != nil {
+		return nil, translateError(err)
 	}
 
 	return &user, nil
 }
 
 // FindByPasswordResetToken は、指定されたパスワードリセットトークンのユーザーをデータベースから検索します
-func (r *userRepository) FindByPasswordResetToken(token string) (*model.User, error) {
+func (r *userRepository) FindByPasswordResetToken(ctx context.Context, token string) (_ *model.User, err error) {
+	ctx, span := startSpan(ctx, "UserRepository.FindByPasswordResetToken")
+	defer func() { endSpan(span, err) }()
+
 	var user model.User
-	if err := r.db.Where("password_reset_token = ?", token).First(&user).Error; err != nil {
-		return nil, err
+	if err := conn(ctx, r.db).Where(notDeleted).Where("password_reset_token = ?", token).First(&user).Error; err != nil {
+		return nil, translateError(err)
 	}
 
 	return &user, nil
 }
 
+// FindByEmailVerificationToken は、指定されたメールアドレス確認トークンのユーザーをデータベースから検索します
+func (r *userRepository) FindByEmailVerificationToken(ctx context.Context, token string) (_ *model.User, err error) {
+	ctx, span := startSpan(ctx, "UserRepository.FindByEmailVerificationToken")
+	defer func() { endSpan(span, err) }()
+
+	var user model.User
+	if err := conn(ctx, r.db).Where(notDeleted).Where("email_verification_token = ?", token).First(&user).Error; err != nil {
+		return nil, translateError(err)
+	}
+
+	return &user, nil
+}
+
+// FindByUnlockToken は、指定されたロック解除トークンのユーザーをデータベースから検索します
+func (r *userRepository) FindByUnlockToken(ctx context.Context, token string) (_ *model.User, err error) {
+	ctx, span := startSpan(ctx, "UserRepository.FindByUnlockToken")
+	defer func() { endSpan(span, err) }()
+
+	var user model.User
+	if err := conn(ctx, r.db).Where(notDeleted).Where("unlock_token = ?", token).First(&user).Error; err != nil {
+		return nil, translateError(err)
+	}
+
+	return &user, nil
+}
+
+// userSortColumns は、並び替えの項目ごとのカラム名です
+var userSortColumns = map[model.UserSortField]string{
+	model.UserSortByID:        "id",
+	model.UserSortByCreatedAt: "created_at",
+	model.UserSortByEmail:     "email",
+	model.UserSortByName:      "name",
+}
+
+// FindAll は、条件に一致するユーザーをデータベースから検索します
+// 並び替えの項目が同じ値のユーザーはIDの順に並べ、query.Afterのユーザーより後から取得します（キーセットページネーション）
+func (r *userRepository) FindAll(ctx context.Context, query model.UserListQuery) (_ []model.User, err error) {
+	ctx, span := startSpan(ctx, "UserRepository.FindAll")
+	defer func() { endSpan(span, err) }()
+
+	column, ok := userSortColumns[query.SortBy]
+	if !ok {
+		return nil, fmt.Errorf("unsupported sort field: %q", query.SortBy)
+	}
+	direction, comparison := "ASC", ">"
+	if query.Order == model.SortDescending {
+		direction, comparison = "DESC", "<"
+	}
+
+	db := applyUserFilter(conn(ctx, r.db).Where(notDeleted), query.Filter)
+	if after := query.After; after != nil {
+		if column == "id" {
+			db = db.Where("id "+comparison+" ?", after.ID)
+		} else {
+			value := userCursorValue(after, query.SortBy)
+			db = db.Where(
+				fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?))", column, comparison),
+				value, value, after.ID,
+			)
+		}
+	}
+	if column != "id" {
+		db = db.Order(column + " " + direction)
+	}
+
+	var users []model.User
+	if err := db.Order("id " + direction).Limit(query.Limit).Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// applyUserFilter は、ユーザー一覧の絞り込み条件をクエリに追加します
+func applyUserFilter(db *gorm.DB, filter model.UserFilter) *gorm.DB {
+	// PostgreSQLとSQLiteで大文字と小文字の扱いを揃えるため、小文字に変換して比較する
+	if filter.EmailPrefix != "" {
+		db = db.Where(`LOWER(email) LIKE ? ESCAPE '\'`, likePrefix(filter.EmailPrefix))
+	}
+	if filter.NamePrefix != "" {
+		db = db.Where(`LOWER(name) LIKE ? ESCAPE '\'`, likePrefix(filter.NamePrefix))
+	}
+	if filter.CreatedFrom != nil {
+		db = db.Where("created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		db = db.Where("created_at < ?", *filter.CreatedTo)
+	}
+	if filter.Role != "" {
+		db = db.Where("role = ?", filter.Role)
+	}
+	if filter.Verified != nil {
+		if *filter.Verified {
+			db = db.Where("email_verified_at IS NOT NULL")
+		} else {
+			db = db.Where("email_verified_at IS NULL")
+		}
+	}
+	return db
+}
+
+// likePrefix は、前方一致のLIKEパターンを作成します
+// 入力に含まれるワイルドカードは通常の文字として扱います
+func likePrefix(prefix string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(strings.ToLower(prefix))
+	return escaped + "%"
+}
+
+// userCursorValue は、カーソルから並び替えに使用している項目の値を取り出します
+func userCursorValue(cursor *model.UserCursor, field model.UserSortField) interface{} {
+	switch field {
+	case model.UserSortByCreatedAt:
+		return cursor.CreatedAt
+	case model.UserSortByEmail:
+		return cursor.Email
+	case model.UserSortByName:
+		return cursor.Name
+	}
+	return cursor.ID
+}
+
 // Update は、既存のユーザー情報をデータベースで更新します
-func (r *userRepository) Update(user *model.User) error {
-	return r.db.Save(user).Error
+// メールアドレスが他のユーザーと重複する場合はmodel.ErrDuplicateEmailを返します
+func (r *userRepository) Update(ctx context.Context, user *model.User) (err error) {
+	ctx, span := startSpan(ctx, "UserRepository.Update")
+	defer func() { endSpan(span, err) }()
+
+	return translateError(conn(ctx, r.db).Save(user).Error)
 }
 
-// Delete は、指定されたIDのユーザーをデータベースから削除します
-func (r *userRepository) Delete(id uint) error {
-	return r.db.Delete(&model.User{}, id).Error
+// RecordLoginFailure は、ログインの失敗を記録し、連続した失敗回数を返します
+// 同時に失敗した場合も数え漏れがないよう、回数はデータベース上で加算します
+// 前回の失敗がresetBeforeより前の場合は1回目として数え直します
+func (r *userRepository) RecordLoginFailure(ctx context.Context, id uint, at, resetBefore time.Time) (_ int, err error) {
+	ctx, span := startSpan(ctx, "UserRepository.RecordLoginFailure")
+	defer func() { endSpan(span, err) }()
+
+	db := conn(ctx, r.db)
+	result := db.Model(&model.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"failed_login_attempts": gorm.Expr(
+				"CASE WHEN last_failed_login_at IS NULL OR last_failed_login_at < ? THEN 1 ELSE failed_login_attempts + 1 END",
+				resetBefore,
+			),
+			"last_failed_login_at": at,
+		})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return 0, model.ErrNotFound
+	}
+
+	var attempts []int
+	if err := db.Model(&model.User{}).Where("id = ?", id).Pluck("failed_login_attempts", &attempts).Error; err != nil {
+		return 0, err
+	}
+	if len(attempts) == 0 {
+		return 0, model.ErrNotFound
+	}
+
+	return attempts[0], nil
+}
+
+// Lock は、指定されたIDのユーザーをlockedUntilまでロックし、ロック解除のトークンを設定します
+func (r *userRepository) Lock(ctx context.Context, id uint, lockedUntil time.Time, unlockToken string, unlockTokenExpires time.Time) (err error) {
+	ctx, span := startSpan(ctx, "UserRepository.Lock")
+	defer func() { endSpan(span, err) }()
+
+	result := conn(ctx, r.db).Model(&model.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"locked_until":         lockedUntil,
+			"unlock_token":         unlockToken,
+			"unlock_token_expires": unlockTokenExpires,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return model.ErrNotFound
+	}
+
+	return nil
+}
+
+// ResetLoginFailures は、指定されたIDのユーザーのログインの失敗回数を0に戻し、ロックとロック解除のトークンを破棄します
+func (r *userRepository) ResetLoginFailures(ctx context.Context, id uint) (err error) {
+	ctx, span := startSpan(ctx, "UserRepository.ResetLoginFailures")
+	defer func() { endSpan(span, err) }()
+
+	result := conn(ctx, r.db).Model(&model.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"failed_login_attempts": 0,
+			"last_failed_login_at":  nil,
+			"locked_until":          nil,
+			"unlock_token":          nil,
+			"unlock_token_expires":  nil,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return model.ErrNotFound
+	}
+
+	return nil
+}
+
+// SetMFASecret は、指定されたIDのユーザーに確認待ちの2段階認証の秘密鍵を設定します
+// 2段階認証を有効にしているユーザーの秘密鍵は変更せず、model.ErrNotFoundを返します
+func (r *userRepository) SetMFASecret(ctx context.Context, id uint, secret string) (err error) {
+	ctx, span := startSpan(ctx, "UserRepository.SetMFASecret")
+	defer func() { endSpan(span, err) }()
+
+	result := conn(ctx, r.db).Model(&model.User{}).
+		Where("id = ? AND mfa_enabled_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"mfa_secret":         secret,
+			"mfa_last_used_step": 0,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return model.ErrNotFound
+	}
+
+	return nil
+}
+
+// EnableMFA は、指定されたIDのユーザーの2段階認証を有効にし、確認に使用した時間ステップを記録します
+func (r *userRepository) EnableMFA(ctx context.Context, id uint, enabledAt time.Time, step int64) (err error) {
+	ctx, span := startSpan(ctx, "UserRepository.EnableMFA")
+	defer func() { endSpan(span, err) }()
+
+	result := conn(ctx, r.db).Model(&model.User{}).
+		Where("id = ? AND mfa_secret IS NOT NULL AND mfa_enabled_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"mfa_enabled_at":     enabledAt,
+			"mfa_last_used_step": step,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return model.ErrNotFound
+	}
+
+	return nil
+}
+
+// UseMFAStep は、指定されたIDのユーザーが最後に使用したTOTPの時間ステップをstepに更新します
+// 同時に同じコードが使用された場合も一方のみ成功するよう、データベース上で比較して更新します
+func (r *userRepository) UseMFAStep(ctx context.Context, id uint, step int64) (_ bool, err error) {
+	ctx, span := startSpan(ctx, "UserRepository.UseMFAStep")
+	defer func() { endSpan(span, err) }()
+
+	result := conn(ctx, r.db).Model(&model.User{}).
+		Where("id = ? AND mfa_last_used_step < ?", id, step).
+		Update("mfa_last_used_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// DisableMFA は、指定されたIDのユーザーの2段階認証を無効にし、秘密鍵を削除します
+func (r *userRepository) DisableMFA(ctx context.Context, id uint) (err error) {
+	ctx, span := startSpan(ctx, "UserRepository.DisableMFA")
+	defer func() { endSpan(span, err) }()
+
+	result := conn(ctx, r.db).Model(&model.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"mfa_secret":         nil,
+			"mfa_enabled_at":     nil,
+			"mfa_last_used_step": 0,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return model.ErrNotFound
+	}
+
+	return nil
+}
+
+// Delete は、指定されたIDのユーザーをdeletedAtに退会済みにします
+// 退会後に使用されないよう、パスワードリセット、メールアドレス確認、ロック解除のトークンは破棄します
+// 該当するユーザーが存在しないか、既に退会済みの場合はmodel.ErrNotFoundを返します
+func (r *userRepository) Delete(ctx context.Context, id uint, deletedAt time.Time) (err error) {
+	ctx, span := startSpan(ctx, "UserRepository.Delete")
+	defer func() { endSpan(span, err) }()
+
+	result := conn(ctx, r.db).Model(&model.User{}).
+		Where(notDeleted).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"deleted_at":                 deletedAt,
+			"pending_email":              nil,
+			"email_verification_token":   nil,
+			"email_verification_expires": nil,
+			"password_reset_token":       nil,
+			"password_reset_expires":     nil,
+			"unlock_token":               nil,
+			"unlock_token_expires":       nil,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return model.ErrNotFound
+	}
+
+	return nil
+}
+
+// Restore は、退会済みのユーザーを復元します
+// 該当するユーザーが存在しないか、退会済みでない場合（完全に削除された場合を含む）はmodel.ErrNotFoundを返します
+func (r *userRepository) Restore(ctx context.Context, id uint) (err error) {
+	ctx, span := startSpan(ctx, "UserRepository.Restore")
+	defer func() { endSpan(span, err) }()
+
+	result := conn(ctx, r.db).Model(&model.User{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return model.ErrNotFound
+	}
+
+	return nil
+}
+
+// FindDeletedBefore は、deletedBeforeより前に退会したユーザーのIDを退会日時の古い順に最大limit件検索します
+func (r *userRepository) FindDeletedBefore(ctx context.Context, deletedBefore time.Time, limit int) (_ []uint, err error) {
+	ctx, span := startSpan(ctx, "UserRepository.FindDeletedBefore")
+	defer func() { endSpan(span, err) }()
+
+	var ids []uint
+	err = conn(ctx, r.db).Model(&model.User{}).
+		Where("deleted_at < ?", deletedBefore).
+		Order("deleted_at, id").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// Purge は、deletedBeforeより前に退会したユーザーをデータベースから完全に削除します
+// 検索の後に退会が取り消された場合に削除しないよう、削除時にも退会日時を確認します
+func (r *userRepository) Purge(ctx context.Context, id uint, deletedBefore time.Time) (err error) {
+	ctx, span := startSpan(ctx, "UserRepository.Purge")
+	defer func() { endSpan(span, err) }()
+
+	result := conn(ctx, r.db).
+		Where("id = ? AND deleted_at < ?", id, deletedBefore).
+		Delete(&model.User{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return model.ErrNotFound
+	}
+
+	return nil
 }
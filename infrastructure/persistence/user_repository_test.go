@@ -0,0 +1,288 @@
+package persistence
+
+import (
+	"context"
+	"testing"
+	"time"
+	"voice-link/domain/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserRepository_FindAll(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	verifiedAt := base
+	users := []*model.User{
+		{Name: "Alice", Email: "alice@example.com", Role: model.RoleAdmin, EmailVerifiedAt: &verifiedAt, CreatedAt: base},
+		{Name: "Bob", Email: "Bob@example.com", Role: model.RoleUser, CreatedAt: base.Add(time.Hour)},
+		{Name: "bob_2", Email: "bob2@example.com", Role: model.RoleUser, EmailVerifiedAt: &verifiedAt, CreatedAt: base.Add(time.Hour)},
+		{Name: "Carol", Email: "carol%@example.com", Role: model.RoleUser, CreatedAt: base.Add(2 * time.Hour)},
+	}
+	for _, user := range users {
+		user.Password = "hashed"
+		assert.NoError(t, repo.Create(ctx, user))
+	}
+
+	// names は、ユーザーの名前の一覧を返します
+	names := func(users []model.User) []string {
+		result := make([]string, len(users))
+		for i, user := range users {
+			result[i] = user.Name
+		}
+		return result
+	}
+
+	verified, unverified := true, false
+	from, to := base.Add(time.Hour), base.Add(2*time.Hour)
+	filters := []struct {
+		name     string
+		filter   model.UserFilter
+		expected []string
+	}{
+		{"条件なし", model.UserFilter{}, []string{"Alice", "Bob", "bob_2", "Carol"}},
+		{"メールアドレスの前方一致は大文字と小文字を区別しない", model.UserFilter{EmailPrefix: "BOB"}, []string{"Bob", "bob_2"}},
+		{"名前の前方一致", model.UserFilter{NamePrefix: "bo"}, []string{"Bob", "bob_2"}},
+		{"ワイルドカードは通常の文字として扱う", model.UserFilter{NamePrefix: "bob_"}, []string{"bob_2"}},
+		{"パーセント記号も通常の文字として扱う", model.UserFilter{EmailPrefix: "carol%@"}, []string{"Carol"}},
+		{"作成日時の範囲は開始を含み終了を含まない", model.UserFilter{CreatedFrom: &from, CreatedTo: &to}, []string{"Bob", "bob_2"}},
+		{"ロール", model.UserFilter{Role: model.RoleAdmin}, []string{"Alice"}},
+		{"確認済み", model.UserFilter{Verified: &verified}, []string{"Alice", "bob_2"}},
+		{"未確認", model.UserFilter{Verified: &unverified, Role: model.RoleUser}, []string{"Bob", "Carol"}},
+	}
+	for _, tt := range filters {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := repo.FindAll(ctx, model.UserListQuery{Filter: tt.filter, SortBy: model.UserSortByID, Order: model.SortAscending, Limit: 10})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, names(result))
+		})
+	}
+
+	t.Run("並び替えとキーセットページネーション", func(t *testing.T) {
+		orders := []struct {
+			sortBy   model.UserSortField
+			order    model.SortOrder
+			expected []string
+		}{
+			// 作成日時が同じユーザーはIDの順に並ぶ
+			{model.UserSortByCreatedAt, model.SortDescending, []string{"Carol", "bob_2", "Bob", "Alice"}},
+			{model.UserSortByCreatedAt, model.SortAscending, []string{"Alice", "Bob", "bob_2", "Carol"}},
+			{model.UserSortByID, model.SortDescending, []string{"Carol", "bob_2", "Bob", "Alice"}},
+			{model.UserSortByName, model.SortAscending, []string{"Alice", "Bob", "Carol", "bob_2"}},
+			{model.UserSortByEmail, model.SortDescending, []string{"carol%@example.com", "bob2@example.com", "alice@example.com", "Bob@example.com"}},
+		}
+		for _, tt := range orders {
+			// 1件ずつ取得して、すべてのユーザーを重複なく順に取得できること
+			var collected []string
+			query := model.UserListQuery{SortBy: tt.sortBy, Order: tt.order, Limit: 1}
+			for i := 0; i < len(users)+1; i++ {
+				page, err := repo.FindAll(ctx, query)
+				assert.NoError(t, err)
+				if len(page) == 0 {
+					break
+				}
+				last := page[0]
+				if tt.sortBy == model.UserSortByEmail {
+					collected = append(collected, last.Email)
+				} else {
+					collected = append(collected, last.Name)
+				}
+				query.After = &model.UserCursor{ID: last.ID, CreatedAt: last.CreatedAt, Email: last.Email, Name: last.Name}
+			}
+			assert.Equal(t, tt.expected, collected, "%s %s", tt.sortBy, tt.order)
+		}
+	})
+
+	t.Run("対応していない並び替えの項目", func(t *testing.T) {
+		_, err := repo.FindAll(ctx, model.UserListQuery{SortBy: "password", Order: model.SortAscending, Limit: 10})
+		assert.Error(t, err)
+	})
+}
+
+func TestUserRepository_SoftDelete(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	resetToken := "reset-token"
+	user := &model.User{Name: "退会するユーザー", Email: "deleted@example.com", Password: "hashed", PasswordResetToken: &resetToken}
+	other := &model.User{Name: "他のユーザー", Email: "other@example.com", Password: "hashed"}
+	assert.NoError(t, repo.Create(ctx, user))
+	assert.NoError(t, repo.Create(ctx, other))
+
+	deletedAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, repo.Delete(ctx, user.ID, deletedAt))
+	// 既に退会済みのユーザーは削除できない
+	assert.ErrorIs(t, repo.Delete(ctx, user.ID, deletedAt), model.ErrNotFound)
+
+	// 退会済みのユーザーはIDやトークン、一覧では見つからない
+	_, err := repo.FindByID(ctx, user.ID)
+	assert.ErrorIs(t, err, model.ErrNotFound)
+	_, err = repo.FindByPasswordResetToken(ctx, resetToken)
+	assert.ErrorIs(t, err, model.ErrNotFound)
+	users, err := repo.FindAll(ctx, model.UserListQuery{SortBy: model.UserSortByID, Order: model.SortAscending, Limit: 10})
+	assert.NoError(t, err)
+	if assert.Len(t, users, 1) {
+		assert.Equal(t, other.ID, users[0].ID)
+	}
+
+	// メールアドレスでは見つかり、完全に削除されるまで同じメールアドレスで登録できない
+	found, err := repo.FindByEmail(ctx, "deleted@example.com")
+	assert.NoError(t, err)
+	assert.True(t, found.IsDeleted())
+	// 退会した日時には指定した日時を記録する
+	assert.True(t, deletedAt.Equal(*found.DeletedAt))
+	assert.Nil(t, found.PasswordResetToken)
+	assert.ErrorIs(t, repo.Create(ctx, &model.User{Name: "新しいユーザー", Email: "deleted@example.com", Password: "hashed"}), model.ErrDuplicateEmail)
+	found, err = repo.FindByIDIncludingDeleted(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.True(t, found.IsDeleted())
+
+	// 復元すると再び見つかる
+	assert.NoError(t, repo.Restore(ctx, user.ID))
+	assert.ErrorIs(t, repo.Restore(ctx, user.ID), model.ErrNotFound)
+	_, err = repo.FindByID(ctx, user.ID)
+	assert.NoError(t, err)
+}
+
+func TestUserRepository_Purge(t *testing.T) {
+	db := setupTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&model.RefreshToken{}))
+	repo := NewUserRepository(db)
+	refreshTokens := NewRefreshTokenRepository(db)
+	ctx := context.Background()
+
+	now := time.Now()
+	users := map[string]*model.User{
+		"expired": {Name: "猶予期間を過ぎたユーザー", Email: "expired@example.com"},
+		"recent":  {Name: "猶予期間中のユーザー", Email: "recent@example.com"},
+		"active":  {Name: "退会していないユーザー", Email: "active@example.com"},
+	}
+	for _, user := range users {
+		user.Password = "hashed"
+		assert.NoError(t, repo.Create(ctx, user))
+	}
+	assert.NoError(t, repo.Delete(ctx, users["expired"].ID, now.Add(-48*time.Hour)))
+	assert.NoError(t, repo.Delete(ctx, users["recent"].ID, now))
+	assert.NoError(t, refreshTokens.Create(ctx, &model.RefreshToken{UserID: users["expired"].ID, TokenHash: "expired", FamilyID: "family", ExpiresAt: now}))
+	assert.NoError(t, refreshTokens.Create(ctx, &model.RefreshToken{UserID: users["active"].ID, TokenHash: "active", FamilyID: "family", ExpiresAt: now}))
+
+	deletedBefore := now.Add(-24 * time.Hour)
+	ids, err := repo.FindDeletedBefore(ctx, deletedBefore, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{users["expired"].ID}, ids)
+
+	// 猶予期間中や退会していないユーザーは削除しない
+	assert.ErrorIs(t, repo.Purge(ctx, users["recent"].ID, deletedBefore), model.ErrNotFound)
+	assert.ErrorIs(t, repo.Purge(ctx, users["active"].ID, deletedBefore), model.ErrNotFound)
+
+	assert.NoError(t, repo.Purge(ctx, users["expired"].ID, deletedBefore))
+	assert.NoError(t, refreshTokens.DeleteAllByUserID(ctx, users["expired"].ID))
+	_, err = repo.FindByEmail(ctx, "expired@example.com")
+	assert.ErrorIs(t, err, model.ErrNotFound)
+	_, err = refreshTokens.FindByTokenHash(ctx, "expired")
+	assert.Error(t, err)
+	_, err = refreshTokens.FindByTokenHash(ctx, "active")
+	assert.NoError(t, err)
+
+	// 完全に削除した後は同じメールアドレスで登録できる
+	assert.NoError(t, repo.Create(ctx, &model.User{Name: "新しいユーザー", Email: "expired@example.com", Password: "hashed"}))
+}
+
+func TestUserRepository_LoginFailures(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	user := &model.User{Name: "テストユーザー", Email: "test@example.com", Password: "hashed"}
+	assert.NoError(t, repo.Create(ctx, user))
+
+	now := time.Now()
+	resetBefore := now.Add(-30 * time.Minute)
+	for expected := 1; expected <= 3; expected++ {
+		failures, err := repo.RecordLoginFailure(ctx, user.ID, now, resetBefore)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, failures)
+	}
+
+	// 最後の失敗がresetBeforeより前の場合は1から数え直す
+	failures, err := repo.RecordLoginFailure(ctx, user.ID, now.Add(time.Hour), now.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, failures)
+
+	_, err = repo.RecordLoginFailure(ctx, user.ID+100, now, resetBefore)
+	assert.ErrorIs(t, err, model.ErrNotFound)
+
+	// ロックするとロック解除トークンで見つかる
+	lockedUntil := now.Add(30 * time.Minute)
+	assert.NoError(t, repo.Lock(ctx, user.ID, lockedUntil, "unlock-token", now.Add(24*time.Hour)))
+	found, err := repo.FindByUnlockToken(ctx, "unlock-token")
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, found.ID)
+	assert.True(t, found.IsLocked(now))
+	assert.False(t, found.IsLocked(lockedUntil.Add(time.Second)))
+
+	// リセットすると失敗回数とロックが解除され、トークンは使用できなくなる
+	assert.NoError(t, repo.ResetLoginFailures(ctx, user.ID))
+	_, err = repo.FindByUnlockToken(ctx, "unlock-token")
+	assert.ErrorIs(t, err, model.ErrNotFound)
+	found, err = repo.FindByID(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, found.FailedLoginAttempts)
+	assert.Nil(t, found.LastFailedLoginAt)
+	assert.Nil(t, found.LockedUntil)
+	assert.False(t, found.IsLocked(now))
+}
+
+func TestUserRepository_MFA(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	user := &model.User{Name: "テストユーザー", Email: "test@example.com", Password: "hashed"}
+	assert.NoError(t, repo.Create(ctx, user))
+
+	// 秘密鍵を設定しただけでは有効にならない
+	assert.NoError(t, repo.SetMFASecret(ctx, user.ID, "SECRET1"))
+	found, err := repo.FindByID(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.False(t, found.IsMFAEnabled())
+
+	// 確認前は秘密鍵を発行し直せる
+	assert.NoError(t, repo.SetMFASecret(ctx, user.ID, "SECRET2"))
+	enabledAt := time.Now().Truncate(time.Second)
+	assert.NoError(t, repo.EnableMFA(ctx, user.ID, enabledAt, 100))
+	found, err = repo.FindByID(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.True(t, found.IsMFAEnabled())
+	assert.Equal(t, "SECRET2", *found.MFASecret)
+	assert.Equal(t, int64(100), found.MFALastUsedStep)
+
+	// 有効にした後は秘密鍵の変更や再度の有効化はできない
+	assert.ErrorIs(t, repo.SetMFASecret(ctx, user.ID, "SECRET3"), model.ErrNotFound)
+	assert.ErrorIs(t, repo.EnableMFA(ctx, user.ID, enabledAt, 101), model.ErrNotFound)
+
+	// 使用済みのステップ以前のコードは使用できない
+	used, err := repo.UseMFAStep(ctx, user.ID, 100)
+	assert.NoError(t, err)
+	assert.False(t, used)
+	used, err = repo.UseMFAStep(ctx, user.ID, 101)
+	assert.NoError(t, err)
+	assert.True(t, used)
+	used, err = repo.UseMFAStep(ctx, user.ID, 101)
+	assert.NoError(t, err)
+	assert.False(t, used)
+
+	// 解除すると秘密鍵と使用済みのステップが削除される
+	assert.NoError(t, repo.DisableMFA(ctx, user.ID))
+	found, err = repo.FindByID(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.False(t, found.IsMFAEnabled())
+	assert.Nil(t, found.MFASecret)
+	assert.Equal(t, int64(0), found.MFALastUsedStep)
+
+	assert.ErrorIs(t, repo.DisableMFA(ctx, user.ID+100), model.ErrNotFound)
+}
@@ -0,0 +1,78 @@
+// package ratelimit は、試行の回数を数えるmodel.RateLimiterの実装を提供します
+// 単一のインスタンスではメモリ上で、複数のインスタンスではRedis互換のサーバーで回数を数えます
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+	"voice-link/domain/model"
+)
+
+// sweepThreshold は、期間が過ぎたキーの掃除を行うキーの件数の閾値です
+const sweepThreshold = 10000
+
+// sweepInterval は、期間が過ぎたキーの掃除を行う最短の間隔です
+// 掃除はすべてのキーを走査するため、多数のキーで試行された場合も毎回は行いません
+const sweepInterval = time.Minute
+
+// window は、キーごとの期間内の試行の回数です
+type window struct {
+	count   int
+	resetAt time.Time // 回数がリセットされる日時
+}
+
+// memoryLimiter は、試行の回数をメモリ上で数えるRateLimiterです
+// 回数はインスタンスごとに数えるため、単一のインスタンスで運用する場合に使用します
+type memoryLimiter struct {
+	clock     model.Clock
+	mu        sync.Mutex
+	windows   map[string]*window
+	nextSweep time.Time // 次に掃除を行える日時
+}
+
+// NewMemoryLimiter は、試行の回数をメモリ上で数えるRateLimiterを作成します
+// clockには通常model.SystemClockを指定し、テストでは任意の時刻を返すClockを指定します
+func NewMemoryLimiter(clock model.Clock) model.RateLimiter {
+	return &memoryLimiter{
+		clock:   clock,
+		windows: make(map[string]*window),
+	}
+}
+
+// Allow は、キーの試行を1回記録し、limitの範囲内かどうかを返します
+func (l *memoryLimiter) Allow(ctx context.Context, key string, limit model.RateLimit) (model.RateLimitResult, error) {
+	now := l.clock.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	w, ok := l.windows[key]
+	if !ok || !now.Before(w.resetAt) {
+		l.sweepLocked(now)
+		w = &window{resetAt: now.Add(limit.Window)}
+		l.windows[key] = w
+	}
+	w.count++
+
+	if w.count > limit.Limit {
+		return model.RateLimitResult{Allowed: false, RetryAfter: w.resetAt.Sub(now)}, nil
+	}
+	return model.RateLimitResult{Allowed: true}, nil
+}
+
+// sweepLocked は、キーの件数が閾値を超えた場合に期間が過ぎたキーを削除します
+// 前回の掃除からsweepIntervalが経過していない場合は行いません
+// 呼び出し元でロックを取得している必要があります
+func (l *memoryLimiter) sweepLocked(now time.Time) {
+	if len(l.windows) < sweepThreshold || now.Before(l.nextSweep) {
+		return
+	}
+	l.nextSweep = now.Add(sweepInterval)
+
+	for key, w := range l.windows {
+		if !now.Before(w.resetAt) {
+			delete(l.windows, key)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+	"voice-link/domain/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock は、テストで時刻を進めるためのClockです
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance は、時刻をdだけ進めます
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestMemoryLimiter_Allow(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	limiter := NewMemoryLimiter(clock)
+	limit := model.RateLimit{Limit: 3, Window: time.Minute}
+
+	// 期間内は上限の回数まで許可する
+	for i := 0; i < limit.Limit; i++ {
+		result, err := limiter.Allow(ctx, "login:alice", limit)
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed, "attempt %d", i+1)
+	}
+
+	// 上限を超えた場合は、最初の試行から期間が過ぎるまでの時間を返す
+	clock.Advance(20 * time.Second)
+	result, err := limiter.Allow(ctx, "login:alice", limit)
+	assert.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 40*time.Second, result.RetryAfter)
+
+	// 他のキーの回数には影響しない
+	result, err = limiter.Allow(ctx, "login:bob", limit)
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	// 期間が過ぎると回数はリセットされる
+	clock.Advance(40 * time.Second)
+	result, err = limiter.Allow(ctx, "login:alice", limit)
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed)
+}
+
+func TestMemoryLimiter_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	limiter := NewMemoryLimiter(model.SystemClock)
+	limit := model.RateLimit{Limit: 50, Window: time.Hour}
+
+	// 同時に試行しても、許可されるのは上限の回数のみ
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := limiter.Allow(ctx, "key", limit)
+			assert.NoError(t, err)
+			if result.Allowed {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, limit.Limit, allowed)
+}
+
+func TestMemoryLimiter_SweepsExpiredWindows(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	limiter := NewMemoryLimiter(clock).(*memoryLimiter)
+	limit := model.RateLimit{Limit: 1, Window: time.Minute}
+
+	for i := 0; i < sweepThreshold; i++ {
+		_, err := limiter.Allow(ctx, "ip:"+time.Duration(i).String(), limit)
+		assert.NoError(t, err)
+	}
+
+	// 閾値を超えた状態で新しい期間を開始すると、期間が過ぎたキーを削除する
+	clock.Advance(time.Minute)
+	_, err := limiter.Allow(ctx, "new-key", limit)
+	assert.NoError(t, err)
+	assert.Len(t, limiter.windows, 1)
+
+	shortLimit := model.RateLimit{Limit: 1, Window: sweepInterval / 2}
+	for i := 0; i < sweepThreshold; i++ {
+		_, err := limiter.Allow(ctx, "user:"+time.Duration(i).String(), shortLimit)
+		assert.NoError(t, err)
+	}
+
+	// 前回の掃除からsweepIntervalが経過するまでは、期間が過ぎたキーがあっても掃除しない
+	clock.Advance(sweepInterval / 2)
+	_, err = limiter.Allow(ctx, "another-key", shortLimit)
+	assert.NoError(t, err)
+	assert.Len(t, limiter.windows, sweepThreshold+2)
+
+	clock.Advance(sweepInterval / 2)
+	_, err = limiter.Allow(ctx, "last-key", shortLimit)
+	assert.NoError(t, err)
+	assert.Len(t, limiter.windows, 1)
+}
@@ -0,0 +1,125 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"time"
+	"voice-link/domain/model"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisTimeout は、接続と1回のコマンドの送受信のタイムアウトです
+// サーバーが応答しない場合に認証のリクエストを長く待たせないよう、クライアントの既定値より短くします
+const redisTimeout = time.Second
+
+// redisKeyPrefix は、回数を保存するRedisのキーの接頭辞です
+const redisKeyPrefix = "voice-link:ratelimit:"
+
+// allowScript は、キーの回数を1増やし、増やした後の回数とキーが期限切れになるまでのミリ秒を返すLuaスクリプトです
+// 最初の試行で期限を設定し、期限が過ぎるとキーが削除されて回数がリセットされます
+// 1つのスクリプトで実行するため、複数のインスタンスから同時に試行しても数え漏れはありません
+var allowScript = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+local ttl = redis.call('PTTL', KEYS[1])
+if count == 1 or ttl < 0 then
+  redis.call('PEXPIRE', KEYS[1], ARGV[1])
+  ttl = tonumber(ARGV[1])
+end
+return {count, ttl}
+`)
+
+// RedisConfig は、Redis互換のサーバーへの接続設定です
+type RedisConfig struct {
+	Addr      string // サーバーのアドレス（例: localhost:6379）
+	Username  string // ACLのユーザー名。空の場合はdefaultユーザーとして認証する
+	Password  string // 空の場合は認証しない
+	DB        int    // 使用するデータベースの番号
+	TLS       bool   // TLSで接続するかどうか
+	TLSCAFile string // サーバーの証明書を検証するCA証明書（PEM形式）のファイルのパス。空の場合はシステムのCAを使用する
+}
+
+// RedisLimiter は、試行の回数をRedis互換のサーバーで数えるRateLimiterです
+// 回数はすべてのインスタンスで共有されるため、複数のインスタンスで運用する場合に使用します
+// 回数の期限はサーバーの時刻で管理します
+type RedisLimiter struct {
+	client *redis.Client
+}
+
+// NewRedisLimiter は、試行の回数をRedis互換のサーバーで数えるRateLimiterを作成します
+// 接続は最初の試行の記録時に確立します
+func NewRedisLimiter(config RedisConfig) (*RedisLimiter, error) {
+	options := &redis.Options{
+		Addr:     config.Addr,
+		Username: config.Username,
+		Password: config.Password,
+		DB:       config.DB,
+
+		DialTimeout:  redisTimeout,
+		ReadTimeout:  redisTimeout,
+		WriteTimeout: redisTimeout,
+	}
+	if config.TLS {
+		tlsConfig, err := redisTLSConfig(config.TLSCAFile)
+		if err != nil {
+			return nil, err
+		}
+		options.TLSConfig = tlsConfig
+	}
+	return &RedisLimiter{client: redis.NewClient(options)}, nil
+}
+
+// redisTLSConfig は、サーバーへの接続に使用するTLSの設定を作成します
+func redisTLSConfig(caFile string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile == "" {
+		return tlsConfig, nil
+	}
+
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read redis ca file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("redis ca file contains no certificates: %s", caFile)
+	}
+	tlsConfig.RootCAs = pool
+	return tlsConfig, nil
+}
+
+// Allow は、キーの試行を1回記録し、limitの範囲内かどうかを返します
+func (l *RedisLimiter) Allow(ctx context.Context, key string, limit model.RateLimit) (model.RateLimitResult, error) {
+	window := limit.Window.Milliseconds()
+	if window < 1 {
+		window = 1
+	}
+
+	// スクリプトはEVALSHAで実行し、サーバーに読み込まれていない場合のみ本文を送信する
+	values, err := allowScript.Run(ctx, l.client, []string{redisKeyPrefix + key}, window).Int64Slice()
+	if err != nil {
+		return model.RateLimitResult{}, err
+	}
+	if len(values) != 2 {
+		return model.RateLimitResult{}, fmt.Errorf("redis: unexpected reply to rate limit script: %v", values)
+	}
+	count, ttl := values[0], values[1]
+
+	if count > int64(limit.Limit) {
+		return model.RateLimitResult{Allowed: false, RetryAfter: time.Duration(ttl) * time.Millisecond}, nil
+	}
+	return model.RateLimitResult{Allowed: true}, nil
+}
+
+// Check は、サーバーに接続できるかどうかを確認します
+func (l *RedisLimiter) Check(ctx context.Context) error {
+	return l.client.Ping(ctx).Err()
+}
+
+// Close は、サーバーへの接続を閉じます
+func (l *RedisLimiter) Close() error {
+	return l.client.Close()
+}
@@ -0,0 +1,129 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"voice-link/domain/model"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisLimiter_Allow(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	server.RequireUserAuth("limiter", "secret")
+	limiter, err := NewRedisLimiter(RedisConfig{Addr: server.Addr(), Username: "limiter", Password: "secret", DB: 1})
+	assert.NoError(t, err)
+	defer limiter.Close()
+	limit := model.RateLimit{Limit: 2, Window: time.Minute}
+
+	for i := 0; i < limit.Limit; i++ {
+		result, err := limiter.Allow(ctx, "login:alice", limit)
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+	}
+
+	result, err := limiter.Allow(ctx, "login:alice", limit)
+	assert.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Greater(t, result.RetryAfter, 59*time.Second)
+	assert.LessOrEqual(t, result.RetryAfter, time.Minute)
+
+	// 他のキーの回数には影響しない
+	result, err = limiter.Allow(ctx, "login:bob", limit)
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	// キーには接頭辞を付け、指定したデータベースに保存する
+	assert.True(t, server.DB(1).Exists(redisKeyPrefix+"login:alice"))
+	assert.False(t, server.DB(0).Exists(redisKeyPrefix+"login:alice"))
+
+	// 期間が過ぎると回数がリセットされる
+	server.FastForward(time.Minute)
+	result, err = limiter.Allow(ctx, "login:alice", limit)
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed)
+}
+
+func TestRedisLimiter_TLS(t *testing.T) {
+	ctx := context.Background()
+	// httptestの自己署名証明書をサーバーの証明書とCA証明書に使用する
+	certServer := httptest.NewTLSServer(nil)
+	defer certServer.Close()
+	server, err := miniredis.RunTLS(certServer.TLS)
+	assert.NoError(t, err)
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certServer.Certificate().Raw})
+	assert.NoError(t, os.WriteFile(caFile, caPEM, 0o600))
+
+	t.Run("CA証明書で検証して接続する", func(t *testing.T) {
+		limiter, err := NewRedisLimiter(RedisConfig{Addr: server.Addr(), TLS: true, TLSCAFile: caFile})
+		assert.NoError(t, err)
+		defer limiter.Close()
+
+		assert.NoError(t, limiter.Check(ctx))
+	})
+
+	t.Run("証明書を検証できない場合は接続しない", func(t *testing.T) {
+		limiter, err := NewRedisLimiter(RedisConfig{Addr: server.Addr(), TLS: true})
+		assert.NoError(t, err)
+		defer limiter.Close()
+
+		assert.Error(t, limiter.Check(ctx))
+	})
+
+	t.Run("CA証明書のファイルが不正な場合", func(t *testing.T) {
+		invalid := filepath.Join(t.TempDir(), "invalid.pem")
+		assert.NoError(t, os.WriteFile(invalid, []byte("not a certificate"), 0o600))
+
+		_, err := NewRedisLimiter(RedisConfig{Addr: server.Addr(), TLS: true, TLSCAFile: invalid})
+		assert.ErrorContains(t, err, "contains no certificates")
+	})
+}
+
+func TestRedisLimiter_Errors(t *testing.T) {
+	ctx := context.Background()
+	limit := model.RateLimit{Limit: 1, Window: time.Minute}
+
+	t.Run("認証に失敗した場合", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		server.RequireAuth("secret")
+		limiter, err := NewRedisLimiter(RedisConfig{Addr: server.Addr(), Password: "wrong"})
+		assert.NoError(t, err)
+		defer limiter.Close()
+
+		_, err = limiter.Allow(ctx, "key", limit)
+		assert.ErrorContains(t, err, "WRONGPASS")
+		assert.Error(t, limiter.Check(ctx))
+	})
+
+	t.Run("サーバーに接続できない場合", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		addr := server.Addr()
+		server.Close()
+		limiter, err := NewRedisLimiter(RedisConfig{Addr: addr})
+		assert.NoError(t, err)
+		defer limiter.Close()
+
+		_, err = limiter.Allow(ctx, "key", limit)
+		assert.Error(t, err)
+		assert.Error(t, limiter.Check(ctx))
+	})
+
+	t.Run("サーバーに接続できる場合", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		limiter, err := NewRedisLimiter(RedisConfig{Addr: server.Addr()})
+		assert.NoError(t, err)
+		defer limiter.Close()
+
+		assert.NoError(t, limiter.Check(ctx))
+	})
+}
@@ -0,0 +1,163 @@
+// package tokenkey は、アクセストークンの署名と検証に使用する非対称鍵（RSAまたはEd25519）を提供します
+// 鍵はPEM形式のファイルから読み込み、kidには公開鍵のJWK Thumbprint（RFC 7638）を使用します
+package tokenkey
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"voice-link/domain/model"
+)
+
+// minRSAKeyBits は、受け付けるRSAの鍵の最小の長さです
+const minRSAKeyBits = 2048
+
+// keySet は、署名に使用する鍵と、ローテーションのために検証にも使用する鍵の集合です
+type keySet struct {
+	signing *model.TokenKey
+	keys    map[string]*model.TokenKey // kidごとの検証用の鍵
+	public  []*model.TokenKey          // 公開する鍵。署名に使用する鍵が先頭
+}
+
+// Load は、署名に使用する秘密鍵のファイルと、検証にのみ使用する鍵のファイルを読み込みます
+// 検証にのみ使用する鍵には、ローテーション前の鍵や、次に署名に使用する予定の鍵を指定します。公開鍵と秘密鍵のどちらも指定できます
+func Load(signingKeyFile string, verificationKeyFiles []string) (model.TokenKeySet, error) {
+	signingKey, err := readKey(signingKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	verificationKeys := make([]crypto.PublicKey, 0, len(verificationKeyFiles))
+	for _, path := range verificationKeyFiles {
+		key, err := readKey(path)
+		if err != nil {
+			return nil, err
+		}
+		if private, ok := key.(crypto.Signer); ok {
+			key = private.Public()
+		}
+		verificationKeys = append(verificationKeys, key)
+	}
+
+	return New(signingKey, verificationKeys...)
+}
+
+// New は、署名に使用する秘密鍵と、検証にのみ使用する公開鍵からTokenKeySetを作成します
+func New(signingKey crypto.PrivateKey, verificationKeys ...crypto.PublicKey) (model.TokenKeySet, error) {
+	signer, ok := signingKey.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported signing key type: %T", signingKey)
+	}
+	signing, err := newTokenKey(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	signing.PrivateKey = signingKey
+
+	s := &keySet{
+		signing: signing,
+		keys:    map[string]*model.TokenKey{signing.ID: signing},
+		public:  []*model.TokenKey{signing},
+	}
+	for _, publicKey := range verificationKeys {
+		key, err := newTokenKey(publicKey)
+		if err != nil {
+			return nil, err
+		}
+		// 同じ鍵が重複して指定された場合は1つとして扱う
+		if _, ok := s.keys[key.ID]; ok {
+			continue
+		}
+		s.keys[key.ID] = key
+		s.public = append(s.public, key)
+	}
+	return s, nil
+}
+
+func (s *keySet) SigningKey() *model.TokenKey {
+	return s.signing
+}
+
+func (s *keySet) VerificationKey(kid string) (*model.TokenKey, bool) {
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+func (s *keySet) PublicKeys() []*model.TokenKey {
+	return s.public
+}
+
+// newTokenKey は、公開鍵の種類から署名アルゴリズムを決定し、kidを付けた検証用の鍵を作成します
+func newTokenKey(publicKey crypto.PublicKey) (*model.TokenKey, error) {
+	key := &model.TokenKey{PublicKey: publicKey}
+	switch k := publicKey.(type) {
+	case *rsa.PublicKey:
+		if k.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("rsa key must be at least %d bits: %d", minRSAKeyBits, k.N.BitLen())
+		}
+		key.Algorithm = model.TokenAlgorithmRS256
+	case ed25519.PublicKey:
+		key.Algorithm = model.TokenAlgorithmEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported key type %T: use RSA or Ed25519", publicKey)
+	}
+
+	jwk, _ := key.JWK()
+	key.ID = thumbprint(jwk)
+	return key, nil
+}
+
+// thumbprint は、JWK Thumbprint（RFC 7638）を返します
+// 必須のメンバーのみを辞書順に並べたJSONのSHA-256ハッシュで、同じ鍵からは常に同じ値になります
+func thumbprint(jwk model.JSONWebKey) string {
+	var canonical string
+	switch jwk.Kty {
+	case "RSA":
+		canonical = fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, jwk.E, jwk.N)
+	case "OKP":
+		canonical = fmt.Sprintf(`{"crv":%q,"kty":"OKP","x":%q}`, jwk.Crv, jwk.X)
+	}
+	sum := sha256.Sum256([]byte(canonical))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// readKey は、PEM形式のファイルから秘密鍵または公開鍵を読み込みます
+func readKey(path string) (interface{}, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	key, err := parsePEM(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse key file %s: %w", path, err)
+	}
+	return key, nil
+}
+
+// parsePEM は、PKCS#8またはPKCS#1の秘密鍵、PKIXまたはPKCS#1の公開鍵を読み込みます
+func parsePEM(content []byte) (interface{}, error) {
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type: %s", block.Type)
+	}
+}
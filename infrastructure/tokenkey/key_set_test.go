@@ -0,0 +1,239 @@
+package tokenkey
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"voice-link/domain/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writePEM は、DERをPEM形式で一時ファイルに書き込み、そのパスを返します
+func writePEM(t *testing.T, name, blockType string, der []byte) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+	return path
+}
+
+func mustMarshalPKCS8(t *testing.T, key interface{}) []byte {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.NoError(t, err)
+	return der
+}
+
+func mustMarshalPKIX(t *testing.T, key interface{}) []byte {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	assert.NoError(t, err)
+	return der
+}
+
+func TestLoad(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	previousRSA, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name              string
+		signingKeyFile    func(t *testing.T) string
+		verificationFiles func(t *testing.T) []string
+		wantAlgorithm     string
+		wantKeys          int
+	}{
+		{
+			name: "PKCS#8のRSAの秘密鍵",
+			signingKeyFile: func(t *testing.T) string {
+				return writePEM(t, "signing.pem", "PRIVATE KEY", mustMarshalPKCS8(t, rsaKey))
+			},
+			wantAlgorithm: model.TokenAlgorithmRS256,
+			wantKeys:      1,
+		},
+		{
+			name: "PKCS#1のRSAの秘密鍵",
+			signingKeyFile: func(t *testing.T) string {
+				return writePEM(t, "signing.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+			},
+			wantAlgorithm: model.TokenAlgorithmRS256,
+			wantKeys:      1,
+		},
+		{
+			name: "Ed25519の秘密鍵と検証用の公開鍵",
+			signingKeyFile: func(t *testing.T) string {
+				return writePEM(t, "signing.pem", "PRIVATE KEY", mustMarshalPKCS8(t, edKey))
+			},
+			verificationFiles: func(t *testing.T) []string {
+				return []string{
+					writePEM(t, "previous.pem", "PUBLIC KEY", mustMarshalPKIX(t, &previousRSA.PublicKey)),
+					writePEM(t, "previous-pkcs1.pem", "RSA PUBLIC KEY", x509.MarshalPKCS1PublicKey(&previousRSA.PublicKey)),
+				}
+			},
+			wantAlgorithm: model.TokenAlgorithmEdDSA,
+			wantKeys:      2, // 同じ鍵は1つとして扱う
+		},
+		{
+			name: "検証用に秘密鍵を指定",
+			signingKeyFile: func(t *testing.T) string {
+				return writePEM(t, "signing.pem", "PRIVATE KEY", mustMarshalPKCS8(t, rsaKey))
+			},
+			verificationFiles: func(t *testing.T) []string {
+				return []string{
+					writePEM(t, "next.pem", "PRIVATE KEY", mustMarshalPKCS8(t, edKey)),
+					// 署名に使用する鍵と同じ鍵は重複しない
+					writePEM(t, "current.pem", "PUBLIC KEY", mustMarshalPKIX(t, &rsaKey.PublicKey)),
+				}
+			},
+			wantAlgorithm: model.TokenAlgorithmRS256,
+			wantKeys:      2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var verificationFiles []string
+			if tt.verificationFiles != nil {
+				verificationFiles = tt.verificationFiles(t)
+			}
+
+			keys, err := Load(tt.signingKeyFile(t), verificationFiles)
+
+			if !assert.NoError(t, err) {
+				return
+			}
+			signing := keys.SigningKey()
+			assert.Equal(t, tt.wantAlgorithm, signing.Algorithm)
+			assert.NotEmpty(t, signing.ID)
+			assert.NotNil(t, signing.PrivateKey)
+			assert.Len(t, keys.PublicKeys(), tt.wantKeys)
+			assert.Equal(t, signing, keys.PublicKeys()[0])
+			for _, key := range keys.PublicKeys() {
+				found, ok := keys.VerificationKey(key.ID)
+				assert.True(t, ok)
+				assert.Equal(t, key, found)
+			}
+			if len(keys.PublicKeys()) > 1 {
+				// 検証にのみ使用する鍵では署名できない
+				assert.Nil(t, keys.PublicKeys()[1].PrivateKey)
+			}
+			_, ok := keys.VerificationKey("")
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	smallRSA, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name              string
+		signingKeyFile    func(t *testing.T) string
+		verificationFiles func(t *testing.T) []string
+		wantErr           string
+	}{
+		{
+			name:           "ファイルが存在しない",
+			signingKeyFile: func(t *testing.T) string { return filepath.Join(t.TempDir(), "missing.pem") },
+			wantErr:        "failed to read key file",
+		},
+		{
+			name: "PEM形式ではない",
+			signingKeyFile: func(t *testing.T) string {
+				path := filepath.Join(t.TempDir(), "signing.pem")
+				assert.NoError(t, os.WriteFile(path, []byte("not a key"), 0o600))
+				return path
+			},
+			wantErr: "no PEM block found",
+		},
+		{
+			name: "署名に公開鍵を指定",
+			signingKeyFile: func(t *testing.T) string {
+				return writePEM(t, "signing.pem", "PUBLIC KEY", mustMarshalPKIX(t, edKey.Public()))
+			},
+			wantErr: "unsupported signing key type",
+		},
+		{
+			name: "短いRSAの鍵",
+			signingKeyFile: func(t *testing.T) string {
+				return writePEM(t, "signing.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(smallRSA))
+			},
+			wantErr: "at least 2048 bits",
+		},
+		{
+			name: "ECDSAの鍵",
+			signingKeyFile: func(t *testing.T) string {
+				return writePEM(t, "signing.pem", "PRIVATE KEY", mustMarshalPKCS8(t, ecKey))
+			},
+			wantErr: "unsupported key type",
+		},
+		{
+			name: "検証用の鍵が不正",
+			signingKeyFile: func(t *testing.T) string {
+				return writePEM(t, "signing.pem", "PRIVATE KEY", mustMarshalPKCS8(t, edKey))
+			},
+			verificationFiles: func(t *testing.T) []string {
+				return []string{writePEM(t, "previous.pem", "CERTIFICATE", []byte("cert"))}
+			},
+			wantErr: "unsupported PEM block type",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var verificationFiles []string
+			if tt.verificationFiles != nil {
+				verificationFiles = tt.verificationFiles(t)
+			}
+
+			keys, err := Load(tt.signingKeyFile(t), verificationFiles)
+
+			assert.Nil(t, keys)
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNew_KeyID(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	first, err := New(edKey)
+	assert.NoError(t, err)
+	second, err := New(edKey)
+	assert.NoError(t, err)
+
+	// 同じ鍵からは常に同じkidになるため、再起動やインスタンス間で一致する
+	assert.Equal(t, first.SigningKey().ID, second.SigningKey().ID)
+
+	other, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	withOther, err := New(edKey, other)
+	assert.NoError(t, err)
+	assert.NotEqual(t, withOther.PublicKeys()[0].ID, withOther.PublicKeys()[1].ID)
+}
+
+func TestThumbprint(t *testing.T) {
+	// RFC 7638 3.1の例
+	jwk := model.JSONWebKey{
+		Kty: "RSA",
+		N:   "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+		E:   "AQAB",
+	}
+
+	assert.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", thumbprint(jwk))
+}
@@ -0,0 +1,105 @@
+// package tracing は、OpenTelemetryによる分散トレースの初期化を提供します
+// 受信したリクエストのtraceparentヘッダー（W3C Trace Context）を引き継ぎ、
+// ハンドラー、ユースケース、リポジトリ、SQLの各層のスパンを同じトレースとして記録します
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// トレースの送信方法です
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+// Config は、トレースの送信設定です
+type Config struct {
+	Exporter    string    // none、otlp、stdoutのいずれか
+	Endpoint    string    // OTLPの送信先（例: localhost:4318）。空の場合はOTEL_EXPORTER_OTLP_ENDPOINTまたは既定値を使用
+	Insecure    bool      // OTLPの送信にTLSを使用しないかどうか
+	SampleRatio float64   // 記録するトレースの割合（0〜1）
+	ServiceName string    // トレースに付与するサービス名
+	Writer      io.Writer // stdout使用時の書き出し先。nilの場合は標準出力
+}
+
+// Setup は、グローバルなTracerProviderとW3C Trace Contextのプロパゲーターを登録します
+// 返される関数は、未送信のスパンを送信してTracerProviderを停止します
+// Exporterがnoneの場合はプロパゲーターのみ登録し、スパンは記録しません
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	// トレースを記録しない場合でも、上流のtraceparentを下流に引き継げるようにする
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if cfg.Exporter == ExporterNone || cfg.Exporter == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		// 上流でサンプリングされたトレースは、割合に関わらず記録する
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// newExporter は、設定に応じたスパンの送信先を作成します
+func newExporter(ctx context.Context, cfg Config) (sdktrace.SpanExporter, error) {
+	switch cfg.Exporter {
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exporter, err := otlptracehttp.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create otlp trace exporter: %w", err)
+		}
+		return exporter, nil
+	case ExporterStdout:
+		w := cfg.Writer
+		if w == nil {
+			w = os.Stdout
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(w))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create stdout trace exporter: %w", err)
+		}
+		return exporter, nil
+	default:
+		return nil, fmt.Errorf("unsupported trace exporter: %q", cfg.Exporter)
+	}
+}
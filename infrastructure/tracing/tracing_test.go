@@ -0,0 +1,56 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSetup_Stdout(t *testing.T) {
+	var buf bytes.Buffer
+	shutdown, err := Setup(context.Background(), Config{
+		Exporter:    ExporterStdout,
+		SampleRatio: 1,
+		ServiceName: "voice-link-test",
+		Writer:      &buf,
+	})
+	assert.NoError(t, err)
+
+	_, span := otel.Tracer("test").Start(context.Background(), "test-span")
+	span.End()
+
+	// 停止時に未送信のスパンが書き出される
+	assert.NoError(t, shutdown(context.Background()))
+	assert.Contains(t, buf.String(), `"Name":"test-span"`)
+	assert.Contains(t, buf.String(), span.SpanContext().TraceID().String())
+	assert.Contains(t, buf.String(), "voice-link-test")
+}
+
+func TestSetup_None(t *testing.T) {
+	shutdown, err := Setup(context.Background(), Config{Exporter: ExporterNone})
+	assert.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+
+	// トレースを記録しない場合でも、受信したtraceparentは下流に引き継がれる
+	incoming := http.Header{}
+	incoming.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), propagation.HeaderCarrier(incoming))
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", trace.SpanContextFromContext(ctx).TraceID().String())
+
+	outgoing := http.Header{}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(outgoing))
+	assert.Equal(t, incoming.Get("traceparent"), outgoing.Get("traceparent"))
+}
+
+func TestSetup_UnsupportedExporter(t *testing.T) {
+	shutdown, err := Setup(context.Background(), Config{Exporter: "jaeger", ServiceName: "voice-link-test"})
+
+	assert.Error(t, err)
+	assert.Nil(t, shutdown)
+}
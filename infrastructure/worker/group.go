@@ -0,0 +1,82 @@
+// package worker は、バックグラウンドで動作する処理の起動と停止を管理する機能を提供します
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// Group は、バックグラウンドで動作する処理をまとめて起動し、シャットダウン時にまとめて停止します
+// 各処理には、Stopで取り消されるコンテキストが渡されます
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+	names  map[string]int // 実行中の処理の名前ごとの件数（停止しない処理の特定に使用）
+}
+
+// NewGroup は、新しいGroupを作成します
+func NewGroup() *Group {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Group{
+		ctx:    ctx,
+		cancel: cancel,
+		names:  make(map[string]int),
+	}
+}
+
+// Go は、fnをバックグラウンドで実行します
+// fnはコンテキストが取り消されたら速やかに終了する必要があります。パニックはログに記録して処理を終了します
+func (g *Group) Go(name string, fn func(ctx context.Context)) {
+	g.mu.Lock()
+	g.names[name]++
+	g.mu.Unlock()
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		defer func() {
+			g.mu.Lock()
+			g.names[name]--
+			if g.names[name] == 0 {
+				delete(g.names, name)
+			}
+			g.mu.Unlock()
+		}()
+		defer func() {
+			if r := recover(); r != nil {
+				slog.Error("background worker panicked", "worker", name, "panic", r)
+			}
+		}()
+
+		fn(g.ctx)
+	}()
+}
+
+// Stop は、すべての処理にコンテキストの取り消しを通知し、終了を待ちます
+// ctxの期限までに終了しなかった場合は、終了していない処理の名前を含むエラーを返します
+func (g *Group) Stop(ctx context.Context) error {
+	g.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		running := make([]string, 0, len(g.names))
+		for name := range g.names {
+			running = append(running, name)
+		}
+		return fmt.Errorf("background workers did not stop in time %v: %w", running, ctx.Err())
+	}
+}
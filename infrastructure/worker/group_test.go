@@ -0,0 +1,63 @@
+package worker
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroup_Stop(t *testing.T) {
+	group := NewGroup()
+
+	var stopped atomic.Int32
+	for i := 0; i < 3; i++ {
+		group.Go("worker", func(ctx context.Context) {
+			<-ctx.Done()
+			stopped.Add(1)
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	assert.NoError(t, group.Stop(ctx))
+	assert.Equal(t, int32(3), stopped.Load())
+}
+
+func TestGroup_StopTimeout(t *testing.T) {
+	group := NewGroup()
+
+	release := make(chan struct{})
+	defer close(release)
+	group.Go("stuck", func(ctx context.Context) {
+		// コンテキストの取り消しに応答しない処理
+		<-release
+	})
+	group.Go("well-behaved", func(ctx context.Context) {
+		<-ctx.Done()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := group.Stop(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Contains(t, err.Error(), "stuck")
+	assert.NotContains(t, err.Error(), "well-behaved")
+}
+
+func TestGroup_RecoversPanic(t *testing.T) {
+	group := NewGroup()
+
+	group.Go("panicking", func(ctx context.Context) {
+		panic("boom")
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	assert.NoError(t, group.Stop(ctx))
+}
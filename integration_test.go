@@ -2,32 +2,74 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
-	"os"
+	"sync"
 	"testing"
+	"time"
 	"voice-link/domain/model"
+	"voice-link/infrastructure/cache"
+	"voice-link/infrastructure/mail"
+	"voice-link/infrastructure/migration"
+	"voice-link/infrastructure/oidc"
+	"voice-link/infrastructure/oidc/oidctest"
 	"voice-link/infrastructure/persistence"
+	"voice-link/infrastructure/ratelimit"
+	"voice-link/infrastructure/tokenkey"
 	"voice-link/interface/handler/auth"
+	"voice-link/interface/handler/common"
+	"voice-link/interface/handler/health"
 	"voice-link/interface/handler/user"
+	"voice-link/interface/middleware"
 	"voice-link/interface/router"
+	"voice-link/interface/validator"
 	"voice-link/usecase"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
+// testJWTSecret は、テストで使用するJWTの署名用の秘密鍵です
+const testJWTSecret = "test-secret"
+
+// テストで発行するアクセストークンのissとaudです
+const (
+	testTokenIssuer   = "voice-link-test"
+	testTokenAudience = "voice-link-test"
+)
+
 // setupTestDB は、テスト用のデータベースを設定します
 func setupTestDB(t *testing.T) *gorm.DB {
 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
 	assert.NoError(t, err)
+	// インメモリのデータベースは接続ごとに作成されるため、同時に処理するリクエストでも接続を1つに限る
+	sqlDB, err := db.DB()
+	assert.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
 
-	// マイグレーション
-	err = db.AutoMigrate(&model.User{})
+	// 本番と同じマイグレーションでスキーマを作成
+	migrator, err := migration.New(db)
+	assert.NoError(t, err)
+	_, err = migrator.Up()
 	assert.NoError(t, err)
 
 	return db
@@ -35,26 +77,99 @@ func setupTestDB(t *testing.T) *gorm.DB {
 
 // setupTestApp は、テスト用のアプリケーションを設定します
 func setupTestApp(t *testing.T) *echo.Echo {
-	// JWT_SECRETの設定
-	os.Setenv("JWT_SECRET", "test-secret")
+	app, _ := setupTestAppWithDB(t)
+	return app
+}
+
+// setupTestAppWithDB は、テスト用のアプリケーションとデータベースを設定します
+func setupTestAppWithDB(t *testing.T) (*echo.Echo, *gorm.DB) {
+	return setupTestAppWithConfig(t, usecase.UserUseCaseConfig{
+		FrontendURL: "http://localhost:3000",
+	})
+}
+
+// setupTestAppWithConfig は、指定された設定でテスト用のアプリケーションとデータベースを設定します
+func setupTestAppWithConfig(t *testing.T, config usecase.UserUseCaseConfig) (*echo.Echo, *gorm.DB) {
+	return setupTestAppWithRouterConfig(t, config, router.Config{})
+}
+
+// setupTestAppWithRouterConfig は、指定されたユースケースとルーティングの設定でテスト用のアプリケーションとデータベースを設定します
+func setupTestAppWithRouterConfig(t *testing.T, config usecase.UserUseCaseConfig, routerConfig router.Config) (*echo.Echo, *gorm.DB) {
+	config.JWTSecret = testJWTSecret
+	if config.TokenKeys == nil {
+		config.TokenKeys = model.NewHMACTokenKeySet(testJWTSecret)
+	}
+	config.TokenIssuer = testTokenIssuer
+	config.TokenAudience = []string{testTokenAudience, "media-relay"}
 
 	// テスト用データベースの設定
 	db := setupTestDB(t)
+	assert.NoError(t, db.Use(persistence.NewTracingPlugin()))
+	assert.NoError(t, db.Use(persistence.NewQueryTimeoutPlugin(5*time.Second)))
 
 	// 依存関係の注入
 	userRepo := persistence.NewUserRepository(db)
-	userUseCase := usecase.NewUserUseCase(userRepo)
+	refreshTokenRepo := persistence.NewRefreshTokenRepository(db)
+	tokenRevocations := cache.NewTokenRevocationCache(persistence.NewTokenRevocationRepository(db), tokenRevocationCacheTTL)
+	mailer := mail.NewOutboxMailer(t.TempDir(), "no-reply@example.com")
+	userUseCase := usecase.NewUserUseCase(userRepo, refreshTokenRepo, persistence.NewSessionRepository(db), persistence.NewRecoveryCodeRepository(db), persistence.NewIdentityRepository(db), persistence.NewOIDCAuthRequestRepository(db), tokenRevocations, persistence.NewTransactionManager(db), mailer, nil, config)
 	authHandler := auth.NewAuthHandler(userUseCase)
 	userHandler := user.NewUserHandler(userUseCase)
+	healthHandler := health.NewHealthHandler()
+	healthHandler.Register("database", true, persistence.NewDatabaseHealthChecker(db))
 
 	// Echoのインスタンスを作成
 	e := echo.New()
 
 	// ルーティングの設定
-	r := router.NewRouter(e, authHandler, userHandler)
+	routerConfig.TokenValidation = middleware.TokenValidation{Keys: config.TokenKeys, Issuer: testTokenIssuer, Audience: testTokenAudience}
+	routerConfig.BodyLimit = "1M"
+	routerConfig.ServiceName = "voice-link-test"
+	r := router.NewRouter(e, authHandler, userHandler, healthHandler, tokenRevocations, routerConfig)
 	r.Setup()
 
-	return e
+	return e, db
+}
+
+// registerTestUser は、テスト用のユーザーを登録してIDを返します
+func registerTestUser(t *testing.T, app *echo.Echo, name, email, password string) uint {
+	registerData := map[string]interface{}{
+		"name":     name,
+		"email":    email,
+		"password": password,
+	}
+
+	jsonData, _ := json.Marshal(registerData)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/register", bytes.NewReader(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	app.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	var response map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &response)
+	return uint(response["id"].(float64))
+}
+
+// loginTestUser は、テスト用のユーザーでログインしてトークンを返します
+func loginTestUser(t *testing.T, app *echo.Echo, email, password string) string {
+	loginData := map[string]interface{}{
+		"email":    email,
+		"password": password,
+	}
+
+	jsonData, _ := json.Marshal(loginData)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", bytes.NewReader(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	app.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &response)
+	return response["token"].(string)
 }
 
 func TestIntegration_UserRegistrationAndLogin(t *testing.T) {
@@ -121,12 +236,79 @@ func TestIntegration_UserRegistrationAndLogin(t *testing.T) {
 
 		app.ServeHTTP(rec, req)
 
-		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Equal(t, http.StatusConflict, rec.Code)
 
 		var response map[string]interface{}
 		json.Unmarshal(rec.Body.Bytes(), &response)
-		assert.Equal(t, "email already exists", response["error"])
+		assert.Equal(t, "email_already_exists", response["code"])
+		assert.Equal(t, "email already exists", response["detail"])
+	})
+
+	// 4. 入力検証のテスト
+	t.Run("入力検証エラー", func(t *testing.T) {
+		registerData := map[string]interface{}{
+			"name":     "",
+			"email":    "invalid-email",
+			"password": "short",
+		}
+
+		jsonData, _ := json.Marshal(registerData)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/register", bytes.NewReader(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+
+		app.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Equal(t, common.ProblemContentType, rec.Header().Get(echo.HeaderContentType))
+
+		var response common.Problem
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		assert.Equal(t, "validation_failed", response.Code)
+		assert.Equal(t, "/api/v1/auth/register", response.Instance)
+		assert.NotEmpty(t, response.RequestID)
+		assert.Equal(t, []validator.FieldError{
+			{Field: "name", Rule: "required", Message: "name is required"},
+			{Field: "email", Rule: "email", Message: "email must be a valid email address"},
+			{Field: "password", Rule: "min", Message: "password must be at least 6 characters"},
+		}, response.Errors)
+	})
+}
+
+func TestIntegration_ConcurrentRegistration(t *testing.T) {
+	// テスト用アプリケーションの設定
+	app := setupTestApp(t)
+
+	jsonData, _ := json.Marshal(map[string]interface{}{
+		"name":     "テストユーザー",
+		"email":    "race@example.com",
+		"password": "password123",
 	})
+
+	// 同じメールアドレスで同時に登録する
+	const requests = 5
+	codes := make(chan int, requests)
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/register", bytes.NewReader(jsonData))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			app.ServeHTTP(rec, req)
+			codes <- rec.Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+
+	// 1件のみ登録され、残りは500ではなく409 Conflictになる
+	counts := make(map[int]int)
+	for code := range codes {
+		counts[code]++
+	}
+	assert.Equal(t, map[int]int{http.StatusCreated: 1, http.StatusConflict: requests - 1}, counts)
 }
 
 func TestIntegration_ProtectedEndpoints(t *testing.T) {
@@ -177,7 +359,7 @@ func TestIntegration_ProtectedEndpoints(t *testing.T) {
 
 		var response map[string]interface{}
 		json.Unmarshal(rec.Body.Bytes(), &response)
-		assert.Equal(t, "Authorization header is required", response["error"])
+		assert.Equal(t, "Authorization header is required", response["detail"])
 	})
 
 	t.Run("有効なトークンでアクセス", func(t *testing.T) {
@@ -206,13 +388,13 @@ func TestIntegration_ProtectedEndpoints(t *testing.T) {
 
 		var response map[string]interface{}
 		json.Unmarshal(rec.Body.Bytes(), &response)
-		assert.Equal(t, "Invalid token", response["error"])
+		assert.Equal(t, "Invalid token", response["detail"])
 	})
 }
 
 func TestIntegration_UserCRUD(t *testing.T) {
 	// テスト用アプリケーションの設定
-	app := setupTestApp(t)
+	app, db := setupTestAppWithDB(t)
 
 	// 1. ユーザー登録
 	registerData := map[string]interface{}{
@@ -267,10 +449,28 @@ func TestIntegration_UserCRUD(t *testing.T) {
 		var response map[string]interface{}
 		json.Unmarshal(rec.Body.Bytes(), &response)
 		assert.Equal(t, "更新されたユーザー", response["name"])
-		assert.Equal(t, "updated@example.com", response["email"])
+		// 新しいメールアドレスは確認が完了するまで保留される
+		assert.Equal(t, "test@example.com", response["email"])
+		assert.Equal(t, "updated@example.com", response["pending_email"])
+	})
+
+	// 4. 新しいメールアドレスの確認
+	t.Run("メールアドレス変更の確認", func(t *testing.T) {
+		var user model.User
+		db.Where("email = ?", "test@example.com").First(&user)
+		assert.NotNil(t, user.EmailVerificationToken)
+
+		jsonData, _ := json.Marshal(map[string]interface{}{"token": *user.EmailVerificationToken})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/verify-email", bytes.NewReader(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+
+		app.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
 	})
 
-	// 4. 更新後のユーザー情報取得のテスト
+	// 5. 更新後のユーザー情報取得のテスト
 	t.Run("更新後のユーザー情報取得", func(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, "/api/v1/users/me", nil)
 		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
@@ -286,3 +486,1396 @@ func TestIntegration_UserCRUD(t *testing.T) {
 		assert.Equal(t, "updated@example.com", response["email"])
 	})
 }
+
+func TestIntegration_AdminAuthorization(t *testing.T) {
+	// テスト用アプリケーションの設定
+	app, db := setupTestAppWithDB(t)
+
+	// 一般ユーザーと管理者を登録
+	userID := registerTestUser(t, app, "一般ユーザー", "user@example.com", "password123")
+	otherID := registerTestUser(t, app, "他のユーザー", "other@example.com", "password123")
+	adminID := registerTestUser(t, app, "管理者", "admin@example.com", "password123")
+
+	// 管理者ロールを付与
+	err := db.Model(&model.User{}).Where("id = ?", adminID).Update("role", model.RoleAdmin).Error
+	assert.NoError(t, err)
+
+	userToken := loginTestUser(t, app, "user@example.com", "password123")
+	adminToken := loginTestUser(t, app, "admin@example.com", "password123")
+
+	t.Run("一般ユーザーは他のユーザー情報を取得できない", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/v1/users/%d", otherID), nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", userToken))
+		rec := httptest.NewRecorder()
+
+		app.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("一般ユーザーは他のユーザー情報を更新できない", func(t *testing.T) {
+		updateData := map[string]interface{}{
+			"name":  "乗っ取り",
+			"email": "hijacked@example.com",
+		}
+
+		jsonData, _ := json.Marshal(updateData)
+		req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/api/v1/users/%d", otherID), bytes.NewReader(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", userToken))
+		rec := httptest.NewRecorder()
+
+		app.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		var other model.User
+		db.First(&other, otherID)
+		assert.Equal(t, "other@example.com", other.Email)
+	})
+
+	t.Run("一般ユーザーは他のユーザーを削除できない", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/api/v1/users/%d", otherID), nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", userToken))
+		rec := httptest.NewRecorder()
+
+		app.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("管理者は他のユーザー情報を取得できる", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/v1/users/%d", userID), nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", adminToken))
+		rec := httptest.NewRecorder()
+
+		app.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var response map[string]interface{}
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		assert.Equal(t, "user@example.com", response["email"])
+		assert.Equal(t, "user", response["role"])
+	})
+
+	t.Run("管理者は他のユーザーを削除できる", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/api/v1/users/%d", otherID), nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", adminToken))
+		rec := httptest.NewRecorder()
+
+		app.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+	})
+
+	t.Run("削除済みのユーザーは見つからない", func(t *testing.T) {
+		for _, method := range []string{http.MethodGet, http.MethodDelete} {
+			req := httptest.NewRequest(method, fmt.Sprintf("/api/v1/users/%d", otherID), nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", adminToken))
+			rec := httptest.NewRecorder()
+
+			app.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+
+			var response map[string]interface{}
+			json.Unmarshal(rec.Body.Bytes(), &response)
+			// メッセージは管理者の言語設定（登録時の既定は日本語）で返される
+			assert.Equal(t, "user_not_found", response["code"])
+			assert.Equal(t, "ユーザーが見つかりません", response["detail"])
+		}
+	})
+}
+
+func TestIntegration_AdminUserListing(t *testing.T) {
+	app, db := setupTestAppWithDB(t)
+
+	adminID := registerTestUser(t, app, "管理者", "admin@example.com", "password123")
+	assert.NoError(t, db.Model(&model.User{}).Where("id = ?", adminID).Update("role", model.RoleAdmin).Error)
+	for i := 1; i <= 4; i++ {
+		registerTestUser(t, app, fmt.Sprintf("ユーザー%d", i), fmt.Sprintf("user%d@example.com", i), "password123")
+	}
+
+	userToken := loginTestUser(t, app, "user1@example.com", "password123")
+	adminToken := loginTestUser(t, app, "admin@example.com", "password123")
+
+	// listUsers は、ユーザー一覧を取得してレスポンスを返します
+	listUsers := func(t *testing.T, token, query string) (int, map[string]interface{}) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users"+query, nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		rec := httptest.NewRecorder()
+
+		app.ServeHTTP(rec, req)
+
+		var response map[string]interface{}
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		return rec.Code, response
+	}
+
+	t.Run("一般ユーザーはユーザー一覧を取得できない", func(t *testing.T) {
+		status, _ := listUsers(t, userToken, "")
+		assert.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("管理者はカーソルで全ページを取得できる", func(t *testing.T) {
+		var emails []string
+		query := "?email_prefix=user&sort=email&order=asc&limit=3"
+		for pages := 0; pages < 3; pages++ {
+			status, response := listUsers(t, adminToken, query)
+			if !assert.Equal(t, http.StatusOK, status) {
+				return
+			}
+			for _, item := range response["data"].([]interface{}) {
+				emails = append(emails, item.(map[string]interface{})["email"].(string))
+			}
+			page := response["page"].(map[string]interface{})
+			assert.Equal(t, float64(3), page["limit"])
+			if page["has_more"] != true {
+				break
+			}
+			query = "?email_prefix=user&sort=email&order=asc&limit=3&cursor=" + page["next_cursor"].(string)
+		}
+		assert.Equal(t, []string{"user1@example.com", "user2@example.com", "user3@example.com", "user4@example.com"}, emails)
+	})
+
+	t.Run("ロールで絞り込む", func(t *testing.T) {
+		status, response := listUsers(t, adminToken, "?role=admin")
+		assert.Equal(t, http.StatusOK, status)
+		data := response["data"].([]interface{})
+		if assert.Len(t, data, 1) {
+			assert.Equal(t, "admin@example.com", data[0].(map[string]interface{})["email"])
+		}
+	})
+
+	t.Run("不正なカーソルは拒否される", func(t *testing.T) {
+		status, response := listUsers(t, adminToken, "?cursor=invalid")
+		assert.Equal(t, http.StatusUnprocessableEntity, status)
+		assert.Equal(t, "invalid_cursor", response["code"])
+	})
+}
+
+func TestIntegration_AccountDeletion(t *testing.T) {
+	app, db := setupTestAppWithDB(t)
+	userID := registerTestUser(t, app, "テストユーザー", "test@example.com", "password123")
+
+	// request は、トークン付きでリクエストを送信してステータスコードを返します
+	request := func(method, path, token string) int {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		rec := httptest.NewRecorder()
+		app.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// register は、ユーザー登録APIを呼び出してステータスコードを返します
+	register := func(email string) int {
+		jsonData, _ := json.Marshal(map[string]interface{}{"name": "新しいユーザー", "email": email, "password": "password123"})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/register", bytes.NewReader(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		app.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	token := loginTestUser(t, app, "test@example.com", "password123")
+	assert.Equal(t, http.StatusNoContent, request(http.MethodDelete, "/api/v1/users/me", token))
+
+	t.Run("退会すると発行済みのトークンは使用できない", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, request(http.MethodGet, "/api/v1/users/me", token))
+	})
+
+	t.Run("完全に削除されるまで同じメールアドレスで登録できない", func(t *testing.T) {
+		assert.Equal(t, http.StatusConflict, register("test@example.com"))
+	})
+
+	t.Run("猶予期間中はログインで復元できる", func(t *testing.T) {
+		restored := loginTestUser(t, app, "test@example.com", "password123")
+		assert.Equal(t, http.StatusOK, request(http.MethodGet, "/api/v1/users/me", restored))
+
+		var user model.User
+		assert.NoError(t, db.First(&user, userID).Error)
+		assert.False(t, user.IsDeleted())
+	})
+
+	t.Run("猶予期間を過ぎると完全に削除され、メールアドレスを再利用できる", func(t *testing.T) {
+		// ログアウトしたトークンの失効情報も完全に削除する
+		loggedOut := loginTestUser(t, app, "test@example.com", "password123")
+		assert.Equal(t, http.StatusOK, request(http.MethodPost, "/api/v1/auth/logout", loggedOut))
+		restored := loginTestUser(t, app, "test@example.com", "password123")
+		assert.Equal(t, http.StatusNoContent, request(http.MethodDelete, "/api/v1/users/me", restored))
+		assert.NoError(t, db.Model(&model.User{}).Where("id = ?", userID).Update("deleted_at", time.Now().Add(-31*24*time.Hour)).Error)
+
+		// 猶予期間を過ぎたユーザーはログインできない
+		jsonData, _ := json.Marshal(map[string]interface{}{"email": "test@example.com", "password": "password123"})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", bytes.NewReader(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		app.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+		userUseCase := usecase.NewUserUseCase(persistence.NewUserRepository(db), persistence.NewRefreshTokenRepository(db), persistence.NewSessionRepository(db), persistence.NewRecoveryCodeRepository(db), persistence.NewIdentityRepository(db), persistence.NewOIDCAuthRequestRepository(db), persistence.NewTokenRevocationRepository(db), persistence.NewTransactionManager(db), mail.NewOutboxMailer(t.TempDir(), "no-reply@example.com"), nil, usecase.UserUseCaseConfig{
+			DeletionGracePeriod: 30 * 24 * time.Hour,
+		})
+		purged, err := userUseCase.PurgeDeletedUsers(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 1, purged)
+
+		for _, table := range []interface{}{&model.RefreshToken{}, &model.Session{}, &model.RevokedToken{}, &model.UserTokenVersion{}} {
+			var count int64
+			db.Model(table).Where("user_id = ?", userID).Count(&count)
+			assert.Equal(t, int64(0), count, "%T", table)
+		}
+		assert.Equal(t, http.StatusCreated, register("test@example.com"))
+	})
+}
+
+func TestIntegration_InternalErrorsAreNotLeaked(t *testing.T) {
+	// テスト用アプリケーションの設定
+	app, db := setupTestAppWithDB(t)
+
+	// データベース接続を閉じて、以降のクエリを失敗させる
+	sqlDB, err := db.DB()
+	assert.NoError(t, err)
+	assert.NoError(t, sqlDB.Close())
+
+	loginData := map[string]interface{}{
+		"email":    "test@example.com",
+		"password": "password123",
+	}
+
+	jsonData, _ := json.Marshal(loginData)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", bytes.NewReader(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	app.ServeHTTP(rec, req)
+
+	// データベースのエラー内容はクライアントに返されない
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	var response map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.Equal(t, "Internal server error", response["detail"])
+}
+
+func TestIntegration_RequestCancellation(t *testing.T) {
+	// テスト用アプリケーションの設定
+	app := setupTestApp(t)
+	registerTestUser(t, app, "テストユーザー", "test@example.com", "password123")
+
+	jsonData, _ := json.Marshal(map[string]interface{}{
+		"email":    "test@example.com",
+		"password": "password123",
+	})
+
+	// 期限切れ（タイムアウト）のリクエストは、SQLを実行せずに503を返す
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", bytes.NewReader(jsonData)).WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	app.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	var response map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.Equal(t, "request_timeout", response["code"])
+
+	// クライアントが切断したリクエストは、処理を中断して499を記録する
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", bytes.NewReader(jsonData)).WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+
+	app.ServeHTTP(rec, req)
+
+	assert.Equal(t, common.StatusClientClosedRequest, rec.Code)
+
+	// 中断の後も同じ接続で処理を続けられる
+	loginTestUser(t, app, "test@example.com", "password123")
+}
+
+func TestIntegration_HealthChecks(t *testing.T) {
+	// テスト用アプリケーションの設定
+	app, db := setupTestAppWithDB(t)
+
+	get := func(path string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rec := httptest.NewRecorder()
+		app.ServeHTTP(rec, req)
+
+		var response map[string]interface{}
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		return rec, response
+	}
+
+	t.Run("データベースに接続できる場合", func(t *testing.T) {
+		rec, response := get("/healthz")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "ok", response["status"])
+
+		rec, response = get("/readyz")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "ok", response["status"])
+		assert.Equal(t, "ok", response["checks"].(map[string]interface{})["database"].(map[string]interface{})["status"])
+	})
+
+	t.Run("データベースに接続できない場合", func(t *testing.T) {
+		sqlDB, err := db.DB()
+		assert.NoError(t, err)
+		assert.NoError(t, sqlDB.Close())
+
+		// プロセスは応答できるため、livenessは成功する
+		rec, _ := get("/healthz")
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		rec, response := get("/readyz")
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Equal(t, "unavailable", response["status"])
+	})
+}
+
+func TestIntegration_BodyLimit(t *testing.T) {
+	// テスト用アプリケーションの設定
+	app := setupTestApp(t)
+
+	// 上限（1M）を超えるリクエストボディ
+	body := bytes.Repeat([]byte("a"), 2*1024*1024)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	app.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+	var response map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.Equal(t, "request_entity_too_large", response["code"])
+}
+
+func TestIntegration_Tracing(t *testing.T) {
+	// スパンをメモリ上に記録する
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	// テスト用アプリケーションの設定
+	app := setupTestApp(t)
+	registerTestUser(t, app, "テストユーザー", "trace@example.com", "password123")
+
+	// 上流のサービスから引き継いだトレースとしてログインする
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	jsonData, _ := json.Marshal(map[string]interface{}{
+		"email":    "trace@example.com",
+		"password": "password123",
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", bytes.NewReader(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+
+	app.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// ハンドラー、ユースケース、リポジトリ、SQLのスパンが同じトレースに親子関係で記録される
+	// SQLのスパンは複数記録されるため、名前ごとにまとめる
+	spans := make(map[string][]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		if span.SpanContext().TraceID().String() == traceID {
+			spans[span.Name()] = append(spans[span.Name()], span)
+		}
+	}
+
+	server, ok := spans["POST /api/v1/auth/login"]
+	if assert.True(t, ok, "server span should be recorded") {
+		assert.Equal(t, "00f067aa0ba902b7", server[0].Parent().SpanID().String())
+	}
+	parents := []struct{ name, parent string }{
+		{"UserUseCase.Login", "POST /api/v1/auth/login"},
+		{"UserRepository.FindByEmail", "UserUseCase.Login"},
+		{"gorm.Query", "UserRepository.FindByEmail"},
+		{"bcrypt.CompareHashAndPassword", "UserUseCase.Login"},
+		{"TokenRevocationRepository.GetUserTokenVersion", "UserUseCase.Login"},
+		{"gorm.Query", "TokenRevocationRepository.GetUserTokenVersion"},
+		{"RefreshTokenRepository.Create", "UserUseCase.Login"},
+		{"gorm.Create", "RefreshTokenRepository.Create"},
+	}
+	for _, p := range parents {
+		parent, ok := spans[p.parent]
+		if !assert.True(t, ok, "%s should be recorded", p.parent) {
+			continue
+		}
+		found := false
+		for _, span := range spans[p.name] {
+			found = found || span.Parent().SpanID() == parent[0].SpanContext().SpanID()
+		}
+		assert.True(t, found, "%s should be recorded as a child of %s", p.name, p.parent)
+	}
+
+	// SQLはプレースホルダーのまま記録され、メールアドレスは含まれない
+	for _, span := range spans["gorm.Query"] {
+		for _, attr := range span.Attributes() {
+			assert.NotContains(t, attr.Value.Emit(), "trace@example.com")
+		}
+	}
+}
+
+func TestIntegration_RefreshTokenRotation(t *testing.T) {
+	// テスト用アプリケーションの設定
+	app := setupTestApp(t)
+	registerTestUser(t, app, "テストユーザー", "test@example.com", "password123")
+
+	// ログインしてリフレッシュトークンを取得
+	loginData := map[string]interface{}{
+		"email":    "test@example.com",
+		"password": "password123",
+	}
+
+	jsonData, _ := json.Marshal(loginData)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", bytes.NewReader(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	app.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var loginResponse map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &loginResponse)
+	originalRefreshToken := loginResponse["refresh_token"].(string)
+	assert.NotEmpty(t, originalRefreshToken)
+
+	// refresh は、リフレッシュトークンでトークン更新APIを呼び出します
+	refresh := func(refreshToken string) *httptest.ResponseRecorder {
+		jsonData, _ := json.Marshal(map[string]interface{}{"refresh_token": refreshToken})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh", bytes.NewReader(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		app.ServeHTTP(rec, req)
+		return rec
+	}
+
+	var rotatedRefreshToken string
+
+	t.Run("トークン更新", func(t *testing.T) {
+		rec := refresh(originalRefreshToken)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var response map[string]interface{}
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		assert.NotEmpty(t, response["token"])
+		rotatedRefreshToken = response["refresh_token"].(string)
+		assert.NotEqual(t, originalRefreshToken, rotatedRefreshToken)
+
+		// 新しいアクセストークンで保護されたエンドポイントにアクセスできる
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users/me", nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", response["token"]))
+		meRec := httptest.NewRecorder()
+		app.ServeHTTP(meRec, req)
+		assert.Equal(t, http.StatusOK, meRec.Code)
+	})
+
+	t.Run("使用済みトークンの再利用検知", func(t *testing.T) {
+		rec := refresh(originalRefreshToken)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+		var response map[string]interface{}
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		assert.Equal(t, "refresh token reuse detected", response["detail"])
+	})
+
+	t.Run("再利用検知後はファミリー全体が失効", func(t *testing.T) {
+		rec := refresh(rotatedRefreshToken)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
+
+func TestIntegration_LogoutAndRevocation(t *testing.T) {
+	// テスト用アプリケーションの設定
+	app, db := setupTestAppWithDB(t)
+	registerTestUser(t, app, "テストユーザー", "test@example.com", "password123")
+
+	// getMe は、トークンで現在のユーザー情報取得APIを呼び出します
+	getMe := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users/me", nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		rec := httptest.NewRecorder()
+		app.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// post は、トークン付きでPOSTリクエストを送信します
+	post := func(path, token string, body map[string]interface{}) *httptest.ResponseRecorder {
+		jsonData, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		}
+		rec := httptest.NewRecorder()
+		app.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("ログアウトしたトークンは使用できない", func(t *testing.T) {
+		token := loginTestUser(t, app, "test@example.com", "password123")
+		other := loginTestUser(t, app, "test@example.com", "password123")
+
+		rec := post("/api/v1/auth/logout", token, nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		assert.Equal(t, http.StatusUnauthorized, getMe(token))
+		// 他のセッションは影響を受けない
+		assert.Equal(t, http.StatusOK, getMe(other))
+	})
+
+	t.Run("認証なしでログアウト", func(t *testing.T) {
+		rec := post("/api/v1/auth/logout", "", nil)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("すべてのセッションからログアウト", func(t *testing.T) {
+		first := loginTestUser(t, app, "test@example.com", "password123")
+		second := loginTestUser(t, app, "test@example.com", "password123")
+
+		rec := post("/api/v1/auth/logout-all", first, nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		assert.Equal(t, http.StatusUnauthorized, getMe(first))
+		assert.Equal(t, http.StatusUnauthorized, getMe(second))
+
+		// 再ログイン後のトークンは使用できる
+		token := loginTestUser(t, app, "test@example.com", "password123")
+		assert.Equal(t, http.StatusOK, getMe(token))
+	})
+
+	t.Run("パスワードリセットで既存のトークンが失効", func(t *testing.T) {
+		token := loginTestUser(t, app, "test@example.com", "password123")
+
+		rec := post("/api/v1/auth/password-reset", "", map[string]interface{}{"email": "test@example.com"})
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		// 発行されたリセットトークンをデータベースから取得
+		var user model.User
+		db.Where("email = ?", "test@example.com").First(&user)
+		assert.NotNil(t, user.PasswordResetToken)
+
+		rec = post("/api/v1/auth/password-reset/confirm", "", map[string]interface{}{
+			"token":        *user.PasswordResetToken,
+			"new_password": "newpassword123",
+		})
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		assert.Equal(t, http.StatusUnauthorized, getMe(token))
+
+		// 新しいパスワードでログインできる
+		newToken := loginTestUser(t, app, "test@example.com", "newpassword123")
+		assert.Equal(t, http.StatusOK, getMe(newToken))
+	})
+}
+
+func TestIntegration_Sessions(t *testing.T) {
+	// テスト用アプリケーションの設定
+	clock := &testClock{now: time.Now()}
+	app, _ := setupTestAppWithConfig(t, usecase.UserUseCaseConfig{
+		FrontendURL: "http://localhost:3000",
+		Clock:       clock,
+	})
+	registerTestUser(t, app, "テストユーザー", "test@example.com", "password123")
+
+	// login は、端末の名前とUser-Agentを指定してログインし、アクセストークンとリフレッシュトークンを返します
+	login := func(deviceName, userAgent string) (string, string) {
+		jsonData, _ := json.Marshal(map[string]interface{}{
+			"email":       "test@example.com",
+			"password":    "password123",
+			"device_name": deviceName,
+		})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", bytes.NewReader(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", userAgent)
+		rec := httptest.NewRecorder()
+		app.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var response common.LoginResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		return response.Token, response.RefreshToken
+	}
+
+	// request は、トークン付きでリクエストを送信します
+	request := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		rec := httptest.NewRecorder()
+		app.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// refresh は、リフレッシュトークンでトークン更新APIを呼び出します
+	refresh := func(refreshToken string) int {
+		jsonData, _ := json.Marshal(map[string]interface{}{"refresh_token": refreshToken})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh", bytes.NewReader(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		app.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	phoneToken, _ := login("My iPhone", "VoiceLink/1.0 (iPhone)")
+	browserToken, browserRefreshToken := login("", "Mozilla/5.0")
+
+	// ログインした端末のセッションの一覧を取得
+	rec := request(http.MethodGet, "/api/v1/users/me/sessions", phoneToken)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var response common.SessionsResponse
+	json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.Len(t, response.Sessions, 2)
+
+	var phone, browser common.SessionResponse
+	for _, session := range response.Sessions {
+		if session.DeviceName == "My iPhone" {
+			phone = session
+		} else {
+			browser = session
+		}
+	}
+	assert.True(t, phone.Current)
+	assert.Equal(t, "VoiceLink/1.0 (iPhone)", phone.UserAgent)
+	assert.NotEmpty(t, phone.IPAddress)
+	assert.False(t, browser.Current)
+	assert.Equal(t, "Mozilla/5.0", browser.UserAgent)
+
+	t.Run("他の端末のセッションを失効", func(t *testing.T) {
+		rec := request(http.MethodDelete, "/api/v1/users/me/sessions/"+browser.ID, phoneToken)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+
+		// 失効したセッションのアクセストークンとリフレッシュトークンは使用できない
+		assert.Equal(t, http.StatusUnauthorized, request(http.MethodGet, "/api/v1/users/me", browserToken).Code)
+		assert.Equal(t, http.StatusUnauthorized, refresh(browserRefreshToken))
+		// 失効させた端末は影響を受けない
+		assert.Equal(t, http.StatusOK, request(http.MethodGet, "/api/v1/users/me", phoneToken).Code)
+
+		rec = request(http.MethodGet, "/api/v1/users/me/sessions", phoneToken)
+		var response common.SessionsResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		if assert.Len(t, response.Sessions, 1) {
+			assert.Equal(t, phone.ID, response.Sessions[0].ID)
+		}
+	})
+
+	t.Run("存在しないセッション", func(t *testing.T) {
+		rec := request(http.MethodDelete, "/api/v1/users/me/sessions/unknown", phoneToken)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		var problem common.Problem
+		json.Unmarshal(rec.Body.Bytes(), &problem)
+		assert.Equal(t, "session_not_found", problem.Code)
+	})
+
+	t.Run("他のユーザーのセッションは失効できない", func(t *testing.T) {
+		registerTestUser(t, app, "他のユーザー", "other@example.com", "password123")
+		otherToken := loginTestUser(t, app, "other@example.com", "password123")
+
+		rec := request(http.MethodDelete, "/api/v1/users/me/sessions/"+phone.ID, otherToken)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, http.StatusOK, request(http.MethodGet, "/api/v1/users/me", phoneToken).Code)
+	})
+
+	t.Run("期限切れのセッションは一覧に含まず、失効もできない", func(t *testing.T) {
+		// リフレッシュトークンの有効期間（30日）の間トークンを更新しなかったセッションは期限切れになる
+		clock.Advance(31 * 24 * time.Hour)
+
+		rec := request(http.MethodGet, "/api/v1/users/me/sessions", phoneToken)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var response common.SessionsResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		assert.Empty(t, response.Sessions)
+
+		rec = request(http.MethodDelete, "/api/v1/users/me/sessions/"+phone.ID, phoneToken)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestIntegration_EmailVerification(t *testing.T) {
+	// メールアドレス確認を必須にしたテスト用アプリケーションの設定
+	app, db := setupTestAppWithConfig(t, usecase.UserUseCaseConfig{
+		FrontendURL:              "http://localhost:3000",
+		RequireEmailVerification: true,
+	})
+	registerTestUser(t, app, "テストユーザー", "test@example.com", "password123")
+
+	// post は、POSTリクエストを送信します
+	post := func(path string, body map[string]interface{}) *httptest.ResponseRecorder {
+		jsonData, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		app.ServeHTTP(rec, req)
+		return rec
+	}
+
+	credentials := map[string]interface{}{
+		"email":    "test@example.com",
+		"password": "password123",
+	}
+
+	t.Run("未確認のユーザーはログインできない", func(t *testing.T) {
+		rec := post("/api/v1/auth/login", credentials)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("確認メールの再送で新しいトークンが発行される", func(t *testing.T) {
+		var before model.User
+		db.Where("email = ?", "test@example.com").First(&before)
+		assert.NotNil(t, before.EmailVerificationToken)
+
+		rec := post("/api/v1/auth/verify-email/resend", map[string]interface{}{"email": "test@example.com"})
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var after model.User
+		db.Where("email = ?", "test@example.com").First(&after)
+		assert.NotNil(t, after.EmailVerificationToken)
+		assert.NotEqual(t, *before.EmailVerificationToken, *after.EmailVerificationToken)
+
+		// 古いトークンは使用できない
+		rec = post("/api/v1/auth/verify-email", map[string]interface{}{"token": *before.EmailVerificationToken})
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	})
+
+	t.Run("確認後はログインできる", func(t *testing.T) {
+		var user model.User
+		db.Where("email = ?", "test@example.com").First(&user)
+
+		rec := post("/api/v1/auth/verify-email", map[string]interface{}{"token": *user.EmailVerificationToken})
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		// トークンは一度しか使用できない
+		rec = post("/api/v1/auth/verify-email", map[string]interface{}{"token": *user.EmailVerificationToken})
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+		db.First(&user, user.ID)
+		assert.NotNil(t, user.EmailVerifiedAt)
+
+		rec = post("/api/v1/auth/login", credentials)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+}
+
+func TestIntegration_LoginLockout(t *testing.T) {
+	// 3回連続で失敗するとロックするテスト用アプリケーションの設定
+	app, db := setupTestAppWithConfig(t, usecase.UserUseCaseConfig{
+		FrontendURL: "http://localhost:3000",
+		Lockout:     usecase.LockoutPolicy{DelayAfter: 10, LockAfter: 3, LockDuration: 30 * time.Minute},
+	})
+	registerTestUser(t, app, "テストユーザー", "test@example.com", "password123")
+
+	// login は、ログインAPIを呼び出します
+	login := func(password string) *httptest.ResponseRecorder {
+		jsonData, _ := json.Marshal(map[string]interface{}{"email": "test@example.com", "password": password})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", bytes.NewReader(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		app.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// unlock は、ロック解除APIを呼び出します
+	unlock := func(token string) *httptest.ResponseRecorder {
+		jsonData, _ := json.Marshal(map[string]interface{}{"token": token})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/unlock", bytes.NewReader(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		app.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, login("wrong-password").Code)
+	assert.Equal(t, http.StatusUnauthorized, login("wrong-password").Code)
+
+	t.Run("連続で失敗するとロックされる", func(t *testing.T) {
+		rec := login("wrong-password")
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.Equal(t, "1800", rec.Header().Get(echo.HeaderRetryAfter))
+		var response common.Problem
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		assert.Equal(t, "account_locked", response.Code)
+
+		// ロック中は正しいパスワードでもログインできない
+		assert.Equal(t, http.StatusTooManyRequests, login("password123").Code)
+	})
+
+	t.Run("ロック解除のメールのトークンでロックを解除できる", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnprocessableEntity, unlock("invalid-token").Code)
+
+		var user model.User
+		assert.NoError(t, db.Where("email = ?", "test@example.com").First(&user).Error)
+		if !assert.NotNil(t, user.UnlockToken) {
+			return
+		}
+		assert.Equal(t, http.StatusOK, unlock(*user.UnlockToken).Code)
+		// トークンは一度しか使用できない
+		assert.Equal(t, http.StatusUnprocessableEntity, unlock(*user.UnlockToken).Code)
+
+		assert.Equal(t, http.StatusOK, login("password123").Code)
+		assert.NoError(t, db.First(&user, user.ID).Error)
+		assert.Equal(t, 0, user.FailedLoginAttempts)
+	})
+}
+
+func TestIntegration_IPRateLimit(t *testing.T) {
+	app, _ := setupTestAppWithRouterConfig(t, usecase.UserUseCaseConfig{
+		FrontendURL: "http://localhost:3000",
+	}, router.Config{
+		RateLimiter: ratelimit.NewMemoryLimiter(model.SystemClock),
+		IPRateLimit: model.RateLimit{Limit: 2, Window: time.Minute},
+	})
+
+	// resend は、指定したIPアドレスから確認メールの再送信APIを呼び出します
+	resend := func(ip, forwardedFor string) *httptest.ResponseRecorder {
+		jsonData, _ := json.Marshal(map[string]interface{}{"email": "test@example.com"})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/verify-email/resend", bytes.NewReader(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = ip + ":12345"
+		if forwardedFor != "" {
+			req.Header.Set(echo.HeaderXForwardedFor, forwardedFor)
+		}
+		rec := httptest.NewRecorder()
+		app.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, resend("192.0.2.1", "").Code)
+	// 信頼するプロキシが設定されていない場合、X-Forwarded-Forで別のクライアントを装っても同じIPアドレスとして数える
+	assert.Equal(t, http.StatusOK, resend("192.0.2.1", "198.51.100.1").Code)
+
+	rec := resend("192.0.2.1", "198.51.100.2")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.NotEmpty(t, rec.Header().Get(echo.HeaderRetryAfter))
+
+	// 他のIPアドレスからのリクエストは制限しない
+	assert.Equal(t, http.StatusOK, resend("192.0.2.2", "").Code)
+}
+
+func TestIntegration_Localization(t *testing.T) {
+	// テスト用アプリケーションの設定
+	app, db := setupTestAppWithDB(t)
+
+	// request は、指定したAccept-Languageヘッダーでリクエストを送信します
+	request := func(method, path, acceptLanguage, token string, body map[string]interface{}) (*httptest.ResponseRecorder, map[string]interface{}) {
+		jsonData, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewReader(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		if acceptLanguage != "" {
+			req.Header.Set("Accept-Language", acceptLanguage)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		}
+		rec := httptest.NewRecorder()
+		app.ServeHTTP(rec, req)
+
+		var response map[string]interface{}
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		return rec, response
+	}
+
+	invalidCredentials := map[string]interface{}{
+		"email":    "nobody@example.com",
+		"password": "password123",
+	}
+
+	t.Run("Accept-Languageで指定した言語のエラーメッセージ", func(t *testing.T) {
+		rec, response := request(http.MethodPost, "/api/v1/auth/login", "ja-JP,ja;q=0.9,en;q=0.8", "", invalidCredentials)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Equal(t, "invalid_credentials", response["code"])
+		assert.Equal(t, "メールアドレスまたはパスワードが正しくありません", response["detail"])
+		assert.Equal(t, "ja", rec.Header().Get("Content-Language"))
+		assert.Contains(t, rec.Header().Values("Vary"), "Accept-Language")
+
+		rec, response = request(http.MethodPost, "/api/v1/auth/login", "en-US", "", invalidCredentials)
+		assert.Equal(t, "invalid_credentials", response["code"])
+		assert.Equal(t, "invalid email or password", response["detail"])
+		assert.Equal(t, "en", rec.Header().Get("Content-Language"))
+	})
+
+	t.Run("入力検証エラーの翻訳", func(t *testing.T) {
+		_, response := request(http.MethodPost, "/api/v1/auth/register", "ja", "", map[string]interface{}{
+			"name":     "テストユーザー",
+			"email":    "test@example.com",
+			"password": "123",
+		})
+		assert.Equal(t, "入力内容に誤りがあります", response["detail"])
+		errors := response["errors"].([]interface{})
+		assert.Len(t, errors, 1)
+		assert.Equal(t, "passwordは6文字以上で入力してください", errors[0].(map[string]interface{})["message"])
+	})
+
+	t.Run("登録時のAccept-Languageがユーザーの言語設定になる", func(t *testing.T) {
+		rec, _ := request(http.MethodPost, "/api/v1/auth/register", "en", "", map[string]interface{}{
+			"name":     "English User",
+			"email":    "english@example.com",
+			"password": "password123",
+		})
+		assert.Equal(t, http.StatusCreated, rec.Code)
+
+		var user model.User
+		db.Where("email = ?", "english@example.com").First(&user)
+		assert.Equal(t, model.LanguageEnglish, user.PreferredLanguage)
+	})
+
+	t.Run("Accept-Languageがない場合はユーザーの言語設定を使用する", func(t *testing.T) {
+		registerTestUser(t, app, "テストユーザー", "user@example.com", "password123")
+		token := loginTestUser(t, app, "user@example.com", "password123")
+
+		// 登録時の既定の言語は日本語
+		rec, response := request(http.MethodPost, "/api/v1/auth/logout-all", "", token, nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "logged_out_all", response["code"])
+		assert.Equal(t, "すべてのセッションからログアウトしました", response["message"])
+
+		// 言語設定を英語に変更すると、次に発行されたトークンから英語で応答する
+		token = loginTestUser(t, app, "user@example.com", "password123")
+		rec, response = request(http.MethodPut, "/api/v1/users/me", "", token, map[string]interface{}{
+			"name":               "テストユーザー",
+			"email":              "user@example.com",
+			"preferred_language": "en",
+		})
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "en", response["preferred_language"])
+
+		token = loginTestUser(t, app, "user@example.com", "password123")
+		_, response = request(http.MethodPost, "/api/v1/auth/logout", "", token, nil)
+		assert.Equal(t, "Logged out successfully", response["message"])
+	})
+
+	t.Run("対応していない言語は指定できない", func(t *testing.T) {
+		token := loginTestUser(t, app, "user@example.com", "password123")
+		rec, response := request(http.MethodPut, "/api/v1/users/me", "en", token, map[string]interface{}{
+			"name":               "テストユーザー",
+			"email":              "user@example.com",
+			"preferred_language": "fr",
+		})
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Equal(t, "validation_failed", response["code"])
+	})
+}
+
+// testClock は、テストで進められる時刻を返すClockです
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// totpCodeAt は、認証アプリと同じ方法（RFC 6238、HMAC-SHA1、6桁、30秒）で時刻tのコードを計算します
+func totpCodeAt(t *testing.T, secret string, at time.Time) string {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	assert.NoError(t, err)
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(at.Unix()/30))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", value%1000000)
+}
+
+func TestIntegration_MFA(t *testing.T) {
+	clock := &testClock{now: time.Now()}
+	app, db := setupTestAppWithConfig(t, usecase.UserUseCaseConfig{
+		FrontendURL: "http://localhost:3000",
+		Clock:       clock,
+	})
+	userID := registerTestUser(t, app, "テストユーザー", "test@example.com", "password123")
+	adminID := registerTestUser(t, app, "管理者", "admin@example.com", "password123")
+	assert.NoError(t, db.Model(&model.User{}).Where("id = ?", adminID).Update("role", model.RoleAdmin).Error)
+	token := loginTestUser(t, app, "test@example.com", "password123")
+	adminToken := loginTestUser(t, app, "admin@example.com", "password123")
+
+	// request は、JSONのリクエストを送信してレスポンスを返します
+	request := func(method, path, token string, body map[string]interface{}) (*httptest.ResponseRecorder, map[string]interface{}) {
+		jsonData, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewReader(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		}
+		rec := httptest.NewRecorder()
+		app.ServeHTTP(rec, req)
+
+		var response map[string]interface{}
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		return rec, response
+	}
+
+	// login は、パスワードを確認してチャレンジトークンを返します
+	login := func(t *testing.T) string {
+		rec, response := request(http.MethodPost, "/api/v1/auth/login", "", map[string]interface{}{"email": "test@example.com", "password": "password123"})
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, true, response["mfa_required"])
+		assert.NotContains(t, response, "token")
+		assert.NotContains(t, response, "refresh_token")
+		return response["mfa_token"].(string)
+	}
+
+	var (
+		secret        string
+		recoveryCodes []interface{}
+	)
+
+	t.Run("認証アプリのコードを確認して有効にする", func(t *testing.T) {
+		rec, response := request(http.MethodPost, "/api/v1/users/me/mfa/confirm", token, map[string]interface{}{"code": "123456"})
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Equal(t, "mfa_enrollment_not_started", response["code"])
+
+		rec, response = request(http.MethodPost, "/api/v1/users/me/mfa", token, nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		secret = response["secret"].(string)
+		assert.Contains(t, response["otpauth_uri"], "otpauth://totp/")
+		assert.Contains(t, response["otpauth_uri"], "secret="+secret)
+
+		rec, response = request(http.MethodPost, "/api/v1/users/me/mfa/confirm", token, map[string]interface{}{"code": totpCodeAt(t, secret, clock.Now().Add(-time.Hour))})
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Equal(t, "mfa_code_mismatch", response["code"])
+
+		rec, response = request(http.MethodPost, "/api/v1/users/me/mfa/confirm", token, map[string]interface{}{"code": totpCodeAt(t, secret, clock.Now())})
+		assert.Equal(t, http.StatusOK, rec.Code)
+		recoveryCodes = response["recovery_codes"].([]interface{})
+		assert.Len(t, recoveryCodes, 10)
+
+		// リカバリーコードはハッシュ値のみ保存する
+		var stored []model.RecoveryCode
+		assert.NoError(t, db.Where("user_id = ?", userID).Find(&stored).Error)
+		assert.Len(t, stored, 10)
+		for _, code := range stored {
+			assert.NotContains(t, recoveryCodes, code.CodeHash)
+		}
+
+		// 認証アプリの秘密鍵は暗号化して保存する
+		var user model.User
+		assert.NoError(t, db.First(&user, userID).Error)
+		assert.NotNil(t, user.MFASecret)
+		assert.NotContains(t, *user.MFASecret, secret)
+
+		rec, _ = request(http.MethodPost, "/api/v1/users/me/mfa", token, nil)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+
+	t.Run("ログインにはパスワードに加えてコードが必要になる", func(t *testing.T) {
+		mfaToken := login(t)
+
+		// 登録の確認に使用したコードは再び使用できない
+		rec, response := request(http.MethodPost, "/api/v1/auth/login/mfa", "", map[string]interface{}{"mfa_token": mfaToken, "code": totpCodeAt(t, secret, clock.Now())})
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Equal(t, "invalid_mfa_code", response["code"])
+
+		clock.Advance(30 * time.Second)
+		rec, response = request(http.MethodPost, "/api/v1/auth/login/mfa", "", map[string]interface{}{"mfa_token": mfaToken, "code": totpCodeAt(t, secret, clock.Now())})
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NotEmpty(t, response["token"])
+		assert.NotEmpty(t, response["refresh_token"])
+
+		// 発行されたアクセストークンで保護されたエンドポイントにアクセスできる
+		rec, _ = request(http.MethodGet, "/api/v1/users/me", response["token"].(string), nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("チャレンジトークンはアクセストークンとして使用できない", func(t *testing.T) {
+		rec, _ := request(http.MethodGet, "/api/v1/users/me", login(t), nil)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("期限切れのチャレンジトークンは拒否する", func(t *testing.T) {
+		mfaToken := login(t)
+		clock.Advance(6 * time.Minute)
+
+		rec, response := request(http.MethodPost, "/api/v1/auth/login/mfa", "", map[string]interface{}{"mfa_token": mfaToken, "code": totpCodeAt(t, secret, clock.Now())})
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Equal(t, "invalid_mfa_token", response["code"])
+	})
+
+	t.Run("リカバリーコードは一度だけ使用できる", func(t *testing.T) {
+		recoveryCode := recoveryCodes[0].(string)
+
+		rec, _ := request(http.MethodPost, "/api/v1/auth/login/mfa", "", map[string]interface{}{"mfa_token": login(t), "code": recoveryCode})
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		rec, response := request(http.MethodPost, "/api/v1/auth/login/mfa", "", map[string]interface{}{"mfa_token": login(t), "code": recoveryCode})
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Equal(t, "invalid_mfa_code", response["code"])
+	})
+
+	t.Run("管理者は2段階認証を解除できる", func(t *testing.T) {
+		rec, _ := request(http.MethodDelete, fmt.Sprintf("/api/v1/users/%d/mfa", userID), token, nil)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		rec, _ = request(http.MethodDelete, fmt.Sprintf("/api/v1/users/%d/mfa", userID), adminToken, nil)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+
+		// 解除後はパスワードのみでログインできる
+		loginTestUser(t, app, "test@example.com", "password123")
+		var count int64
+		assert.NoError(t, db.Model(&model.RecoveryCode{}).Where("user_id = ?", userID).Count(&count).Error)
+		assert.Zero(t, count)
+
+		rec, response := request(http.MethodDelete, fmt.Sprintf("/api/v1/users/%d/mfa", userID), adminToken, nil)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Equal(t, "mfa_not_enabled", response["code"])
+	})
+
+	t.Run("本人はコードを入力して解除できる", func(t *testing.T) {
+		rec, response := request(http.MethodPost, "/api/v1/users/me/mfa", token, nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		secret = response["secret"].(string)
+		clock.Advance(30 * time.Second)
+		rec, _ = request(http.MethodPost, "/api/v1/users/me/mfa/confirm", token, map[string]interface{}{"code": totpCodeAt(t, secret, clock.Now())})
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		rec, _ = request(http.MethodPost, "/api/v1/users/me/mfa/disable", token, map[string]interface{}{"code": "not-a-code"})
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+		clock.Advance(30 * time.Second)
+		rec, _ = request(http.MethodPost, "/api/v1/users/me/mfa/disable", token, map[string]interface{}{"code": totpCodeAt(t, secret, clock.Now())})
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		loginTestUser(t, app, "test@example.com", "password123")
+	})
+}
+
+func TestIntegration_OIDC(t *testing.T) {
+	// ローカルで起動したプロバイダーのモックに対して、ディスカバリーからIDトークンの検証までを行う
+	server := oidctest.NewServer("voice-link", "client-secret")
+	defer server.Close()
+	provider := oidc.NewProvider(oidc.Config{
+		Issuer:       server.Issuer(),
+		ClientID:     server.ClientID,
+		ClientSecret: server.ClientSecret,
+		RedirectURL:  "http://localhost:3000/oauth/callback/mock",
+	})
+	app, db := setupTestAppWithConfig(t, usecase.UserUseCaseConfig{
+		FrontendURL:   "http://localhost:3000",
+		OIDCProviders: map[string]model.OIDCProvider{"mock": provider},
+	})
+
+	// request は、JSONのリクエストを送信してレスポンスを返します
+	request := func(method, path, token string, body map[string]interface{}) (*httptest.ResponseRecorder, map[string]interface{}) {
+		jsonData, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewReader(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		}
+		rec := httptest.NewRecorder()
+		app.ServeHTTP(rec, req)
+
+		var response map[string]interface{}
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		return rec, response
+	}
+
+	// authorize は、認可の開始からプロバイダーのリダイレクトまでを行い、コールバックに送信する値を返します
+	authorize := func(t *testing.T, path, token string) map[string]interface{} {
+		rec, response := request(http.MethodPost, path, token, nil)
+		if !assert.Equal(t, http.StatusOK, rec.Code) {
+			return nil
+		}
+		code, state, err := server.Authorize(response["authorization_url"].(string))
+		assert.NoError(t, err)
+		// フロントエンドはリダイレクトされたstateが開始時の値と一致することを確認する
+		assert.Equal(t, response["state"], state)
+		return map[string]interface{}{"code": code, "state": state}
+	}
+
+	var oidcUserToken string
+
+	t.Run("連携していないアカウントでログインするとユーザーを登録する", func(t *testing.T) {
+		callback := authorize(t, "/api/v1/auth/oidc/mock", "")
+		rec, response := request(http.MethodPost, "/api/v1/auth/oidc/mock/callback", "", callback)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NotEmpty(t, response["refresh_token"])
+		oidcUserToken = response["token"].(string)
+
+		rec, response = request(http.MethodGet, "/api/v1/users/me", oidcUserToken, nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "mock-user@example.com", response["email"])
+		assert.Equal(t, "Mock User", response["name"])
+		// プロバイダーが確認済みのメールアドレスは確認済みとして登録する
+		assert.NotNil(t, response["email_verified_at"])
+
+		// 同じstateは再び使用できない
+		rec, response = request(http.MethodPost, "/api/v1/auth/oidc/mock/callback", "", callback)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Equal(t, "invalid_oidc_state", response["code"])
+	})
+
+	t.Run("連携したアカウントで再びログインできる", func(t *testing.T) {
+		rec, _ := request(http.MethodPost, "/api/v1/auth/oidc/mock/callback", "", authorize(t, "/api/v1/auth/oidc/mock", ""))
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var count int64
+		assert.NoError(t, db.Model(&model.User{}).Where("email = ?", "mock-user@example.com").Count(&count).Error)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("同じメールアドレスのユーザーには自動で連携しない", func(t *testing.T) {
+		registerTestUser(t, app, "テストユーザー", "test@example.com", "password123")
+		server.SetAccount(oidctest.Account{Subject: "test-subject", Email: "test@example.com", EmailVerified: true, Name: "Test User"})
+
+		rec, response := request(http.MethodPost, "/api/v1/auth/oidc/mock/callback", "", authorize(t, "/api/v1/auth/oidc/mock", ""))
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Equal(t, "oidc_email_already_exists", response["code"])
+	})
+
+	t.Run("ログイン済みのユーザーにアカウントを連携して解除する", func(t *testing.T) {
+		token := loginTestUser(t, app, "test@example.com", "password123")
+
+		rec, response := request(http.MethodPost, "/api/v1/users/me/identities/mock/callback", token, authorize(t, "/api/v1/users/me/identities/mock", token))
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, "mock", response["provider"])
+
+		// 連携後はプロバイダーのアカウントでログインできる
+		rec, _ = request(http.MethodPost, "/api/v1/auth/oidc/mock/callback", "", authorize(t, "/api/v1/auth/oidc/mock", ""))
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		rec, response = request(http.MethodGet, "/api/v1/users/me/identities", token, nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Len(t, response["identities"], 1)
+
+		// ログインのために発行したstateは連携に使用できない
+		rec, response = request(http.MethodPost, "/api/v1/users/me/identities/mock/callback", token, authorize(t, "/api/v1/auth/oidc/mock", ""))
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Equal(t, "invalid_oidc_state", response["code"])
+
+		// パスワードでログインできるため、最後の連携も解除できる
+		rec, _ = request(http.MethodDelete, "/api/v1/users/me/identities/mock", token, nil)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		rec, response = request(http.MethodDelete, "/api/v1/users/me/identities/mock", token, nil)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, "identity_not_found", response["code"])
+	})
+
+	t.Run("別のユーザーに連携済みのアカウントは連携できない", func(t *testing.T) {
+		token := loginTestUser(t, app, "test@example.com", "password123")
+		server.SetAccount(oidctest.Account{Subject: "mock-user", Email: "mock-user@example.com", EmailVerified: true})
+
+		rec, response := request(http.MethodPost, "/api/v1/users/me/identities/mock/callback", token, authorize(t, "/api/v1/users/me/identities/mock", token))
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Equal(t, "identity_already_linked", response["code"])
+	})
+
+	t.Run("パスワードのないユーザーの最後の連携は解除できない", func(t *testing.T) {
+		rec, response := request(http.MethodDelete, "/api/v1/users/me/identities/mock", oidcUserToken, nil)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Equal(t, "last_login_method", response["code"])
+	})
+
+	t.Run("設定されていないプロバイダー", func(t *testing.T) {
+		rec, response := request(http.MethodPost, "/api/v1/auth/oidc/github", "", nil)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, "oidc_provider_not_found", response["code"])
+	})
+}
+
+func TestIntegration_AsymmetricTokenSigning(t *testing.T) {
+	// RSAの鍵で署名し、次に切り替える予定のEd25519の鍵も公開する
+	signingKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	nextKey, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	keys, err := tokenkey.New(signingKey, nextKey)
+	assert.NoError(t, err)
+
+	app, _ := setupTestAppWithConfig(t, usecase.UserUseCaseConfig{
+		FrontendURL: "http://localhost:3000",
+		TokenKeys:   keys,
+	})
+	registerTestUser(t, app, "テストユーザー", "test@example.com", "password123")
+	accessToken := loginTestUser(t, app, "test@example.com", "password123")
+
+	// 署名した鍵のkidが付与される
+	header, _, err := jwt.NewParser().ParseUnverified(accessToken, jwt.MapClaims{})
+	assert.NoError(t, err)
+	assert.Equal(t, "RS256", header.Header["alg"])
+	assert.Equal(t, keys.SigningKey().ID, header.Header["kid"])
+
+	// 他のサービスは公開されたJWKSで検証できる
+	req := httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get("Cache-Control"), "max-age=")
+	var jwks struct {
+		Keys []model.JSONWebKey `json:"keys"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &jwks))
+	if !assert.Len(t, jwks.Keys, 2) {
+		return
+	}
+	assert.Equal(t, "RSA", jwks.Keys[0].Kty)
+	assert.Equal(t, "OKP", jwks.Keys[1].Kty)
+	// 秘密鍵の成分は公開しない
+	assert.NotContains(t, rec.Body.String(), `"d"`)
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(accessToken, claims, func(token *jwt.Token) (interface{}, error) {
+		for _, jwk := range jwks.Keys {
+			if jwk.Kid == token.Header["kid"] && jwk.Kty == "RSA" {
+				n, _ := base64.RawURLEncoding.DecodeString(jwk.N)
+				e, _ := base64.RawURLEncoding.DecodeString(jwk.E)
+				return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown kid")
+	}, jwt.WithValidMethods([]string{"RS256"}), jwt.WithIssuer(testTokenIssuer), jwt.WithAudience("media-relay"))
+	assert.NoError(t, err)
+
+	// 保護されたエンドポイントにアクセスできる
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/users/me", nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	rec = httptest.NewRecorder()
+	app.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// 共有の秘密鍵で署名したトークンは受け付けない
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": 1,
+		"iss":     testTokenIssuer,
+		"aud":     testTokenAudience,
+		"exp":     time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(testJWTSecret))
+	assert.NoError(t, err)
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/users/me", nil)
+	req.Header.Set("Authorization", "Bearer "+forged)
+	rec = httptest.NewRecorder()
+	app.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestIntegration_JWKSWithSharedSecret(t *testing.T) {
+	// 共有の秘密鍵は公開しない
+	app := setupTestApp(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"keys":[]}`, rec.Body.String())
+}
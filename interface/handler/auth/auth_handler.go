@@ -3,7 +3,10 @@ package auth
 
 import (
 	"net/http"
+	"voice-link/domain/model"
 	"voice-link/interface/handler/common"
+	"voice-link/interface/i18n"
+	"voice-link/interface/middleware"
 	"voice-link/usecase"
 
 	"github.com/labstack/echo/v4"
@@ -24,15 +27,24 @@ func NewAuthHandler(userUseCase usecase.UserUseCase) *AuthHandler {
 func (h *AuthHandler) Register(c echo.Context) error {
 	req := new(common.RegisterUserRequest)
 	if err := c.Bind(req); err != nil { // リクエストボディをバインド
-		return common.SendBadRequestError(c, "Invalid request body")
+		return common.ErrInvalidRequestBody
+	}
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	// 言語が指定されていない場合は、Accept-Languageヘッダーで指定された言語を利用者の言語とする
+	language := model.Language(req.PreferredLanguage)
+	if language == "" {
+		language = i18n.RequestedLanguage(c)
 	}
 
 	// ユースケースレイヤーを呼び出してユーザー登録を実行
-	user, err := h.userUseCase.Register(req.Name, req.Email, req.Password)
+	user, err := h.userUseCase.Register(c.Request().Context(), req.Name, req.Email, req.Password, language)
 
-	// エラーが発生した場合
+	// エラーはHTTPErrorHandlerで種別に応じたレスポンスに変換される
 	if err != nil {
-		return common.SendInternalServerError(c, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusCreated, user) // 201 Createdとユーザー情報を返却
@@ -42,45 +54,242 @@ func (h *AuthHandler) Register(c echo.Context) error {
 func (h *AuthHandler) Login(c echo.Context) error {
 	req := new(common.LoginRequest)
 	if err := c.Bind(req); err != nil {
-		return common.SendBadRequestError(c, "Invalid request body")
+		return common.ErrInvalidRequestBody
+	}
+	if err := c.Validate(req); err != nil {
+		return err
 	}
 
 	// ユースケースレイヤーを呼び出してログインを実行
-	token, err := h.userUseCase.Login(req.Email, req.Password)
+	result, err := h.userUseCase.Login(c.Request().Context(), req.Email, req.Password, clientInfo(c, req.DeviceName))
+	if err != nil {
+		return err
+	}
+
+	return sendLoginResult(c, result)
+}
+
+// BeginOIDCLogin は、URLで指定されたプロバイダーのアカウントでのログインを開始するハンドラー関数です
+// 認可画面のURLと、コールバックで送信するstateを返します
+func (h *AuthHandler) BeginOIDCLogin(c echo.Context) error {
+	authorization, err := h.userUseCase.BeginOIDCLogin(c.Request().Context(), c.Param("provider"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, common.NewOIDCAuthorizationResponse(authorization))
+}
+
+// CompleteOIDCLogin は、プロバイダーからリダイレクトされた認可コードでログインするハンドラー関数です
+// 連携したアカウントがない場合は新しいユーザーを登録し、Accept-Languageヘッダーで指定された言語を利用者の言語とします
+func (h *AuthHandler) CompleteOIDCLogin(c echo.Context) error {
+	req := new(common.OIDCCallbackRequest)
+	if err := c.Bind(req); err != nil {
+		return common.ErrInvalidRequestBody
+	}
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	result, err := h.userUseCase.CompleteOIDCLogin(c.Request().Context(), c.Param("provider"), req.Code, req.State, i18n.RequestedLanguage(c), clientInfo(c, req.DeviceName))
+	if err != nil {
+		return err
+	}
+
+	return sendLoginResult(c, result)
+}
+
+// clientInfo は、セッションに記録するリクエスト元の端末の情報を返します
+// IPアドレスはecho.Context.RealIPで取得するため、信頼するプロキシを設定した場合はX-Forwarded-Forの値を使用します
+func clientInfo(c echo.Context, deviceName string) usecase.ClientInfo {
+	return usecase.ClientInfo{
+		DeviceName: deviceName,
+		UserAgent:  c.Request().UserAgent(),
+		IPAddress:  c.RealIP(),
+	}
+}
+
+// sendLoginResult は、ログインの結果をレスポンスとして返します
+// 2段階認証を有効にしているユーザーには、トークンの代わりにチャレンジを返します
+func sendLoginResult(c echo.Context, result *usecase.LoginResult) error {
+	if result.MFAChallenge != nil {
+		return c.JSON(http.StatusOK, common.MFAChallengeResponse{
+			MFARequired: true,
+			MFAToken:    result.MFAChallenge.Token,
+			ExpiresIn:   result.MFAChallenge.ExpiresIn,
+		})
+	}
+
+	return c.JSON(http.StatusOK, newLoginResponse(result.Tokens))
+}
+
+// VerifyMFA は、ログイン時の2段階認証のコードを確認してトークンを発行するハンドラー関数です
+func (h *AuthHandler) VerifyMFA(c echo.Context) error {
+	req := new(common.VerifyMFARequest)
+	if err := c.Bind(req); err != nil {
+		return common.ErrInvalidRequestBody
+	}
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	// ユースケースレイヤーを呼び出してコードを確認
+	tokens, err := h.userUseCase.VerifyMFA(c.Request().Context(), req.MFAToken, req.Code, clientInfo(c, req.DeviceName))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, newLoginResponse(tokens))
+}
+
+// RefreshToken は、リフレッシュトークンを使用してトークンを再発行するハンドラー関数です
+func (h *AuthHandler) RefreshToken(c echo.Context) error {
+	req := new(common.RefreshTokenRequest)
+	if err := c.Bind(req); err != nil {
+		return common.ErrInvalidRequestBody
+	}
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	// ユースケースレイヤーを呼び出してトークンのローテーションを実行
+	tokens, err := h.userUseCase.RefreshToken(c.Request().Context(), req.RefreshToken, clientInfo(c, ""))
 	if err != nil {
-		return common.SendUnauthorizedError(c, err.Error())
+		return err
 	}
 
-	return c.JSON(http.StatusOK, common.LoginResponse{Token: token})
+	return c.JSON(http.StatusOK, newLoginResponse(tokens))
+}
+
+// Logout は、現在のセッションからログアウトするハンドラー関数です
+// 使用中のアクセストークンと、同じセッションのリフレッシュトークンを失効させます
+func (h *AuthHandler) Logout(c echo.Context) error {
+	claims := middleware.GetClaimsFromContext(c)
+	if claims == nil {
+		return common.ErrNotAuthenticated
+	}
+
+	// ユースケースレイヤーを呼び出してログアウトを実行
+	if err := h.userUseCase.Logout(c.Request().Context(), claims.UserID, claims.ID, claims.SessionID, claims.ExpiresAt.Time); err != nil {
+		return err
+	}
+
+	return common.SendMessageResponse(c, http.StatusOK, common.CodeLoggedOut, "Logged out successfully")
+}
+
+// LogoutAll は、すべてのセッションからログアウトするハンドラー関数です
+func (h *AuthHandler) LogoutAll(c echo.Context) error {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == 0 {
+		return common.ErrNotAuthenticated
+	}
+
+	// ユースケースレイヤーを呼び出して全セッションのログアウトを実行
+	if err := h.userUseCase.LogoutAll(c.Request().Context(), userID); err != nil {
+		return err
+	}
+
+	return common.SendMessageResponse(c, http.StatusOK, common.CodeLoggedOutAll, "Logged out from all sessions successfully")
+}
+
+// newLoginResponse は、発行されたトークンの組からレスポンスボディを作成します
+func newLoginResponse(tokens *usecase.TokenPair) common.LoginResponse {
+	return common.LoginResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    tokens.ExpiresIn,
+	}
 }
 
 // RequestPasswordReset は、パスワードリセットのリクエストを処理するハンドラー関数です
 func (h *AuthHandler) RequestPasswordReset(c echo.Context) error {
 	req := new(common.PasswordResetRequest)
 	if err := c.Bind(req); err != nil {
-		return common.SendBadRequestError(c, "Invalid request body")
+		return common.ErrInvalidRequestBody
+	}
+	if err := c.Validate(req); err != nil {
+		return err
 	}
 
 	// ユースケースレイヤーを呼び出してパスワードリセットリクエストを実行
-	if err := h.userUseCase.RequestPasswordReset(req.Email); err != nil {
-		return common.SendInternalServerError(c, err.Error())
+	if err := h.userUseCase.RequestPasswordReset(c.Request().Context(), req.Email); err != nil {
+		return err
 	}
 
 	// セキュリティ上の理由で、常に成功レスポンスを返す
-	return common.SendMessageResponse(c, http.StatusOK, "If the email exists, a password reset link has been sent")
+	return common.SendMessageResponse(c, http.StatusOK, common.CodePasswordResetRequested, "If the email exists, a password reset link has been sent")
 }
 
 // ResetPassword は、パスワードリセットトークンを使用してパスワードをリセットするハンドラー関数です
 func (h *AuthHandler) ResetPassword(c echo.Context) error {
 	req := new(common.PasswordResetConfirmRequest)
 	if err := c.Bind(req); err != nil {
-		return common.SendBadRequestError(c, "Invalid request body")
+		return common.ErrInvalidRequestBody
+	}
+	if err := c.Validate(req); err != nil {
+		return err
 	}
 
 	// ユースケースレイヤーを呼び出してパスワードリセットを実行
-	if err := h.userUseCase.ResetPassword(req.Token, req.NewPassword); err != nil {
-		return common.SendBadRequestError(c, err.Error())
+	if err := h.userUseCase.ResetPassword(c.Request().Context(), req.Token, req.NewPassword); err != nil {
+		return err
+	}
+
+	return common.SendMessageResponse(c, http.StatusOK, common.CodePasswordReset, "Password has been reset successfully")
+}
+
+// VerifyEmail は、メールアドレス確認トークンを使用してメールアドレスを確認するハンドラー関数です
+func (h *AuthHandler) VerifyEmail(c echo.Context) error {
+	req := new(common.VerifyEmailRequest)
+	if err := c.Bind(req); err != nil {
+		return common.ErrInvalidRequestBody
+	}
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	// ユースケースレイヤーを呼び出してメールアドレスの確認を実行
+	if err := h.userUseCase.VerifyEmail(c.Request().Context(), req.Token); err != nil {
+		return err
+	}
+
+	return common.SendMessageResponse(c, http.StatusOK, common.CodeEmailVerified, "Email address has been verified successfully")
+}
+
+// ResendVerificationEmail は、メールアドレス確認用のメールを再送信するハンドラー関数です
+func (h *AuthHandler) ResendVerificationEmail(c echo.Context) error {
+	req := new(common.ResendVerificationEmailRequest)
+	if err := c.Bind(req); err != nil {
+		return common.ErrInvalidRequestBody
+	}
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	// ユースケースレイヤーを呼び出して確認メールの再送信を実行
+	if err := h.userUseCase.ResendVerificationEmail(c.Request().Context(), req.Email); err != nil {
+		return err
+	}
+
+	// セキュリティ上の理由で、常に成功レスポンスを返す
+	return common.SendMessageResponse(c, http.StatusOK, common.CodeVerificationEmailSent, "If the email exists and is not verified, a verification email has been sent")
+}
+
+// UnlockAccount は、ロック解除トークンを使用してロックされたアカウントのロックを解除するハンドラー関数です
+func (h *AuthHandler) UnlockAccount(c echo.Context) error {
+	req := new(common.UnlockAccountRequest)
+	if err := c.Bind(req); err != nil {
+		return common.ErrInvalidRequestBody
+	}
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	// ユースケースレイヤーを呼び出してアカウントのロックの解除を実行
+	if err := h.userUseCase.UnlockAccount(c.Request().Context(), req.Token); err != nil {
+		return err
 	}
 
-	return common.SendMessageResponse(c, http.StatusOK, "Password has been reset successfully")
+	return common.SendMessageResponse(c, http.StatusOK, common.CodeAccountUnlocked, "Account has been unlocked successfully")
 }
@@ -5,12 +5,19 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 	"voice-link/domain/model"
 	"voice-link/interface/handler/common"
+	"voice-link/interface/middleware"
+	"voice-link/interface/validator"
+	"voice-link/usecase"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestAuthHandler_Register(t *testing.T) {
@@ -34,7 +41,7 @@ func TestAuthHandler_Register(t *testing.T) {
 					Name:  "テストユーザー",
 					Email: "test@example.com",
 				}
-				mockUC.On("Register", "テストユーザー", "test@example.com", "password123").Return(user, nil)
+				mockUC.On("Register", mock.Anything, "テストユーザー", "test@example.com", "password123", model.Language("")).Return(user, nil)
 			},
 			expectedStatus: http.StatusCreated,
 		},
@@ -46,10 +53,10 @@ func TestAuthHandler_Register(t *testing.T) {
 				Password: "password123",
 			},
 			mockSetup: func(mockUC *common.MockUserUseCase) {
-				mockUC.On("Register", "テストユーザー", "test@example.com", "password123").Return(nil, assert.AnError)
+				mockUC.On("Register", mock.Anything, "テストユーザー", "test@example.com", "password123", model.Language("")).Return(nil, usecase.ErrEmailAlreadyExists)
 			},
-			expectedStatus: http.StatusInternalServerError,
-			expectedError:  assert.AnError.Error(),
+			expectedStatus: http.StatusConflict,
+			expectedError:  usecase.ErrEmailAlreadyExists.Error(),
 		},
 	}
 
@@ -72,6 +79,8 @@ func TestAuthHandler_Register(t *testing.T) {
 
 			// Echoコンテキストの作成
 			e := echo.New()
+			e.Validator = validator.New()
+			e.HTTPErrorHandler = common.HTTPErrorHandler
 			c := e.NewContext(req, rec)
 
 			// ハンドラーの実行
@@ -79,10 +88,13 @@ func TestAuthHandler_Register(t *testing.T) {
 
 			// アサーション
 			if tt.expectedError != "" {
+				// エラーはEchoのエラーハンドラーでレスポンスに変換される
 				assert.Error(t, err)
-				var response common.ErrorResponse
+				e.HTTPErrorHandler(err, c)
+				assert.Equal(t, tt.expectedStatus, rec.Code)
+				var response common.Problem
 				json.Unmarshal(rec.Body.Bytes(), &response)
-				assert.Equal(t, tt.expectedError, response.Error)
+				assert.Equal(t, tt.expectedError, response.Detail)
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.expectedStatus, rec.Code)
@@ -96,22 +108,43 @@ func TestAuthHandler_Register(t *testing.T) {
 
 func TestAuthHandler_Login(t *testing.T) {
 	tests := []struct {
-		name           string
-		requestBody    common.LoginRequest
-		mockSetup      func(*common.MockUserUseCase)
-		expectedStatus int
-		expectedError  string
+		name            string
+		requestBody     common.LoginRequest
+		mockSetup       func(*common.MockUserUseCase)
+		expectedStatus  int
+		expectedError   string
+		expectChallenge bool
 	}{
 		{
 			name: "正常なログイン",
+			requestBody: common.LoginRequest{
+				Email:      "test@example.com",
+				Password:   "password123",
+				DeviceName: "My iPhone",
+			},
+			mockSetup: func(mockUC *common.MockUserUseCase) {
+				tokens := &usecase.TokenPair{
+					AccessToken:  "jwt-token",
+					RefreshToken: "refresh-token",
+					ExpiresIn:    900,
+				}
+				client := usecase.ClientInfo{DeviceName: "My iPhone", UserAgent: "test-agent", IPAddress: "192.0.2.1"}
+				mockUC.On("Login", mock.Anything, "test@example.com", "password123", client).Return(&usecase.LoginResult{Tokens: tokens}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "2段階認証が必要な場合はチャレンジを返す",
 			requestBody: common.LoginRequest{
 				Email:    "test@example.com",
 				Password: "password123",
 			},
 			mockSetup: func(mockUC *common.MockUserUseCase) {
-				mockUC.On("Login", "test@example.com", "password123").Return("jwt-token", nil)
+				challenge := &usecase.MFAChallenge{Token: "mfa-token", ExpiresIn: 300}
+				mockUC.On("Login", mock.Anything, "test@example.com", "password123", mock.Anything).Return(&usecase.LoginResult{MFAChallenge: challenge}, nil)
 			},
-			expectedStatus: http.StatusOK,
+			expectedStatus:  http.StatusOK,
+			expectChallenge: true,
 		},
 		{
 			name: "認証失敗",
@@ -120,10 +153,22 @@ func TestAuthHandler_Login(t *testing.T) {
 				Password: "wrongpassword",
 			},
 			mockSetup: func(mockUC *common.MockUserUseCase) {
-				mockUC.On("Login", "test@example.com", "wrongpassword").Return("", assert.AnError)
+				mockUC.On("Login", mock.Anything, "test@example.com", "wrongpassword", mock.Anything).Return(nil, usecase.ErrInvalidEmailOrPassword)
 			},
 			expectedStatus: http.StatusUnauthorized,
-			expectedError:  assert.AnError.Error(),
+			expectedError:  usecase.ErrInvalidEmailOrPassword.Error(),
+		},
+		{
+			name: "ロックされたアカウント",
+			requestBody: common.LoginRequest{
+				Email:    "test@example.com",
+				Password: "password123",
+			},
+			mockSetup: func(mockUC *common.MockUserUseCase) {
+				mockUC.On("Login", mock.Anything, "test@example.com", "password123", mock.Anything).Return(nil, usecase.WithRetryAfter(usecase.ErrAccountLocked, 30*time.Minute))
+			},
+			expectedStatus: http.StatusTooManyRequests,
+			expectedError:  usecase.ErrAccountLocked.Error(),
 		},
 	}
 
@@ -142,10 +187,13 @@ func TestAuthHandler_Login(t *testing.T) {
 			// テスト用のリクエストとレスポンスを作成
 			req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", bytes.NewReader(reqBody))
 			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("User-Agent", "test-agent")
 			rec := httptest.NewRecorder()
 
 			// Echoコンテキストの作成
 			e := echo.New()
+			e.Validator = validator.New()
+			e.HTTPErrorHandler = common.HTTPErrorHandler
 			c := e.NewContext(req, rec)
 
 			// ハンドラーの実行
@@ -153,10 +201,20 @@ func TestAuthHandler_Login(t *testing.T) {
 
 			// アサーション
 			if tt.expectedError != "" {
+				// エラーはEchoのエラーハンドラーでレスポンスに変換される
 				assert.Error(t, err)
-				var response common.ErrorResponse
+				e.HTTPErrorHandler(err, c)
+				assert.Equal(t, tt.expectedStatus, rec.Code)
+				var response common.Problem
 				json.Unmarshal(rec.Body.Bytes(), &response)
-				assert.Equal(t, tt.expectedError, response.Error)
+				assert.Equal(t, tt.expectedError, response.Detail)
+			} else if tt.expectChallenge {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedStatus, rec.Code)
+				var response common.MFAChallengeResponse
+				json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.Equal(t, common.MFAChallengeResponse{MFARequired: true, MFAToken: "mfa-token", ExpiresIn: 300}, response)
+				assert.NotContains(t, rec.Body.String(), "refresh_token")
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.expectedStatus, rec.Code)
@@ -168,6 +226,264 @@ func TestAuthHandler_Login(t *testing.T) {
 	}
 }
 
+func TestAuthHandler_RefreshToken(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestBody    common.RefreshTokenRequest
+		mockSetup      func(*common.MockUserUseCase)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name: "正常なトークン更新",
+			requestBody: common.RefreshTokenRequest{
+				RefreshToken: "refresh-token",
+			},
+			mockSetup: func(mockUC *common.MockUserUseCase) {
+				tokens := &usecase.TokenPair{
+					AccessToken:  "new-jwt-token",
+					RefreshToken: "new-refresh-token",
+					ExpiresIn:    900,
+				}
+				mockUC.On("RefreshToken", mock.Anything, "refresh-token", mock.Anything).Return(tokens, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "無効なリフレッシュトークン",
+			requestBody: common.RefreshTokenRequest{
+				RefreshToken: "invalid-token",
+			},
+			mockSetup: func(mockUC *common.MockUserUseCase) {
+				mockUC.On("RefreshToken", mock.Anything, "invalid-token", mock.Anything).Return(nil, usecase.ErrInvalidRefreshToken)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  usecase.ErrInvalidRefreshToken.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// モックの設定
+			mockUC := new(common.MockUserUseCase)
+			tt.mockSetup(mockUC)
+
+			// ハンドラーの作成
+			handler := NewAuthHandler(mockUC)
+
+			// リクエストボディの準備
+			reqBody, _ := json.Marshal(tt.requestBody)
+
+			// テスト用のリクエストとレスポンスを作成
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh", bytes.NewReader(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			// Echoコンテキストの作成
+			e := echo.New()
+			e.Validator = validator.New()
+			e.HTTPErrorHandler = common.HTTPErrorHandler
+			c := e.NewContext(req, rec)
+
+			// ハンドラーの実行
+			err := handler.RefreshToken(c)
+
+			// エラーはEchoのエラーハンドラーでレスポンスに変換される
+			if err != nil {
+				e.HTTPErrorHandler(err, c)
+			}
+
+			// アサーション
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedError != "" {
+				var response common.Problem
+				json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.Equal(t, tt.expectedError, response.Detail)
+			} else {
+				var response common.LoginResponse
+				json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.Equal(t, "new-jwt-token", response.Token)
+				assert.Equal(t, "new-refresh-token", response.RefreshToken)
+				assert.Equal(t, "Bearer", response.TokenType)
+			}
+
+			// モックの検証
+			mockUC.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthHandler_Logout(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	tests := []struct {
+		name           string
+		claims         *middleware.JWTClaims
+		mockSetup      func(*common.MockUserUseCase)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name: "正常なログアウト",
+			claims: &middleware.JWTClaims{
+				UserID:    1,
+				SessionID: "session-1",
+				RegisteredClaims: jwt.RegisteredClaims{
+					ID:        "jti-1",
+					ExpiresAt: jwt.NewNumericDate(expiresAt),
+				},
+			},
+			mockSetup: func(mockUC *common.MockUserUseCase) {
+				mockUC.On("Logout", mock.Anything, uint(1), "jti-1", "session-1", expiresAt).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "認証されていないユーザー",
+			claims: nil,
+			mockSetup: func(mockUC *common.MockUserUseCase) {
+				// モックの設定は不要（認証エラーで早期リターン）
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "User not authenticated",
+		},
+		{
+			name: "ログアウトエラー",
+			claims: &middleware.JWTClaims{
+				UserID:    1,
+				SessionID: "session-1",
+				RegisteredClaims: jwt.RegisteredClaims{
+					ID:        "jti-1",
+					ExpiresAt: jwt.NewNumericDate(expiresAt),
+				},
+			},
+			mockSetup: func(mockUC *common.MockUserUseCase) {
+				mockUC.On("Logout", mock.Anything, uint(1), "jti-1", "session-1", expiresAt).Return(assert.AnError)
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedError:  "Internal server error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// モックの設定
+			mockUC := new(common.MockUserUseCase)
+			tt.mockSetup(mockUC)
+
+			// ハンドラーの作成
+			handler := NewAuthHandler(mockUC)
+
+			// テスト用のリクエストとレスポンスを作成
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/logout", nil)
+			rec := httptest.NewRecorder()
+
+			// Echoコンテキストの作成
+			e := echo.New()
+			e.Validator = validator.New()
+			e.HTTPErrorHandler = common.HTTPErrorHandler
+			c := e.NewContext(req, rec)
+
+			// 検証済みのクレームをコンテキストに設定
+			if tt.claims != nil {
+				c.Set("claims", tt.claims)
+			}
+
+			// ハンドラーの実行
+			err := handler.Logout(c)
+
+			// エラーはEchoのエラーハンドラーでレスポンスに変換される
+			if err != nil {
+				e.HTTPErrorHandler(err, c)
+			}
+
+			// アサーション
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedError != "" {
+				var response common.Problem
+				json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.Equal(t, tt.expectedError, response.Detail)
+			}
+
+			// モックの検証
+			mockUC.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthHandler_LogoutAll(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         uint
+		mockSetup      func(*common.MockUserUseCase)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:   "正常な全セッションのログアウト",
+			userID: 1,
+			mockSetup: func(mockUC *common.MockUserUseCase) {
+				mockUC.On("LogoutAll", mock.Anything, uint(1)).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "認証されていないユーザー",
+			userID: 0,
+			mockSetup: func(mockUC *common.MockUserUseCase) {
+				// モックの設定は不要（認証エラーで早期リターン）
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "User not authenticated",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// モックの設定
+			mockUC := new(common.MockUserUseCase)
+			tt.mockSetup(mockUC)
+
+			// ハンドラーの作成
+			handler := NewAuthHandler(mockUC)
+
+			// テスト用のリクエストとレスポンスを作成
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/logout-all", nil)
+			rec := httptest.NewRecorder()
+
+			// Echoコンテキストの作成
+			e := echo.New()
+			e.Validator = validator.New()
+			e.HTTPErrorHandler = common.HTTPErrorHandler
+			c := e.NewContext(req, rec)
+
+			// ユーザーIDをコンテキストに設定
+			if tt.userID != 0 {
+				c.Set("user_id", tt.userID)
+			}
+
+			// ハンドラーの実行
+			err := handler.LogoutAll(c)
+
+			// エラーはEchoのエラーハンドラーでレスポンスに変換される
+			if err != nil {
+				e.HTTPErrorHandler(err, c)
+			}
+
+			// アサーション
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedError != "" {
+				var response common.Problem
+				json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.Equal(t, tt.expectedError, response.Detail)
+			}
+
+			// モックの検証
+			mockUC.AssertExpectations(t)
+		})
+	}
+}
+
 func TestAuthHandler_RequestPasswordReset(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -182,7 +498,7 @@ func TestAuthHandler_RequestPasswordReset(t *testing.T) {
 				Email: "test@example.com",
 			},
 			mockSetup: func(mockUC *common.MockUserUseCase) {
-				mockUC.On("RequestPasswordReset", "test@example.com").Return(nil)
+				mockUC.On("RequestPasswordReset", mock.Anything, "test@example.com").Return(nil)
 			},
 			expectedStatus: http.StatusOK,
 		},
@@ -192,10 +508,10 @@ func TestAuthHandler_RequestPasswordReset(t *testing.T) {
 				Email: "test@example.com",
 			},
 			mockSetup: func(mockUC *common.MockUserUseCase) {
-				mockUC.On("RequestPasswordReset", "test@example.com").Return(assert.AnError)
+				mockUC.On("RequestPasswordReset", mock.Anything, "test@example.com").Return(assert.AnError)
 			},
 			expectedStatus: http.StatusInternalServerError,
-			expectedError:  assert.AnError.Error(),
+			expectedError:  "Internal server error",
 		},
 	}
 
@@ -218,6 +534,8 @@ func TestAuthHandler_RequestPasswordReset(t *testing.T) {
 
 			// Echoコンテキストの作成
 			e := echo.New()
+			e.Validator = validator.New()
+			e.HTTPErrorHandler = common.HTTPErrorHandler
 			c := e.NewContext(req, rec)
 
 			// ハンドラーの実行
@@ -225,10 +543,13 @@ func TestAuthHandler_RequestPasswordReset(t *testing.T) {
 
 			// アサーション
 			if tt.expectedError != "" {
+				// エラーはEchoのエラーハンドラーでレスポンスに変換される
 				assert.Error(t, err)
-				var response common.ErrorResponse
+				e.HTTPErrorHandler(err, c)
+				assert.Equal(t, tt.expectedStatus, rec.Code)
+				var response common.Problem
 				json.Unmarshal(rec.Body.Bytes(), &response)
-				assert.Equal(t, tt.expectedError, response.Error)
+				assert.Equal(t, tt.expectedError, response.Detail)
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.expectedStatus, rec.Code)
@@ -255,7 +576,7 @@ func TestAuthHandler_ResetPassword(t *testing.T) {
 				NewPassword: "newpassword123",
 			},
 			mockSetup: func(mockUC *common.MockUserUseCase) {
-				mockUC.On("ResetPassword", "valid-token", "newpassword123").Return(nil)
+				mockUC.On("ResetPassword", mock.Anything, "valid-token", "newpassword123").Return(nil)
 			},
 			expectedStatus: http.StatusOK,
 		},
@@ -266,10 +587,10 @@ func TestAuthHandler_ResetPassword(t *testing.T) {
 				NewPassword: "newpassword123",
 			},
 			mockSetup: func(mockUC *common.MockUserUseCase) {
-				mockUC.On("ResetPassword", "invalid-token", "newpassword123").Return(assert.AnError)
+				mockUC.On("ResetPassword", mock.Anything, "invalid-token", "newpassword123").Return(usecase.ErrInvalidResetToken)
 			},
-			expectedStatus: http.StatusBadRequest,
-			expectedError:  assert.AnError.Error(),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedError:  usecase.ErrInvalidResetToken.Error(),
 		},
 	}
 
@@ -292,6 +613,8 @@ func TestAuthHandler_ResetPassword(t *testing.T) {
 
 			// Echoコンテキストの作成
 			e := echo.New()
+			e.Validator = validator.New()
+			e.HTTPErrorHandler = common.HTTPErrorHandler
 			c := e.NewContext(req, rec)
 
 			// ハンドラーの実行
@@ -299,10 +622,13 @@ func TestAuthHandler_ResetPassword(t *testing.T) {
 
 			// アサーション
 			if tt.expectedError != "" {
+				// エラーはEchoのエラーハンドラーでレスポンスに変換される
 				assert.Error(t, err)
-				var response common.ErrorResponse
+				e.HTTPErrorHandler(err, c)
+				assert.Equal(t, tt.expectedStatus, rec.Code)
+				var response common.Problem
 				json.Unmarshal(rec.Body.Bytes(), &response)
-				assert.Equal(t, tt.expectedError, response.Error)
+				assert.Equal(t, tt.expectedError, response.Detail)
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.expectedStatus, rec.Code)
@@ -313,3 +639,528 @@ func TestAuthHandler_ResetPassword(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthHandler_VerifyEmail(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestBody    common.VerifyEmailRequest
+		mockSetup      func(*common.MockUserUseCase)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name: "正常なメールアドレス確認",
+			requestBody: common.VerifyEmailRequest{
+				Token: "valid-token",
+			},
+			mockSetup: func(mockUC *common.MockUserUseCase) {
+				mockUC.On("VerifyEmail", mock.Anything, "valid-token").Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "無効なトークン",
+			requestBody: common.VerifyEmailRequest{
+				Token: "invalid-token",
+			},
+			mockSetup: func(mockUC *common.MockUserUseCase) {
+				mockUC.On("VerifyEmail", mock.Anything, "invalid-token").Return(usecase.ErrInvalidVerificationToken)
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedError:  usecase.ErrInvalidVerificationToken.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// モックの設定
+			mockUC := new(common.MockUserUseCase)
+			tt.mockSetup(mockUC)
+
+			// ハンドラーの作成
+			handler := NewAuthHandler(mockUC)
+
+			// リクエストボディの準備
+			reqBody, _ := json.Marshal(tt.requestBody)
+
+			// テスト用のリクエストとレスポンスを作成
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/verify-email", bytes.NewReader(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			// Echoコンテキストの作成
+			e := echo.New()
+			e.Validator = validator.New()
+			e.HTTPErrorHandler = common.HTTPErrorHandler
+			c := e.NewContext(req, rec)
+
+			// ハンドラーの実行
+			err := handler.VerifyEmail(c)
+
+			// エラーはEchoのエラーハンドラーでレスポンスに変換される
+			if err != nil {
+				e.HTTPErrorHandler(err, c)
+			}
+
+			// アサーション
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedError != "" {
+				var response common.Problem
+				json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.Equal(t, tt.expectedError, response.Detail)
+			}
+
+			mockUC.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthHandler_ResendVerificationEmail(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestBody    common.ResendVerificationEmailRequest
+		mockSetup      func(*common.MockUserUseCase)
+		expectedStatus int
+	}{
+		{
+			name: "正常な再送リクエスト",
+			requestBody: common.ResendVerificationEmailRequest{
+				Email: "test@example.com",
+			},
+			mockSetup: func(mockUC *common.MockUserUseCase) {
+				mockUC.On("ResendVerificationEmail", mock.Anything, "test@example.com").Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "内部エラー",
+			requestBody: common.ResendVerificationEmailRequest{
+				Email: "test@example.com",
+			},
+			mockSetup: func(mockUC *common.MockUserUseCase) {
+				mockUC.On("ResendVerificationEmail", mock.Anything, "test@example.com").Return(assert.AnError)
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// モックの設定
+			mockUC := new(common.MockUserUseCase)
+			tt.mockSetup(mockUC)
+
+			// ハンドラーの作成
+			handler := NewAuthHandler(mockUC)
+
+			// リクエストボディの準備
+			reqBody, _ := json.Marshal(tt.requestBody)
+
+			// テスト用のリクエストとレスポンスを作成
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/verify-email/resend", bytes.NewReader(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			// Echoコンテキストの作成
+			e := echo.New()
+			e.Validator = validator.New()
+			e.HTTPErrorHandler = common.HTTPErrorHandler
+			c := e.NewContext(req, rec)
+
+			// ハンドラーの実行
+			err := handler.ResendVerificationEmail(c)
+
+			// エラーはEchoのエラーハンドラーでレスポンスに変換される
+			if err != nil {
+				e.HTTPErrorHandler(err, c)
+			}
+
+			// アサーション
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			mockUC.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthHandler_UnlockAccount(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestBody    common.UnlockAccountRequest
+		mockSetup      func(*common.MockUserUseCase)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name: "正常なロックの解除",
+			requestBody: common.UnlockAccountRequest{
+				Token: "valid-token",
+			},
+			mockSetup: func(mockUC *common.MockUserUseCase) {
+				mockUC.On("UnlockAccount", mock.Anything, "valid-token").Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "無効なトークン",
+			requestBody: common.UnlockAccountRequest{
+				Token: "invalid-token",
+			},
+			mockSetup: func(mockUC *common.MockUserUseCase) {
+				mockUC.On("UnlockAccount", mock.Anything, "invalid-token").Return(usecase.ErrInvalidUnlockToken)
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedError:  usecase.ErrInvalidUnlockToken.Error(),
+		},
+		{
+			name: "期限切れのトークン",
+			requestBody: common.UnlockAccountRequest{
+				Token: "expired-token",
+			},
+			mockSetup: func(mockUC *common.MockUserUseCase) {
+				mockUC.On("UnlockAccount", mock.Anything, "expired-token").Return(usecase.ErrUnlockTokenExpired)
+			},
+			expectedStatus: http.StatusGone,
+			expectedError:  usecase.ErrUnlockTokenExpired.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// モックの設定
+			mockUC := new(common.MockUserUseCase)
+			tt.mockSetup(mockUC)
+
+			// ハンドラーの作成
+			handler := NewAuthHandler(mockUC)
+
+			// リクエストボディの準備
+			reqBody, _ := json.Marshal(tt.requestBody)
+
+			// テスト用のリクエストとレスポンスを作成
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/unlock", bytes.NewReader(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			// Echoコンテキストの作成
+			e := echo.New()
+			e.Validator = validator.New()
+			e.HTTPErrorHandler = common.HTTPErrorHandler
+			c := e.NewContext(req, rec)
+
+			// ハンドラーの実行
+			err := handler.UnlockAccount(c)
+
+			// エラーはEchoのエラーハンドラーでレスポンスに変換される
+			if err != nil {
+				e.HTTPErrorHandler(err, c)
+			}
+
+			// アサーション
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedError != "" {
+				var response common.Problem
+				json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.Equal(t, tt.expectedError, response.Detail)
+			}
+
+			mockUC.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthHandler_VerifyMFA(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestBody    common.VerifyMFARequest
+		mockSetup      func(*common.MockUserUseCase)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name: "正常な2段階認証",
+			requestBody: common.VerifyMFARequest{
+				MFAToken: "mfa-token",
+				Code:     "123456",
+			},
+			mockSetup: func(mockUC *common.MockUserUseCase) {
+				tokens := &usecase.TokenPair{
+					AccessToken:  "jwt-token",
+					RefreshToken: "refresh-token",
+					ExpiresIn:    900,
+				}
+				mockUC.On("VerifyMFA", mock.Anything, "mfa-token", "123456", mock.Anything).Return(tokens, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "コードが未入力",
+			requestBody: common.VerifyMFARequest{
+				MFAToken: "mfa-token",
+			},
+			mockSetup:      func(mockUC *common.MockUserUseCase) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "誤ったコード",
+			requestBody: common.VerifyMFARequest{
+				MFAToken: "mfa-token",
+				Code:     "000000",
+			},
+			mockSetup: func(mockUC *common.MockUserUseCase) {
+				mockUC.On("VerifyMFA", mock.Anything, "mfa-token", "000000", mock.Anything).Return(nil, usecase.ErrInvalidMFACode)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  usecase.ErrInvalidMFACode.Error(),
+		},
+		{
+			name: "無効なチャレンジトークン",
+			requestBody: common.VerifyMFARequest{
+				MFAToken: "expired-token",
+				Code:     "123456",
+			},
+			mockSetup: func(mockUC *common.MockUserUseCase) {
+				mockUC.On("VerifyMFA", mock.Anything, "expired-token", "123456", mock.Anything).Return(nil, usecase.ErrInvalidMFAToken)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  usecase.ErrInvalidMFAToken.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// モックの設定
+			mockUC := new(common.MockUserUseCase)
+			tt.mockSetup(mockUC)
+
+			// ハンドラーの作成
+			handler := NewAuthHandler(mockUC)
+
+			// リクエストボディの準備
+			reqBody, _ := json.Marshal(tt.requestBody)
+
+			// テスト用のリクエストとレスポンスを作成
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login/mfa", bytes.NewReader(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			// Echoコンテキストの作成
+			e := echo.New()
+			e.Validator = validator.New()
+			e.HTTPErrorHandler = common.HTTPErrorHandler
+			c := e.NewContext(req, rec)
+
+			// ハンドラーの実行
+			err := handler.VerifyMFA(c)
+
+			// エラーはEchoのエラーハンドラーでレスポンスに変換される
+			if err != nil {
+				e.HTTPErrorHandler(err, c)
+			}
+
+			// アサーション
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedError != "" {
+				var response common.Problem
+				json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.Equal(t, tt.expectedError, response.Detail)
+			}
+			if tt.expectedStatus == http.StatusOK {
+				var response common.LoginResponse
+				json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.Equal(t, "jwt-token", response.Token)
+				assert.Equal(t, "refresh-token", response.RefreshToken)
+			}
+
+			mockUC.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthHandler_BeginOIDCLogin(t *testing.T) {
+	tests := []struct {
+		name           string
+		provider       string
+		mockSetup      func(*common.MockUserUseCase)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:     "認可画面のURLの発行",
+			provider: "google",
+			mockSetup: func(mockUC *common.MockUserUseCase) {
+				authorization := &usecase.OIDCAuthorization{
+					URL:       "https://accounts.google.com/o/oauth2/v2/auth?state=state",
+					State:     "state",
+					ExpiresIn: 600,
+				}
+				mockUC.On("BeginOIDCLogin", mock.Anything, "google").Return(authorization, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:     "設定されていないプロバイダー",
+			provider: "github",
+			mockSetup: func(mockUC *common.MockUserUseCase) {
+				mockUC.On("BeginOIDCLogin", mock.Anything, "github").Return(nil, usecase.ErrUnknownOIDCProvider)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedError:  usecase.ErrUnknownOIDCProvider.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUC := new(common.MockUserUseCase)
+			tt.mockSetup(mockUC)
+			handler := NewAuthHandler(mockUC)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/oidc/"+tt.provider, nil)
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+			e.HTTPErrorHandler = common.HTTPErrorHandler
+			c := e.NewContext(req, rec)
+			c.SetParamNames("provider")
+			c.SetParamValues(tt.provider)
+
+			if err := handler.BeginOIDCLogin(c); err != nil {
+				e.HTTPErrorHandler(err, c)
+			}
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedError != "" {
+				var response common.Problem
+				json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.Equal(t, tt.expectedError, response.Detail)
+			}
+			if tt.expectedStatus == http.StatusOK {
+				var response common.OIDCAuthorizationResponse
+				json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.Equal(t, "https://accounts.google.com/o/oauth2/v2/auth?state=state", response.AuthorizationURL)
+				assert.Equal(t, "state", response.State)
+				assert.Equal(t, int64(600), response.ExpiresIn)
+			}
+
+			mockUC.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthHandler_CompleteOIDCLogin(t *testing.T) {
+	tokens := &usecase.TokenPair{
+		AccessToken:  "jwt-token",
+		RefreshToken: "refresh-token",
+		ExpiresIn:    900,
+	}
+
+	tests := []struct {
+		name           string
+		contentType    string
+		body           string
+		mockSetup      func(*common.MockUserUseCase)
+		expectedStatus int
+		expectedError  string
+		expectMFA      bool
+	}{
+		{
+			name:        "JSONでのコールバック",
+			contentType: echo.MIMEApplicationJSON,
+			body:        `{"code":"auth-code","state":"state"}`,
+			mockSetup: func(mockUC *common.MockUserUseCase) {
+				mockUC.On("CompleteOIDCLogin", mock.Anything, "google", "auth-code", "state", model.Language(""), mock.Anything).Return(&usecase.LoginResult{Tokens: tokens}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "フォームでのコールバック（form_post）",
+			contentType: echo.MIMEApplicationForm,
+			body:        "code=auth-code&state=state",
+			mockSetup: func(mockUC *common.MockUserUseCase) {
+				mockUC.On("CompleteOIDCLogin", mock.Anything, "google", "auth-code", "state", model.Language(""), mock.Anything).Return(&usecase.LoginResult{Tokens: tokens}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "2段階認証を有効にしているユーザー",
+			contentType: echo.MIMEApplicationJSON,
+			body:        `{"code":"auth-code","state":"state"}`,
+			mockSetup: func(mockUC *common.MockUserUseCase) {
+				result := &usecase.LoginResult{MFAChallenge: &usecase.MFAChallenge{Token: "mfa-token", ExpiresIn: 300}}
+				mockUC.On("CompleteOIDCLogin", mock.Anything, "google", "auth-code", "state", model.Language(""), mock.Anything).Return(result, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectMFA:      true,
+		},
+		{
+			name:           "stateが未入力",
+			contentType:    echo.MIMEApplicationJSON,
+			body:           `{"code":"auth-code"}`,
+			mockSetup:      func(mockUC *common.MockUserUseCase) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "無効なstate",
+			contentType: echo.MIMEApplicationJSON,
+			body:        `{"code":"auth-code","state":"unknown"}`,
+			mockSetup: func(mockUC *common.MockUserUseCase) {
+				mockUC.On("CompleteOIDCLogin", mock.Anything, "google", "auth-code", "unknown", model.Language(""), mock.Anything).Return(nil, usecase.ErrInvalidOIDCState)
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedError:  usecase.ErrInvalidOIDCState.Error(),
+		},
+		{
+			name:        "同じメールアドレスのユーザーが存在",
+			contentType: echo.MIMEApplicationJSON,
+			body:        `{"code":"auth-code","state":"state"}`,
+			mockSetup: func(mockUC *common.MockUserUseCase) {
+				mockUC.On("CompleteOIDCLogin", mock.Anything, "google", "auth-code", "state", model.Language(""), mock.Anything).Return(nil, usecase.ErrOIDCEmailAlreadyExists)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedError:  usecase.ErrOIDCEmailAlreadyExists.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUC := new(common.MockUserUseCase)
+			tt.mockSetup(mockUC)
+			handler := NewAuthHandler(mockUC)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/oidc/google/callback", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+			e.Validator = validator.New()
+			e.HTTPErrorHandler = common.HTTPErrorHandler
+			c := e.NewContext(req, rec)
+			c.SetParamNames("provider")
+			c.SetParamValues("google")
+
+			if err := handler.CompleteOIDCLogin(c); err != nil {
+				e.HTTPErrorHandler(err, c)
+			}
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedError != "" {
+				var response common.Problem
+				json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.Equal(t, tt.expectedError, response.Detail)
+			}
+			if tt.expectedStatus == http.StatusOK && tt.expectMFA {
+				var response common.MFAChallengeResponse
+				json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.True(t, response.MFARequired)
+				assert.Equal(t, "mfa-token", response.MFAToken)
+			} else if tt.expectedStatus == http.StatusOK {
+				var response common.LoginResponse
+				json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.Equal(t, "jwt-token", response.Token)
+				assert.Equal(t, "refresh-token", response.RefreshToken)
+			}
+
+			mockUC.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"voice-link/interface/validator"
+	"voice-link/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HTTPErrorHandler は、ハンドラーから返されたエラーをHTTPレスポンスに変換するEchoのエラーハンドラーです
+// ユースケースのエラーは種別に応じたステータスコードに変換し、想定外のエラーは内容をログに記録して
+// クライアントには汎用的なメッセージのみを返します
+// データベースの処理がタイムアウトした場合は503 Service Unavailable、クライアントの切断で中断した場合は499を記録します
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	var (
+		validationErrors validator.ValidationErrors
+		useCaseError     *usecase.Error
+		handlerError     *HTTPError
+		echoError        *echo.HTTPError
+	)
+
+	var responseErr error
+	switch {
+	case errors.Is(err, context.Canceled):
+		// クライアントが切断したため、応答は届かない。アクセスログのためにステータスのみ記録する
+		slog.InfoContext(c.Request().Context(), "request canceled by client", "method", c.Request().Method, "path", c.Request().URL.Path)
+		responseErr = c.NoContent(StatusClientClosedRequest)
+	case errors.Is(err, context.DeadlineExceeded):
+		slog.WarnContext(c.Request().Context(), "request timed out", "method", c.Request().Method, "path", c.Request().URL.Path, "error", err)
+		responseErr = SendProblem(c, http.StatusServiceUnavailable, CodeRequestTimeout, "Request timed out")
+	case errors.As(err, &validationErrors):
+		responseErr = SendValidationError(c, validationErrors)
+	case errors.As(err, &useCaseError):
+		var retryable *usecase.RetryAfterError
+		if errors.As(err, &retryable) {
+			SetRetryAfter(c, retryable.RetryAfter())
+		}
+		responseErr = SendProblem(c, statusCodeOf(useCaseError), useCaseError.Code(), useCaseError.Error())
+	case errors.As(err, &handlerError):
+		responseErr = SendProblem(c, handlerError.Status, handlerError.Code, handlerError.Detail)
+	case errors.As(err, &echoError):
+		// ルーティングなど、Echo自身が返すエラー
+		if echoError.Internal != nil || echoError.Code >= http.StatusInternalServerError {
+			slog.ErrorContext(c.Request().Context(), "http error", "method", c.Request().Method, "path", c.Request().URL.Path, "error", err)
+		}
+		if echoError.Code >= http.StatusInternalServerError {
+			responseErr = SendInternalServerError(c)
+		} else {
+			responseErr = SendProblem(c, echoError.Code, codeOfStatus(echoError.Code), httpErrorMessage(echoError))
+		}
+	default:
+		slog.ErrorContext(c.Request().Context(), "internal server error", "method", c.Request().Method, "path", c.Request().URL.Path, "error", err)
+		responseErr = SendInternalServerError(c)
+	}
+
+	if responseErr != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to send error response", "error", responseErr)
+	}
+}
+
+// statusCodeOf は、ユースケースのエラーの種別に対応するHTTPステータスコードを返します
+func statusCodeOf(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, usecase.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, usecase.ErrInvalidCredentials):
+		return http.StatusUnauthorized
+	case errors.Is(err, usecase.ErrExpired):
+		return http.StatusGone
+	case errors.Is(err, usecase.ErrValidation):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, usecase.ErrTooManyRequests):
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
+
+// SetRetryAfter は、再試行できるようになるまでの時間をRetry-Afterヘッダーに秒単位で設定します
+// 1秒未満は切り上げます
+func SetRetryAfter(c echo.Context, retryAfter time.Duration) {
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	c.Response().Header().Set(echo.HeaderRetryAfter, strconv.FormatInt(max(seconds, 1), 10))
+}
+
+// codeOfStatus は、個別のエラーコードを持たないエラーのコードをステータスコードから生成します
+// 例えば404 Not Foundはnot_foundになります
+func codeOfStatus(status int) string {
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+}
+
+// httpErrorMessage は、echo.HTTPErrorからクライアントに返すメッセージを取り出します
+func httpErrorMessage(err *echo.HTTPError) string {
+	if message, ok := err.Message.(string); ok {
+		return message
+	}
+	if err.Message != nil {
+		return fmt.Sprint(err.Message)
+	}
+	return http.StatusText(err.Code)
+}
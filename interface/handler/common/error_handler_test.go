@@ -0,0 +1,193 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"voice-link/interface/validator"
+	"voice-link/usecase"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPErrorHandler(t *testing.T) {
+	tests := []struct {
+		name            string
+		err             error
+		expectedStatus  int
+		expectedCode    string
+		expectedMessage string
+	}{
+		{
+			name:            "存在しないリソース",
+			err:             usecase.ErrUserNotFound,
+			expectedStatus:  http.StatusNotFound,
+			expectedCode:    "user_not_found",
+			expectedMessage: "user not found",
+		},
+		{
+			name:            "競合",
+			err:             usecase.ErrEmailAlreadyExists,
+			expectedStatus:  http.StatusConflict,
+			expectedCode:    "email_already_exists",
+			expectedMessage: "email already exists",
+		},
+		{
+			name:            "認証情報の誤り",
+			err:             usecase.ErrInvalidEmailOrPassword,
+			expectedStatus:  http.StatusUnauthorized,
+			expectedCode:    "invalid_credentials",
+			expectedMessage: "invalid email or password",
+		},
+		{
+			name:            "期限切れのトークン",
+			err:             usecase.ErrResetTokenExpired,
+			expectedStatus:  http.StatusGone,
+			expectedCode:    "reset_token_expired",
+			expectedMessage: "reset token has expired",
+		},
+		{
+			name:            "ビジネスルール違反",
+			err:             usecase.ErrInvalidVerificationToken,
+			expectedStatus:  http.StatusUnprocessableEntity,
+			expectedCode:    "invalid_verification_token",
+			expectedMessage: "invalid or expired verification token",
+		},
+		{
+			name:            "試行回数の超過",
+			err:             usecase.ErrTooManyLoginAttempts,
+			expectedStatus:  http.StatusTooManyRequests,
+			expectedCode:    "too_many_login_attempts",
+			expectedMessage: "too many login attempts, please try again later",
+		},
+		{
+			name:            "ラップされたユースケースのエラー",
+			err:             fmt.Errorf("update user: %w", usecase.ErrUserNotFound),
+			expectedStatus:  http.StatusNotFound,
+			expectedCode:    "user_not_found",
+			expectedMessage: "user not found",
+		},
+		{
+			name:            "ハンドラーのエラー",
+			err:             ErrInvalidRequestBody,
+			expectedStatus:  http.StatusBadRequest,
+			expectedCode:    "invalid_request_body",
+			expectedMessage: "Invalid request body",
+		},
+		{
+			name:            "Echoのエラー",
+			err:             echo.NewHTTPError(http.StatusMethodNotAllowed).SetInternal(errors.New("no matching route")),
+			expectedStatus:  http.StatusMethodNotAllowed,
+			expectedCode:    "method_not_allowed",
+			expectedMessage: "Method Not Allowed",
+		},
+		{
+			name:            "存在しないルート",
+			err:             echo.ErrNotFound,
+			expectedStatus:  http.StatusNotFound,
+			expectedCode:    "not_found",
+			expectedMessage: "Not Found",
+		},
+		{
+			name:            "データベースの処理のタイムアウト",
+			err:             fmt.Errorf("find user: %w", context.DeadlineExceeded),
+			expectedStatus:  http.StatusServiceUnavailable,
+			expectedCode:    "request_timeout",
+			expectedMessage: "Request timed out",
+		},
+		{
+			name:            "想定外のエラーは内容を返さない",
+			err:             errors.New("pq: connection refused"),
+			expectedStatus:  http.StatusInternalServerError,
+			expectedCode:    "internal_error",
+			expectedMessage: "Internal server error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/users/1", nil)
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+			c.Response().Header().Set(echo.HeaderXRequestID, "request-1")
+
+			HTTPErrorHandler(tt.err, c)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			assert.Equal(t, ProblemContentType, rec.Header().Get(echo.HeaderContentType))
+
+			var response Problem
+			json.Unmarshal(rec.Body.Bytes(), &response)
+			assert.Equal(t, "about:blank", response.Type)
+			assert.Equal(t, http.StatusText(tt.expectedStatus), response.Title)
+			assert.Equal(t, tt.expectedStatus, response.Status)
+			assert.Equal(t, tt.expectedCode, response.Code)
+			assert.Equal(t, tt.expectedMessage, response.Detail)
+			assert.Equal(t, "/api/v1/users/1", response.Instance)
+			assert.Equal(t, "request-1", response.RequestID)
+		})
+	}
+}
+
+func TestHTTPErrorHandler_ValidationErrors(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+
+	HTTPErrorHandler(validator.ValidationErrors{
+		{Field: "email", Rule: "email", Message: "email must be a valid email address"},
+	}, c)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	var response Problem
+	json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.Equal(t, "validation_failed", response.Code)
+	assert.Len(t, response.Errors, 1)
+	assert.Equal(t, "email", response.Errors[0].Field)
+}
+
+func TestHTTPErrorHandler_RetryAfter(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter time.Duration
+		expected   string
+	}{
+		{"秒未満は切り上げる", 1500 * time.Millisecond, "2"},
+		{"最小は1秒", 0, "1"},
+		{"分単位", 30 * time.Minute, "1800"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", nil)
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+
+			HTTPErrorHandler(usecase.WithRetryAfter(usecase.ErrAccountLocked, tt.retryAfter), c)
+
+			assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+			assert.Equal(t, tt.expected, rec.Header().Get(echo.HeaderRetryAfter))
+			var response Problem
+			json.Unmarshal(rec.Body.Bytes(), &response)
+			assert.Equal(t, "account_locked", response.Code)
+		})
+	}
+}
+
+func TestHTTPErrorHandler_ClientClosedRequest(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/1", nil)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+
+	HTTPErrorHandler(fmt.Errorf("find user: %w", context.Canceled), c)
+
+	// クライアントには届かないため本文は送らず、ステータスのみ記録する
+	assert.Equal(t, StatusClientClosedRequest, rec.Code)
+	assert.Empty(t, rec.Body.String())
+}
@@ -0,0 +1,52 @@
+package common
+
+import "net/http"
+
+// ハンドラーやミドルウェアで使用するエラーコードです
+// ユースケースのエラーコードはusecase.Error.Codeで定義されています
+const (
+	CodeInvalidRequestBody         = "invalid_request_body"
+	CodeInvalidUserID              = "invalid_user_id"
+	CodeInvalidQueryParameter      = "invalid_query_parameter"
+	CodeNotAuthenticated           = "not_authenticated"
+	CodeAuthorizationRequired      = "authorization_required"
+	CodeInvalidAuthorizationHeader = "invalid_authorization_header"
+	CodeInvalidToken               = "invalid_token"
+	CodeTokenRevoked               = "token_revoked"
+	CodeInsufficientPermissions    = "insufficient_permissions"
+	CodeValidationFailed           = "validation_failed"
+	CodeInternalError              = "internal_error"
+	CodeRequestTimeout             = "request_timeout"
+	CodeTooManyRequests            = "too_many_requests"
+)
+
+// StatusClientClosedRequest は、処理の完了前にクライアントが接続を閉じたことを表すステータスコードです
+// 標準のステータスコードではありませんが、nginxなどと同じく499をアクセスログとメトリクスに記録します
+const StatusClientClosedRequest = 499
+
+// HTTPError は、ハンドラーで発生したエラーをHTTPステータスコードとエラーコードとともに表します
+// HTTPErrorHandlerによってapplication/problem+json形式のレスポンスに変換されます
+type HTTPError struct {
+	Status int    // HTTPステータスコード
+	Code   string // 機械可読なエラーコード
+	Detail string // エラーの説明
+}
+
+// NewHTTPError は、HTTPErrorの新しいインスタンスを作成します
+func NewHTTPError(status int, code, detail string) *HTTPError {
+	return &HTTPError{Status: status, Code: code, Detail: detail}
+}
+
+// Error は、errorインターフェースを実装します
+func (e *HTTPError) Error() string {
+	return e.Detail
+}
+
+// ハンドラーで共通して使用するエラーです
+var (
+	ErrInvalidRequestBody    = NewHTTPError(http.StatusBadRequest, CodeInvalidRequestBody, "Invalid request body")
+	ErrInvalidUserID         = NewHTTPError(http.StatusBadRequest, CodeInvalidUserID, "Invalid user ID")
+	ErrInvalidQueryParameter = NewHTTPError(http.StatusBadRequest, CodeInvalidQueryParameter, "Invalid query parameter")
+	ErrNotAuthenticated      = NewHTTPError(http.StatusUnauthorized, CodeNotAuthenticated, "User not authenticated")
+	ErrTooManyRequests       = NewHTTPError(http.StatusTooManyRequests, CodeTooManyRequests, "Too many requests")
+)
@@ -2,7 +2,10 @@
 package common
 
 import (
+	"context"
+	"time"
 	"voice-link/domain/model"
+	"voice-link/usecase"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -12,46 +15,192 @@ type MockUserUseCase struct {
 	mock.Mock
 }
 
-func (m *MockUserUseCase) Register(name, email, password string) (*model.User, error) {
-	args := m.Called(name, email, password)
+func (m *MockUserUseCase) Register(ctx context.Context, name, email, password string, language model.Language) (*model.User, error) {
+	args := m.Called(ctx, name, email, password, language)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*model.User), args.Error(1)
 }
 
-func (m *MockUserUseCase) Login(email, password string) (string, error) {
-	args := m.Called(email, password)
-	return args.String(0), args.Error(1)
+func (m *MockUserUseCase) Login(ctx context.Context, email, password string, client usecase.ClientInfo) (*usecase.LoginResult, error) {
+	args := m.Called(ctx, email, password, client)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*usecase.LoginResult), args.Error(1)
+}
+
+func (m *MockUserUseCase) VerifyMFA(ctx context.Context, mfaToken, code string, client usecase.ClientInfo) (*usecase.TokenPair, error) {
+	args := m.Called(ctx, mfaToken, code, client)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*usecase.TokenPair), args.Error(1)
 }
 
-func (m *MockUserUseCase) GetByID(id uint) (*model.User, error) {
-	args := m.Called(id)
+func (m *MockUserUseCase) RefreshToken(ctx context.Context, refreshToken string, client usecase.ClientInfo) (*usecase.TokenPair, error) {
+	args := m.Called(ctx, refreshToken, client)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*usecase.TokenPair), args.Error(1)
+}
+
+func (m *MockUserUseCase) Logout(ctx context.Context, userID uint, jti, sessionID string, expiresAt time.Time) error {
+	args := m.Called(ctx, userID, jti, sessionID, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockUserUseCase) LogoutAll(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockUserUseCase) GetByID(ctx context.Context, id uint) (*model.User, error) {
+	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*model.User), args.Error(1)
 }
 
-func (m *MockUserUseCase) UpdateUser(id uint, name, email string) (*model.User, error) {
-	args := m.Called(id, name, email)
+func (m *MockUserUseCase) ListUsers(ctx context.Context, params usecase.ListUsersParams) (*usecase.UserPage, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*usecase.UserPage), args.Error(1)
+}
+
+func (m *MockUserUseCase) PurgeDeletedUsers(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockUserUseCase) PurgeExpiredTokens(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockUserUseCase) UpdateUser(ctx context.Context, id uint, name, email string, language model.Language) (*model.User, error) {
+	args := m.Called(ctx, id, name, email, language)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*model.User), args.Error(1)
 }
 
-func (m *MockUserUseCase) DeleteUser(id uint) error {
-	args := m.Called(id)
+func (m *MockUserUseCase) DeleteUser(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockUserUseCase) RequestPasswordReset(ctx context.Context, email string) error {
+	args := m.Called(ctx, email)
+	return args.Error(0)
+}
+
+func (m *MockUserUseCase) ResetPassword(ctx context.Context, token, newPassword string) error {
+	args := m.Called(ctx, token, newPassword)
 	return args.Error(0)
 }
 
-func (m *MockUserUseCase) RequestPasswordReset(email string) error {
-	args := m.Called(email)
+func (m *MockUserUseCase) VerifyEmail(ctx context.Context, token string) error {
+	args := m.Called(ctx, token)
 	return args.Error(0)
 }
 
-func (m *MockUserUseCase) ResetPassword(token, newPassword string) error {
-	args := m.Called(token, newPassword)
+func (m *MockUserUseCase) ResendVerificationEmail(ctx context.Context, email string) error {
+	args := m.Called(ctx, email)
+	return args.Error(0)
+}
+
+func (m *MockUserUseCase) UnlockAccount(ctx context.Context, token string) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockUserUseCase) BeginMFAEnrollment(ctx context.Context, userID uint) (*usecase.MFAEnrollment, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*usecase.MFAEnrollment), args.Error(1)
+}
+
+func (m *MockUserUseCase) ConfirmMFAEnrollment(ctx context.Context, userID uint, code string) ([]string, error) {
+	args := m.Called(ctx, userID, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockUserUseCase) DisableMFA(ctx context.Context, userID uint, code string) error {
+	args := m.Called(ctx, userID, code)
+	return args.Error(0)
+}
+
+func (m *MockUserUseCase) ResetMFA(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockUserUseCase) BeginOIDCLogin(ctx context.Context, provider string) (*usecase.OIDCAuthorization, error) {
+	args := m.Called(ctx, provider)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*usecase.OIDCAuthorization), args.Error(1)
+}
+
+func (m *MockUserUseCase) CompleteOIDCLogin(ctx context.Context, provider, code, state string, language model.Language, client usecase.ClientInfo) (*usecase.LoginResult, error) {
+	args := m.Called(ctx, provider, code, state, language, client)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*usecase.LoginResult), args.Error(1)
+}
+
+func (m *MockUserUseCase) BeginOIDCLink(ctx context.Context, userID uint, provider string) (*usecase.OIDCAuthorization, error) {
+	args := m.Called(ctx, userID, provider)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*usecase.OIDCAuthorization), args.Error(1)
+}
+
+func (m *MockUserUseCase) CompleteOIDCLink(ctx context.Context, userID uint, provider, code, state string) (*model.Identity, error) {
+	args := m.Called(ctx, userID, provider, code, state)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Identity), args.Error(1)
+}
+
+func (m *MockUserUseCase) ListIdentities(ctx context.Context, userID uint) ([]model.Identity, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Identity), args.Error(1)
+}
+
+func (m *MockUserUseCase) UnlinkIdentity(ctx context.Context, userID uint, provider string) error {
+	args := m.Called(ctx, userID, provider)
+	return args.Error(0)
+}
+
+func (m *MockUserUseCase) ListSessions(ctx context.Context, userID uint) ([]model.Session, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Session), args.Error(1)
+}
+
+func (m *MockUserUseCase) RevokeSession(ctx context.Context, userID uint, sessionID string) error {
+	args := m.Called(ctx, userID, sessionID)
 	return args.Error(0)
 }
@@ -1,29 +1,105 @@
 // package common は、ハンドラー間で共有される共通の型を提供します
 package common
 
+import (
+	"time"
+	"voice-link/domain/model"
+	"voice-link/usecase"
+)
+
 // RegisterUserRequest は、ユーザー登録APIのリクエストボディの構造を定義します
 // バリデーションタグを使用して、各フィールドの制約を指定しています
 type RegisterUserRequest struct {
-	Name     string `json:"name" validate:"required"`           // 名前（必須）
-	Email    string `json:"email" validate:"required,email"`    // メールアドレス（必須、メール形式）
-	Password string `json:"password" validate:"required,min=6"` // パスワード（必須、最小6文字）
+	Name              string `json:"name" validate:"required"`                  // 名前（必須）
+	Email             string `json:"email" validate:"required,email"`           // メールアドレス（必須、メール形式）
+	Password          string `json:"password" validate:"required,min=6"`        // パスワード（必須、最小6文字）
+	PreferredLanguage string `json:"preferred_language" validate:"oneof=ja en"` // 言語（任意、省略時はAccept-Languageヘッダーから決定）
 }
 
 // LoginRequest は、ログインAPIのリクエストボディの構造を定義します
 type LoginRequest struct {
-	Email    string `json:"email" validate:"required,email"` // メールアドレス（必須、メール形式）
-	Password string `json:"password" validate:"required"`    // パスワード（必須）
+	Email      string `json:"email" validate:"required,email"` // メールアドレス（必須、メール形式）
+	Password   string `json:"password" validate:"required"`    // パスワード（必須）
+	DeviceName string `json:"device_name" validate:"max=100"`  // セッションの一覧に表示する端末の名前（任意、最大100文字）
 }
 
-// LoginResponse は、ログインAPIのレスポンスボディの構造を定義します
+// LoginResponse は、ログインAPIおよびトークン更新APIのレスポンスボディの構造を定義します
 type LoginResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`         // アクセストークン
+	RefreshToken string `json:"refresh_token"` // リフレッシュトークン
+	TokenType    string `json:"token_type"`    // トークン種別（常にBearer）
+	ExpiresIn    int64  `json:"expires_in"`    // アクセストークンの有効期間（秒）
+}
+
+// MFAChallengeResponse は、2段階認証を有効にしているユーザーのログインAPIのレスポンスボディの構造を定義します
+// mfa_tokenと認証アプリのコードを2段階認証APIに送信するとトークンが発行されます
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required"` // 2段階認証が必要かどうか（常にtrue）
+	MFAToken    string `json:"mfa_token"`    // 2段階認証APIに送信するチャレンジトークン
+	ExpiresIn   int64  `json:"expires_in"`   // チャレンジトークンの有効期間（秒）
+}
+
+// VerifyMFARequest は、ログイン時の2段階認証APIのリクエストボディの構造を定義します
+type VerifyMFARequest struct {
+	MFAToken   string `json:"mfa_token" validate:"required"`  // ログインAPIが返したチャレンジトークン（必須）
+	Code       string `json:"code" validate:"required"`       // 認証アプリの6桁のコード、またはリカバリーコード（必須）
+	DeviceName string `json:"device_name" validate:"max=100"` // セッションの一覧に表示する端末の名前（任意、最大100文字）
+}
+
+// MFACodeRequest は、2段階認証の登録の確認と解除APIのリクエストボディの構造を定義します
+type MFACodeRequest struct {
+	Code string `json:"code" validate:"required"` // 認証アプリのコード（必須、解除時はリカバリーコードも可）
+}
+
+// MFAEnrollmentResponse は、2段階認証の登録開始APIのレスポンスボディの構造を定義します
+type MFAEnrollmentResponse struct {
+	Secret     string `json:"secret"`      // Base32形式の秘密鍵（QRコードを読み取れない場合に手入力する）
+	OTPAuthURI string `json:"otpauth_uri"` // QRコードにして認証アプリで読み取るotpauth URI
+}
+
+// RecoveryCodesResponse は、2段階認証の登録の確認APIのレスポンスボディの構造を定義します
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"` // リカバリーコード（この応答でのみ取得できる）
+}
+
+// RefreshTokenRequest は、トークン更新APIのリクエストボディの構造を定義します
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"` // リフレッシュトークン（必須）
 }
 
 // UpdateUserRequest は、ユーザー情報更新APIのリクエストボディの構造を定義します
 type UpdateUserRequest struct {
-	Name  string `json:"name" validate:"required"`        // 名前（必須）
-	Email string `json:"email" validate:"required,email"` // メールアドレス（必須、メール形式）
+	Name              string `json:"name" validate:"required"`                  // 名前（必須）
+	Email             string `json:"email" validate:"required,email"`           // メールアドレス（必須、メール形式）
+	PreferredLanguage string `json:"preferred_language" validate:"oneof=ja en"` // 言語（任意、省略時は変更しない）
+}
+
+// ListUsersRequest は、ユーザー一覧APIのクエリパラメーターの構造を定義します
+// 日時はRFC 3339形式で指定します
+type ListUsersRequest struct {
+	EmailPrefix string     `query:"email_prefix"`                                               // メールアドレスの前方一致（任意）
+	NamePrefix  string     `query:"name_prefix"`                                                // 名前の前方一致（任意）
+	CreatedFrom *time.Time `query:"created_from"`                                               // 作成日時の下限（任意、この日時を含む）
+	CreatedTo   *time.Time `query:"created_to"`                                                 // 作成日時の上限（任意、この日時を含まない）
+	Role        string     `query:"role" json:"role" validate:"oneof=user admin"`               // ロール（任意）
+	Verified    *bool      `query:"verified"`                                                   // メールアドレスの確認状態（任意）
+	Sort        string     `query:"sort" json:"sort" validate:"oneof=id created_at email name"` // 並び替えの項目（任意、省略時はcreated_at）
+	Order       string     `query:"order" json:"order" validate:"oneof=asc desc"`               // 並び順（任意、省略時はdesc）
+	Limit       int        `query:"limit"`                                                      // 1ページの件数（任意、省略時は20、最大100）
+	Cursor      string     `query:"cursor"`                                                     // 前のページのnext_cursor（任意）
+}
+
+// ListUsersResponse は、ユーザー一覧APIのレスポンスボディの構造を定義します
+type ListUsersResponse struct {
+	Data []model.User `json:"data"` // ユーザーの一覧
+	Page PageInfo     `json:"page"` // ページの情報
+}
+
+// PageInfo は、カーソルベースのページネーションのページ情報を定義します
+type PageInfo struct {
+	Limit      int    `json:"limit"`                 // 1ページの件数
+	HasMore    bool   `json:"has_more"`              // 次のページが存在するかどうか
+	NextCursor string `json:"next_cursor,omitempty"` // 次のページを取得するためのカーソル
 }
 
 // PasswordResetRequest は、パスワードリセットリクエストAPIのリクエストボディの構造を定義します
@@ -36,3 +112,72 @@ type PasswordResetConfirmRequest struct {
 	Token       string `json:"token" validate:"required"`              // リセットトークン（必須）
 	NewPassword string `json:"new_password" validate:"required,min=6"` // 新しいパスワード（必須、最小6文字）
 }
+
+// VerifyEmailRequest は、メールアドレス確認APIのリクエストボディの構造を定義します
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"` // メールアドレス確認トークン（必須）
+}
+
+// UnlockAccountRequest は、アカウントのロック解除APIのリクエストボディの構造を定義します
+type UnlockAccountRequest struct {
+	Token string `json:"token" validate:"required"` // ロック解除トークン（必須）
+}
+
+// ResendVerificationEmailRequest は、確認メール再送信APIのリクエストボディの構造を定義します
+type ResendVerificationEmailRequest struct {
+	Email string `json:"email" validate:"required,email"` // メールアドレス（必須、メール形式）
+}
+
+// OIDCAuthorizationResponse は、連携したアカウントでのログインと連携の開始APIのレスポンスボディの構造を定義します
+// クライアントはstateを保存して認可画面へ移動し、リダイレクト先で受け取ったstateと一致することを確認します
+type OIDCAuthorizationResponse struct {
+	AuthorizationURL string `json:"authorization_url"` // プロバイダーの認可画面のURL
+	State            string `json:"state"`             // コールバックで送信するstate
+	ExpiresIn        int64  `json:"expires_in"`        // コールバックを受け付ける期間（秒）
+}
+
+// NewOIDCAuthorizationResponse は、認可の開始の結果からレスポンスボディを作成します
+func NewOIDCAuthorizationResponse(authorization *usecase.OIDCAuthorization) OIDCAuthorizationResponse {
+	return OIDCAuthorizationResponse{
+		AuthorizationURL: authorization.URL,
+		State:            authorization.State,
+		ExpiresIn:        authorization.ExpiresIn,
+	}
+}
+
+// OIDCCallbackRequest は、プロバイダーからリダイレクトされた後のコールバックAPIのリクエストボディの構造を定義します
+// response_mode=form_postのプロバイダーから直接送信できるよう、フォーム形式も受け付けます
+type OIDCCallbackRequest struct {
+	Code       string `json:"code" form:"code" validate:"required"`              // 認可コード（必須）
+	State      string `json:"state" form:"state" validate:"required"`            // 開始APIが返したstate（必須）
+	DeviceName string `json:"device_name" form:"device_name" validate:"max=100"` // セッションの一覧に表示する端末の名前（任意、最大100文字、ログイン時のみ使用）
+}
+
+// IdentitiesResponse は、連携しているアカウントの一覧APIのレスポンスボディの構造を定義します
+type IdentitiesResponse struct {
+	Identities []model.Identity `json:"identities"` // 連携した順のアカウント
+}
+
+// SessionResponse は、ログインしている端末のセッションのレスポンスボディの構造を定義します
+type SessionResponse struct {
+	model.Session
+	Current bool `json:"current"` // リクエストに使用したアクセストークンのセッションかどうか
+}
+
+// SessionsResponse は、セッションの一覧APIのレスポンスボディの構造を定義します
+type SessionsResponse struct {
+	Sessions []SessionResponse `json:"sessions"` // 最近使用した順のセッション
+}
+
+// NewSessionsResponse は、セッションの一覧からレスポンスボディを作成します
+// currentSessionIDと一致するセッションを、リクエストした端末のセッションとして示します
+func NewSessionsResponse(sessions []model.Session, currentSessionID string) SessionsResponse {
+	response := SessionsResponse{Sessions: make([]SessionResponse, len(sessions))}
+	for i, session := range sessions {
+		response.Sessions[i] = SessionResponse{
+			Session: session,
+			Current: currentSessionID != "" && session.ID == currentSessionID,
+		}
+	}
+	return response
+}
@@ -3,46 +3,127 @@ package common
 
 import (
 	"net/http"
+	"voice-link/domain/model"
+	"voice-link/interface/i18n"
+	"voice-link/interface/validator"
 
 	"github.com/labstack/echo/v4"
 )
 
-// ErrorResponse は、エラーレスポンスの構造を定義します
-type ErrorResponse struct {
-	Error string `json:"error"`
+// ProblemContentType は、エラーレスポンスのContent-Typeです
+const ProblemContentType = "application/problem+json"
+
+// problemTypeBlank は、問題の種類を個別のURIで定義しない場合のtypeです
+// この場合、titleにはHTTPステータスの説明を設定します（RFC 7807 4.2）
+const problemTypeBlank = "about:blank"
+
+// Problem は、RFC 7807（Problem Details for HTTP APIs）形式のエラーレスポンスの構造を定義します
+// クライアントはdetailの文言ではなく、codeでエラーを判定します
+type Problem struct {
+	Type      string                 `json:"type"`                 // 問題の種類
+	Title     string                 `json:"title"`                // HTTPステータスの説明
+	Status    int                    `json:"status"`               // HTTPステータスコード
+	Detail    string                 `json:"detail,omitempty"`     // 人が読むためのエラーの説明
+	Instance  string                 `json:"instance,omitempty"`   // エラーが発生したリクエストのパス
+	Code      string                 `json:"code"`                 // 機械可読なエラーコード
+	RequestID string                 `json:"request_id,omitempty"` // 問い合わせ時に使用するリクエストID
+	Errors    []validator.FieldError `json:"errors,omitempty"`     // フィールドごとの入力検証エラー
 }
 
+// 成功時のメッセージコードです
+const (
+	CodeLoggedOut              = "logged_out"
+	CodeLoggedOutAll           = "logged_out_all"
+	CodePasswordResetRequested = "password_reset_requested"
+	CodePasswordReset          = "password_reset"
+	CodeEmailVerified          = "email_verified"
+	CodeVerificationEmailSent  = "verification_email_sent"
+	CodeAccountUnlocked        = "account_unlocked"
+)
+
 // MessageResponse は、メッセージレスポンスの構造を定義します
 type MessageResponse struct {
-	Message string `json:"message"`
+	Message string `json:"message"` // 利用者の言語に翻訳されたメッセージ
+	Code    string `json:"code"`    // 機械可読なメッセージコード
 }
 
-// SendErrorResponse は、エラーレスポンスを送信するヘルパー関数です
-func SendErrorResponse(c echo.Context, statusCode int, message string) error {
-	return c.JSON(statusCode, ErrorResponse{Error: message})
+// SendMessageResponse は、メッセージレスポンスを送信するヘルパー関数です
+// messageはcodeに対応する翻訳がない場合に使用されます
+func SendMessageResponse(c echo.Context, statusCode int, code, message string) error {
+	lang := setContentLanguage(c)
+	return c.JSON(statusCode, MessageResponse{Message: localize(lang, code, message, nil), Code: code})
 }
 
-// SendMessageResponse は、メッセージレスポンスを送信するヘルパー関数です
-func SendMessageResponse(c echo.Context, statusCode int, message string) error {
-	return c.JSON(statusCode, MessageResponse{Message: message})
+// SendProblem は、application/problem+json形式のエラーレスポンスを送信するヘルパー関数です
+// detailはcodeに対応する翻訳がない場合に使用されます
+func SendProblem(c echo.Context, statusCode int, code, detail string) error {
+	return sendProblem(c, newProblem(c, statusCode, code, detail))
+}
+
+// SendValidationError は、入力検証エラーを422 Unprocessable Entityとして送信します
+// フィールドごとのエラーはerrorsに含まれます
+func SendValidationError(c echo.Context, validationErrors validator.ValidationErrors) error {
+	problem := newProblem(c, http.StatusUnprocessableEntity, CodeValidationFailed, "Validation failed")
+
+	lang := i18n.LanguageFromContext(c)
+	problem.Errors = make([]validator.FieldError, len(validationErrors))
+	for i, fe := range validationErrors {
+		fe.Message = localize(lang, "validation."+fe.Rule, fe.Message, map[string]string{
+			"field": fe.Field,
+			"param": fe.Param,
+		})
+		problem.Errors[i] = fe
+	}
+	return sendProblem(c, problem)
+}
+
+// SendInternalServerError は、詳細を含まない500 Internal Server Errorを送信します
+func SendInternalServerError(c echo.Context) error {
+	return SendProblem(c, http.StatusInternalServerError, CodeInternalError, "Internal server error")
+}
+
+// newProblem は、リクエストの情報を設定したProblemを作成します
+// detailは利用者の言語に翻訳されます
+func newProblem(c echo.Context, statusCode int, code, detail string) *Problem {
+	lang := setContentLanguage(c)
+	return &Problem{
+		Type:      problemTypeBlank,
+		Title:     http.StatusText(statusCode),
+		Status:    statusCode,
+		Detail:    localize(lang, code, detail, nil),
+		Instance:  c.Request().URL.Path,
+		Code:      code,
+		RequestID: requestID(c),
+	}
 }
 
-// SendBadRequestError は、400 Bad Requestエラーを送信します
-func SendBadRequestError(c echo.Context, message string) error {
-	return SendErrorResponse(c, http.StatusBadRequest, message)
+// sendProblem は、ProblemをContent-Typeを指定して送信します
+func sendProblem(c echo.Context, problem *Problem) error {
+	c.Response().Header().Set(echo.HeaderContentType, ProblemContentType)
+	return c.JSON(problem.Status, problem)
 }
 
-// SendInternalServerError は、500 Internal Server Errorを送信します
-func SendInternalServerError(c echo.Context, message string) error {
-	return SendErrorResponse(c, http.StatusInternalServerError, message)
+// setContentLanguage は、応答に使用する言語を決定してContent-Languageヘッダーに設定します
+func setContentLanguage(c echo.Context) model.Language {
+	lang := i18n.LanguageFromContext(c)
+	c.Response().Header().Set(i18n.HeaderContentLanguage, string(lang))
+	return lang
 }
 
-// SendUnauthorizedError は、401 Unauthorizedエラーを送信します
-func SendUnauthorizedError(c echo.Context, message string) error {
-	return SendErrorResponse(c, http.StatusUnauthorized, message)
+// localize は、codeに対応するメッセージを翻訳します
+// カタログに定義されていない場合はfallbackをそのまま返します
+func localize(lang model.Language, code, fallback string, params map[string]string) string {
+	if message, ok := i18n.Message(lang, code, params); ok {
+		return message
+	}
+	return fallback
 }
 
-// SendNotFoundError は、404 Not Foundエラーを送信します
-func SendNotFoundError(c echo.Context, message string) error {
-	return SendErrorResponse(c, http.StatusNotFound, message)
+// requestID は、RequestIDミドルウェアが発行したリクエストIDを取得します
+// クライアントがX-Request-IDヘッダーを指定した場合はその値が使用されます
+func requestID(c echo.Context) string {
+	if id := c.Response().Header().Get(echo.HeaderXRequestID); id != "" {
+		return id
+	}
+	return c.Request().Header.Get(echo.HeaderXRequestID)
 }
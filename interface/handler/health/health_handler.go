@@ -0,0 +1,141 @@
+// package health は、オーケストレーターやロードバランサーが使用するヘルスチェックのハンドラーを提供します
+package health
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+	"voice-link/domain/model"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ヘルスチェックの結果を表す状態です
+const (
+	StatusOK          = "ok"          // すべての依存先が利用可能
+	StatusDegraded    = "degraded"    // 重要でない依存先が利用できないが、リクエストは処理できる
+	StatusUnavailable = "unavailable" // 重要な依存先が利用できず、リクエストを処理できない
+)
+
+// レスポンスに含める利用不可の原因です
+// 接続先のホストやアドレスを公開しないよう、詳細なエラーはログにのみ出力します
+const (
+	ReasonTimeout     = "timeout"      // 確認が時間内に完了しなかった
+	ReasonCheckFailed = "check failed" // 確認がエラーを返した
+)
+
+// defaultCheckTimeout は、各依存先の確認にかける時間の上限です
+const defaultCheckTimeout = 2 * time.Second
+
+// check は、登録された依存先の確認です
+type check struct {
+	name     string
+	critical bool
+	checker  model.HealthChecker
+}
+
+// CheckResult は、依存先ごとの確認結果です
+type CheckResult struct {
+	Status     string `json:"status"`          // okまたはunavailable
+	Critical   bool   `json:"critical"`        // 利用できない場合にサービス全体を利用不可とするかどうか
+	Error      string `json:"error,omitempty"` // 利用できない場合の原因（ReasonTimeoutまたはReasonCheckFailed）
+	DurationMS int64  `json:"duration_ms"`     // 確認にかかった時間（ミリ秒）
+}
+
+// Response は、ヘルスチェックのレスポンスボディの構造を定義します
+type Response struct {
+	Status string                 `json:"status"`           // 全体の状態
+	Checks map[string]CheckResult `json:"checks,omitempty"` // 依存先ごとの確認結果
+}
+
+// HealthHandler は、ヘルスチェックのHTTPリクエストを処理するハンドラー構造体です
+type HealthHandler struct {
+	checks  []check
+	timeout time.Duration
+}
+
+// NewHealthHandler は、HealthHandlerの新しいインスタンスを作成するファクトリ関数です
+func NewHealthHandler() *HealthHandler {
+	return &HealthHandler{timeout: defaultCheckTimeout}
+}
+
+// Register は、レディネスチェックで確認する依存先を登録します
+// criticalな依存先が利用できない場合、レディネスチェックは503 Service Unavailableを返します
+func (h *HealthHandler) Register(name string, critical bool, checker model.HealthChecker) {
+	h.checks = append(h.checks, check{name: name, critical: critical, checker: checker})
+}
+
+// Liveness は、プロセスが応答できることを返すハンドラー関数です
+// 依存先の状態は確認しないため、データベースの障害でコンテナが再起動されることはありません
+func (h *HealthHandler) Liveness(c echo.Context) error {
+	return c.JSON(http.StatusOK, Response{Status: StatusOK})
+}
+
+// Readiness は、登録されたすべての依存先を確認し、リクエストを処理できるかどうかを返すハンドラー関数です
+func (h *HealthHandler) Readiness(c echo.Context) error {
+	results := h.runChecks(c.Request().Context())
+
+	response := Response{Status: StatusOK, Checks: results}
+	for _, result := range results {
+		if result.Status == StatusOK {
+			continue
+		}
+		if result.Critical {
+			response.Status = StatusUnavailable
+			break
+		}
+		response.Status = StatusDegraded
+	}
+
+	status := http.StatusOK
+	if response.Status == StatusUnavailable {
+		status = http.StatusServiceUnavailable
+	}
+	// 確認結果がキャッシュされないようにする
+	c.Response().Header().Set(echo.HeaderCacheControl, "no-store")
+	return c.JSON(status, response)
+}
+
+// runChecks は、登録された依存先を並行して確認します
+func (h *HealthHandler) runChecks(ctx context.Context) map[string]CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]CheckResult, len(h.checks))
+	)
+	for _, chk := range h.checks {
+		wg.Add(1)
+		go func(chk check) {
+			defer wg.Done()
+
+			start := time.Now()
+			err := chk.checker.Check(ctx)
+			result := CheckResult{
+				Status:     StatusOK,
+				Critical:   chk.critical,
+				DurationMS: time.Since(start).Milliseconds(),
+			}
+			if err != nil {
+				slog.WarnContext(ctx, "health check failed", "check", chk.name, "critical", chk.critical, "error", err)
+				result.Status = StatusUnavailable
+				result.Error = ReasonCheckFailed
+				if errors.Is(err, context.DeadlineExceeded) {
+					result.Error = ReasonTimeout
+				}
+			}
+
+			mu.Lock()
+			results[chk.name] = result
+			mu.Unlock()
+		}(chk)
+	}
+	wg.Wait()
+
+	return results
+}
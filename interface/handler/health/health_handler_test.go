@@ -0,0 +1,128 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"voice-link/domain/model"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// healthy は、常に利用可能な依存先です
+var healthy = model.HealthCheckerFunc(func(ctx context.Context) error { return nil })
+
+// unhealthy は、常に利用できない依存先です
+var unhealthy = model.HealthCheckerFunc(func(ctx context.Context) error { return errors.New("connection refused") })
+
+// serve は、ハンドラー関数を呼び出してレスポンスを返します
+func serve(t *testing.T, handlerFunc echo.HandlerFunc) (*httptest.ResponseRecorder, Response) {
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+
+	assert.NoError(t, handlerFunc(c))
+
+	var response Response
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	return rec, response
+}
+
+func TestHealthHandler_Liveness(t *testing.T) {
+	handler := NewHealthHandler()
+	// 依存先が利用できなくてもプロセスが応答できれば成功
+	handler.Register("database", true, unhealthy)
+
+	rec, response := serve(t, handler.Liveness)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, StatusOK, response.Status)
+	assert.Empty(t, response.Checks)
+}
+
+func TestHealthHandler_Readiness(t *testing.T) {
+	tests := []struct {
+		name           string
+		register       func(*HealthHandler)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "すべての依存先が利用可能",
+			register: func(h *HealthHandler) {
+				h.Register("database", true, healthy)
+				h.Register("mailer", false, healthy)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   StatusOK,
+		},
+		{
+			name: "重要でない依存先が利用できない",
+			register: func(h *HealthHandler) {
+				h.Register("database", true, healthy)
+				h.Register("mailer", false, unhealthy)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   StatusDegraded,
+		},
+		{
+			name: "重要な依存先が利用できない",
+			register: func(h *HealthHandler) {
+				h.Register("database", true, unhealthy)
+				h.Register("mailer", false, unhealthy)
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   StatusUnavailable,
+		},
+		{
+			name:           "依存先の登録なし",
+			register:       func(h *HealthHandler) {},
+			expectedStatus: http.StatusOK,
+			expectedBody:   StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHealthHandler()
+			tt.register(handler)
+
+			rec, response := serve(t, handler.Readiness)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			assert.Equal(t, tt.expectedBody, response.Status)
+			assert.Equal(t, "no-store", rec.Header().Get(echo.HeaderCacheControl))
+		})
+	}
+}
+
+func TestHealthHandler_ReadinessBreakdown(t *testing.T) {
+	handler := NewHealthHandler()
+	handler.Register("database", true, healthy)
+	handler.Register("mailer", false, unhealthy)
+
+	_, response := serve(t, handler.Readiness)
+
+	assert.Equal(t, CheckResult{Status: StatusOK, Critical: true}, response.Checks["database"])
+	assert.Equal(t, CheckResult{Status: StatusUnavailable, Critical: false, Error: ReasonCheckFailed}, response.Checks["mailer"])
+}
+
+func TestHealthHandler_ReadinessTimeout(t *testing.T) {
+	handler := NewHealthHandler()
+	handler.timeout = 10 * time.Millisecond
+	// 応答しない依存先はタイムアウトで利用不可と判定される
+	handler.Register("database", true, model.HealthCheckerFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+
+	rec, response := serve(t, handler.Readiness)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, ReasonTimeout, response.Checks["database"].Error)
+}
@@ -0,0 +1,44 @@
+// package jwks は、アクセストークンを検証する他のサービスに署名の公開鍵を公開するハンドラーを提供します
+package jwks
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+	"voice-link/domain/model"
+
+	"github.com/labstack/echo/v4"
+)
+
+// cacheMaxAge は、公開鍵のキャッシュを許可する期間です
+// 鍵のローテーションでは、次の鍵を公開してからこの期間が経過した後に署名に使用する鍵を切り替えます
+const cacheMaxAge = 5 * time.Minute
+
+// Response は、JWKS（RFC 7517）のレスポンスボディの構造を定義します
+type Response struct {
+	Keys []model.JSONWebKey `json:"keys"`
+}
+
+// JWKSHandler は、署名の公開鍵を返すハンドラー構造体です
+type JWKSHandler struct {
+	keys model.TokenKeySet
+}
+
+// NewJWKSHandler は、JWKSHandlerの新しいインスタンスを作成するファクトリ関数です
+func NewJWKSHandler(keys model.TokenKeySet) *JWKSHandler {
+	return &JWKSHandler{keys: keys}
+}
+
+// JWKS は、アクセストークンの検証に使用できる公開鍵をJWKS形式で返すハンドラー関数です
+// 共有の秘密鍵で署名している場合は、空のkeysを返します
+func (h *JWKSHandler) JWKS(c echo.Context) error {
+	response := Response{Keys: []model.JSONWebKey{}}
+	for _, key := range h.keys.PublicKeys() {
+		if jwk, ok := key.JWK(); ok {
+			response.Keys = append(response.Keys, jwk)
+		}
+	}
+
+	c.Response().Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(cacheMaxAge.Seconds())))
+	return c.JSON(http.StatusOK, response)
+}
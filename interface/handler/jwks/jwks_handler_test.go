@@ -0,0 +1,64 @@
+package jwks
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"voice-link/domain/model"
+	"voice-link/infrastructure/tokenkey"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// serve は、JWKSのハンドラー関数を呼び出してレスポンスを返します
+func serve(t *testing.T, keys model.TokenKeySet) (*httptest.ResponseRecorder, Response) {
+	req := httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+
+	assert.NoError(t, NewJWKSHandler(keys).JWKS(c))
+
+	var response Response
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	return rec, response
+}
+
+func TestJWKSHandler_JWKS(t *testing.T) {
+	current, currentKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	previous, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	keys, err := tokenkey.New(currentKey, previous)
+	assert.NoError(t, err)
+
+	rec, response := serve(t, keys)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "public, max-age=300", rec.Header().Get("Cache-Control"))
+	if !assert.Len(t, response.Keys, 2) {
+		return
+	}
+	assert.Equal(t, model.JSONWebKey{
+		Kty: "OKP",
+		Kid: keys.SigningKey().ID,
+		Use: "sig",
+		Alg: model.TokenAlgorithmEdDSA,
+		Crv: "Ed25519",
+		X:   base64.RawURLEncoding.EncodeToString(current),
+	}, response.Keys[0])
+	assert.Equal(t, base64.RawURLEncoding.EncodeToString(previous), response.Keys[1].X)
+}
+
+func TestJWKSHandler_JWKS_SharedSecret(t *testing.T) {
+	// 共有の秘密鍵は公開しない
+	rec, response := serve(t, model.NewHMACTokenKeySet("secret"))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"keys":[]}`, rec.Body.String())
+	assert.Empty(t, response.Keys)
+}
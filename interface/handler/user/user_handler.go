@@ -4,6 +4,7 @@ package user
 import (
 	"net/http"
 	"strconv"
+	"voice-link/domain/model"
 	"voice-link/interface/handler/common"
 	"voice-link/interface/middleware"
 	"voice-link/usecase"
@@ -28,28 +29,74 @@ func (h *UserHandler) GetUser(c echo.Context) error {
 	// URLパラメータからIDを取得し、uint型に変換
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		return common.SendBadRequestError(c, "Invalid user ID")
+		return common.ErrInvalidUserID
 	}
 
 	// ユースケースレイヤーを呼び出してユーザー情報を取得
-	user, err := h.userUseCase.GetByID(uint(id))
+	user, err := h.userUseCase.GetByID(c.Request().Context(), uint(id))
 	if err != nil {
-		return common.SendNotFoundError(c, "User not found")
+		return err
 	}
 
 	return c.JSON(http.StatusOK, user) // 200 OKとユーザー情報を返却
 }
 
+// ListUsers は、ユーザーの一覧を取得するハンドラー関数です
+// クエリパラメーターで絞り込みと並び替えを指定し、next_cursorで次のページを取得します
+func (h *UserHandler) ListUsers(c echo.Context) error {
+	req := new(common.ListUsersRequest)
+	if err := c.Bind(req); err != nil { // クエリパラメーターをバインド
+		return common.ErrInvalidQueryParameter
+	}
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	// ユースケースレイヤーを呼び出してユーザーの一覧を取得
+	page, err := h.userUseCase.ListUsers(c.Request().Context(), usecase.ListUsersParams{
+		Filter: model.UserFilter{
+			EmailPrefix: req.EmailPrefix,
+			NamePrefix:  req.NamePrefix,
+			CreatedFrom: req.CreatedFrom,
+			CreatedTo:   req.CreatedTo,
+			Role:        model.Role(req.Role),
+			Verified:    req.Verified,
+		},
+		SortBy: model.UserSortField(req.Sort),
+		Order:  model.SortOrder(req.Order),
+		Limit:  req.Limit,
+		Cursor: req.Cursor,
+	})
+	if err != nil {
+		return err
+	}
+
+	// 該当するユーザーがいない場合もdataは空の配列で返す
+	users := page.Users
+	if users == nil {
+		users = []model.User{}
+	}
+
+	return c.JSON(http.StatusOK, common.ListUsersResponse{
+		Data: users,
+		Page: common.PageInfo{
+			Limit:      page.Limit,
+			HasMore:    page.HasMore(),
+			NextCursor: page.NextCursor,
+		},
+	})
+}
+
 // GetCurrentUser は、現在ログインしているユーザーの情報を取得するハンドラー関数です
 func (h *UserHandler) GetCurrentUser(c echo.Context) error {
 	userID := middleware.GetUserIDFromContext(c)
 	if userID == 0 {
-		return common.SendUnauthorizedError(c, "User not authenticated")
+		return common.ErrNotAuthenticated
 	}
 
-	user, err := h.userUseCase.GetByID(userID)
+	user, err := h.userUseCase.GetByID(c.Request().Context(), userID)
 	if err != nil {
-		return common.SendNotFoundError(c, "User not found")
+		return err
 	}
 
 	return c.JSON(http.StatusOK, user)
@@ -61,18 +108,21 @@ func (h *UserHandler) UpdateUser(c echo.Context) error {
 	// URLパラメータからIDを取得し、uint型に変換
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		return common.SendBadRequestError(c, "Invalid user ID")
+		return common.ErrInvalidUserID
 	}
 
 	req := new(common.UpdateUserRequest)
 	if err := c.Bind(req); err != nil { // リクエストボディをバインド
-		return common.SendBadRequestError(c, "Invalid request body")
+		return common.ErrInvalidRequestBody
+	}
+	if err := c.Validate(req); err != nil {
+		return err
 	}
 
 	// ユースケースレイヤーを呼び出してユーザー情報を更新
-	user, err := h.userUseCase.UpdateUser(uint(id), req.Name, req.Email)
+	user, err := h.userUseCase.UpdateUser(c.Request().Context(), uint(id), req.Name, req.Email, model.Language(req.PreferredLanguage))
 	if err != nil {
-		return common.SendInternalServerError(c, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, user) // 200 OKと更新後のユーザー情報を返却
@@ -82,17 +132,20 @@ func (h *UserHandler) UpdateUser(c echo.Context) error {
 func (h *UserHandler) UpdateCurrentUser(c echo.Context) error {
 	userID := middleware.GetUserIDFromContext(c)
 	if userID == 0 {
-		return common.SendUnauthorizedError(c, "User not authenticated")
+		return common.ErrNotAuthenticated
 	}
 
 	req := new(common.UpdateUserRequest)
 	if err := c.Bind(req); err != nil {
-		return common.SendBadRequestError(c, "Invalid request body")
+		return common.ErrInvalidRequestBody
+	}
+	if err := c.Validate(req); err != nil {
+		return err
 	}
 
-	user, err := h.userUseCase.UpdateUser(userID, req.Name, req.Email)
+	user, err := h.userUseCase.UpdateUser(c.Request().Context(), userID, req.Name, req.Email, model.Language(req.PreferredLanguage))
 	if err != nil {
-		return common.SendInternalServerError(c, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, user)
@@ -104,12 +157,12 @@ func (h *UserHandler) DeleteUser(c echo.Context) error {
 	// URLパラメータからIDを取得し、uint型に変換
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		return common.SendBadRequestError(c, "Invalid user ID")
+		return common.ErrInvalidUserID
 	}
 
 	// ユースケースレイヤーを呼び出してユーザーを削除
-	if err := h.userUseCase.DeleteUser(uint(id)); err != nil {
-		return common.SendInternalServerError(c, err.Error())
+	if err := h.userUseCase.DeleteUser(c.Request().Context(), uint(id)); err != nil {
+		return err
 	}
 
 	return c.NoContent(http.StatusNoContent) // 204 No Contentを返却
@@ -119,11 +172,188 @@ func (h *UserHandler) DeleteUser(c echo.Context) error {
 func (h *UserHandler) DeleteCurrentUser(c echo.Context) error {
 	userID := middleware.GetUserIDFromContext(c)
 	if userID == 0 {
-		return common.SendUnauthorizedError(c, "User not authenticated")
+		return common.ErrNotAuthenticated
+	}
+
+	if err := h.userUseCase.DeleteUser(c.Request().Context(), userID); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// BeginMFAEnrollment は、現在ログインしているユーザーの2段階認証の登録を開始するハンドラー関数です
+// 認証アプリに登録する秘密鍵とotpauth URIを返します
+func (h *UserHandler) BeginMFAEnrollment(c echo.Context) error {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == 0 {
+		return common.ErrNotAuthenticated
+	}
+
+	enrollment, err := h.userUseCase.BeginMFAEnrollment(c.Request().Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, common.MFAEnrollmentResponse{
+		Secret:     enrollment.Secret,
+		OTPAuthURI: enrollment.URI,
+	})
+}
+
+// ConfirmMFAEnrollment は、認証アプリのコードを確認して2段階認証を有効にするハンドラー関数です
+// リカバリーコードを返します
+func (h *UserHandler) ConfirmMFAEnrollment(c echo.Context) error {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == 0 {
+		return common.ErrNotAuthenticated
+	}
+
+	req := new(common.MFACodeRequest)
+	if err := c.Bind(req); err != nil {
+		return common.ErrInvalidRequestBody
+	}
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	codes, err := h.userUseCase.ConfirmMFAEnrollment(c.Request().Context(), userID, req.Code)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, common.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableMFA は、現在ログインしているユーザーがコードを入力して2段階認証を解除するハンドラー関数です
+func (h *UserHandler) DisableMFA(c echo.Context) error {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == 0 {
+		return common.ErrNotAuthenticated
+	}
+
+	req := new(common.MFACodeRequest)
+	if err := c.Bind(req); err != nil {
+		return common.ErrInvalidRequestBody
+	}
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	if err := h.userUseCase.DisableMFA(c.Request().Context(), userID, req.Code); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ResetMFA は、指定されたIDのユーザーの2段階認証を管理者が解除するハンドラー関数です
+// 認証アプリとリカバリーコードをすべて失った利用者の復旧に使用します
+func (h *UserHandler) ResetMFA(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return common.ErrInvalidUserID
+	}
+
+	if err := h.userUseCase.ResetMFA(c.Request().Context(), uint(id)); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ListIdentities は、認証済みユーザーが連携している外部のアカウントの一覧を取得するハンドラー関数です
+func (h *UserHandler) ListIdentities(c echo.Context) error {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == 0 {
+		return common.ErrNotAuthenticated
+	}
+
+	identities, err := h.userUseCase.ListIdentities(c.Request().Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, common.IdentitiesResponse{Identities: identities})
+}
+
+// BeginOIDCLink は、認証済みユーザーにURLで指定されたプロバイダーのアカウントを連携する手続きを開始するハンドラー関数です
+func (h *UserHandler) BeginOIDCLink(c echo.Context) error {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == 0 {
+		return common.ErrNotAuthenticated
+	}
+
+	authorization, err := h.userUseCase.BeginOIDCLink(c.Request().Context(), userID, c.Param("provider"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, common.NewOIDCAuthorizationResponse(authorization))
+}
+
+// CompleteOIDCLink は、プロバイダーからリダイレクトされた認可コードでアカウントを連携するハンドラー関数です
+func (h *UserHandler) CompleteOIDCLink(c echo.Context) error {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == 0 {
+		return common.ErrNotAuthenticated
+	}
+
+	req := new(common.OIDCCallbackRequest)
+	if err := c.Bind(req); err != nil {
+		return common.ErrInvalidRequestBody
+	}
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	identity, err := h.userUseCase.CompleteOIDCLink(c.Request().Context(), userID, c.Param("provider"), req.Code, req.State)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, identity)
+}
+
+// UnlinkIdentity は、認証済みユーザーからURLで指定されたプロバイダーのアカウントの連携を解除するハンドラー関数です
+func (h *UserHandler) UnlinkIdentity(c echo.Context) error {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == 0 {
+		return common.ErrNotAuthenticated
+	}
+
+	if err := h.userUseCase.UnlinkIdentity(c.Request().Context(), userID, c.Param("provider")); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ListSessions は、認証済みユーザーがログインしている端末のセッションの一覧を取得するハンドラー関数です
+// リクエストに使用したアクセストークンのセッションにはcurrentを付けて返します
+func (h *UserHandler) ListSessions(c echo.Context) error {
+	claims := middleware.GetClaimsFromContext(c)
+	if claims == nil {
+		return common.ErrNotAuthenticated
+	}
+
+	sessions, err := h.userUseCase.ListSessions(c.Request().Context(), claims.UserID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, common.NewSessionsResponse(sessions, claims.SessionID))
+}
+
+// RevokeSession は、認証済みユーザーのURLで指定されたセッションを失効させ、その端末をログアウトさせるハンドラー関数です
+func (h *UserHandler) RevokeSession(c echo.Context) error {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == 0 {
+		return common.ErrNotAuthenticated
 	}
 
-	if err := h.userUseCase.DeleteUser(userID); err != nil {
-		return common.SendInternalServerError(c, err.Error())
+	if err := h.userUseCase.RevokeSession(c.Request().Context(), userID, c.Param("id")); err != nil {
+		return err
 	}
 
 	return c.NoContent(http.StatusNoContent)
@@ -2,15 +2,21 @@ package user
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 	"voice-link/domain/model"
 	"voice-link/interface/handler/common"
+	"voice-link/interface/middleware"
+	"voice-link/interface/validator"
+	"voice-link/usecase"
 
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestUserHandler_GetUser(t *testing.T) {
@@ -30,7 +36,7 @@ func TestUserHandler_GetUser(t *testing.T) {
 					Name:  "テストユーザー",
 					Email: "test@example.com",
 				}
-				mockUC.On("GetByID", uint(1)).Return(user, nil)
+				mockUC.On("GetByID", mock.Anything, uint(1)).Return(user, nil)
 			},
 			expectedStatus: http.StatusOK,
 		},
@@ -38,10 +44,10 @@ func TestUserHandler_GetUser(t *testing.T) {
 			name:   "ユーザーが見つからない",
 			userID: "999",
 			mockSetup: func(mockUC *common.MockUserUseCase) {
-				mockUC.On("GetByID", uint(999)).Return(nil, assert.AnError)
+				mockUC.On("GetByID", mock.Anything, uint(999)).Return(nil, usecase.ErrUserNotFound)
 			},
 			expectedStatus: http.StatusNotFound,
-			expectedError:  "User not found",
+			expectedError:  usecase.ErrUserNotFound.Error(),
 		},
 		{
 			name:   "無効なユーザーID",
@@ -69,6 +75,8 @@ func TestUserHandler_GetUser(t *testing.T) {
 
 			// Echoコンテキストの作成
 			e := echo.New()
+			e.Validator = validator.New()
+			e.HTTPErrorHandler = common.HTTPErrorHandler
 			c := e.NewContext(req, rec)
 			c.SetParamNames("id")
 			c.SetParamValues(tt.userID)
@@ -78,10 +86,13 @@ func TestUserHandler_GetUser(t *testing.T) {
 
 			// アサーション
 			if tt.expectedError != "" {
+				// エラーはEchoのエラーハンドラーでレスポンスに変換される
 				assert.Error(t, err)
-				var response common.ErrorResponse
+				e.HTTPErrorHandler(err, c)
+				assert.Equal(t, tt.expectedStatus, rec.Code)
+				var response common.Problem
 				json.Unmarshal(rec.Body.Bytes(), &response)
-				assert.Equal(t, tt.expectedError, response.Error)
+				assert.Equal(t, tt.expectedError, response.Detail)
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.expectedStatus, rec.Code)
@@ -93,6 +104,139 @@ func TestUserHandler_GetUser(t *testing.T) {
 	}
 }
 
+func TestUserHandler_PassesRequestContext(t *testing.T) {
+	// クライアントの切断やタイムアウトがユースケースに伝わるよう、リクエストのコンテキストを渡す
+	type contextKey struct{}
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), contextKey{}, "request"))
+	defer cancel()
+
+	mockUC := new(common.MockUserUseCase)
+	mockUC.On("GetByID", mock.MatchedBy(func(ctx context.Context) bool {
+		return ctx.Value(contextKey{}) == "request"
+	}), uint(1)).Return(&model.User{ID: 1}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/1", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("1")
+
+	assert.NoError(t, NewUserHandler(mockUC).GetUser(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockUC.AssertExpectations(t)
+}
+
+func TestUserHandler_ListUsers(t *testing.T) {
+	createdFrom := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	verified := false
+
+	tests := []struct {
+		name           string
+		query          string
+		mockSetup      func(*common.MockUserUseCase)
+		expectedStatus int
+		expectedCode   string
+		expectedBody   string
+	}{
+		{
+			name:  "クエリパラメーターで絞り込みと並び替えを指定する",
+			query: "?email_prefix=user&name_prefix=%E3%83%86&created_from=2026-01-01T00:00:00Z&role=user&verified=false&sort=email&order=asc&limit=1&cursor=abc",
+			mockSetup: func(mockUC *common.MockUserUseCase) {
+				mockUC.On("ListUsers", mock.Anything, usecase.ListUsersParams{
+					Filter: model.UserFilter{
+						EmailPrefix: "user",
+						NamePrefix:  "テ",
+						CreatedFrom: &createdFrom,
+						Role:        model.RoleUser,
+						Verified:    &verified,
+					},
+					SortBy: model.UserSortByEmail,
+					Order:  model.SortAscending,
+					Limit:  1,
+					Cursor: "abc",
+				}).Return(&usecase.UserPage{
+					Users:      []model.User{{ID: 1, Name: "テストユーザー", Email: "user@example.com"}},
+					Limit:      1,
+					NextCursor: "next",
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"page":{"limit":1,"has_more":true,"next_cursor":"next"}`,
+		},
+		{
+			name:  "該当するユーザーがいない場合は空の配列を返す",
+			query: "",
+			mockSetup: func(mockUC *common.MockUserUseCase) {
+				mockUC.On("ListUsers", mock.Anything, usecase.ListUsersParams{}).Return(&usecase.UserPage{Limit: 20}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"data":[],"page":{"limit":20,"has_more":false}}`,
+		},
+		{
+			name:           "数値として解釈できない件数",
+			query:          "?limit=many",
+			mockSetup:      func(mockUC *common.MockUserUseCase) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   common.CodeInvalidQueryParameter,
+		},
+		{
+			name:           "RFC 3339形式でない日時",
+			query:          "?created_to=yesterday",
+			mockSetup:      func(mockUC *common.MockUserUseCase) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   common.CodeInvalidQueryParameter,
+		},
+		{
+			name:           "対応していない並び替えの項目",
+			query:          "?sort=password",
+			mockSetup:      func(mockUC *common.MockUserUseCase) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   common.CodeValidationFailed,
+		},
+		{
+			name:  "不正なカーソル",
+			query: "?cursor=invalid",
+			mockSetup: func(mockUC *common.MockUserUseCase) {
+				mockUC.On("ListUsers", mock.Anything, usecase.ListUsersParams{Cursor: "invalid"}).Return(nil, usecase.ErrInvalidCursor)
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   "invalid_cursor",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUC := new(common.MockUserUseCase)
+			tt.mockSetup(mockUC)
+			handler := NewUserHandler(mockUC)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/users"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			e := echo.New()
+			e.Validator = validator.New()
+			e.HTTPErrorHandler = common.HTTPErrorHandler
+			c := e.NewContext(req, rec)
+
+			err := handler.ListUsers(c)
+
+			if tt.expectedCode != "" {
+				assert.Error(t, err)
+				e.HTTPErrorHandler(err, c)
+				assert.Equal(t, tt.expectedStatus, rec.Code)
+				var response common.Problem
+				json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.Equal(t, tt.expectedCode, response.Code)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedStatus, rec.Code)
+				assert.Contains(t, rec.Body.String(), tt.expectedBody)
+			}
+
+			mockUC.AssertExpectations(t)
+		})
+	}
+}
+
 func TestUserHandler_GetCurrentUser(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -110,7 +254,7 @@ func TestUserHandler_GetCurrentUser(t *testing.T) {
 					Name:  "テストユーザー",
 					Email: "test@example.com",
 				}
-				mockUC.On("GetByID", uint(1)).Return(user, nil)
+				mockUC.On("GetByID", mock.Anything, uint(1)).Return(user, nil)
 			},
 			expectedStatus: http.StatusOK,
 		},
@@ -127,10 +271,10 @@ func TestUserHandler_GetCurrentUser(t *testing.T) {
 			name:   "ユーザーが見つからない",
 			userID: 1,
 			mockSetup: func(mockUC *common.MockUserUseCase) {
-				mockUC.On("GetByID", uint(1)).Return(nil, assert.AnError)
+				mockUC.On("GetByID", mock.Anything, uint(1)).Return(nil, usecase.ErrUserNotFound)
 			},
 			expectedStatus: http.StatusNotFound,
-			expectedError:  "User not found",
+			expectedError:  usecase.ErrUserNotFound.Error(),
 		},
 	}
 
@@ -149,6 +293,8 @@ func TestUserHandler_GetCurrentUser(t *testing.T) {
 
 			// Echoコンテキストの作成
 			e := echo.New()
+			e.Validator = validator.New()
+			e.HTTPErrorHandler = common.HTTPErrorHandler
 			c := e.NewContext(req, rec)
 
 			// ユーザーIDをコンテキストに設定
@@ -161,10 +307,13 @@ func TestUserHandler_GetCurrentUser(t *testing.T) {
 
 			// アサーション
 			if tt.expectedError != "" {
+				// エラーはEchoのエラーハンドラーでレスポンスに変換される
 				assert.Error(t, err)
-				var response common.ErrorResponse
+				e.HTTPErrorHandler(err, c)
+				assert.Equal(t, tt.expectedStatus, rec.Code)
+				var response common.Problem
 				json.Unmarshal(rec.Body.Bytes(), &response)
-				assert.Equal(t, tt.expectedError, response.Error)
+				assert.Equal(t, tt.expectedError, response.Detail)
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.expectedStatus, rec.Code)
@@ -198,7 +347,7 @@ func TestUserHandler_UpdateUser(t *testing.T) {
 					Name:  "更新されたユーザー",
 					Email: "updated@example.com",
 				}
-				mockUC.On("UpdateUser", uint(1), "更新されたユーザー", "updated@example.com").Return(user, nil)
+				mockUC.On("UpdateUser", mock.Anything, uint(1), "更新されたユーザー", "updated@example.com", model.Language("")).Return(user, nil)
 			},
 			expectedStatus: http.StatusOK,
 		},
@@ -215,6 +364,32 @@ func TestUserHandler_UpdateUser(t *testing.T) {
 			expectedStatus: http.StatusBadRequest,
 			expectedError:  "Invalid user ID",
 		},
+		{
+			name:   "メールアドレス重複エラー",
+			userID: "1",
+			requestBody: common.UpdateUserRequest{
+				Name:  "更新されたユーザー",
+				Email: "taken@example.com",
+			},
+			mockSetup: func(mockUC *common.MockUserUseCase) {
+				mockUC.On("UpdateUser", mock.Anything, uint(1), "更新されたユーザー", "taken@example.com", model.Language("")).Return(nil, usecase.ErrEmailAlreadyExists)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedError:  usecase.ErrEmailAlreadyExists.Error(),
+		},
+		{
+			name:   "入力検証エラー",
+			userID: "1",
+			requestBody: common.UpdateUserRequest{
+				Name:  "",
+				Email: "invalid-email",
+			},
+			mockSetup: func(mockUC *common.MockUserUseCase) {
+				// モックの設定は不要（入力検証エラーで早期リターン）
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedError:  "Validation failed",
+		},
 		{
 			name:   "更新エラー",
 			userID: "1",
@@ -223,10 +398,10 @@ func TestUserHandler_UpdateUser(t *testing.T) {
 				Email: "updated@example.com",
 			},
 			mockSetup: func(mockUC *common.MockUserUseCase) {
-				mockUC.On("UpdateUser", uint(1), "更新されたユーザー", "updated@example.com").Return(nil, assert.AnError)
+				mockUC.On("UpdateUser", mock.Anything, uint(1), "更新されたユーザー", "updated@example.com", model.Language("")).Return(nil, assert.AnError)
 			},
 			expectedStatus: http.StatusInternalServerError,
-			expectedError:  assert.AnError.Error(),
+			expectedError:  "Internal server error",
 		},
 	}
 
@@ -249,6 +424,8 @@ func TestUserHandler_UpdateUser(t *testing.T) {
 
 			// Echoコンテキストの作成
 			e := echo.New()
+			e.Validator = validator.New()
+			e.HTTPErrorHandler = common.HTTPErrorHandler
 			c := e.NewContext(req, rec)
 			c.SetParamNames("id")
 			c.SetParamValues(tt.userID)
@@ -258,10 +435,13 @@ func TestUserHandler_UpdateUser(t *testing.T) {
 
 			// アサーション
 			if tt.expectedError != "" {
+				// エラーはEchoのエラーハンドラーでレスポンスに変換される
 				assert.Error(t, err)
-				var response common.ErrorResponse
+				e.HTTPErrorHandler(err, c)
+				assert.Equal(t, tt.expectedStatus, rec.Code)
+				var response common.Problem
 				json.Unmarshal(rec.Body.Bytes(), &response)
-				assert.Equal(t, tt.expectedError, response.Error)
+				assert.Equal(t, tt.expectedError, response.Detail)
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.expectedStatus, rec.Code)
@@ -285,7 +465,7 @@ func TestUserHandler_DeleteUser(t *testing.T) {
 			name:   "正常なユーザー削除",
 			userID: "1",
 			mockSetup: func(mockUC *common.MockUserUseCase) {
-				mockUC.On("DeleteUser", uint(1)).Return(nil)
+				mockUC.On("DeleteUser", mock.Anything, uint(1)).Return(nil)
 			},
 			expectedStatus: http.StatusNoContent,
 		},
@@ -302,10 +482,10 @@ func TestUserHandler_DeleteUser(t *testing.T) {
 			name:   "削除エラー",
 			userID: "1",
 			mockSetup: func(mockUC *common.MockUserUseCase) {
-				mockUC.On("DeleteUser", uint(1)).Return(assert.AnError)
+				mockUC.On("DeleteUser", mock.Anything, uint(1)).Return(assert.AnError)
 			},
 			expectedStatus: http.StatusInternalServerError,
-			expectedError:  assert.AnError.Error(),
+			expectedError:  "Internal server error",
 		},
 	}
 
@@ -324,6 +504,8 @@ func TestUserHandler_DeleteUser(t *testing.T) {
 
 			// Echoコンテキストの作成
 			e := echo.New()
+			e.Validator = validator.New()
+			e.HTTPErrorHandler = common.HTTPErrorHandler
 			c := e.NewContext(req, rec)
 			c.SetParamNames("id")
 			c.SetParamValues(tt.userID)
@@ -333,10 +515,13 @@ func TestUserHandler_DeleteUser(t *testing.T) {
 
 			// アサーション
 			if tt.expectedError != "" {
+				// エラーはEchoのエラーハンドラーでレスポンスに変換される
 				assert.Error(t, err)
-				var response common.ErrorResponse
+				e.HTTPErrorHandler(err, c)
+				assert.Equal(t, tt.expectedStatus, rec.Code)
+				var response common.Problem
 				json.Unmarshal(rec.Body.Bytes(), &response)
-				assert.Equal(t, tt.expectedError, response.Error)
+				assert.Equal(t, tt.expectedError, response.Detail)
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.expectedStatus, rec.Code)
@@ -347,3 +532,471 @@ func TestUserHandler_DeleteUser(t *testing.T) {
 		})
 	}
 }
+
+func TestUserHandler_BeginMFAEnrollment(t *testing.T) {
+	t.Run("秘密鍵とotpauth URIを返す", func(t *testing.T) {
+		mockUC := new(common.MockUserUseCase)
+		mockUC.On("BeginMFAEnrollment", mock.Anything, uint(1)).Return(&usecase.MFAEnrollment{
+			Secret: "JBSWY3DPEHPK3PXP",
+			URI:    "otpauth://totp/Voice%20Link:test@example.com?secret=JBSWY3DPEHPK3PXP",
+		}, nil)
+		handler := NewUserHandler(mockUC)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/users/me/mfa", nil)
+		rec := httptest.NewRecorder()
+		e := echo.New()
+		c := e.NewContext(req, rec)
+		c.Set("user_id", uint(1))
+
+		err := handler.BeginMFAEnrollment(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var response common.MFAEnrollmentResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		assert.Equal(t, "JBSWY3DPEHPK3PXP", response.Secret)
+		assert.Contains(t, response.OTPAuthURI, "otpauth://totp/")
+		mockUC.AssertExpectations(t)
+	})
+
+	t.Run("有効にしている場合は409", func(t *testing.T) {
+		mockUC := new(common.MockUserUseCase)
+		mockUC.On("BeginMFAEnrollment", mock.Anything, uint(1)).Return(nil, usecase.ErrMFAAlreadyEnabled)
+		handler := NewUserHandler(mockUC)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/users/me/mfa", nil)
+		rec := httptest.NewRecorder()
+		e := echo.New()
+		e.HTTPErrorHandler = common.HTTPErrorHandler
+		c := e.NewContext(req, rec)
+		c.Set("user_id", uint(1))
+
+		err := handler.BeginMFAEnrollment(c)
+
+		assert.Error(t, err)
+		e.HTTPErrorHandler(err, c)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		mockUC.AssertExpectations(t)
+	})
+}
+
+func TestUserHandler_MFACode(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		requestBody    string
+		mockSetup      func(*common.MockUserUseCase)
+		handle         func(*UserHandler, echo.Context) error
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:        "登録の確認でリカバリーコードを返す",
+			path:        "/api/v1/users/me/mfa/confirm",
+			requestBody: `{"code":"123456"}`,
+			mockSetup: func(mockUC *common.MockUserUseCase) {
+				mockUC.On("ConfirmMFAEnrollment", mock.Anything, uint(1), "123456").Return([]string{"aaaa-bbbb-cccc-dddd"}, nil)
+			},
+			handle:         (*UserHandler).ConfirmMFAEnrollment,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "登録の確認でコードが一致しない",
+			path:        "/api/v1/users/me/mfa/confirm",
+			requestBody: `{"code":"000000"}`,
+			mockSetup: func(mockUC *common.MockUserUseCase) {
+				mockUC.On("ConfirmMFAEnrollment", mock.Anything, uint(1), "000000").Return(nil, usecase.ErrMFACodeMismatch)
+			},
+			handle:         (*UserHandler).ConfirmMFAEnrollment,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedError:  usecase.ErrMFACodeMismatch.Error(),
+		},
+		{
+			name:           "登録の確認でコードが未入力",
+			path:           "/api/v1/users/me/mfa/confirm",
+			requestBody:    `{}`,
+			mockSetup:      func(mockUC *common.MockUserUseCase) {},
+			handle:         (*UserHandler).ConfirmMFAEnrollment,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "正常な解除",
+			path:        "/api/v1/users/me/mfa/disable",
+			requestBody: `{"code":"123456"}`,
+			mockSetup: func(mockUC *common.MockUserUseCase) {
+				mockUC.On("DisableMFA", mock.Anything, uint(1), "123456").Return(nil)
+			},
+			handle:         (*UserHandler).DisableMFA,
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:        "有効にしていない場合の解除",
+			path:        "/api/v1/users/me/mfa/disable",
+			requestBody: `{"code":"123456"}`,
+			mockSetup: func(mockUC *common.MockUserUseCase) {
+				mockUC.On("DisableMFA", mock.Anything, uint(1), "123456").Return(usecase.ErrMFANotEnabled)
+			},
+			handle:         (*UserHandler).DisableMFA,
+			expectedStatus: http.StatusConflict,
+			expectedError:  usecase.ErrMFANotEnabled.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// モックの設定
+			mockUC := new(common.MockUserUseCase)
+			tt.mockSetup(mockUC)
+
+			// ハンドラーの作成
+			handler := NewUserHandler(mockUC)
+
+			// テスト用のリクエストとレスポンスを作成
+			req := httptest.NewRequest(http.MethodPost, tt.path, bytes.NewReader([]byte(tt.requestBody)))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			// Echoコンテキストの作成
+			e := echo.New()
+			e.Validator = validator.New()
+			e.HTTPErrorHandler = common.HTTPErrorHandler
+			c := e.NewContext(req, rec)
+			c.Set("user_id", uint(1))
+
+			// ハンドラーの実行
+			err := tt.handle(handler, c)
+
+			// エラーはEchoのエラーハンドラーでレスポンスに変換される
+			if err != nil {
+				e.HTTPErrorHandler(err, c)
+			}
+
+			// アサーション
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedError != "" {
+				var response common.Problem
+				json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.Equal(t, tt.expectedError, response.Detail)
+			}
+			if tt.expectedStatus == http.StatusOK {
+				var response common.RecoveryCodesResponse
+				json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.Equal(t, []string{"aaaa-bbbb-cccc-dddd"}, response.RecoveryCodes)
+			}
+
+			mockUC.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserHandler_ResetMFA(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         string
+		mockSetup      func(*common.MockUserUseCase)
+		expectedStatus int
+	}{
+		{
+			name:   "正常な解除",
+			userID: "2",
+			mockSetup: func(mockUC *common.MockUserUseCase) {
+				mockUC.On("ResetMFA", mock.Anything, uint(2)).Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "無効なユーザーID",
+			userID:         "invalid",
+			mockSetup:      func(mockUC *common.MockUserUseCase) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "ユーザーが見つからない",
+			userID: "2",
+			mockSetup: func(mockUC *common.MockUserUseCase) {
+				mockUC.On("ResetMFA", mock.Anything, uint(2)).Return(usecase.ErrUserNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUC := new(common.MockUserUseCase)
+			tt.mockSetup(mockUC)
+			handler := NewUserHandler(mockUC)
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/v1/users/"+tt.userID+"/mfa", nil)
+			rec := httptest.NewRecorder()
+			e := echo.New()
+			e.HTTPErrorHandler = common.HTTPErrorHandler
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(tt.userID)
+
+			if err := handler.ResetMFA(c); err != nil {
+				e.HTTPErrorHandler(err, c)
+			}
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			mockUC.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserHandler_Identities(t *testing.T) {
+	t.Run("連携しているアカウントの一覧", func(t *testing.T) {
+		mockUC := new(common.MockUserUseCase)
+		mockUC.On("ListIdentities", mock.Anything, uint(1)).Return([]model.Identity{
+			{ID: 1, UserID: 1, Provider: "google", Subject: "google-subject", Email: "test@gmail.com"},
+		}, nil)
+		handler := NewUserHandler(mockUC)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users/me/identities", nil)
+		rec := httptest.NewRecorder()
+		e := echo.New()
+		c := e.NewContext(req, rec)
+		c.Set("user_id", uint(1))
+
+		err := handler.ListIdentities(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"provider":"google"`)
+		// プロバイダーのアカウントの識別子は返さない
+		assert.NotContains(t, rec.Body.String(), "google-subject")
+		mockUC.AssertExpectations(t)
+	})
+
+	t.Run("連携の開始", func(t *testing.T) {
+		mockUC := new(common.MockUserUseCase)
+		mockUC.On("BeginOIDCLink", mock.Anything, uint(1), "google").Return(&usecase.OIDCAuthorization{
+			URL:       "https://accounts.google.com/o/oauth2/v2/auth?state=state",
+			State:     "state",
+			ExpiresIn: 600,
+		}, nil)
+		handler := NewUserHandler(mockUC)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/users/me/identities/google", nil)
+		rec := httptest.NewRecorder()
+		e := echo.New()
+		c := e.NewContext(req, rec)
+		c.Set("user_id", uint(1))
+		c.SetParamNames("provider")
+		c.SetParamValues("google")
+
+		err := handler.BeginOIDCLink(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var response common.OIDCAuthorizationResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		assert.Equal(t, "state", response.State)
+		mockUC.AssertExpectations(t)
+	})
+
+	t.Run("連携", func(t *testing.T) {
+		mockUC := new(common.MockUserUseCase)
+		mockUC.On("CompleteOIDCLink", mock.Anything, uint(1), "google", "auth-code", "state").Return(&model.Identity{
+			ID: 1, UserID: 1, Provider: "google", Subject: "google-subject", Email: "test@gmail.com",
+		}, nil)
+		handler := NewUserHandler(mockUC)
+
+		reqBody, _ := json.Marshal(common.OIDCCallbackRequest{Code: "auth-code", State: "state"})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/users/me/identities/google/callback", bytes.NewReader(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		e := echo.New()
+		e.Validator = validator.New()
+		c := e.NewContext(req, rec)
+		c.Set("user_id", uint(1))
+		c.SetParamNames("provider")
+		c.SetParamValues("google")
+
+		err := handler.CompleteOIDCLink(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"email":"test@gmail.com"`)
+		mockUC.AssertExpectations(t)
+	})
+
+	t.Run("別のユーザーに連携済みの場合は409", func(t *testing.T) {
+		mockUC := new(common.MockUserUseCase)
+		mockUC.On("CompleteOIDCLink", mock.Anything, uint(1), "google", "auth-code", "state").Return(nil, usecase.ErrIdentityAlreadyLinked)
+		handler := NewUserHandler(mockUC)
+
+		reqBody, _ := json.Marshal(common.OIDCCallbackRequest{Code: "auth-code", State: "state"})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/users/me/identities/google/callback", bytes.NewReader(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		e := echo.New()
+		e.Validator = validator.New()
+		e.HTTPErrorHandler = common.HTTPErrorHandler
+		c := e.NewContext(req, rec)
+		c.Set("user_id", uint(1))
+		c.SetParamNames("provider")
+		c.SetParamValues("google")
+
+		err := handler.CompleteOIDCLink(c)
+
+		assert.Error(t, err)
+		e.HTTPErrorHandler(err, c)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		mockUC.AssertExpectations(t)
+	})
+
+	t.Run("連携の解除", func(t *testing.T) {
+		mockUC := new(common.MockUserUseCase)
+		mockUC.On("UnlinkIdentity", mock.Anything, uint(1), "google").Return(nil)
+		handler := NewUserHandler(mockUC)
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/users/me/identities/google", nil)
+		rec := httptest.NewRecorder()
+		e := echo.New()
+		c := e.NewContext(req, rec)
+		c.Set("user_id", uint(1))
+		c.SetParamNames("provider")
+		c.SetParamValues("google")
+
+		err := handler.UnlinkIdentity(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		mockUC.AssertExpectations(t)
+	})
+
+	t.Run("最後のログイン方法の解除は409", func(t *testing.T) {
+		mockUC := new(common.MockUserUseCase)
+		mockUC.On("UnlinkIdentity", mock.Anything, uint(1), "google").Return(usecase.ErrLastLoginMethod)
+		handler := NewUserHandler(mockUC)
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/users/me/identities/google", nil)
+		rec := httptest.NewRecorder()
+		e := echo.New()
+		e.HTTPErrorHandler = common.HTTPErrorHandler
+		c := e.NewContext(req, rec)
+		c.Set("user_id", uint(1))
+		c.SetParamNames("provider")
+		c.SetParamValues("google")
+
+		err := handler.UnlinkIdentity(c)
+
+		assert.Error(t, err)
+		e.HTTPErrorHandler(err, c)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		mockUC.AssertExpectations(t)
+	})
+
+	t.Run("未認証の場合は401", func(t *testing.T) {
+		mockUC := new(common.MockUserUseCase)
+		handler := NewUserHandler(mockUC)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users/me/identities", nil)
+		rec := httptest.NewRecorder()
+		e := echo.New()
+		c := e.NewContext(req, rec)
+
+		err := handler.ListIdentities(c)
+
+		assert.Equal(t, common.ErrNotAuthenticated, err)
+		mockUC.AssertNotCalled(t, "ListIdentities", mock.Anything, mock.Anything)
+	})
+}
+
+func TestUserHandler_Sessions(t *testing.T) {
+	t.Run("セッションの一覧", func(t *testing.T) {
+		now := time.Now()
+		mockUC := new(common.MockUserUseCase)
+		mockUC.On("ListSessions", mock.Anything, uint(1)).Return([]model.Session{
+			{ID: "session-1", UserID: 1, DeviceName: "My iPhone", UserAgent: "iOS", IPAddress: "192.0.2.1", CreatedAt: now, LastSeenAt: now, ExpiresAt: now.Add(time.Hour)},
+			{ID: "session-2", UserID: 1, UserAgent: "Chrome", IPAddress: "192.0.2.2", CreatedAt: now, LastSeenAt: now, ExpiresAt: now.Add(time.Hour)},
+		}, nil)
+		handler := NewUserHandler(mockUC)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users/me/sessions", nil)
+		rec := httptest.NewRecorder()
+		e := echo.New()
+		c := e.NewContext(req, rec)
+		c.Set("user_id", uint(1))
+		c.Set("claims", &middleware.JWTClaims{UserID: 1, SessionID: "session-2"})
+
+		err := handler.ListSessions(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var response common.SessionsResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		if assert.Len(t, response.Sessions, 2) {
+			assert.Equal(t, "session-1", response.Sessions[0].ID)
+			assert.Equal(t, "My iPhone", response.Sessions[0].DeviceName)
+			assert.False(t, response.Sessions[0].Current)
+			assert.Equal(t, "session-2", response.Sessions[1].ID)
+			assert.True(t, response.Sessions[1].Current)
+		}
+		// 失効の管理に使用する項目は返さない
+		assert.NotContains(t, rec.Body.String(), "expires_at")
+		assert.NotContains(t, rec.Body.String(), "revoked_at")
+		mockUC.AssertExpectations(t)
+	})
+
+	t.Run("セッションの失効", func(t *testing.T) {
+		mockUC := new(common.MockUserUseCase)
+		mockUC.On("RevokeSession", mock.Anything, uint(1), "session-1").Return(nil)
+		handler := NewUserHandler(mockUC)
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/users/me/sessions/session-1", nil)
+		rec := httptest.NewRecorder()
+		e := echo.New()
+		c := e.NewContext(req, rec)
+		c.Set("user_id", uint(1))
+		c.SetParamNames("id")
+		c.SetParamValues("session-1")
+
+		err := handler.RevokeSession(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		mockUC.AssertExpectations(t)
+	})
+
+	t.Run("存在しないセッションの失効は404", func(t *testing.T) {
+		mockUC := new(common.MockUserUseCase)
+		mockUC.On("RevokeSession", mock.Anything, uint(1), "unknown").Return(usecase.ErrSessionNotFound)
+		handler := NewUserHandler(mockUC)
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/users/me/sessions/unknown", nil)
+		rec := httptest.NewRecorder()
+		e := echo.New()
+		e.HTTPErrorHandler = common.HTTPErrorHandler
+		c := e.NewContext(req, rec)
+		c.Set("user_id", uint(1))
+		c.SetParamNames("id")
+		c.SetParamValues("unknown")
+
+		err := handler.RevokeSession(c)
+
+		assert.Error(t, err)
+		e.HTTPErrorHandler(err, c)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		var response common.Problem
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		assert.Equal(t, "session_not_found", response.Code)
+		mockUC.AssertExpectations(t)
+	})
+
+	t.Run("未認証の場合は401", func(t *testing.T) {
+		mockUC := new(common.MockUserUseCase)
+		handler := NewUserHandler(mockUC)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users/me/sessions", nil)
+		rec := httptest.NewRecorder()
+		e := echo.New()
+		c := e.NewContext(req, rec)
+
+		err := handler.ListSessions(c)
+
+		assert.Equal(t, common.ErrNotAuthenticated, err)
+		mockUC.AssertNotCalled(t, "ListSessions", mock.Anything, mock.Anything)
+	})
+}
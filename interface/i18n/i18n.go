@@ -0,0 +1,137 @@
+// package i18n は、APIのメッセージを利用者の言語に合わせて翻訳する機能を提供します
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"voice-link/domain/model"
+
+	"github.com/labstack/echo/v4"
+)
+
+// FallbackLanguage は、Accept-Languageヘッダーも利用者の言語設定もない場合に使用する言語です
+// 既存のクライアントとの互換性のため、従来どおり英語で応答します
+const FallbackLanguage = model.LanguageEnglish
+
+// 言語の決定に使用するHTTPヘッダーです
+const (
+	HeaderAcceptLanguage  = "Accept-Language"
+	HeaderContentLanguage = "Content-Language"
+)
+
+// コンテキストに言語を保存する際のキーです
+const (
+	requestedLanguageKey = "requested_language"
+	userLanguageKey      = "user_language"
+)
+
+// Middleware は、Accept-Languageヘッダーから応答に使用する言語を決定するミドルウェアです
+// 決定した言語はLanguageFromContextで取得できます
+func Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if lang, ok := Negotiate(c.Request().Header.Get(HeaderAcceptLanguage)); ok {
+				c.Set(requestedLanguageKey, lang)
+			}
+			// 応答の内容がAccept-Languageによって変わることをキャッシュに伝える
+			c.Response().Header().Add(echo.HeaderVary, HeaderAcceptLanguage)
+			return next(c)
+		}
+	}
+}
+
+// Negotiate は、Accept-Languageヘッダーの値から対応している言語のうち最も優先度の高いものを選択します
+// ja-JPのような地域付きの指定は主言語（ja）として扱います
+func Negotiate(acceptLanguage string) (model.Language, bool) {
+	type candidate struct {
+		lang    model.Language
+		quality float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		lang := model.Language(primary)
+		if !lang.IsValid() {
+			continue
+		}
+
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			q, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = q
+		}
+		// q=0は「この言語は受け付けない」という意味
+		if quality <= 0 {
+			continue
+		}
+		candidates = append(candidates, candidate{lang, quality})
+	}
+
+	if len(candidates) == 0 {
+		return "", false
+	}
+
+	// 同じ優先度の場合はヘッダーに記載された順を維持する
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+	return candidates[0].lang, true
+}
+
+// SetUserLanguage は、認証済みの利用者が設定している言語をコンテキストに保存します
+// Accept-Languageヘッダーで言語が指定されていない場合に使用されます
+func SetUserLanguage(c echo.Context, lang model.Language) {
+	if lang.IsValid() {
+		c.Set(userLanguageKey, lang)
+	}
+}
+
+// RequestedLanguage は、Accept-Languageヘッダーで指定された言語を返します
+// 対応している言語が指定されていない場合は空文字を返します
+func RequestedLanguage(c echo.Context) model.Language {
+	lang, _ := c.Get(requestedLanguageKey).(model.Language)
+	return lang
+}
+
+// LanguageFromContext は、応答に使用する言語を返します
+// Accept-Languageヘッダー、利用者の言語設定、FallbackLanguageの順に決定します
+func LanguageFromContext(c echo.Context) model.Language {
+	if lang := RequestedLanguage(c); lang != "" {
+		return lang
+	}
+	if lang, ok := c.Get(userLanguageKey).(model.Language); ok {
+		return lang
+	}
+	return FallbackLanguage
+}
+
+// Message は、メッセージコードに対応する指定した言語のメッセージを返します
+// メッセージ中の{name}形式のプレースホルダーはparamsの値で置き換えられます
+// カタログにコードが定義されていない場合はfalseを返します
+func Message(lang model.Language, code string, params map[string]string) (string, bool) {
+	translations, ok := messages[code]
+	if !ok {
+		return "", false
+	}
+	message, ok := translations[lang]
+	if !ok {
+		if message, ok = translations[FallbackLanguage]; !ok {
+			return "", false
+		}
+	}
+
+	if len(params) == 0 {
+		return message, true
+	}
+	replacements := make([]string, 0, len(params)*2)
+	for name, value := range params {
+		replacements = append(replacements, "{"+name+"}", value)
+	}
+	return strings.NewReplacer(replacements...).Replace(message), true
+}
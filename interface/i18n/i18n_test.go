@@ -0,0 +1,124 @@
+package i18n
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"voice-link/domain/model"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		expectedLang   model.Language
+		expectedOK     bool
+	}{
+		{name: "日本語", acceptLanguage: "ja", expectedLang: model.LanguageJapanese, expectedOK: true},
+		{name: "地域付きの指定", acceptLanguage: "en-US", expectedLang: model.LanguageEnglish, expectedOK: true},
+		{name: "大文字小文字の違い", acceptLanguage: "JA-jp", expectedLang: model.LanguageJapanese, expectedOK: true},
+		{name: "優先度の高い言語を選択", acceptLanguage: "ja;q=0.5, en;q=0.9", expectedLang: model.LanguageEnglish, expectedOK: true},
+		{name: "同じ優先度は記載順", acceptLanguage: "en, ja", expectedLang: model.LanguageEnglish, expectedOK: true},
+		{name: "対応していない言語は無視", acceptLanguage: "fr-FR, de;q=0.9, ja;q=0.1", expectedLang: model.LanguageJapanese, expectedOK: true},
+		{name: "q=0は受け付けない", acceptLanguage: "en;q=0, ja;q=0.1", expectedLang: model.LanguageJapanese, expectedOK: true},
+		{name: "対応している言語がない", acceptLanguage: "fr, *", expectedOK: false},
+		{name: "ヘッダーなし", acceptLanguage: "", expectedOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lang, ok := Negotiate(tt.acceptLanguage)
+
+			assert.Equal(t, tt.expectedOK, ok)
+			assert.Equal(t, tt.expectedLang, lang)
+		})
+	}
+}
+
+func TestLanguageFromContext(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		userLanguage   model.Language
+		expectedLang   model.Language
+	}{
+		{name: "Accept-Languageを優先", acceptLanguage: "en", userLanguage: model.LanguageJapanese, expectedLang: model.LanguageEnglish},
+		{name: "利用者の言語設定", acceptLanguage: "", userLanguage: model.LanguageJapanese, expectedLang: model.LanguageJapanese},
+		{name: "対応していない言語の指定は利用者の言語設定", acceptLanguage: "fr", userLanguage: model.LanguageJapanese, expectedLang: model.LanguageJapanese},
+		{name: "指定がない場合", acceptLanguage: "", userLanguage: "", expectedLang: FallbackLanguage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Echoの設定
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			if tt.acceptLanguage != "" {
+				req.Header.Set(HeaderAcceptLanguage, tt.acceptLanguage)
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			// テスト用のハンドラー
+			var lang model.Language
+			handler := func(c echo.Context) error {
+				SetUserLanguage(c, tt.userLanguage)
+				lang = LanguageFromContext(c)
+				return c.NoContent(http.StatusOK)
+			}
+
+			// テスト実行
+			err := Middleware()(handler)(c)
+
+			// アサーション
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedLang, lang)
+			assert.Equal(t, HeaderAcceptLanguage, rec.Header().Get(echo.HeaderVary))
+		})
+	}
+}
+
+func TestMessage(t *testing.T) {
+	tests := []struct {
+		name            string
+		lang            model.Language
+		code            string
+		params          map[string]string
+		expectedMessage string
+		expectedOK      bool
+	}{
+		{name: "日本語のメッセージ", lang: model.LanguageJapanese, code: "user_not_found", expectedMessage: "ユーザーが見つかりません", expectedOK: true},
+		{name: "英語のメッセージ", lang: model.LanguageEnglish, code: "user_not_found", expectedMessage: "user not found", expectedOK: true},
+		{
+			name:            "プレースホルダーの置き換え",
+			lang:            model.LanguageJapanese,
+			code:            "validation.min",
+			params:          map[string]string{"field": "password", "param": "6"},
+			expectedMessage: "passwordは6文字以上で入力してください",
+			expectedOK:      true,
+		},
+		{name: "対応していない言語は英語", lang: "fr", code: "logged_out", expectedMessage: "Logged out successfully", expectedOK: true},
+		{name: "定義されていないコード", lang: model.LanguageJapanese, code: "unknown_code", expectedOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, ok := Message(tt.lang, tt.code, tt.params)
+
+			assert.Equal(t, tt.expectedOK, ok)
+			assert.Equal(t, tt.expectedMessage, message)
+		})
+	}
+}
+
+// TestMessagesAreComplete は、すべてのメッセージが対応しているすべての言語で定義されていることを確認します
+func TestMessagesAreComplete(t *testing.T) {
+	for code, translations := range messages {
+		for _, lang := range model.SupportedLanguages() {
+			assert.NotEmpty(t, translations[lang], "message %q is missing for %q", code, lang)
+		}
+	}
+}
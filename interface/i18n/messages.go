@@ -0,0 +1,278 @@
+package i18n
+
+import "voice-link/domain/model"
+
+// messages は、メッセージコードごとの各言語のメッセージです
+// エラーメッセージはエラーレスポンスのcodeと同じキーで定義します
+// 入力検証のメッセージはvalidation.{ルール名}のキーで定義し、{field}と{param}を使用できます
+var messages = map[string]map[model.Language]string{
+	// ユースケースのエラー
+	"user_not_found": {
+		model.LanguageJapanese: "ユーザーが見つかりません",
+		model.LanguageEnglish:  "user not found",
+	},
+	"email_already_exists": {
+		model.LanguageJapanese: "このメールアドレスは既に登録されています",
+		model.LanguageEnglish:  "email already exists",
+	},
+	"unsupported_language": {
+		model.LanguageJapanese: "対応していない言語です",
+		model.LanguageEnglish:  "unsupported language",
+	},
+	"invalid_credentials": {
+		model.LanguageJapanese: "メールアドレスまたはパスワードが正しくありません",
+		model.LanguageEnglish:  "invalid email or password",
+	},
+	"email_not_verified": {
+		model.LanguageJapanese: "メールアドレスの確認が完了していません",
+		model.LanguageEnglish:  "email address is not verified",
+	},
+	"invalid_refresh_token": {
+		model.LanguageJapanese: "リフレッシュトークンが無効です",
+		model.LanguageEnglish:  "invalid refresh token",
+	},
+	"refresh_token_reused": {
+		model.LanguageJapanese: "リフレッシュトークンの再利用が検出されました",
+		model.LanguageEnglish:  "refresh token reuse detected",
+	},
+	"refresh_token_expired": {
+		model.LanguageJapanese: "リフレッシュトークンの有効期限が切れています",
+		model.LanguageEnglish:  "refresh token has expired",
+	},
+	"invalid_reset_token": {
+		model.LanguageJapanese: "リセットトークンが無効か、有効期限が切れています",
+		model.LanguageEnglish:  "invalid or expired reset token",
+	},
+	"reset_token_expired": {
+		model.LanguageJapanese: "リセットトークンの有効期限が切れています",
+		model.LanguageEnglish:  "reset token has expired",
+	},
+	"invalid_verification_token": {
+		model.LanguageJapanese: "確認トークンが無効か、有効期限が切れています",
+		model.LanguageEnglish:  "invalid or expired verification token",
+	},
+	"verification_token_expired": {
+		model.LanguageJapanese: "確認トークンの有効期限が切れています",
+		model.LanguageEnglish:  "verification token has expired",
+	},
+	"invalid_sort": {
+		model.LanguageJapanese: "並び替えの項目または順序が不正です",
+		model.LanguageEnglish:  "unsupported sort field or order",
+	},
+	"invalid_page_limit": {
+		model.LanguageJapanese: "取得件数は1から100の範囲で指定してください",
+		model.LanguageEnglish:  "limit must be between 1 and 100",
+	},
+	"invalid_cursor": {
+		model.LanguageJapanese: "カーソルが不正です",
+		model.LanguageEnglish:  "invalid cursor",
+	},
+	"invalid_role_filter": {
+		model.LanguageJapanese: "対応していないロールです",
+		model.LanguageEnglish:  "unsupported role",
+	},
+	"invalid_created_range": {
+		model.LanguageJapanese: "作成日時の開始は終了より前を指定してください",
+		model.LanguageEnglish:  "created_from must be before created_to",
+	},
+	"too_many_login_attempts": {
+		model.LanguageJapanese: "ログインの試行回数が多すぎます。しばらくしてから再度お試しください",
+		model.LanguageEnglish:  "too many login attempts, please try again later",
+	},
+	"account_locked": {
+		model.LanguageJapanese: "ログインの失敗が続いたため、アカウントを一時的にロックしました。メールに記載されたリンクからロックを解除できます",
+		model.LanguageEnglish:  "account is temporarily locked due to too many failed login attempts",
+	},
+	"too_many_email_requests": {
+		model.LanguageJapanese: "メールの送信のリクエストが多すぎます。しばらくしてから再度お試しください",
+		model.LanguageEnglish:  "too many email requests, please try again later",
+	},
+	"invalid_unlock_token": {
+		model.LanguageJapanese: "ロック解除トークンが無効か、有効期限が切れています",
+		model.LanguageEnglish:  "invalid or expired unlock token",
+	},
+	"unlock_token_expired": {
+		model.LanguageJapanese: "ロック解除トークンの有効期限が切れています",
+		model.LanguageEnglish:  "unlock token has expired",
+	},
+	"invalid_mfa_token": {
+		model.LanguageJapanese: "2段階認証のトークンが無効か、有効期限が切れています。もう一度ログインしてください",
+		model.LanguageEnglish:  "invalid or expired MFA token",
+	},
+	"invalid_mfa_code": {
+		model.LanguageJapanese: "認証コードが正しくありません",
+		model.LanguageEnglish:  "invalid authentication code",
+	},
+	"mfa_code_mismatch": {
+		model.LanguageJapanese: "認証コードが一致しません",
+		model.LanguageEnglish:  "authentication code does not match",
+	},
+	"mfa_already_enabled": {
+		model.LanguageJapanese: "2段階認証はすでに有効です",
+		model.LanguageEnglish:  "two-factor authentication is already enabled",
+	},
+	"mfa_not_enabled": {
+		model.LanguageJapanese: "2段階認証は有効になっていません",
+		model.LanguageEnglish:  "two-factor authentication is not enabled",
+	},
+	"mfa_enrollment_not_started": {
+		model.LanguageJapanese: "2段階認証の登録が開始されていません",
+		model.LanguageEnglish:  "two-factor authentication enrollment has not been started",
+	},
+	"oidc_provider_not_found": {
+		model.LanguageJapanese: "対応していないログイン方法です",
+		model.LanguageEnglish:  "unsupported login provider",
+	},
+	"invalid_oidc_state": {
+		model.LanguageJapanese: "ログインの要求が無効か、有効期限が切れています。もう一度やり直してください",
+		model.LanguageEnglish:  "invalid or expired authorization state",
+	},
+	"oidc_login_failed": {
+		model.LanguageJapanese: "ログイン先のサービスでの認証を確認できませんでした",
+		model.LanguageEnglish:  "failed to verify the login with the provider",
+	},
+	"oidc_email_required": {
+		model.LanguageJapanese: "ログイン先のサービスからメールアドレスを取得できませんでした",
+		model.LanguageEnglish:  "the provider did not share an email address",
+	},
+	"oidc_email_already_exists": {
+		model.LanguageJapanese: "このメールアドレスのアカウントは既に存在します。ログインしてからアカウントの設定で連携してください",
+		model.LanguageEnglish:  "an account with this email already exists; log in and link the provider from your account settings",
+	},
+	"identity_already_linked": {
+		model.LanguageJapanese: "このアカウントは既に連携されています",
+		model.LanguageEnglish:  "this provider account is already linked",
+	},
+	"identity_not_found": {
+		model.LanguageJapanese: "連携しているアカウントが見つかりません",
+		model.LanguageEnglish:  "linked account not found",
+	},
+	"last_login_method": {
+		model.LanguageJapanese: "ログインする方法がなくなるため、連携を解除できません。先にパスワードを設定してください",
+		model.LanguageEnglish:  "cannot unlink the only way to log in; set a password first",
+	},
+	"session_not_found": {
+		model.LanguageJapanese: "セッションが見つかりません",
+		model.LanguageEnglish:  "session not found",
+	},
+
+	// ハンドラーとミドルウェアのエラー
+	"invalid_request_body": {
+		model.LanguageJapanese: "リクエストボディが不正です",
+		model.LanguageEnglish:  "Invalid request body",
+	},
+	"invalid_user_id": {
+		model.LanguageJapanese: "ユーザーIDが不正です",
+		model.LanguageEnglish:  "Invalid user ID",
+	},
+	"invalid_query_parameter": {
+		model.LanguageJapanese: "クエリパラメーターが不正です",
+		model.LanguageEnglish:  "Invalid query parameter",
+	},
+	"not_authenticated": {
+		model.LanguageJapanese: "認証されていません",
+		model.LanguageEnglish:  "User not authenticated",
+	},
+	"authorization_required": {
+		model.LanguageJapanese: "Authorizationヘッダーが必要です",
+		model.LanguageEnglish:  "Authorization header is required",
+	},
+	"invalid_authorization_header": {
+		model.LanguageJapanese: "Authorizationヘッダーの形式が不正です",
+		model.LanguageEnglish:  "Invalid authorization header format",
+	},
+	"invalid_token": {
+		model.LanguageJapanese: "トークンが無効です",
+		model.LanguageEnglish:  "Invalid token",
+	},
+	"token_revoked": {
+		model.LanguageJapanese: "トークンは失効しています",
+		model.LanguageEnglish:  "Token has been revoked",
+	},
+	"insufficient_permissions": {
+		model.LanguageJapanese: "権限がありません",
+		model.LanguageEnglish:  "Insufficient permissions",
+	},
+	"validation_failed": {
+		model.LanguageJapanese: "入力内容に誤りがあります",
+		model.LanguageEnglish:  "Validation failed",
+	},
+	"internal_error": {
+		model.LanguageJapanese: "サーバー内部でエラーが発生しました",
+		model.LanguageEnglish:  "Internal server error",
+	},
+	"request_timeout": {
+		model.LanguageJapanese: "処理が時間内に完了しませんでした。しばらくしてから再度お試しください",
+		model.LanguageEnglish:  "Request timed out",
+	},
+	"too_many_requests": {
+		model.LanguageJapanese: "リクエストが多すぎます。しばらくしてから再度お試しください",
+		model.LanguageEnglish:  "Too many requests",
+	},
+
+	// ルーティングなどEcho自身が返すエラー
+	"not_found": {
+		model.LanguageJapanese: "リソースが見つかりません",
+		model.LanguageEnglish:  "Not Found",
+	},
+	"method_not_allowed": {
+		model.LanguageJapanese: "許可されていないメソッドです",
+		model.LanguageEnglish:  "Method Not Allowed",
+	},
+	"request_entity_too_large": {
+		model.LanguageJapanese: "リクエストボディが大きすぎます",
+		model.LanguageEnglish:  "Request Entity Too Large",
+	},
+
+	// 成功時のメッセージ
+	"logged_out": {
+		model.LanguageJapanese: "ログアウトしました",
+		model.LanguageEnglish:  "Logged out successfully",
+	},
+	"logged_out_all": {
+		model.LanguageJapanese: "すべてのセッションからログアウトしました",
+		model.LanguageEnglish:  "Logged out from all sessions successfully",
+	},
+	"password_reset_requested": {
+		model.LanguageJapanese: "メールアドレスが登録されている場合は、パスワード再設定用のリンクを送信しました",
+		model.LanguageEnglish:  "If the email exists, a password reset link has been sent",
+	},
+	"password_reset": {
+		model.LanguageJapanese: "パスワードを再設定しました",
+		model.LanguageEnglish:  "Password has been reset successfully",
+	},
+	"email_verified": {
+		model.LanguageJapanese: "メールアドレスを確認しました",
+		model.LanguageEnglish:  "Email address has been verified successfully",
+	},
+	"verification_email_sent": {
+		model.LanguageJapanese: "メールアドレスが登録済みかつ未確認の場合は、確認メールを送信しました",
+		model.LanguageEnglish:  "If the email exists and is not verified, a verification email has been sent",
+	},
+	"account_unlocked": {
+		model.LanguageJapanese: "アカウントのロックを解除しました",
+		model.LanguageEnglish:  "Account has been unlocked successfully",
+	},
+
+	// 入力検証のメッセージ
+	"validation.required": {
+		model.LanguageJapanese: "{field}は必須です",
+		model.LanguageEnglish:  "{field} is required",
+	},
+	"validation.email": {
+		model.LanguageJapanese: "{field}は有効なメールアドレスではありません",
+		model.LanguageEnglish:  "{field} must be a valid email address",
+	},
+	"validation.min": {
+		model.LanguageJapanese: "{field}は{param}文字以上で入力してください",
+		model.LanguageEnglish:  "{field} must be at least {param} characters",
+	},
+	"validation.max": {
+		model.LanguageJapanese: "{field}は{param}文字以下で入力してください",
+		model.LanguageEnglish:  "{field} must be at most {param} characters",
+	},
+	"validation.oneof": {
+		model.LanguageJapanese: "{field}は[{param}]のいずれかを指定してください",
+		model.LanguageEnglish:  "{field} must be one of [{param}]",
+	},
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AccessLog は、リクエストごとの処理結果をloggerに出力するミドルウェアです
+// クエリ文字列にはトークンが含まれる場合があるため、パスのみを記録します
+// リクエストID、ユーザーIDはリクエストのコンテキストから付与されます
+func AccessLog(logger *slog.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+			if err != nil {
+				// エラーレスポンスのステータスコードを記録するため、ここでエラーハンドラーを呼び出す
+				c.Error(err)
+			}
+
+			req := c.Request()
+			status := c.Response().Status
+			level := slog.LevelInfo
+			if status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+
+			// 認証ミドルウェアで設定されたユーザーIDを含めるため、処理後のリクエストのコンテキストを使用する
+			logger.LogAttrs(req.Context(), level, "request completed",
+				slog.String("method", req.Method),
+				slog.String("route", c.Path()),
+				slog.String("path", req.URL.Path),
+				slog.Int("status", status),
+				slog.Int64("bytes_out", c.Response().Size),
+				slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+				slog.String("remote_ip", c.RealIP()),
+				slog.String("user_agent", req.UserAgent()),
+			)
+
+			return err
+		}
+	}
+}
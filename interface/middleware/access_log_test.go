@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"voice-link/interface/handler/common"
+	"voice-link/logging"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logging.New(&buf, slog.LevelInfo)
+
+	e := echo.New()
+	e.HTTPErrorHandler = common.HTTPErrorHandler
+	e.Use(RequestID(), AccessLog(logger))
+	e.GET("/users/:id", func(c echo.Context) error {
+		// 認証ミドルウェアと同様に、処理中に設定されたユーザーIDもログに含める
+		c.SetRequest(c.Request().WithContext(logging.WithUserID(c.Request().Context(), 42)))
+		return common.NewHTTPError(http.StatusNotFound, "user_not_found", "user not found")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42?token=secret-reset-token", nil)
+	req.Header.Set(echo.HeaderXRequestID, "test-request-id")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	var entry map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "request completed", entry["msg"])
+	assert.Equal(t, "INFO", entry["level"])
+	assert.Equal(t, "GET", entry["method"])
+	assert.Equal(t, "/users/:id", entry["route"])
+	assert.Equal(t, float64(http.StatusNotFound), entry["status"])
+	assert.Equal(t, "test-request-id", entry["request_id"])
+	assert.Equal(t, float64(42), entry["user_id"])
+	// クエリ文字列は記録しない
+	assert.Equal(t, "/users/42", entry["path"])
+	assert.NotContains(t, buf.String(), "secret-reset-token")
+}
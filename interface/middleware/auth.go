@@ -1,69 +1,163 @@
 package middleware
 
 import (
+	"context"
+	"errors"
+	"log/slog"
 	"net/http"
-	"os"
+	"strconv"
 	"strings"
+	"voice-link/domain/model"
+	"voice-link/interface/handler/common"
+	"voice-link/interface/i18n"
+	"voice-link/logging"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // JWTClaims は、JWTトークンに含まれるクレーム情報を定義します
 type JWTClaims struct {
-	UserID uint `json:"user_id"`
+	UserID       uint           `json:"user_id"`
+	Role         model.Role     `json:"role"`
+	SessionID    string         `json:"sid"`            // 同じログインから発行されたトークンを識別するID
+	TokenVersion uint           `json:"token_version"`  // 発行時点のユーザーのトークン世代
+	Language     model.Language `json:"lang,omitempty"` // 発行時点のユーザーの言語設定
 	jwt.RegisteredClaims
 }
 
+// TokenValidation は、アクセストークンの検証の設定です
+type TokenValidation struct {
+	Keys     model.TokenKeySet // 署名の検証に使用する鍵
+	Issuer   string            // 要求するiss。空の場合は検証しません
+	Audience string            // 要求するaud。空の場合は検証しません
+}
+
+// parserOptions は、有効期限、iss、audを検証するパーサーのオプションを返します
+func (v TokenValidation) parserOptions() []jwt.ParserOption {
+	options := []jwt.ParserOption{jwt.WithExpirationRequired()}
+	if v.Issuer != "" {
+		options = append(options, jwt.WithIssuer(v.Issuer))
+	}
+	if v.Audience != "" {
+		options = append(options, jwt.WithAudience(v.Audience))
+	}
+	return options
+}
+
+// keyFunc は、トークンのkidに対応する検証用の鍵を返します
+// 鍵と異なるアルゴリズムで署名されたトークンは、公開鍵をHMACの秘密鍵として悪用されないよう拒否します
+func (v TokenValidation) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := v.Keys.VerificationKey(kid)
+	if !ok {
+		return nil, jwt.ErrTokenUnverifiable
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, jwt.ErrSignatureInvalid
+	}
+	return key.PublicKey, nil
+}
+
 // AuthMiddleware は、JWTトークンによる認証を行うミドルウェアです
-func AuthMiddleware() echo.MiddlewareFunc {
+// validationの鍵で署名を、iss、audと有効期限を検証し、tokenRevocationsを参照してログアウト、セッションの失効、パスワード変更で失効したトークンを拒否します
+// 拒否したトークンはmetricsに理由ごとに記録します（nilの場合は記録しません）
+func AuthMiddleware(validation TokenValidation, tokenRevocations model.TokenRevocationStore, metrics TokenMetrics) echo.MiddlewareFunc {
+	parserOptions := validation.parserOptions()
+
+	// reject は、拒否した理由を記録してエラーレスポンスを送信します
+	reject := func(c echo.Context, status int, code, detail string) error {
+		if metrics != nil {
+			metrics.TokenRejected(code)
+		}
+		return common.SendProblem(c, status, code, detail)
+	}
+
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			// Authorizationヘッダーからトークンを取得
 			authHeader := c.Request().Header.Get("Authorization")
 			if authHeader == "" {
-				return c.JSON(http.StatusUnauthorized, map[string]string{
-					"error": "Authorization header is required",
-				})
+				return reject(c, http.StatusUnauthorized, common.CodeAuthorizationRequired, "Authorization header is required")
 			}
 
 			// Bearerトークンの形式をチェック
 			tokenParts := strings.Split(authHeader, " ")
 			if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-				return c.JSON(http.StatusUnauthorized, map[string]string{
-					"error": "Invalid authorization header format",
-				})
+				return reject(c, http.StatusUnauthorized, common.CodeInvalidAuthorizationHeader, "Invalid authorization header format")
 			}
 
 			tokenString := tokenParts[1]
 
 			// JWTトークンを検証
-			token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-				// 署名アルゴリズムの検証
-				if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-					return nil, jwt.ErrSignatureInvalid
-				}
-				return []byte(os.Getenv("JWT_SECRET")), nil
-			})
+			token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, validation.keyFunc, parserOptions...)
 
 			if err != nil {
-				return c.JSON(http.StatusUnauthorized, map[string]string{
-					"error": "Invalid token",
-				})
+				return reject(c, http.StatusUnauthorized, common.CodeInvalidToken, "Invalid token")
 			}
 
 			// クレームの取得
-			if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid {
-				// コンテキストにユーザーIDを設定
-				c.Set("user_id", claims.UserID)
-				return next(c)
+			claims, ok := token.Claims.(*JWTClaims)
+			if !ok || !token.Valid {
+				return reject(c, http.StatusUnauthorized, common.CodeInvalidToken, "Invalid token claims")
+			}
+
+			// 失効済みトークンのチェック
+			revoked, err := isTokenRevoked(c.Request().Context(), tokenRevocations, claims)
+			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+				// クライアントの切断やタイムアウトは、エラーハンドラーで共通の応答に変換する
+				return err
+			}
+			if err != nil {
+				slog.ErrorContext(c.Request().Context(), "failed to check token revocation", "error", err)
+				return common.SendProblem(c, http.StatusInternalServerError, common.CodeInternalError, "Failed to verify token")
 			}
+			if revoked {
+				return reject(c, http.StatusUnauthorized, common.CodeTokenRevoked, "Token has been revoked")
+			}
+
+			// コンテキストにユーザーIDとロールを設定
+			c.Set("user_id", claims.UserID)
+			c.Set("role", claims.Role)
+			c.Set("claims", claims)
+			// 以降のログとトレースに認証済みのユーザーIDを付与する
+			ctx := logging.WithUserID(c.Request().Context(), claims.UserID)
+			trace.SpanFromContext(ctx).SetAttributes(attribute.String("enduser.id", strconv.FormatUint(uint64(claims.UserID), 10)))
+			c.SetRequest(c.Request().WithContext(ctx))
+			// Accept-Languageヘッダーがない場合は、ユーザーの言語設定でメッセージを返す
+			i18n.SetUserLanguage(c, claims.Language)
+			return next(c)
+		}
+	}
+}
 
-			return c.JSON(http.StatusUnauthorized, map[string]string{
-				"error": "Invalid token claims",
-			})
+// isTokenRevoked は、トークンが個別に、セッション単位で、またはユーザー単位で一括失効されているかを判定します
+func isTokenRevoked(ctx context.Context, tokenRevocations model.TokenRevocationStore, claims *JWTClaims) (bool, error) {
+	// jtiを持つトークンのみ個別の失効を確認する
+	if claims.ID != "" {
+		revoked, err := tokenRevocations.IsTokenRevoked(ctx, claims.ID)
+		if err != nil || revoked {
+			return revoked, err
 		}
 	}
+
+	// ログアウトした端末や、他の端末から失効させたセッションのトークン
+	if claims.SessionID != "" {
+		revoked, err := tokenRevocations.IsSessionRevoked(ctx, claims.SessionID)
+		if err != nil || revoked {
+			return revoked, err
+		}
+	}
+
+	// 現在の世代より前に発行されたトークンは失効済み
+	version, err := tokenRevocations.GetUserTokenVersion(ctx, claims.UserID)
+	if err != nil {
+		return false, err
+	}
+
+	return claims.TokenVersion < version, nil
 }
 
 // GetUserIDFromContext は、コンテキストからユーザーIDを取得するヘルパー関数です
@@ -78,3 +172,21 @@ func GetUserIDFromContext(c echo.Context) uint {
 		return 0
 	}
 }
+
+// GetRoleFromContext は、コンテキストからユーザーのロールを取得するヘルパー関数です
+func GetRoleFromContext(c echo.Context) model.Role {
+	switch v := c.Get("role").(type) {
+	case model.Role:
+		return v
+	case string:
+		return model.Role(v)
+	default:
+		return ""
+	}
+}
+
+// GetClaimsFromContext は、コンテキストから検証済みのJWTクレームを取得するヘルパー関数です
+func GetClaimsFromContext(c echo.Context) *JWTClaims {
+	claims, _ := c.Get("claims").(*JWTClaims)
+	return claims
+}
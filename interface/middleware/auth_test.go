@@ -1,22 +1,83 @@
 package middleware
 
 import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
-	"os"
 	"testing"
 	"time"
+	"voice-link/domain/model"
+	"voice-link/logging"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 )
 
-func TestAuthMiddleware(t *testing.T) {
-	// JWT_SECRETの設定
-	os.Setenv("JWT_SECRET", "test-secret")
+// testJWTSecret は、テストで使用するJWTの署名用の秘密鍵です
+const testJWTSecret = "test-secret"
+
+// testTokenValidation は、testJWTSecretで署名したトークンを受け付ける検証の設定です
+var testTokenValidation = TokenValidation{Keys: model.NewHMACTokenKeySet(testJWTSecret)}
+
+// stubTokenRevocationStore は、テスト用のインメモリTokenRevocationStoreです
+type stubTokenRevocationStore struct {
+	revoked  map[string]bool
+	sessions map[string]bool
+	versions map[uint]uint
+	err      error
+}
+
+func newStubTokenRevocationStore() *stubTokenRevocationStore {
+	return &stubTokenRevocationStore{
+		revoked:  make(map[string]bool),
+		sessions: make(map[string]bool),
+		versions: make(map[uint]uint),
+	}
+}
+
+func (s *stubTokenRevocationStore) RevokeToken(ctx context.Context, jti string, userID uint, expiresAt time.Time) error {
+	s.revoked[jti] = true
+	return s.err
+}
+
+func (s *stubTokenRevocationStore) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	return s.revoked[jti], s.err
+}
+
+func (s *stubTokenRevocationStore) RevokeAllUserTokens(ctx context.Context, userID uint) error {
+	s.versions[userID]++
+	return s.err
+}
+
+func (s *stubTokenRevocationStore) GetUserTokenVersion(ctx context.Context, userID uint) (uint, error) {
+	return s.versions[userID], s.err
+}
+
+func (s *stubTokenRevocationStore) RevokeSession(ctx context.Context, sessionID string) error {
+	s.sessions[sessionID] = true
+	return s.err
+}
+
+func (s *stubTokenRevocationStore) IsSessionRevoked(ctx context.Context, sessionID string) (bool, error) {
+	return s.sessions[sessionID], s.err
+}
+
+func (s *stubTokenRevocationStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	return 0, s.err
+}
+
+func (s *stubTokenRevocationStore) DeleteAllByUserID(ctx context.Context, userID uint) error {
+	delete(s.versions, userID)
+	return s.err
+}
 
+func TestAuthMiddleware(t *testing.T) {
 	tests := []struct {
 		name           string
 		authHeader     string
@@ -30,7 +91,12 @@ func TestAuthMiddleware(t *testing.T) {
 			authHeader:     "",
 			expectedStatus: http.StatusUnauthorized,
 			expectedBody: map[string]interface{}{
-				"error": "Authorization header is required",
+				"type":     "about:blank",
+				"title":    "Unauthorized",
+				"status":   float64(http.StatusUnauthorized),
+				"detail":   "Authorization header is required",
+				"instance": "/test",
+				"code":     "authorization_required",
 			},
 			shouldSetUser:  false,
 			expectedUserID: 0,
@@ -40,7 +106,12 @@ func TestAuthMiddleware(t *testing.T) {
 			authHeader:     "InvalidFormat",
 			expectedStatus: http.StatusUnauthorized,
 			expectedBody: map[string]interface{}{
-				"error": "Invalid authorization header format",
+				"type":     "about:blank",
+				"title":    "Unauthorized",
+				"status":   float64(http.StatusUnauthorized),
+				"detail":   "Invalid authorization header format",
+				"instance": "/test",
+				"code":     "invalid_authorization_header",
 			},
 			shouldSetUser:  false,
 			expectedUserID: 0,
@@ -50,7 +121,12 @@ func TestAuthMiddleware(t *testing.T) {
 			authHeader:     "Bearer invalid-token",
 			expectedStatus: http.StatusUnauthorized,
 			expectedBody: map[string]interface{}{
-				"error": "Invalid token",
+				"type":     "about:blank",
+				"title":    "Unauthorized",
+				"status":   float64(http.StatusUnauthorized),
+				"detail":   "Invalid token",
+				"instance": "/test",
+				"code":     "invalid_token",
 			},
 			shouldSetUser:  false,
 			expectedUserID: 0,
@@ -77,7 +153,7 @@ func TestAuthMiddleware(t *testing.T) {
 					"exp":     time.Now().Add(time.Hour).Unix(),
 					"iat":     time.Now().Unix(),
 				})
-				tokenString, _ := token.SignedString([]byte("test-secret"))
+				tokenString, _ := token.SignedString([]byte(testJWTSecret))
 				tt.authHeader = "Bearer " + tokenString
 			}
 
@@ -86,12 +162,16 @@ func TestAuthMiddleware(t *testing.T) {
 				if tt.shouldSetUser {
 					userID := GetUserIDFromContext(c)
 					assert.Equal(t, tt.expectedUserID, userID)
+					// 以降のログに出力するユーザーIDがリクエストのコンテキストに設定される
+					logUserID, ok := logging.UserIDFromContext(c.Request().Context())
+					assert.True(t, ok)
+					assert.Equal(t, tt.expectedUserID, logUserID)
 				}
 				return c.String(http.StatusOK, "success")
 			}
 
 			// ミドルウェアの適用
-			middleware := AuthMiddleware()
+			middleware := AuthMiddleware(testTokenValidation, newStubTokenRevocationStore(), nil)
 			handlerWithMiddleware := middleware(handler)
 
 			// リクエストの作成
@@ -169,9 +249,6 @@ func TestGetUserIDFromContext(t *testing.T) {
 }
 
 func TestJWTTokenValidation(t *testing.T) {
-	// JWT_SECRETの設定
-	os.Setenv("JWT_SECRET", "test-secret")
-
 	tests := []struct {
 		name           string
 		userID         uint
@@ -207,7 +284,7 @@ func TestJWTTokenValidation(t *testing.T) {
 				"exp":     exp.Unix(),
 				"iat":     time.Now().Unix(),
 			})
-			tokenString, _ := token.SignedString([]byte("test-secret"))
+			tokenString, _ := token.SignedString([]byte(testJWTSecret))
 
 			// Echoの設定
 			e := echo.New()
@@ -220,7 +297,7 @@ func TestJWTTokenValidation(t *testing.T) {
 			}
 
 			// ミドルウェアの適用
-			middleware := AuthMiddleware()
+			middleware := AuthMiddleware(testTokenValidation, newStubTokenRevocationStore(), nil)
 			handlerWithMiddleware := middleware(handler)
 
 			// リクエストの作成
@@ -244,3 +321,302 @@ func TestJWTTokenValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthMiddleware_Revocation(t *testing.T) {
+	tests := []struct {
+		name           string
+		jti            string
+		tokenVersion   uint
+		storeSetup     func(*stubTokenRevocationStore)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:           "失効していないトークン",
+			jti:            "jti-1",
+			tokenVersion:   1,
+			storeSetup:     func(store *stubTokenRevocationStore) { store.versions[1] = 1 },
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "ログアウトで失効したトークン",
+			jti:            "jti-2",
+			storeSetup:     func(store *stubTokenRevocationStore) { store.revoked["jti-2"] = true },
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "Token has been revoked",
+		},
+		{
+			name:           "一括失効より前の世代のトークン",
+			jti:            "jti-3",
+			tokenVersion:   0,
+			storeSetup:     func(store *stubTokenRevocationStore) { store.versions[1] = 1 },
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "Token has been revoked",
+		},
+		{
+			name:           "セッションが失効したトークン",
+			jti:            "jti-5",
+			storeSetup:     func(store *stubTokenRevocationStore) { store.sessions["session-1"] = true },
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "Token has been revoked",
+		},
+		{
+			name:           "失効情報の取得に失敗",
+			jti:            "jti-4",
+			storeSetup:     func(store *stubTokenRevocationStore) { store.err = assert.AnError },
+			expectedStatus: http.StatusInternalServerError,
+			expectedError:  "Internal server error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 失効ストアの設定
+			store := newStubTokenRevocationStore()
+			tt.storeSetup(store)
+
+			// トークンの生成
+			token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+				"user_id":       1,
+				"sid":           "session-1",
+				"token_version": tt.tokenVersion,
+				"jti":           tt.jti,
+				"exp":           time.Now().Add(time.Hour).Unix(),
+				"iat":           time.Now().Unix(),
+			})
+			tokenString, _ := token.SignedString([]byte(testJWTSecret))
+
+			// Echoの設定
+			e := echo.New()
+
+			// テスト用のハンドラー
+			handler := func(c echo.Context) error {
+				claims := GetClaimsFromContext(c)
+				assert.Equal(t, tt.jti, claims.ID)
+				assert.Equal(t, "session-1", claims.SessionID)
+				return c.String(http.StatusOK, "success")
+			}
+
+			// リクエストの作成
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.Header.Set("Authorization", "Bearer "+tokenString)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			// テスト実行
+			err := AuthMiddleware(testTokenValidation, store, nil)(handler)(c)
+
+			// アサーション
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedError != "" {
+				var response map[string]interface{}
+				json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.Equal(t, tt.expectedError, response["detail"])
+			}
+		})
+	}
+}
+
+func TestAuthMiddleware_RevocationCheckTimeout(t *testing.T) {
+	// 失効情報の取得がタイムアウトした場合はエラーハンドラーに委ねる
+	store := newStubTokenRevocationStore()
+	store.err = fmt.Errorf("check revocation: %w", context.DeadlineExceeded)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": 1,
+		"jti":     "jti-1",
+		"exp":     time.Now().Add(time.Hour).Unix(),
+	})
+	tokenString, _ := token.SignedString([]byte(testJWTSecret))
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("Authorization", "Bearer "+tokenString)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+
+	handler := func(c echo.Context) error {
+		t.Fatal("handler should not be called")
+		return nil
+	}
+	err := AuthMiddleware(testTokenValidation, store, nil)(handler)(c)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.False(t, c.Response().Committed)
+}
+
+// stubTokenMetrics は、拒否の理由を保持するテスト用のTokenMetricsです
+type stubTokenMetrics struct {
+	reasons []string
+}
+
+func (s *stubTokenMetrics) TokenRejected(reason string) {
+	s.reasons = append(s.reasons, reason)
+}
+
+func TestAuthMiddleware_RecordsRejections(t *testing.T) {
+	store := newStubTokenRevocationStore()
+	store.revoked["revoked-jti"] = true
+	metrics := &stubTokenMetrics{}
+	middleware := AuthMiddleware(testTokenValidation, store, metrics)
+
+	revoked := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": 1,
+		"jti":     "revoked-jti",
+		"exp":     time.Now().Add(time.Hour).Unix(),
+	})
+	revokedToken, _ := revoked.SignedString([]byte(testJWTSecret))
+
+	for _, authHeader := range []string{"", "Basic abc", "Bearer invalid", "Bearer " + revokedToken} {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		if authHeader != "" {
+			req.Header.Set("Authorization", authHeader)
+		}
+		c := echo.New().NewContext(req, httptest.NewRecorder())
+
+		assert.NoError(t, middleware(func(c echo.Context) error { return nil })(c))
+	}
+
+	assert.Equal(t, []string{"authorization_required", "invalid_authorization_header", "invalid_token", "token_revoked"}, metrics.reasons)
+}
+
+// stubTokenKeySet は、テスト用の非対称鍵のTokenKeySetです
+type stubTokenKeySet struct {
+	keys []*model.TokenKey // 先頭が署名に使用する鍵
+}
+
+func (s *stubTokenKeySet) SigningKey() *model.TokenKey {
+	return s.keys[0]
+}
+
+func (s *stubTokenKeySet) VerificationKey(kid string) (*model.TokenKey, bool) {
+	for _, key := range s.keys {
+		if key.ID == kid {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+func (s *stubTokenKeySet) PublicKeys() []*model.TokenKey {
+	return s.keys
+}
+
+func TestAuthMiddleware_AsymmetricKeys(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	// 署名に使用するRSAの鍵と、ローテーション前のEd25519の鍵を検証に使用する
+	keys := &stubTokenKeySet{keys: []*model.TokenKey{
+		{ID: "current", Algorithm: model.TokenAlgorithmRS256, PrivateKey: rsaKey, PublicKey: &rsaKey.PublicKey},
+		{ID: "previous", Algorithm: model.TokenAlgorithmEdDSA, PublicKey: edPublic},
+	}}
+	validation := TokenValidation{Keys: keys, Issuer: "voice-link", Audience: "voice-link"}
+
+	validClaims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"user_id": 1,
+			"iss":     "voice-link",
+			"aud":     []string{"voice-link", "media-relay"},
+			"exp":     time.Now().Add(time.Hour).Unix(),
+		}
+	}
+	sign := func(method jwt.SigningMethod, kid string, key interface{}, claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(method, claims)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString(key)
+		assert.NoError(t, err)
+		return signed
+	}
+
+	withClaim := func(name string, value interface{}) jwt.MapClaims {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name           string
+		token          string
+		expectedStatus int
+	}{
+		{
+			name:           "署名に使用する鍵のトークン",
+			token:          sign(jwt.SigningMethodRS256, "current", rsaKey, validClaims()),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "ローテーション前の鍵のトークン",
+			token:          sign(jwt.SigningMethodEdDSA, "previous", edPrivate, validClaims()),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "未知のkid",
+			token:          sign(jwt.SigningMethodRS256, "unknown", rsaKey, validClaims()),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "kidなし",
+			token:          sign(jwt.SigningMethodRS256, "", rsaKey, validClaims()),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "鍵と異なるアルゴリズム",
+			token:          sign(jwt.SigningMethodEdDSA, "current", edPrivate, validClaims()),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			// 公開鍵をHMACの秘密鍵として署名したトークンを受け付けない
+			name:           "公開鍵で署名したHS256のトークン",
+			token:          sign(jwt.SigningMethodHS256, "previous", []byte(edPublic), validClaims()),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "共有の秘密鍵で署名したトークン",
+			token:          sign(jwt.SigningMethodHS256, "", []byte(testJWTSecret), validClaims()),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "異なるiss",
+			token:          sign(jwt.SigningMethodRS256, "current", rsaKey, withClaim("iss", "other-service")),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "issなし",
+			token:          sign(jwt.SigningMethodRS256, "current", rsaKey, withClaim("iss", nil)),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "このAPIを含まないaud",
+			token:          sign(jwt.SigningMethodRS256, "current", rsaKey, withClaim("aud", "media-relay")),
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			handler := func(c echo.Context) error {
+				assert.Equal(t, uint(1), GetUserIDFromContext(c))
+				return c.NoContent(http.StatusOK)
+			}
+
+			err := AuthMiddleware(validation, newStubTokenRevocationStore(), nil)(handler)(c)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"net/http"
+	"voice-link/domain/model"
+	"voice-link/interface/handler/common"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RequirePermission は、指定された権限を持つロールのユーザーのみアクセスを許可するミドルウェアです
+// AuthMiddlewareの後に適用する必要があります
+func RequirePermission(permission model.Permission) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			// AuthMiddlewareで設定されたロールを取得
+			role := GetRoleFromContext(c)
+			if !role.HasPermission(permission) {
+				return common.SendProblem(c, http.StatusForbidden, common.CodeInsufficientPermissions, "Insufficient permissions")
+			}
+
+			return next(c)
+		}
+	}
+}
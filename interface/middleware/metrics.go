@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// unmatchedRoute は、どのルートにも一致しなかったリクエストを記録する際のルート名です
+// 存在しないパスごとに系列が増えないよう、パスの代わりにこの値を使用します
+const unmatchedRoute = "unmatched"
+
+// RequestMetrics は、HTTPリクエストの件数と処理時間を記録する先です
+type RequestMetrics interface {
+	// ObserveRequest は、リクエストの処理結果を記録します
+	// routeには/api/v1/users/:idのようなルートのテンプレートを指定します
+	ObserveRequest(method, route string, status int, duration time.Duration)
+}
+
+// TokenMetrics は、AuthMiddlewareでトークンを拒否した件数を記録する先です
+type TokenMetrics interface {
+	// TokenRejected は、トークンを拒否したことを記録します。reasonにはエラーコードを指定します
+	TokenRejected(reason string)
+}
+
+// Metrics は、リクエストごとのメソッド、ルート、ステータスコード、処理時間を記録するミドルウェアです
+// skipPathsに一致するパスのリクエストは記録しません
+func Metrics(metrics RequestMetrics, skipPaths ...string) echo.MiddlewareFunc {
+	skip := make(map[string]bool, len(skipPaths))
+	for _, path := range skipPaths {
+		skip[path] = true
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if skip[c.Request().URL.Path] {
+				return next(c)
+			}
+
+			start := time.Now()
+			err := next(c)
+			if err != nil {
+				// エラーレスポンスのステータスコードを記録するため、ここでエラーハンドラーを呼び出す
+				c.Error(err)
+			}
+
+			route := c.Path()
+			if route == "" || route == "/*" {
+				route = unmatchedRoute
+			}
+			metrics.ObserveRequest(c.Request().Method, route, c.Response().Status, time.Since(start))
+
+			return err
+		}
+	}
+}
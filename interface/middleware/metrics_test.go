@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"voice-link/interface/handler/common"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// observedRequest は、記録されたリクエストです
+type observedRequest struct {
+	method string
+	route  string
+	status int
+}
+
+// stubRequestMetrics は、記録されたリクエストを保持するテスト用のRequestMetricsです
+type stubRequestMetrics struct {
+	requests []observedRequest
+}
+
+func (s *stubRequestMetrics) ObserveRequest(method, route string, status int, duration time.Duration) {
+	s.requests = append(s.requests, observedRequest{method, route, status})
+}
+
+func TestMetrics(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		expected []observedRequest
+	}{
+		{
+			name:     "ルートのテンプレートで記録する",
+			method:   http.MethodGet,
+			path:     "/users/42",
+			expected: []observedRequest{{http.MethodGet, "/users/:id", http.StatusOK}},
+		},
+		{
+			name:     "エラーレスポンスのステータスコードを記録する",
+			method:   http.MethodDelete,
+			path:     "/users/42",
+			expected: []observedRequest{{http.MethodDelete, "/users/:id", http.StatusNotFound}},
+		},
+		{
+			name:     "存在しないパスはまとめて記録する",
+			method:   http.MethodGet,
+			path:     "/no/such/path",
+			expected: []observedRequest{{http.MethodGet, unmatchedRoute, http.StatusNotFound}},
+		},
+		{
+			name:     "除外したパスは記録しない",
+			method:   http.MethodGet,
+			path:     "/metrics",
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := &stubRequestMetrics{}
+
+			e := echo.New()
+			e.HTTPErrorHandler = common.HTTPErrorHandler
+			e.Use(Metrics(metrics, "/metrics"))
+			e.GET("/users/:id", func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+			e.DELETE("/users/:id", func(c echo.Context) error {
+				return common.NewHTTPError(http.StatusNotFound, "user_not_found", "user not found")
+			})
+			e.GET("/metrics", func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+			assert.Equal(t, tt.expected, metrics.requests)
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"log/slog"
+	"voice-link/domain/model"
+	"voice-link/interface/handler/common"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RateLimit は、クライアントのIPアドレスとルートごとにリクエストの回数を制限するミドルウェアです
+// 制限を超えた場合は、429 Too Many Requestsと再試行できるようになるまでの秒数をRetry-Afterヘッダーで返します
+// 回数の記録先に問題がある場合は、利用者が操作できなくならないようログに記録して許可します
+// IPアドレスはecho.Context.RealIPで取得するため、プロキシの背後で運用する場合はEcho.IPExtractorを設定してください
+func RateLimit(limiter model.RateLimiter, limit model.RateLimit) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx := c.Request().Context()
+			key := "ip:" + c.RealIP() + ":" + c.Request().Method + ":" + c.Path()
+
+			result, err := limiter.Allow(ctx, key, limit)
+			if err != nil {
+				slog.ErrorContext(ctx, "failed to check rate limit; allowing request", "error", err)
+				return next(c)
+			}
+			if !result.Allowed {
+				common.SetRetryAfter(c, result.RetryAfter)
+				return common.ErrTooManyRequests
+			}
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"voice-link/domain/model"
+	"voice-link/interface/handler/common"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubRateLimiter は、キーごとの試行回数を数え、limitを超えると拒否するテスト用のRateLimiterです
+type stubRateLimiter struct {
+	counts map[string]int
+	err    error
+}
+
+func (l *stubRateLimiter) Allow(ctx context.Context, key string, limit model.RateLimit) (model.RateLimitResult, error) {
+	if l.err != nil {
+		return model.RateLimitResult{}, l.err
+	}
+	l.counts[key]++
+	if l.counts[key] > limit.Limit {
+		return model.RateLimitResult{Allowed: false, RetryAfter: 1500 * time.Millisecond}, nil
+	}
+	return model.RateLimitResult{Allowed: true}, nil
+}
+
+func TestRateLimit(t *testing.T) {
+	limit := model.RateLimit{Limit: 2, Window: time.Minute}
+
+	// serve は、指定したIPアドレスからのリクエストをミドルウェアに通し、レスポンスを返します
+	serve := func(limiter model.RateLimiter, ip string) *httptest.ResponseRecorder {
+		e := echo.New()
+		e.HTTPErrorHandler = common.HTTPErrorHandler
+		e.POST("/auth/login", func(c echo.Context) error {
+			return c.NoContent(http.StatusOK)
+		}, RateLimit(limiter, limit))
+
+		req := httptest.NewRequest(http.MethodPost, "/auth/login", nil)
+		req.RemoteAddr = ip + ":12345"
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("上限を超えると429を返す", func(t *testing.T) {
+		limiter := &stubRateLimiter{counts: map[string]int{}}
+		assert.Equal(t, http.StatusOK, serve(limiter, "192.0.2.1").Code)
+		assert.Equal(t, http.StatusOK, serve(limiter, "192.0.2.1").Code)
+
+		rec := serve(limiter, "192.0.2.1")
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		// 再試行までの秒数は切り上げる
+		assert.Equal(t, "2", rec.Header().Get(echo.HeaderRetryAfter))
+		assert.Contains(t, rec.Body.String(), common.CodeTooManyRequests)
+
+		// 他のIPアドレスからのリクエストは制限しない
+		assert.Equal(t, http.StatusOK, serve(limiter, "192.0.2.2").Code)
+		assert.Contains(t, limiter.counts, "ip:192.0.2.1:POST:/auth/login")
+	})
+
+	t.Run("回数の記録に失敗した場合は許可する", func(t *testing.T) {
+		limiter := &stubRateLimiter{err: errors.New("connection refused")}
+		assert.Equal(t, http.StatusOK, serve(limiter, "192.0.2.1").Code)
+	})
+}
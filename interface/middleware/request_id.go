@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"regexp"
+	"voice-link/logging"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/random"
+	"go.opentelemetry.io/otel/baggage"
+)
+
+// requestIDBaggageKey は、下流のサービスにリクエストIDを伝えるW3C Baggageのキーです
+const requestIDBaggageKey = "request.id"
+
+// validRequestID は、クライアントから受け取ったリクエストIDとして使用できる値に一致します
+// ログへの改行や制御文字の混入を防ぐため、英数字と一部の記号のみ許可します
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:\-]{1,128}$`)
+
+// RequestID は、リクエストごとにIDを発行するミドルウェアです
+// クライアントがX-Request-IDヘッダーで有効な値を指定した場合はその値を引き継ぎます
+// IDはレスポンスヘッダー、リクエストのコンテキスト（ログ）、W3C Baggage（下流への呼び出し）に設定します
+func RequestID() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			requestID := c.Request().Header.Get(echo.HeaderXRequestID)
+			if !validRequestID.MatchString(requestID) {
+				requestID = random.String(32)
+			}
+			c.Response().Header().Set(echo.HeaderXRequestID, requestID)
+
+			ctx := logging.WithRequestID(c.Request().Context(), requestID)
+			if member, err := baggage.NewMember(requestIDBaggageKey, requestID); err == nil {
+				if bag, err := baggage.FromContext(ctx).SetMember(member); err == nil {
+					ctx = baggage.ContextWithBaggage(ctx, bag)
+				}
+			}
+			c.SetRequest(c.Request().WithContext(ctx))
+
+			return next(c)
+		}
+	}
+}
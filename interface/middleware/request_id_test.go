@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"voice-link/logging"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/baggage"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		expectSame bool
+	}{
+		{
+			name:       "指定がない場合は発行する",
+			header:     "",
+			expectSame: false,
+		},
+		{
+			name:       "有効なIDは引き継ぐ",
+			header:     "upstream-4bf92f3577b34da6",
+			expectSame: true,
+		},
+		{
+			name:       "改行を含むIDは使用しない",
+			header:     "abc\ninjected",
+			expectSame: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var contextID, baggageID string
+			handler := RequestID()(func(c echo.Context) error {
+				contextID = logging.RequestIDFromContext(c.Request().Context())
+				baggageID = baggage.FromContext(c.Request().Context()).Member(requestIDBaggageKey).Value()
+				return c.NoContent(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			if tt.header != "" {
+				req.Header.Set(echo.HeaderXRequestID, tt.header)
+			}
+			rec := httptest.NewRecorder()
+
+			assert.NoError(t, handler(echo.New().NewContext(req, rec)))
+
+			responseID := rec.Header().Get(echo.HeaderXRequestID)
+			assert.NotEmpty(t, responseID)
+			assert.Equal(t, responseID, contextID)
+			assert.Equal(t, responseID, baggageID)
+			if tt.expectSame {
+				assert.Equal(t, tt.header, responseID)
+			} else {
+				assert.NotEqual(t, tt.header, responseID)
+				assert.Len(t, responseID, 32)
+			}
+		})
+	}
+}
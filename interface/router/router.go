@@ -1,34 +1,123 @@
 package router
 
 import (
+	"log/slog"
+	"net"
+	"net/http"
+	"voice-link/domain/model"
 	"voice-link/interface/handler/auth"
+	"voice-link/interface/handler/common"
+	"voice-link/interface/handler/health"
+	"voice-link/interface/handler/jwks"
 	"voice-link/interface/handler/user"
+	"voice-link/interface/i18n"
 	authMiddleware "voice-link/interface/middleware"
+	"voice-link/interface/validator"
 
 	"github.com/labstack/echo/v4"
 	echoMiddleware "github.com/labstack/echo/v4/middleware"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
 )
 
+// 監視用のエンドポイントのパスです
+const (
+	metricsPath   = "/metrics"
+	livenessPath  = "/healthz"
+	readinessPath = "/readyz"
+)
+
+// jwksPath は、アクセストークンの署名の公開鍵を公開するパスです
+const jwksPath = "/.well-known/jwks.json"
+
+// Config は、ルーティングとミドルウェアの設定を定義します
+type Config struct {
+	TokenValidation authMiddleware.TokenValidation // アクセストークンの署名の検証に使用する鍵と、要求するiss、aud
+	BodyLimit       string                         // リクエストボディの最大サイズ（例: 1M）。空の場合は制限しない
+	RequestMetrics  authMiddleware.RequestMetrics  // HTTPリクエストの記録先。nilの場合は記録しない
+	TokenMetrics    authMiddleware.TokenMetrics    // 拒否したトークンの記録先。nilの場合は記録しない
+	MetricsHandler  http.Handler                   // /metricsで公開するハンドラー。nilの場合は公開しない
+	ServiceName     string                         // トレースに記録するサービス名。空の場合はトレースを記録しない
+	Logger          *slog.Logger                   // アクセスログの出力先。nilの場合はslog.Default()
+	RateLimiter     model.RateLimiter              // 試行回数の記録先。nilの場合は制限しない
+	IPRateLimit     model.RateLimit                // IPアドレスとエンドポイントごとの/auth以下へのリクエスト数の上限
+	TrustedProxies  []*net.IPNet                   // X-Forwarded-Forを信頼するプロキシのアドレスの範囲。空の場合は接続元のアドレスを使用する
+}
+
 type Router struct {
-	echo        *echo.Echo
-	authHandler *auth.AuthHandler
-	userHandler *user.UserHandler
+	echo             *echo.Echo
+	authHandler      *auth.AuthHandler
+	userHandler      *user.UserHandler
+	healthHandler    *health.HealthHandler
+	tokenRevocations model.TokenRevocationStore
+	config           Config
 }
 
-func NewRouter(e *echo.Echo, authHandler *auth.AuthHandler, userHandler *user.UserHandler) *Router {
+func NewRouter(e *echo.Echo, authHandler *auth.AuthHandler, userHandler *user.UserHandler, healthHandler *health.HealthHandler, tokenRevocations model.TokenRevocationStore, config Config) *Router {
 	return &Router{
-		echo:        e,
-		authHandler: authHandler,
-		userHandler: userHandler,
+		echo:             e,
+		authHandler:      authHandler,
+		userHandler:      userHandler,
+		healthHandler:    healthHandler,
+		tokenRevocations: tokenRevocations,
+		config:           config,
 	}
 }
 
 func (r *Router) Setup() {
+	// リクエストボディの検証器を登録
+	r.echo.Validator = validator.New()
+	// ハンドラーから返されたエラーをレスポンスに変換するエラーハンドラーを登録
+	r.echo.HTTPErrorHandler = common.HTTPErrorHandler
+
+	// 試行回数の制限やログに使用するクライアントのIPアドレスの取得方法を設定する
+	// X-Forwarded-Forは偽装できるため、信頼するプロキシを経由した場合のみ使用する
+	r.echo.IPExtractor = r.ipExtractor()
+
+	logger := r.config.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
 	// ミドルウェアの設定
-	r.echo.Use(echoMiddleware.Logger())
-	r.echo.Use(echoMiddleware.Recover())
+	if r.config.ServiceName != "" {
+		// 受信したtraceparentヘッダーを引き継いでリクエストのスパンを開始し、
+		// リクエストのコンテキストを通じてユースケースとリポジトリに伝える
+		r.echo.Use(otelecho.Middleware(r.config.ServiceName, otelecho.WithSkipper(isMonitoringRequest)))
+	}
+	// リクエストIDはエラーレスポンスとすべてのログに含めるため、ルーティングや認証より前に発行する
+	r.echo.Use(authMiddleware.RequestID())
+	// エラーレスポンスを含むすべての応答の言語を決定する
+	r.echo.Use(i18n.Middleware())
+	if r.config.RequestMetrics != nil {
+		// メトリクスの取得自体は記録しない
+		r.echo.Use(authMiddleware.Metrics(r.config.RequestMetrics, metricsPath))
+	}
+	r.echo.Use(authMiddleware.AccessLog(logger))
+	r.echo.Use(echoMiddleware.RecoverWithConfig(echoMiddleware.RecoverConfig{
+		LogErrorFunc: func(c echo.Context, err error, stack []byte) error {
+			logger.ErrorContext(c.Request().Context(), "panic recovered", "error", err, "stack", string(stack))
+			return err
+		},
+	}))
+	if r.config.BodyLimit != "" {
+		// 上限を超えるリクエストは413 Request Entity Too Largeで拒否する
+		r.echo.Use(echoMiddleware.BodyLimit(r.config.BodyLimit))
+	}
 	r.echo.Use(echoMiddleware.CORS())
 
+	// ヘルスチェック（認証不要、APIのバージョンに依存しない）
+	// livenessはプロセスの応答のみ、readinessは依存先の状態も確認する
+	r.echo.GET(livenessPath, r.healthHandler.Liveness)
+	r.echo.GET(readinessPath, r.healthHandler.Readiness)
+
+	// Prometheus形式のメトリクス
+	if r.config.MetricsHandler != nil {
+		r.echo.GET(metricsPath, echo.WrapHandler(r.config.MetricsHandler))
+	}
+
+	// アクセストークンを検証する他のサービス向けの公開鍵（認証不要、APIのバージョンに依存しない）
+	r.echo.GET(jwksPath, jwks.NewJWKSHandler(r.config.TokenValidation.Keys).JWKS)
+
 	// APIバージョン1のグループ
 	v1 := r.echo.Group("/api/v1")
 
@@ -39,25 +128,72 @@ func (r *Router) Setup() {
 	r.setupProtectedRoutes(v1)
 }
 
+// ipExtractor は、クライアントのIPアドレスを取得する方法を返します
+// 信頼するプロキシが設定されていない場合は、接続元のアドレスを使用します
+func (r *Router) ipExtractor() echo.IPExtractor {
+	if len(r.config.TrustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+
+	// 既定で信頼されるループバックやプライベートネットワークのアドレスも、明示的に設定された場合のみ信頼する
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, network := range r.config.TrustedProxies {
+		options = append(options, echo.TrustIPRange(network))
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
+
+// isMonitoringRequest は、ヘルスチェックやメトリクスの取得など、トレースを記録しないリクエストかどうかを判定します
+func isMonitoringRequest(c echo.Context) bool {
+	switch c.Request().URL.Path {
+	case livenessPath, readinessPath, metricsPath:
+		return true
+	}
+	return false
+}
+
 func (r *Router) setupPublicRoutes(api *echo.Group) {
 	// 認証関連のルーティング
 	auth := api.Group("/auth")
+	if r.config.RateLimiter != nil && r.config.IPRateLimit.Limit > 0 {
+		// 総当たりや大量のメール送信を防ぐため、IPアドレスとエンドポイントごとにリクエスト数を制限する
+		auth.Use(authMiddleware.RateLimit(r.config.RateLimiter, r.config.IPRateLimit))
+	}
 	{
 		// ユーザー登録
 		auth.POST("/register", r.authHandler.Register)
 		// ログイン
 		auth.POST("/login", r.authHandler.Login)
+		// ログイン時の2段階認証
+		auth.POST("/login/mfa", r.authHandler.VerifyMFA)
+		// トークン更新（リフレッシュトークンのローテーション）
+		auth.POST("/refresh", r.authHandler.RefreshToken)
 		// パスワードリセットリクエスト
 		auth.POST("/password-reset", r.authHandler.RequestPasswordReset)
 		// パスワードリセット確認
 		auth.POST("/password-reset/confirm", r.authHandler.ResetPassword)
+		// メールアドレス確認
+		auth.POST("/verify-email", r.authHandler.VerifyEmail)
+		// メールアドレス確認メールの再送信
+		auth.POST("/verify-email/resend", r.authHandler.ResendVerificationEmail)
+		// ロックされたアカウントのロック解除
+		auth.POST("/unlock", r.authHandler.UnlockAccount)
+		// 外部のプロバイダーのアカウントでのログインの開始とコールバック
+		auth.POST("/oidc/:provider", r.authHandler.BeginOIDCLogin)
+		auth.POST("/oidc/:provider/callback", r.authHandler.CompleteOIDCLogin)
+
+		// ログアウト（認証が必要）
+		requireAuth := authMiddleware.AuthMiddleware(r.config.TokenValidation, r.tokenRevocations, r.config.TokenMetrics)
+		auth.POST("/logout", r.authHandler.Logout, requireAuth)
+		// すべてのセッションからログアウト（認証が必要）
+		auth.POST("/logout-all", r.authHandler.LogoutAll, requireAuth)
 	}
 }
 
 func (r *Router) setupProtectedRoutes(api *echo.Group) {
 	// 認証ミドルウェアを適用
 	protected := api.Group("")
-	protected.Use(authMiddleware.AuthMiddleware())
+	protected.Use(authMiddleware.AuthMiddleware(r.config.TokenValidation, r.tokenRevocations, r.config.TokenMetrics))
 
 	// ユーザー関連のルーティング
 	users := protected.Group("/users")
@@ -68,10 +204,25 @@ func (r *Router) setupProtectedRoutes(api *echo.Group) {
 		users.PUT("/me", r.userHandler.UpdateCurrentUser)
 		// 現在のユーザーの削除
 		users.DELETE("/me", r.userHandler.DeleteCurrentUser)
+		// 2段階認証の登録の開始、確認と解除
+		users.POST("/me/mfa", r.userHandler.BeginMFAEnrollment)
+		users.POST("/me/mfa/confirm", r.userHandler.ConfirmMFAEnrollment)
+		users.POST("/me/mfa/disable", r.userHandler.DisableMFA)
+		// 外部のプロバイダーのアカウントの一覧、連携と連携の解除
+		users.GET("/me/identities", r.userHandler.ListIdentities)
+		users.POST("/me/identities/:provider", r.userHandler.BeginOIDCLink)
+		users.POST("/me/identities/:provider/callback", r.userHandler.CompleteOIDCLink)
+		users.DELETE("/me/identities/:provider", r.userHandler.UnlinkIdentity)
+		// ログインしている端末のセッションの一覧と失効
+		users.GET("/me/sessions", r.userHandler.ListSessions)
+		users.DELETE("/me/sessions/:id", r.userHandler.RevokeSession)
 
-		// 管理者用のルーティング（特定のユーザーIDを指定）
-		users.GET("/:id", r.userHandler.GetUser)
-		users.PUT("/:id", r.userHandler.UpdateUser)
-		users.DELETE("/:id", r.userHandler.DeleteUser)
+		// 管理者用のルーティング（ユーザーの一覧、特定のユーザーIDを指定）
+		// 各操作に対応する権限を持つロールのみアクセス可能
+		users.GET("", r.userHandler.ListUsers, authMiddleware.RequirePermission(model.PermissionUsersRead))
+		users.GET("/:id", r.userHandler.GetUser, authMiddleware.RequirePermission(model.PermissionUsersRead))
+		users.PUT("/:id", r.userHandler.UpdateUser, authMiddleware.RequirePermission(model.PermissionUsersWrite))
+		users.DELETE("/:id", r.userHandler.DeleteUser, authMiddleware.RequirePermission(model.PermissionUsersDelete))
+		users.DELETE("/:id/mfa", r.userHandler.ResetMFA, authMiddleware.RequirePermission(model.PermissionUsersWrite))
 	}
 }
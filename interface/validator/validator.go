@@ -0,0 +1,213 @@
+// package validator は、リクエスト構造体のvalidateタグに基づく入力検証を提供します
+package validator
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// FieldError は、1つのフィールドに対する検証エラーを表します
+// フロントエンドがフォームの入力欄に対応付けられるよう、フィールド名にはJSONのキーを使用します
+type FieldError struct {
+	Field   string `json:"field"`   // フィールド名（JSONのキー）
+	Rule    string `json:"rule"`    // 違反したルール（required、email、minなど）
+	Param   string `json:"-"`       // ルールのパラメーター（min=6の6など）
+	Message string `json:"message"` // エラーメッセージ
+}
+
+// ValidationErrors は、リクエスト全体の検証エラーの一覧です
+type ValidationErrors []FieldError
+
+// Error は、errorインターフェースを実装します
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, fe := range e {
+		messages[i] = fe.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// ruleFunc は、フィールドの値がルールを満たすかどうかを判定する関数です
+type ruleFunc func(value reflect.Value, param string) bool
+
+// messageFunc は、ルール違反時のエラーメッセージを生成する関数です
+type messageFunc func(field, param string) string
+
+// rule は、検証ルールの定義です
+type rule struct {
+	check   ruleFunc
+	message messageFunc
+}
+
+// rules は、validateタグで使用できるルールの一覧です
+// 新しいルールを追加する場合は、ここに定義を追加します
+var rules = map[string]rule{
+	"required": {
+		check: func(value reflect.Value, _ string) bool {
+			return !value.IsZero()
+		},
+		message: func(field, _ string) string {
+			return fmt.Sprintf("%s is required", field)
+		},
+	},
+	"email": {
+		check: func(value reflect.Value, _ string) bool {
+			return isEmail(value.String())
+		},
+		message: func(field, _ string) string {
+			return fmt.Sprintf("%s must be a valid email address", field)
+		},
+	},
+	"min": {
+		check: func(value reflect.Value, param string) bool {
+			n, ok := length(value)
+			limit, err := strconv.Atoi(param)
+			return ok && err == nil && n >= limit
+		},
+		message: func(field, param string) string {
+			return fmt.Sprintf("%s must be at least %s characters", field, param)
+		},
+	},
+	"max": {
+		check: func(value reflect.Value, param string) bool {
+			n, ok := length(value)
+			limit, err := strconv.Atoi(param)
+			return ok && err == nil && n <= limit
+		},
+		message: func(field, param string) string {
+			return fmt.Sprintf("%s must be at most %s characters", field, param)
+		},
+	},
+	"oneof": {
+		check: func(value reflect.Value, param string) bool {
+			for _, candidate := range strings.Fields(param) {
+				if fmt.Sprint(value.Interface()) == candidate {
+					return true
+				}
+			}
+			return false
+		},
+		message: func(field, param string) string {
+			return fmt.Sprintf("%s must be one of [%s]", field, param)
+		},
+	},
+}
+
+// Validator は、echo.Validatorインターフェースを実装する検証器です
+type Validator struct{}
+
+// New は、Validatorの新しいインスタンスを作成します
+func New() *Validator {
+	return &Validator{}
+}
+
+// Validate は、構造体のvalidateタグに従ってフィールドを検証します
+// 検証に失敗した場合はValidationErrorsを返します
+// 未定義のルールが指定されている場合は、実装の誤りとしてそれ以外のエラーを返します
+func (v *Validator) Validate(i interface{}) error {
+	value := reflect.ValueOf(i)
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return fmt.Errorf("validator: cannot validate nil %T", i)
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return fmt.Errorf("validator: expected struct but got %T", i)
+	}
+
+	var errs ValidationErrors
+	typ := value.Type()
+	for idx := 0; idx < typ.NumField(); idx++ {
+		field := typ.Field(idx)
+		tag := field.Tag.Get("validate")
+		if tag == "" || tag == "-" || !field.IsExported() {
+			continue
+		}
+
+		fieldError, err := validateField(fieldName(field), value.Field(idx), tag)
+		if err != nil {
+			return err
+		}
+		if fieldError != nil {
+			errs = append(errs, *fieldError)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validateField は、1つのフィールドをタグに記載された順にルールで検証します
+// 最初に違反したルールのみを返します
+func validateField(name string, value reflect.Value, tag string) (*FieldError, error) {
+	definitions := strings.Split(tag, ",")
+
+	// requiredが指定されていない空のフィールドは検証しない
+	if value.IsZero() && !contains(definitions, "required") {
+		return nil, nil
+	}
+
+	for _, definition := range definitions {
+		ruleName, param, _ := strings.Cut(definition, "=")
+		r, ok := rules[ruleName]
+		if !ok {
+			return nil, fmt.Errorf("validator: unknown rule %q on field %s", ruleName, name)
+		}
+		if !r.check(value, param) {
+			return &FieldError{
+				Field:   name,
+				Rule:    ruleName,
+				Param:   param,
+				Message: r.message(name, param),
+			}, nil
+		}
+	}
+	return nil, nil
+}
+
+// fieldName は、JSONタグからフィールド名を取得します
+func fieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
+
+// length は、文字列の文字数またはスライス・マップの要素数を返します
+func length(value reflect.Value) (int, bool) {
+	switch value.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(value.String()), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return value.Len(), true
+	}
+	return 0, false
+}
+
+// isEmail は、文字列が表示名を含まない単一のメールアドレスかどうかを判定します
+func isEmail(s string) bool {
+	address, err := mail.ParseAddress(s)
+	if err != nil || address.Address != s {
+		return false
+	}
+	_, domain, _ := strings.Cut(address.Address, "@")
+	return domain != "" && !strings.HasPrefix(domain, "[")
+}
+
+// contains は、スライスに指定した値が含まれているかどうかを判定します
+func contains(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
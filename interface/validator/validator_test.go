@@ -0,0 +1,91 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testRequest struct {
+	Name     string `json:"name" validate:"required,max=10"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,min=6"`
+	Language string `json:"language,omitempty" validate:"oneof=ja en"`
+}
+
+func TestValidator_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		request  *testRequest
+		expected ValidationErrors
+	}{
+		{
+			name: "正常なリクエスト",
+			request: &testRequest{
+				Name:     "テストユーザー",
+				Email:    "test@example.com",
+				Password: "password123",
+				Language: "ja",
+			},
+			expected: nil,
+		},
+		{
+			name:    "必須項目が空",
+			request: &testRequest{},
+			expected: ValidationErrors{
+				{Field: "name", Rule: "required", Message: "name is required"},
+				{Field: "email", Rule: "required", Message: "email is required"},
+				{Field: "password", Rule: "required", Message: "password is required"},
+			},
+		},
+		{
+			name: "形式と文字数の違反",
+			request: &testRequest{
+				Name:     "とても長い名前のテストユーザー",
+				Email:    "Test User <test@example.com>",
+				Password: "パスワード",
+				Language: "fr",
+			},
+			expected: ValidationErrors{
+				{Field: "name", Rule: "max", Param: "10", Message: "name must be at most 10 characters"},
+				{Field: "email", Rule: "email", Message: "email must be a valid email address"},
+				{Field: "password", Rule: "min", Param: "6", Message: "password must be at least 6 characters"},
+				{Field: "language", Rule: "oneof", Param: "ja en", Message: "language must be one of [ja en]"},
+			},
+		},
+		{
+			name: "マルチバイト文字は1文字として数える",
+			request: &testRequest{
+				Name:     "山田太郎",
+				Email:    "yamada@example.com",
+				Password: "パスワード123",
+			},
+			expected: nil,
+		},
+	}
+
+	v := New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Validate(tt.request)
+			if tt.expected == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Equal(t, tt.expected, err)
+		})
+	}
+}
+
+func TestValidator_UnknownRule(t *testing.T) {
+	request := &struct {
+		Name string `json:"name" validate:"required,unknown"`
+	}{Name: "test"}
+
+	err := New().Validate(request)
+
+	// 未定義のルールは検証エラーではなく実装の誤りとして扱われる
+	assert.Error(t, err)
+	_, ok := err.(ValidationErrors)
+	assert.False(t, ok)
+}
@@ -0,0 +1,100 @@
+// package logging は、log/slogによるJSON形式の構造化ログを提供します
+// リクエストIDやユーザーID、トレースIDはコンテキストから自動的に付与し、
+// パスワードやトークン、メールアドレス、Authorizationヘッダーは出力する前に伏せ字にします
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ログに付与する属性のキーです
+const (
+	RequestIDKey = "request_id"
+	UserIDKey    = "user_id"
+	TraceIDKey   = "trace_id"
+	SpanIDKey    = "span_id"
+)
+
+// contextKey は、コンテキストに値を保持するためのキーの型です
+type contextKey int
+
+const (
+	requestIDContextKey contextKey = iota
+	userIDContextKey
+)
+
+// WithRequestID は、リクエストIDを保持したコンテキストを返します
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey, requestID)
+}
+
+// RequestIDFromContext は、コンテキストに保持されたリクエストIDを返します
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDContextKey).(string)
+	return requestID
+}
+
+// WithUserID は、認証済みのユーザーIDを保持したコンテキストを返します
+func WithUserID(ctx context.Context, userID uint) context.Context {
+	return context.WithValue(ctx, userIDContextKey, userID)
+}
+
+// UserIDFromContext は、コンテキストに保持されたユーザーIDを返します
+func UserIDFromContext(ctx context.Context) (uint, bool) {
+	userID, ok := ctx.Value(userIDContextKey).(uint)
+	return userID, ok
+}
+
+// New は、JSON形式でwに出力するロガーを作成します
+// *Contextメソッドで出力したログには、コンテキストのリクエストID、ユーザーID、トレースIDを付与します
+func New(w io.Writer, level slog.Leveler) *slog.Logger {
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redact,
+	})
+	return slog.New(&contextHandler{handler})
+}
+
+// ParseLevel は、debug、info、warn、errorのいずれかの文字列をログレベルに変換します
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(s))
+	return level, err
+}
+
+// contextHandler は、コンテキストに保持された値をログに付与するハンドラーです
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle は、コンテキストの値を属性として追加してログを出力します
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		record.AddAttrs(slog.String(RequestIDKey, requestID))
+	}
+	if userID, ok := UserIDFromContext(ctx); ok {
+		record.AddAttrs(slog.Uint64(UserIDKey, uint64(userID)))
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(
+			slog.String(TraceIDKey, spanContext.TraceID().String()),
+			slog.String(SpanIDKey, spanContext.SpanID().String()),
+		)
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs は、属性を追加したハンドラーを返します
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup は、グループを追加したハンドラーを返します
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{h.Handler.WithGroup(name)}
+}
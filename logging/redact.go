@@ -0,0 +1,95 @@
+package logging
+
+import (
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// redacted は、伏せ字にした値の代わりに出力する文字列です
+const redacted = "[REDACTED]"
+
+// sensitiveKeys は、値をすべて伏せ字にする属性のキーです
+// キーは小文字にし、ハイフンをアンダースコアに置き換えて比較します
+var sensitiveKeys = map[string]bool{
+	"password":      true,
+	"authorization": true,
+	"cookie":        true,
+	"set_cookie":    true,
+	"token":         true,
+	"secret":        true,
+}
+
+// sensitiveKeySuffixes は、値をすべて伏せ字にする属性のキーの接尾辞です
+var sensitiveKeySuffixes = []string{"_password", "_token", "_secret"}
+
+// sensitiveHeaders は、伏せ字にするHTTPヘッダーです
+var sensitiveHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization"}
+
+var (
+	// emailPattern は、メッセージやエラーに含まれるメールアドレスに一致します
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@([A-Za-z0-9\-]+\.)+[A-Za-z]{2,}`)
+	// jwtPattern は、JWT（ヘッダー.ペイロード.署名）に一致します
+	jwtPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]*`)
+	// bearerPattern は、Bearerスキームの認証情報に一致します
+	bearerPattern = regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9._~+/\-]+=*`)
+	// opaqueTokenPattern は、リフレッシュトークンやリセットトークンなどの16進数のトークンに一致します
+	// トレースID（32文字）は伏せ字にしないよう、64文字以上のみ対象とします
+	opaqueTokenPattern = regexp.MustCompile(`\b[0-9a-f]{64,}\b`)
+)
+
+// redact は、ログに出力する属性から機密情報を取り除きます
+// slog.HandlerOptions.ReplaceAttrとして、メッセージを含むすべての属性に適用されます
+func redact(groups []string, a slog.Attr) slog.Attr {
+	if isSensitiveKey(a.Key) {
+		return slog.String(a.Key, redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, scrub(a.Value.String()))
+	case slog.KindAny:
+		switch v := a.Value.Any().(type) {
+		case error:
+			return slog.String(a.Key, scrub(v.Error()))
+		case http.Header:
+			return slog.Any(a.Key, redactHeader(v))
+		}
+	}
+
+	return a
+}
+
+// isSensitiveKey は、値をすべて伏せ字にする属性のキーかどうかを判定します
+func isSensitiveKey(key string) bool {
+	key = strings.ReplaceAll(strings.ToLower(key), "-", "_")
+	if sensitiveKeys[key] {
+		return true
+	}
+	for _, suffix := range sensitiveKeySuffixes {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// scrub は、文字列に含まれるメールアドレスやトークンを伏せ字にします
+func scrub(s string) string {
+	s = jwtPattern.ReplaceAllString(s, redacted)
+	s = bearerPattern.ReplaceAllString(s, "Bearer "+redacted)
+	s = opaqueTokenPattern.ReplaceAllString(s, redacted)
+	return emailPattern.ReplaceAllString(s, redacted)
+}
+
+// redactHeader は、認証情報を含むヘッダーを伏せ字にしたコピーを返します
+func redactHeader(header http.Header) http.Header {
+	clone := header.Clone()
+	for _, name := range sensitiveHeaders {
+		if _, ok := clone[name]; ok {
+			clone[name] = []string{redacted}
+		}
+	}
+	return clone
+}
@@ -1,68 +1,501 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
+	"voice-link/config"
 	"voice-link/domain/model"
+	"voice-link/infrastructure/cache"
+	"voice-link/infrastructure/mail"
+	"voice-link/infrastructure/metrics"
+	"voice-link/infrastructure/migration"
+	"voice-link/infrastructure/oidc"
 	"voice-link/infrastructure/persistence"
+	"voice-link/infrastructure/ratelimit"
+	"voice-link/infrastructure/tokenkey"
+	"voice-link/infrastructure/tracing"
+	"voice-link/infrastructure/worker"
 	"voice-link/interface/handler/auth"
+	"voice-link/interface/handler/health"
 	"voice-link/interface/handler/user"
+	"voice-link/interface/middleware"
 	"voice-link/interface/router"
+	"voice-link/logging"
 	"voice-link/usecase"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
+// tokenRevocationCacheTTL は、トークンの失効情報をメモリ上にキャッシュする期間です
+const tokenRevocationCacheTTL = 30 * time.Second
+
+// mailQueueSize は、バックグラウンドで送信するまで保持するメールの件数の上限です
+const mailQueueSize = 1000
+
+// tracingShutdownTimeout は、終了時に未送信のスパンの送信を待つ時間です
+const tracingShutdownTimeout = 5 * time.Second
+
+// slowSQLThreshold は、警告としてログに出力するSQLの実行時間です
+const slowSQLThreshold = 200 * time.Millisecond
+
 func main() {
-	// JWT_SECRETの設定
-	if os.Getenv("JWT_SECRET") == "" {
-		os.Setenv("JWT_SECRET", "your-secret-key-change-in-production")
+	if err := run(); err != nil {
+		slog.Error("application terminated", "error", err)
+		os.Exit(1)
+	}
+}
+
+// run は、アプリケーションを起動し、終了シグナルを受け取るまで実行します
+// 終了時はHTTPサーバー、バックグラウンド処理、データベース接続の順に停止します
+func run() error {
+	// 設定の読み込み
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	// 以降のログはJSON形式で出力し、機密情報は伏せ字にする
+	level, err := logging.ParseLevel(cfg.Log.Level)
+	if err != nil {
+		return fmt.Errorf("invalid log level: %w", err)
+	}
+	logger := logging.New(os.Stdout, level)
+	slog.SetDefault(logger)
+
+	if cfg.UsesDefaultJWTSecret() {
+		slog.Warn("using the default JWT secret; set JWT_SECRET before deploying")
 	}
 
 	// データベース接続
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=Asia/Tokyo",
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_NAME"),
-		os.Getenv("DB_PORT"),
-	)
-
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(cfg.Database.DSN()), &gorm.Config{
+		Logger: persistence.NewLogger(logger, slowSQLThreshold),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	sqlDB, err := db.DB()
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		return fmt.Errorf("failed to get database connection pool: %w", err)
+	}
+	// deferは逆順に実行されるため、データベース接続は最後に閉じられる
+	defer func() {
+		if err := sqlDB.Close(); err != nil {
+			slog.Error("failed to close database connections", "error", err)
+		}
+	}()
+
+	migrator, err := migration.New(db)
+	if err != nil {
+		return fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	// migrateサブコマンドの場合はマイグレーションのみ実行して終了
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrateCommand(migrator, os.Args[2:]); err != nil {
+			return fmt.Errorf("migration failed: %w", err)
+		}
+		return nil
+	}
+
+	// 起動時に未適用のマイグレーションを適用
+	// 複数のレプリカが同時に起動してもロックにより一度だけ適用される
+	if cfg.Database.AutoMigrate {
+		if _, err := migrator.Up(); err != nil {
+			return fmt.Errorf("failed to migrate database: %w", err)
+		}
 	}
 
-	// マイグレーション
-	if err := db.AutoMigrate(&model.User{}); err != nil {
-		log.Fatalf("Failed to migrate database: %v", err)
+	// トレースの送信先の設定
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		SampleRatio: cfg.Tracing.SampleRatio,
+		ServiceName: cfg.Tracing.ServiceName,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %w", err)
+	}
+	// 未送信のスパンは、HTTPサーバーの停止後、データベース接続を閉じる前に送信する
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Error("failed to flush traces", "error", err)
+		}
+	}()
+	// マイグレーションの適用後に登録し、リクエストで実行したSQLのみ記録する
+	if err := db.Use(persistence.NewTracingPlugin()); err != nil {
+		return fmt.Errorf("failed to register tracing plugin: %w", err)
+	}
+	// 時間のかかるマイグレーションを中断しないよう、SQLのタイムアウトもマイグレーションの適用後に設定する
+	if err := db.Use(persistence.NewQueryTimeoutPlugin(cfg.Database.QueryTimeout)); err != nil {
+		return fmt.Errorf("failed to register query timeout plugin: %w", err)
 	}
 
+	// メトリクスの収集
+	appMetrics := metrics.New()
+	appMetrics.RegisterDB(sqlDB, cfg.Database.Name)
+
+	// バックグラウンド処理はHTTPサーバーの停止後、データベース接続を閉じる前に停止する
+	workers := worker.NewGroup()
+
 	// 依存関係の注入
 	userRepo := persistence.NewUserRepository(db)
-	userUseCase := usecase.NewUserUseCase(userRepo)
+	refreshTokenRepo := persistence.NewRefreshTokenRepository(db)
+	sessionRepo := persistence.NewSessionRepository(db)
+	recoveryCodeRepo := persistence.NewRecoveryCodeRepository(db)
+	identityRepo := persistence.NewIdentityRepository(db)
+	oidcAuthRequestRepo := persistence.NewOIDCAuthRequestRepository(db)
+	tokenRevocations := cache.NewTokenRevocationCache(persistence.NewTokenRevocationRepository(db), tokenRevocationCacheTTL)
+	transactions := persistence.NewTransactionManager(db)
+	// メールはリクエストの処理とは別にバックグラウンドで送信する
+	// 停止時はキューに残ったメールを送信してから終了する
+	mailer := mail.NewQueuedMailer(newMailer(cfg.Mail), mailQueueSize)
+	workers.Go("mailer", mailer.Run)
+	rateLimiter, err := newRateLimiter(cfg.RateLimit)
+	if err != nil {
+		return fmt.Errorf("failed to set up rate limiter: %w", err)
+	}
+	if closer, ok := rateLimiter.(io.Closer); ok {
+		defer func() {
+			if err := closer.Close(); err != nil {
+				slog.Error("failed to close rate limiter", "error", err)
+			}
+		}()
+	}
+	tokenKeys, err := newTokenKeys(cfg.Auth)
+	if err != nil {
+		return fmt.Errorf("failed to load token signing keys: %w", err)
+	}
+	oidcProviders, err := newOIDCProviders(cfg.OIDC)
+	if err != nil {
+		return fmt.Errorf("failed to set up oidc providers: %w", err)
+	}
+	trustedProxies, err := cfg.Server.TrustedProxyNetworks()
+	if err != nil {
+		return fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+	userUseCase := usecase.NewUserUseCase(userRepo, refreshTokenRepo, sessionRepo, recoveryCodeRepo, identityRepo, oidcAuthRequestRepo, tokenRevocations, transactions, mailer, appMetrics, usecase.UserUseCaseConfig{
+		FrontendURL:              cfg.FrontendURL,
+		RequireEmailVerification: cfg.Auth.RequireEmailVerification,
+		JWTSecret:                cfg.Auth.JWTSecret,
+		TokenKeys:                tokenKeys,
+		TokenIssuer:              cfg.Auth.JWTIssuer,
+		TokenAudience:            cfg.Auth.JWTAudience,
+		DeletionGracePeriod:      cfg.Account.DeletionGracePeriod,
+		Lockout: usecase.LockoutPolicy{
+			DelayAfter:   cfg.Auth.Lockout.DelayAfter,
+			BaseDelay:    cfg.Auth.Lockout.BaseDelay,
+			MaxDelay:     cfg.Auth.Lockout.MaxDelay,
+			LockAfter:    cfg.Auth.Lockout.Threshold,
+			LockDuration: cfg.Auth.Lockout.Duration,
+		},
+		MFAIssuer:        cfg.Auth.MFAIssuer,
+		MFAEncryptionKey: cfg.Auth.MFAEncryptionKey,
+		RateLimiter:      rateLimiter,
+		LoginRateLimit:   model.RateLimit(cfg.RateLimit.Login),
+		EmailRateLimit:   model.RateLimit(cfg.RateLimit.Email),
+		OIDCProviders:    oidcProviders,
+	})
 	authHandler := auth.NewAuthHandler(userUseCase)
 	userHandler := user.NewUserHandler(userUseCase)
 
+	// 猶予期間を過ぎた退会済みのユーザーを定期的に完全に削除する
+	workers.Go("purge-deleted-users", func(ctx context.Context) {
+		runPeriodically(ctx, cfg.Account.PurgeInterval, func(ctx context.Context) {
+			purgeDeletedUsers(ctx, userUseCase)
+		})
+	})
+	// 有効期限を過ぎたトークンの失効情報、リフレッシュトークン、セッションを定期的に削除する
+	workers.Go("purge-expired-tokens", func(ctx context.Context) {
+		runPeriodically(ctx, cfg.Account.PurgeInterval, func(ctx context.Context) {
+			purgeExpiredTokens(ctx, userUseCase)
+		})
+	})
+
+	// レディネスチェックで確認する依存先
+	// メールの送信に失敗してもリクエストは処理できるため、メールサーバーは重要な依存先としない
+	// 試行回数の記録先に問題がある場合も制限せずに処理するため、重要な依存先としない
+	healthHandler := health.NewHealthHandler()
+	healthHandler.Register("database", true, persistence.NewDatabaseHealthChecker(db))
+	healthHandler.Register("migrations", true, migrator)
+	healthHandler.Register("mailer", false, mailer)
+	if checker, ok := rateLimiter.(model.HealthChecker); ok {
+		healthHandler.Register("rate_limiter", false, checker)
+	}
+
 	// Echoのインスタンスを作成
 	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+	e.Server.ReadTimeout = cfg.Server.ReadTimeout
+	e.Server.WriteTimeout = cfg.Server.WriteTimeout
+	e.Server.IdleTimeout = cfg.Server.IdleTimeout
 
 	// ルーティングの設定
-	r := router.NewRouter(e, authHandler, userHandler)
+	r := router.NewRouter(e, authHandler, userHandler, healthHandler, tokenRevocations, router.Config{
+		TokenValidation: middleware.TokenValidation{
+			Keys:     tokenKeys,
+			Issuer:   cfg.Auth.JWTIssuer,
+			Audience: cfg.Auth.JWTAudience[0],
+		},
+		BodyLimit:      cfg.Server.BodyLimit,
+		RequestMetrics: appMetrics,
+		TokenMetrics:   appMetrics,
+		MetricsHandler: appMetrics.Handler(),
+		ServiceName:    cfg.Tracing.ServiceName,
+		Logger:         logger,
+		RateLimiter:    rateLimiter,
+		IPRateLimit:    model.RateLimit(cfg.RateLimit.IP),
+		TrustedProxies: trustedProxies,
+	})
 	r.Setup()
 
+	// SIGINTまたはSIGTERM（docker stopなど）を受け取ったらシャットダウンを開始する
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// サーバーの起動
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("server is starting", "port", cfg.Server.Port, "env", cfg.Env)
+		serverErr <- e.Start(fmt.Sprintf(":%d", cfg.Server.Port))
+	}()
+
+	select {
+	case err := <-serverErr:
+		// 起動に失敗した場合もバックグラウンド処理を停止してから終了する
+		if stopErr := shutdown(e, workers, cfg.Server.ShutdownTimeout); stopErr != nil {
+			slog.Error("shutdown failed", "error", stopErr)
+		}
+		return fmt.Errorf("failed to start server: %w", err)
+	case <-ctx.Done():
+	}
+
+	// 以降のシグナルは既定の動作に戻し、2回目のシグナルで強制終了できるようにする
+	stop()
+	slog.Info("shutting down; waiting for in-flight requests", "timeout", cfg.Server.ShutdownTimeout.String())
+	if err := shutdown(e, workers, cfg.Server.ShutdownTimeout); err != nil {
+		return err
+	}
+
+	slog.Info("server stopped")
+	return nil
+}
+
+// shutdown は、新しいリクエストの受け付けを停止して処理中のリクエストの完了を待ち、
+// その後バックグラウンド処理を停止します。全体でtimeoutを超えた場合はエラーを返します
+func shutdown(e *echo.Echo, workers *worker.Group, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var errs []error
+	if err := e.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("failed to shut down http server: %w", err))
+	}
+	if err := workers.Stop(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// runPeriodically は、ctxが取り消されるまでintervalごとにtaskを実行します
+// 起動直後にも一度実行します
+func runPeriodically(ctx context.Context, interval time.Duration, task func(ctx context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		task(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeDeletedUsers は、猶予期間を過ぎた退会済みのユーザーを完全に削除します
+// 失敗した場合はログに記録し、次の実行で再試行します
+func purgeDeletedUsers(ctx context.Context, userUseCase usecase.UserUseCase) {
+	purged, err := userUseCase.PurgeDeletedUsers(ctx)
+	if err != nil && ctx.Err() == nil {
+		slog.ErrorContext(ctx, "failed to purge deleted users", "purged", purged, "error", err)
+	} else if purged > 0 {
+		slog.InfoContext(ctx, "purged deleted users", "purged", purged)
+	}
+}
+
+// purgeExpiredTokens は、有効期限を過ぎたトークンの失効情報、リフレッシュトークン、セッションを削除します
+// 失敗した場合はログに記録し、次の実行で再試行します
+func purgeExpiredTokens(ctx context.Context, userUseCase usecase.UserUseCase) {
+	purged, err := userUseCase.PurgeExpiredTokens(ctx)
+	if err != nil && ctx.Err() == nil {
+		slog.ErrorContext(ctx, "failed to purge expired tokens", "purged", purged, "error", err)
+	} else if purged > 0 {
+		slog.InfoContext(ctx, "purged expired tokens", "purged", purged)
+	}
+}
+
+// runMigrateCommand は、migrateサブコマンドを実行します
+//
+//	migrate up          未適用のマイグレーションをすべて適用
+//	migrate down [N]    適用済みのマイグレーションを新しいものからN件（省略時は1件）取り消し
+//	migrate status      マイグレーションの適用状況を表示
+func runMigrateCommand(migrator *migration.Migrator, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: migrate up|down [N]|status")
+	}
+
+	switch args[0] {
+	case "up":
+		count, err := migrator.Up()
+		if err != nil {
+			return err
+		}
+		slog.Info("migrations applied", "count", count)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid number of migrations to roll back: %s", args[1])
+			}
+			steps = n
+		}
+		count, err := migrator.Down(steps)
+		if err != nil {
+			return err
+		}
+		slog.Info("migrations rolled back", "count", count)
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			switch {
+			case status.Missing:
+				state = "applied at " + status.AppliedAt.Format(time.RFC3339) + " (missing in this binary)"
+			case status.AppliedAt != nil:
+				state = "applied at " + status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%06d_%s\t%s\n", status.Version, status.Name, state)
+		}
+	default:
+		return fmt.Errorf("unknown migrate command: %s", args[0])
 	}
 
-	log.Printf("Server is starting on port %s", port)
-	if err := e.Start(fmt.Sprintf(":%s", port)); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	return nil
+}
+
+// newMailer は、設定に応じてメール送信の実装を作成します
+// smtpを指定した場合はSMTPサーバー経由で送信し、それ以外の場合はファイルに書き出します
+func newMailer(cfg config.MailConfig) model.Mailer {
+	if cfg.Mailer == config.MailerSMTP {
+		return mail.NewSMTPMailer(mail.SMTPConfig{
+			Host:     cfg.SMTP.Host,
+			Port:     cfg.SMTP.Port,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+			From:     cfg.From,
+		})
+	}
+
+	return mail.NewOutboxMailer(cfg.OutboxDir, cfg.From)
+}
+
+// newTokenKeys は、アクセストークンの署名に使用する鍵を作成します
+// 秘密鍵のファイルが設定されていない場合は、JWT_SECRETによるHS256の署名を使用します
+func newTokenKeys(cfg config.AuthConfig) (model.TokenKeySet, error) {
+	if cfg.JWTSigningKeyFile == "" {
+		return model.NewHMACTokenKeySet(cfg.JWTSecret), nil
+	}
+
+	keys, err := tokenkey.Load(cfg.JWTSigningKeyFile, cfg.JWTVerificationKeyFiles)
+	if err != nil {
+		return nil, err
+	}
+	signingKey := keys.SigningKey()
+	slog.Info("loaded token signing keys", "algorithm", signingKey.Algorithm, "kid", signingKey.ID, "verification_keys", len(keys.PublicKeys()))
+	return keys, nil
+}
+
+// newOIDCProviders は、クライアントIDが設定された外部のプロバイダーをプロバイダー名ごとに作成します
+// プロバイダーとの通信は初回のログイン時に行うため、起動時にはプロバイダーに接続しません
+func newOIDCProviders(cfg config.OIDCConfig) (map[string]model.OIDCProvider, error) {
+	providers := make(map[string]model.OIDCProvider)
+
+	if cfg.Google.ClientID != "" {
+		providers[config.OIDCProviderGoogle] = oidc.NewProvider(oidc.Config{
+			Issuer:       oidc.GoogleIssuer,
+			ClientID:     cfg.Google.ClientID,
+			ClientSecret: cfg.Google.ClientSecret,
+			RedirectURL:  cfg.ProviderRedirectURL(config.OIDCProviderGoogle),
+		})
+	}
+
+	if cfg.Apple.ClientID != "" {
+		pem, err := os.ReadFile(cfg.Apple.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read apple private key: %w", err)
+		}
+		key, err := jwt.ParseECPrivateKeyFromPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse apple private key: %w", err)
+		}
+		// Appleはprofileスコープに対応しておらず、メールアドレスを要求する場合はform_postで認可コードを返す
+		providers[config.OIDCProviderApple] = oidc.NewProvider(oidc.Config{
+			Issuer:           oidc.AppleIssuer,
+			ClientID:         cfg.Apple.ClientID,
+			RedirectURL:      cfg.ProviderRedirectURL(config.OIDCProviderApple),
+			Scopes:           []string{"openid", "email", "name"},
+			ResponseMode:     oidc.ResponseModeFormPost,
+			ClientSecretFunc: oidc.AppleClientSecret(cfg.Apple.TeamID, cfg.Apple.KeyID, cfg.Apple.ClientID, key),
+		})
+	}
+
+	if cfg.Generic.ClientID != "" {
+		providers[cfg.Generic.Name] = oidc.NewProvider(oidc.Config{
+			Issuer:       cfg.Generic.Issuer,
+			ClientID:     cfg.Generic.ClientID,
+			ClientSecret: cfg.Generic.ClientSecret,
+			RedirectURL:  cfg.ProviderRedirectURL(cfg.Generic.Name),
+		})
 	}
+
+	return providers, nil
+}
+
+// newRateLimiter は、設定に応じて試行回数の記録先を作成します
+// redisを指定した場合は複数のインスタンスで回数を共有し、それ以外の場合はインスタンスのメモリに記録します
+func newRateLimiter(cfg config.RateLimitConfig) (model.RateLimiter, error) {
+	if cfg.Backend == config.RateLimitBackendRedis {
+		return ratelimit.NewRedisLimiter(ratelimit.RedisConfig{
+			Addr:      cfg.Redis.Addr,
+			Username:  cfg.Redis.Username,
+			Password:  cfg.Redis.Password,
+			DB:        cfg.Redis.DB,
+			TLS:       cfg.Redis.TLS,
+			TLSCAFile: cfg.Redis.TLSCAFile,
+		})
+	}
+
+	return ratelimit.NewMemoryLimiter(model.SystemClock), nil
 }
@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+	"voice-link/domain/model"
+)
+
+const (
+	// defaultDeletionGracePeriod は、退会後にログインで復元できる既定の期間です
+	defaultDeletionGracePeriod = 30 * 24 * time.Hour
+	// purgeBatchSize は、完全に削除するユーザーを一度に検索する件数です
+	purgeBatchSize = 100
+)
+
+// isRestorable は、退会済みのユーザーが猶予期間中で復元できるかどうかを判定します
+func (u *userUseCase) isRestorable(user *model.User) bool {
+	return user.DeletedAt != nil && u.config.Clock.Now().Sub(*user.DeletedAt) < u.config.DeletionGracePeriod
+}
+
+// restoreUser は、退会済みのユーザーを復元します
+// 同時に完全に削除された場合は、存在しないユーザーとしてログインを拒否します
+func (u *userUseCase) restoreUser(ctx context.Context, user *model.User) error {
+	err := u.userRepo.Restore(ctx, user.ID)
+	if errors.Is(err, model.ErrNotFound) {
+		return ErrInvalidEmailOrPassword
+	}
+	if err != nil {
+		return err
+	}
+
+	user.DeletedAt = nil
+	slog.InfoContext(ctx, "restored deleted user on login", "user_id", user.ID)
+	return nil
+}
+
+// PurgeDeletedUsers は、猶予期間を過ぎた退会済みのユーザーとそのリフレッシュトークン、セッション、トークンの失効情報、リカバリーコード、連携したアカウントを完全に削除し、削除した件数を返します
+// 完全に削除した後は、同じメールアドレスで新しく登録できるようになります
+func (u *userUseCase) PurgeDeletedUsers(ctx context.Context) (purged int, err error) {
+	ctx, span := startSpan(ctx, "UserUseCase.PurgeDeletedUsers")
+	defer func() { endSpan(span, err) }()
+
+	deletedBefore := u.config.Clock.Now().Add(-u.config.DeletionGracePeriod)
+	for {
+		ids, err := u.userRepo.FindDeletedBefore(ctx, deletedBefore, purgeBatchSize)
+		if err != nil {
+			return purged, err
+		}
+
+		for _, id := range ids {
+			err := u.transactions.WithinTransaction(ctx, func(ctx context.Context) error {
+				if err := u.userRepo.Purge(ctx, id, deletedBefore); err != nil {
+					return err
+				}
+				if err := u.recoveryCodeRepo.DeleteAllByUserID(ctx, id); err != nil {
+					return err
+				}
+				if err := u.identityRepo.DeleteAllByUserID(ctx, id); err != nil {
+					return err
+				}
+				if err := u.refreshTokenRepo.DeleteAllByUserID(ctx, id); err != nil {
+					return err
+				}
+				if err := u.sessionRepo.DeleteAllByUserID(ctx, id); err != nil {
+					return err
+				}
+				return u.tokenRevocations.DeleteAllByUserID(ctx, id)
+			})
+			// 検索した後にログインで復元されたユーザーは削除しない
+			if errors.Is(err, model.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return purged, err
+			}
+			purged++
+		}
+
+		if len(ids) < purgeBatchSize {
+			return purged, nil
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"voice-link/domain/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestUserUseCase_LoginRestoresDeletedUser(t *testing.T) {
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	config := testUserUseCaseConfig
+	config.DeletionGracePeriod = 7 * 24 * time.Hour
+	config.Clock = &fakeClock{now: now}
+
+	// deletedUser は、指定した時間だけ前に退会したユーザーを返します
+	deletedUser := func(ago time.Duration) *model.User {
+		deletedAt := now.Add(-ago)
+		return &model.User{ID: 1, Email: "test@example.com", Password: string(hashedPassword), DeletedAt: &deletedAt}
+	}
+
+	t.Run("猶予期間中はログインで退会を取り消す", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockTokenRepo := new(MockRefreshTokenRepository)
+		mockRevocations := new(MockTokenRevocationStore)
+		mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(deletedUser(24*time.Hour), nil)
+		mockRepo.On("Restore", mock.Anything, uint(1)).Return(nil)
+		mockRevocations.On("GetUserTokenVersion", mock.Anything, uint(1)).Return(uint(1), nil)
+		mockSessions := new(MockSessionRepository)
+		mockSessions.On("Create", mock.Anything, mock.AnythingOfType("*model.Session")).Return(nil)
+		mockTokenRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.RefreshToken")).Return(nil)
+		useCase := NewUserUseCase(mockRepo, mockTokenRepo, mockSessions, new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), mockRevocations, new(stubTransactionManager), new(MockMailer), nil, config)
+
+		result, err := useCase.Login(context.Background(), "test@example.com", "password123", ClientInfo{})
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, result.Tokens.AccessToken)
+		mockRepo.AssertExpectations(t)
+		mockTokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("パスワードが間違っている場合は復元しない", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(deletedUser(24*time.Hour), nil)
+		mockRepo.On("RecordLoginFailure", mock.Anything, uint(1), mock.Anything, mock.Anything).Return(1, nil)
+		useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, config)
+
+		_, err := useCase.Login(context.Background(), "test@example.com", "wrongpassword", ClientInfo{})
+
+		assert.ErrorIs(t, err, ErrInvalidEmailOrPassword)
+		mockRepo.AssertNotCalled(t, "Restore", mock.Anything, mock.Anything)
+	})
+
+	t.Run("猶予期間を過ぎたユーザーはログインできない", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(deletedUser(config.DeletionGracePeriod), nil)
+		useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, config)
+
+		_, err := useCase.Login(context.Background(), "test@example.com", "password123", ClientInfo{})
+
+		assert.ErrorIs(t, err, ErrInvalidEmailOrPassword)
+		mockRepo.AssertNotCalled(t, "Restore", mock.Anything, mock.Anything)
+	})
+
+	t.Run("同時に完全に削除された場合はログインできない", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(deletedUser(24*time.Hour), nil)
+		mockRepo.On("Restore", mock.Anything, uint(1)).Return(model.ErrNotFound)
+		useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, config)
+
+		_, err := useCase.Login(context.Background(), "test@example.com", "password123", ClientInfo{})
+
+		assert.ErrorIs(t, err, ErrInvalidEmailOrPassword)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestUserUseCase_DeletedUserCannotRequestEmails(t *testing.T) {
+	deletedAt := time.Now().Add(-time.Hour)
+	user := &model.User{ID: 1, Email: "test@example.com", DeletedAt: &deletedAt}
+
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(user, nil)
+	mockMailer := new(MockMailer)
+	useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), mockMailer, nil, testUserUseCaseConfig)
+
+	// 存在しないユーザーと同じく成功を返し、メールは送信しない
+	assert.NoError(t, useCase.RequestPasswordReset(context.Background(), "test@example.com"))
+	assert.NoError(t, useCase.ResendVerificationEmail(context.Background(), "test@example.com"))
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	mockMailer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+}
+
+func TestUserUseCase_PurgeDeletedUsers(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	config := testUserUseCaseConfig
+	config.DeletionGracePeriod = 24 * time.Hour
+	config.Clock = &fakeClock{now: now}
+
+	// 猶予期間の開始が現在から猶予期間を引いた日時であること
+	isDeletedBefore := now.Add(-config.DeletionGracePeriod)
+
+	t.Run("猶予期間を過ぎたユーザーとリフレッシュトークン、セッション、トークンの失効情報、リカバリーコード、連携したアカウントを削除する", func(t *testing.T) {
+		firstBatch := make([]uint, purgeBatchSize)
+		for i := range firstBatch {
+			firstBatch[i] = uint(i + 1)
+		}
+
+		mockRepo := new(MockUserRepository)
+		mockTokenRepo := new(MockRefreshTokenRepository)
+		// 件数が上限に達した場合は続けて検索する
+		mockRepo.On("FindDeletedBefore", mock.Anything, isDeletedBefore, purgeBatchSize).Return(firstBatch, nil).Once()
+		mockRepo.On("FindDeletedBefore", mock.Anything, isDeletedBefore, purgeBatchSize).Return([]uint{1000, 1001}, nil).Once()
+		// 検索した後に復元されたユーザーは削除しない
+		mockRepo.On("Purge", mock.Anything, uint(1001), isDeletedBefore).Return(model.ErrNotFound)
+		mockRepo.On("Purge", mock.Anything, mock.Anything, isDeletedBefore).Return(nil)
+		mockTokenRepo.On("DeleteAllByUserID", mock.Anything, mock.Anything).Return(nil)
+		mockRecoveryCodeRepo := new(MockRecoveryCodeRepository)
+		mockRecoveryCodeRepo.On("DeleteAllByUserID", mock.Anything, mock.Anything).Return(nil)
+		mockIdentityRepo := new(MockIdentityRepository)
+		mockIdentityRepo.On("DeleteAllByUserID", mock.Anything, mock.Anything).Return(nil)
+		mockSessions := new(MockSessionRepository)
+		mockSessions.On("DeleteAllByUserID", mock.Anything, mock.Anything).Return(nil)
+		mockRevocations := new(MockTokenRevocationStore)
+		mockRevocations.On("DeleteAllByUserID", mock.Anything, mock.Anything).Return(nil)
+		transactions := new(stubTransactionManager)
+		useCase := NewUserUseCase(mockRepo, mockTokenRepo, mockSessions, mockRecoveryCodeRepo, mockIdentityRepo, new(MockOIDCAuthRequestRepository), mockRevocations, transactions, new(MockMailer), nil, config)
+
+		purged, err := useCase.PurgeDeletedUsers(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, purgeBatchSize+1, purged)
+		// ユーザーごとにトランザクションで削除する
+		assert.Equal(t, purgeBatchSize+2, transactions.calls)
+		mockRepo.AssertExpectations(t)
+		mockTokenRepo.AssertNumberOfCalls(t, "DeleteAllByUserID", purgeBatchSize+1)
+		mockTokenRepo.AssertNotCalled(t, "DeleteAllByUserID", mock.Anything, uint(1001))
+		mockRecoveryCodeRepo.AssertNumberOfCalls(t, "DeleteAllByUserID", purgeBatchSize+1)
+		mockIdentityRepo.AssertNumberOfCalls(t, "DeleteAllByUserID", purgeBatchSize+1)
+		mockSessions.AssertNumberOfCalls(t, "DeleteAllByUserID", purgeBatchSize+1)
+		mockRevocations.AssertNumberOfCalls(t, "DeleteAllByUserID", purgeBatchSize+1)
+	})
+
+	t.Run("削除に失敗した場合はそれまでの件数とエラーを返す", func(t *testing.T) {
+		errDatabase := errors.New("database error")
+		mockRepo := new(MockUserRepository)
+		mockTokenRepo := new(MockRefreshTokenRepository)
+		mockRepo.On("FindDeletedBefore", mock.Anything, isDeletedBefore, purgeBatchSize).Return([]uint{1, 2}, nil)
+		mockRepo.On("Purge", mock.Anything, uint(1), isDeletedBefore).Return(nil)
+		mockRepo.On("Purge", mock.Anything, uint(2), isDeletedBefore).Return(errDatabase)
+		mockTokenRepo.On("DeleteAllByUserID", mock.Anything, uint(1)).Return(nil)
+		mockRecoveryCodeRepo := new(MockRecoveryCodeRepository)
+		mockRecoveryCodeRepo.On("DeleteAllByUserID", mock.Anything, uint(1)).Return(nil)
+		mockIdentityRepo := new(MockIdentityRepository)
+		mockIdentityRepo.On("DeleteAllByUserID", mock.Anything, uint(1)).Return(nil)
+		mockSessions := new(MockSessionRepository)
+		mockSessions.On("DeleteAllByUserID", mock.Anything, uint(1)).Return(nil)
+		mockRevocations := new(MockTokenRevocationStore)
+		mockRevocations.On("DeleteAllByUserID", mock.Anything, uint(1)).Return(nil)
+		useCase := NewUserUseCase(mockRepo, mockTokenRepo, mockSessions, mockRecoveryCodeRepo, mockIdentityRepo, new(MockOIDCAuthRequestRepository), mockRevocations, new(stubTransactionManager), new(MockMailer), nil, config)
+
+		purged, err := useCase.PurgeDeletedUsers(context.Background())
+
+		assert.ErrorIs(t, err, errDatabase)
+		assert.Equal(t, 1, purged)
+	})
+}
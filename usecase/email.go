@@ -0,0 +1,79 @@
+package usecase
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"net/url"
+	texttemplate "text/template"
+	"voice-link/domain/model"
+)
+
+//go:embed templates/*.tmpl
+var emailTemplateFS embed.FS
+
+var (
+	textEmailTemplates = texttemplate.Must(texttemplate.ParseFS(emailTemplateFS, "templates/*.txt.tmpl"))
+	htmlEmailTemplates = htmltemplate.Must(htmltemplate.ParseFS(emailTemplateFS, "templates/*.html.tmpl"))
+)
+
+// emailSubjects は、メールの種類と言語ごとの件名です
+var emailSubjects = map[string]map[model.Language]string{
+	"password_reset": {
+		model.LanguageJapanese: "【Voice Link】パスワード再設定のご案内",
+		model.LanguageEnglish:  "[Voice Link] Reset your password",
+	},
+	"email_verification": {
+		model.LanguageJapanese: "【Voice Link】メールアドレスの確認",
+		model.LanguageEnglish:  "[Voice Link] Confirm your email address",
+	},
+	"account_locked": {
+		model.LanguageJapanese: "【Voice Link】アカウントを一時的にロックしました",
+		model.LanguageEnglish:  "[Voice Link] Your account has been temporarily locked",
+	},
+}
+
+// renderEmail は、テンプレートからテキストとHTMLの本文を持つメールを作成します
+// 指定された言語のテンプレートが存在しない場合は既定の言語を使用します
+func renderEmail(to, name string, lang model.Language, data interface{}) (*model.Mail, error) {
+	subjects, ok := emailSubjects[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown email template: %s", name)
+	}
+	if _, ok := subjects[lang]; !ok {
+		lang = model.DefaultLanguage
+	}
+
+	var text bytes.Buffer
+	if err := textEmailTemplates.ExecuteTemplate(&text, fmt.Sprintf("%s.%s.txt.tmpl", name, lang), data); err != nil {
+		return nil, err
+	}
+
+	var html bytes.Buffer
+	if err := htmlEmailTemplates.ExecuteTemplate(&html, fmt.Sprintf("%s.%s.html.tmpl", name, lang), data); err != nil {
+		return nil, err
+	}
+
+	return &model.Mail{
+		To:       to,
+		Subject:  subjects[lang],
+		TextBody: text.String(),
+		HTMLBody: html.String(),
+	}, nil
+}
+
+// buildFrontendURL は、フロントエンドのURLにパスとトークンのクエリを付与したリンクを作成します
+func buildFrontendURL(baseURL, path, token string) (string, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", err
+	}
+
+	u = u.JoinPath(path)
+	query := u.Query()
+	query.Set("token", token)
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
@@ -0,0 +1,95 @@
+package usecase
+
+import (
+	"strings"
+	"testing"
+	"voice-link/domain/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderEmail(t *testing.T) {
+	data := map[string]interface{}{
+		"Name":             "<テスト>",
+		"ResetURL":         "http://localhost:3000/reset-password?token=abc",
+		"ExpiresInMinutes": 60,
+	}
+
+	tests := []struct {
+		name            string
+		lang            model.Language
+		expectedSubject string
+		expectedText    string
+	}{
+		{
+			name:            "日本語",
+			lang:            "ja",
+			expectedSubject: "【Voice Link】パスワード再設定のご案内",
+			expectedText:    "このリンクの有効期限は60分です。",
+		},
+		{
+			name:            "英語",
+			lang:            "en",
+			expectedSubject: "[Voice Link] Reset your password",
+			expectedText:    "This link expires in 60 minutes.",
+		},
+		{
+			name:            "未対応の言語は既定の言語を使用",
+			lang:            "fr",
+			expectedSubject: "【Voice Link】パスワード再設定のご案内",
+			expectedText:    "このリンクの有効期限は60分です。",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// テスト実行
+			mail, err := renderEmail("test@example.com", "password_reset", tt.lang, data)
+
+			// アサーション
+			assert.NoError(t, err)
+			assert.Equal(t, "test@example.com", mail.To)
+			assert.Equal(t, tt.expectedSubject, mail.Subject)
+			assert.Contains(t, mail.TextBody, tt.expectedText)
+			assert.Contains(t, mail.TextBody, "http://localhost:3000/reset-password?token=abc")
+			// HTML本文ではユーザー入力がエスケープされること
+			assert.Contains(t, mail.HTMLBody, "&lt;テスト&gt;")
+			assert.False(t, strings.Contains(mail.HTMLBody, "<テスト>"))
+		})
+	}
+}
+
+func TestRenderEmail_UnknownTemplate(t *testing.T) {
+	mail, err := renderEmail("test@example.com", "unknown", "ja", nil)
+
+	assert.Error(t, err)
+	assert.Nil(t, mail)
+}
+
+func TestBuildFrontendURL(t *testing.T) {
+	tests := []struct {
+		name        string
+		baseURL     string
+		expectedURL string
+	}{
+		{
+			name:        "ホストのみ",
+			baseURL:     "http://localhost:3000",
+			expectedURL: "http://localhost:3000/reset-password?token=a%2Bb",
+		},
+		{
+			name:        "パスと末尾のスラッシュ付き",
+			baseURL:     "https://app.example.com/voice/",
+			expectedURL: "https://app.example.com/voice/reset-password?token=a%2Bb",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link, err := buildFrontendURL(tt.baseURL, "/reset-password", "a+b")
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedURL, link)
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+	"voice-link/domain/model"
+)
+
+// emailVerificationTTL は、メールアドレス確認トークンの有効期間です
+const emailVerificationTTL = 24 * time.Hour
+
+// prepareEmailVerification は、メールアドレス確認用のトークンを生成してユーザーに設定します
+// ユーザーの保存は呼び出し元で行います
+func (u *userUseCase) prepareEmailVerification(user *model.User) (string, error) {
+	token, err := generateSecureToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	expires := u.config.Clock.Now().Add(emailVerificationTTL)
+	user.EmailVerificationToken = &token
+	user.EmailVerificationExpires = &expires
+
+	return token, nil
+}
+
+// sendVerificationEmail は、確認用のリンクを記載したメールを指定されたアドレスに送信します
+// 送信に失敗した場合でもユーザーは再送信できるため、ログに記録するのみとします
+func (u *userUseCase) sendVerificationEmail(ctx context.Context, user *model.User, to, token string) {
+	verifyURL, err := buildFrontendURL(u.config.FrontendURL, "/verify-email", token)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to build verification url", "user_id", user.ID, "error", err)
+		return
+	}
+
+	mail, err := renderEmail(to, "email_verification", user.PreferredLanguage, map[string]interface{}{
+		"Name":           user.Name,
+		"VerifyURL":      verifyURL,
+		"ExpiresInHours": int(emailVerificationTTL.Hours()),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to render verification email", "user_id", user.ID, "error", err)
+		return
+	}
+
+	if err := u.mailer.Send(ctx, mail); err != nil {
+		slog.ErrorContext(ctx, "failed to send verification email", "user_id", user.ID, "error", err)
+	}
+}
+
+// VerifyEmail は、メールアドレス確認トークンを使用してメールアドレスを確認済みにします
+// メールアドレスの変更待ちの場合は、確認が完了した時点で新しいアドレスに変更します
+func (u *userUseCase) VerifyEmail(ctx context.Context, token string) (err error) {
+	ctx, span := startSpan(ctx, "UserUseCase.VerifyEmail")
+	defer func() { endSpan(span, err) }()
+
+	return u.transactions.WithinTransaction(ctx, func(ctx context.Context) error {
+		// トークンでユーザーを検索
+		user, err := u.userRepo.FindByEmailVerificationToken(ctx, token)
+		if errors.Is(err, model.ErrNotFound) {
+			return ErrInvalidVerificationToken
+		}
+		if err != nil {
+			return err
+		}
+
+		// トークンの有効期限をチェック
+		if user.EmailVerificationExpires == nil || u.config.Clock.Now().After(*user.EmailVerificationExpires) {
+			return ErrVerificationTokenExpired
+		}
+
+		// 確認待ちのメールアドレスがある場合は変更を反映
+		if user.PendingEmail != nil {
+			// 確認待ちの間に他のユーザーが同じアドレスを登録している可能性がある
+			existingUser, err := u.userRepo.FindByEmail(ctx, *user.PendingEmail)
+			if err == nil && existingUser.ID != user.ID {
+				return ErrEmailAlreadyExists
+			}
+			if err != nil && !errors.Is(err, model.ErrNotFound) {
+				return err
+			}
+
+			user.Email = *user.PendingEmail
+			user.PendingEmail = nil
+		}
+
+		// 確認済みにして、トークンをクリア
+		now := u.config.Clock.Now()
+		user.EmailVerifiedAt = &now
+		user.EmailVerificationToken = nil
+		user.EmailVerificationExpires = nil
+
+		// 確認と同時に他のユーザーが同じアドレスを登録した場合は、一意制約の違反として検出される
+		return translateDuplicateEmail(u.userRepo.Update(ctx, user))
+	})
+}
+
+// ResendVerificationEmail は、メールアドレス確認用のメールを再送信します
+// 確認待ちの変更後アドレスがある場合はそのアドレスに、未確認の場合は現在のアドレスに送信します
+func (u *userUseCase) ResendVerificationEmail(ctx context.Context, email string) (err error) {
+	ctx, span := startSpan(ctx, "UserUseCase.ResendVerificationEmail")
+	defer func() { endSpan(span, err) }()
+
+	// 任意のアドレスに大量のメールを送信できないよう、メールアドレスごとの回数を制限する
+	if err := u.checkRateLimit(ctx, rateLimitKey("verification_email", email), u.config.EmailRateLimit, ErrTooManyEmailRequests); err != nil {
+		return err
+	}
+
+	var (
+		user      *model.User
+		to, token string
+	)
+	err = u.transactions.WithinTransaction(ctx, func(ctx context.Context) error {
+		// ユーザーが存在するかチェック
+		found, err := u.userRepo.FindByEmail(ctx, email)
+		if errors.Is(err, model.ErrNotFound) {
+			// セキュリティ上の理由で、ユーザーが存在しない場合でも成功を返す
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		// 退会済みのユーザーも存在しない場合と同じく成功を返す
+		if found.IsDeleted() {
+			return nil
+		}
+
+		if found.PendingEmail != nil {
+			to = *found.PendingEmail
+		} else if found.EmailVerifiedAt == nil {
+			to = found.Email
+		} else {
+			// 確認済みの場合は何もしない
+			return nil
+		}
+		user = found
+
+		// 新しいトークンを発行し、以前のトークンは無効にする
+		if token, err = u.prepareEmailVerification(user); err != nil {
+			return err
+		}
+
+		return u.userRepo.Update(ctx, user)
+	})
+	if err != nil || user == nil {
+		return err
+	}
+
+	u.sendVerificationEmail(ctx, user, to, token)
+
+	return nil
+}
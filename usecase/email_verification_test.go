@@ -0,0 +1,257 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"voice-link/domain/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestUserUseCase_VerifyEmail(t *testing.T) {
+	// 期限はシステムの時刻ではなく、時刻の取得元の時刻で判定する
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	config := testUserUseCaseConfig
+	config.Clock = &fakeClock{now: now}
+
+	tests := []struct {
+		name          string
+		tokenInput    string
+		mockSetup     func(*MockUserRepository)
+		expectedEmail string
+		expectedError error
+	}{
+		{
+			name:       "登録時のメールアドレス確認",
+			tokenInput: "valid-token",
+			mockSetup: func(mockRepo *MockUserRepository) {
+				token := "valid-token"
+				expires := now.Add(time.Hour)
+				user := &model.User{
+					ID:                       1,
+					Email:                    "test@example.com",
+					EmailVerificationToken:   &token,
+					EmailVerificationExpires: &expires,
+				}
+				mockRepo.On("FindByEmailVerificationToken", mock.Anything, "valid-token").Return(user, nil)
+				mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(u *model.User) bool {
+					return u.EmailVerifiedAt != nil && u.EmailVerificationToken == nil && u.Email == "test@example.com"
+				})).Return(nil)
+			},
+			expectedError: nil,
+		},
+		{
+			name:       "メールアドレス変更の確認",
+			tokenInput: "change-token",
+			mockSetup: func(mockRepo *MockUserRepository) {
+				token := "change-token"
+				pending := "new@example.com"
+				expires := now.Add(time.Hour)
+				user := &model.User{
+					ID:                       1,
+					Email:                    "old@example.com",
+					PendingEmail:             &pending,
+					EmailVerificationToken:   &token,
+					EmailVerificationExpires: &expires,
+				}
+				mockRepo.On("FindByEmailVerificationToken", mock.Anything, "change-token").Return(user, nil)
+				mockRepo.On("FindByEmail", mock.Anything, "new@example.com").Return(nil, model.ErrNotFound)
+				// 確認が完了した時点で新しいアドレスに変更されること
+				mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(u *model.User) bool {
+					return u.Email == "new@example.com" && u.PendingEmail == nil && u.EmailVerifiedAt != nil
+				})).Return(nil)
+			},
+			expectedError: nil,
+		},
+		{
+			name:       "確認待ちの間に他のユーザーが登録したアドレス",
+			tokenInput: "conflict-token",
+			mockSetup: func(mockRepo *MockUserRepository) {
+				token := "conflict-token"
+				pending := "taken@example.com"
+				expires := now.Add(time.Hour)
+				user := &model.User{
+					ID:                       1,
+					Email:                    "old@example.com",
+					PendingEmail:             &pending,
+					EmailVerificationToken:   &token,
+					EmailVerificationExpires: &expires,
+				}
+				mockRepo.On("FindByEmailVerificationToken", mock.Anything, "conflict-token").Return(user, nil)
+				mockRepo.On("FindByEmail", mock.Anything, "taken@example.com").Return(&model.User{ID: 2}, nil)
+			},
+			expectedError: errors.New("email already exists"),
+		},
+		{
+			name:       "確認と同時に他のユーザーが登録したアドレス",
+			tokenInput: "race-token",
+			mockSetup: func(mockRepo *MockUserRepository) {
+				token := "race-token"
+				pending := "race@example.com"
+				expires := now.Add(time.Hour)
+				user := &model.User{
+					ID:                       1,
+					Email:                    "old@example.com",
+					PendingEmail:             &pending,
+					EmailVerificationToken:   &token,
+					EmailVerificationExpires: &expires,
+				}
+				mockRepo.On("FindByEmailVerificationToken", mock.Anything, "race-token").Return(user, nil)
+				mockRepo.On("FindByEmail", mock.Anything, "race@example.com").Return(nil, model.ErrNotFound)
+				// 一意制約の違反は重複エラーとして返される
+				mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*model.User")).Return(model.ErrDuplicateEmail)
+			},
+			expectedError: errors.New("email already exists"),
+		},
+		{
+			name:       "無効なトークン",
+			tokenInput: "invalid-token",
+			mockSetup: func(mockRepo *MockUserRepository) {
+				mockRepo.On("FindByEmailVerificationToken", mock.Anything, "invalid-token").Return(nil, model.ErrNotFound)
+			},
+			expectedError: errors.New("invalid or expired verification token"),
+		},
+		{
+			name:       "期限切れトークン",
+			tokenInput: "expired-token",
+			mockSetup: func(mockRepo *MockUserRepository) {
+				token := "expired-token"
+				expires := now.Add(-time.Hour)
+				user := &model.User{
+					ID:                       1,
+					EmailVerificationToken:   &token,
+					EmailVerificationExpires: &expires,
+				}
+				mockRepo.On("FindByEmailVerificationToken", mock.Anything, "expired-token").Return(user, nil)
+			},
+			expectedError: errors.New("verification token has expired"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// モックの設定
+			mockRepo := new(MockUserRepository)
+			tt.mockSetup(mockRepo)
+
+			// ユースケースの作成
+			useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, config)
+
+			// テスト実行
+			err := useCase.VerifyEmail(context.Background(), tt.tokenInput)
+
+			// アサーション
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserUseCase_ResendVerificationEmail(t *testing.T) {
+	tests := []struct {
+		name       string
+		emailInput string
+		mockSetup  func(*MockUserRepository, *MockMailer)
+	}{
+		{
+			name:       "未確認のユーザー",
+			emailInput: "test@example.com",
+			mockSetup: func(mockRepo *MockUserRepository, mockMailer *MockMailer) {
+				user := &model.User{ID: 1, Name: "テストユーザー", Email: "test@example.com"}
+				mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(user, nil)
+				mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(u *model.User) bool {
+					return u.EmailVerificationToken != nil
+				})).Return(nil)
+				mockMailer.On("Send", mock.Anything, mock.MatchedBy(func(mail *model.Mail) bool {
+					return mail.To == "test@example.com"
+				})).Return(nil)
+			},
+		},
+		{
+			name:       "メールアドレス変更の確認待ち",
+			emailInput: "old@example.com",
+			mockSetup: func(mockRepo *MockUserRepository, mockMailer *MockMailer) {
+				verifiedAt := time.Now()
+				pending := "new@example.com"
+				user := &model.User{ID: 1, Email: "old@example.com", EmailVerifiedAt: &verifiedAt, PendingEmail: &pending}
+				mockRepo.On("FindByEmail", mock.Anything, "old@example.com").Return(user, nil)
+				mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*model.User")).Return(nil)
+				// 変更後のアドレスに送信されること
+				mockMailer.On("Send", mock.Anything, mock.MatchedBy(func(mail *model.Mail) bool {
+					return mail.To == "new@example.com"
+				})).Return(nil)
+			},
+		},
+		{
+			name:       "確認済みのユーザー",
+			emailInput: "verified@example.com",
+			mockSetup: func(mockRepo *MockUserRepository, mockMailer *MockMailer) {
+				verifiedAt := time.Now()
+				user := &model.User{ID: 1, Email: "verified@example.com", EmailVerifiedAt: &verifiedAt}
+				mockRepo.On("FindByEmail", mock.Anything, "verified@example.com").Return(user, nil)
+			},
+		},
+		{
+			name:       "存在しないユーザー",
+			emailInput: "nonexistent@example.com",
+			mockSetup: func(mockRepo *MockUserRepository, mockMailer *MockMailer) {
+				mockRepo.On("FindByEmail", mock.Anything, "nonexistent@example.com").Return(nil, model.ErrNotFound)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// モックの設定
+			mockRepo := new(MockUserRepository)
+			mockMailer := new(MockMailer)
+			tt.mockSetup(mockRepo, mockMailer)
+
+			// ユースケースの作成
+			useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), mockMailer, nil, testUserUseCaseConfig)
+
+			// テスト実行とアサーション
+			assert.NoError(t, useCase.ResendVerificationEmail(context.Background(), tt.emailInput))
+
+			mockRepo.AssertExpectations(t)
+			mockMailer.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserUseCase_LoginRequiresEmailVerification(t *testing.T) {
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	user := &model.User{
+		ID:       1,
+		Email:    "test@example.com",
+		Password: string(hashedPassword),
+	}
+
+	// モックの設定
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(user, nil)
+
+	// メールアドレス確認を必須にしたユースケースの作成
+	config := testUserUseCaseConfig
+	config.RequireEmailVerification = true
+	useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, config)
+
+	// テスト実行
+	result, err := useCase.Login(context.Background(), "test@example.com", "password123", ClientInfo{})
+
+	// アサーション
+	assert.Error(t, err)
+	assert.Equal(t, "email address is not verified", err.Error())
+	assert.Nil(t, result)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,120 @@
+package usecase
+
+import (
+	"errors"
+	"time"
+)
+
+// エラーの種別を表すセンチネルエラーです
+// 呼び出し側はerrors.Isで種別を判定し、HTTPステータスコードなどに対応付けます
+var (
+	// ErrNotFound は、対象のリソースが存在しないことを表します
+	ErrNotFound = errors.New("not found")
+	// ErrConflict は、既存のリソースと競合することを表します
+	ErrConflict = errors.New("conflict")
+	// ErrInvalidCredentials は、認証情報またはトークンが正しくないことを表します
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrExpired は、トークンなどの有効期限が切れていることを表します
+	ErrExpired = errors.New("expired")
+	// ErrValidation は、入力値がビジネスルールを満たしていないことを表します
+	ErrValidation = errors.New("validation failed")
+	// ErrTooManyRequests は、試行の回数の制限を超えたため、しばらく待つ必要があることを表します
+	ErrTooManyRequests = errors.New("too many requests")
+)
+
+// Error は、種別と安定したエラーコード、クライアントに返却できるメッセージを持つユースケースのエラーです
+// Errorメソッドはメッセージのみを返し、errors.Isでは種別と一致します
+type Error struct {
+	kind    error
+	code    string
+	message string
+}
+
+// newError は、指定された種別、エラーコード、メッセージのエラーを作成します
+func newError(kind error, code, message string) *Error {
+	return &Error{kind: kind, code: code, message: message}
+}
+
+// Error は、errorインターフェースを実装します
+func (e *Error) Error() string {
+	return e.message
+}
+
+// Code は、クライアントが判定に使用する機械可読なエラーコードを返します
+// メッセージと異なり、一度公開したコードは変更しません
+func (e *Error) Code() string {
+	return e.code
+}
+
+// Unwrap は、エラーの種別を返します
+func (e *Error) Unwrap() error {
+	return e.kind
+}
+
+// RetryAfterError は、ユースケースのエラーに再試行できるようになるまでの時間を付加したエラーです
+// errors.Isとerrors.Asでは元のエラーと一致します
+type RetryAfterError struct {
+	err        *Error
+	retryAfter time.Duration
+}
+
+// WithRetryAfter は、errにretryAfterを付加したエラーを作成します
+func WithRetryAfter(err *Error, retryAfter time.Duration) *RetryAfterError {
+	return &RetryAfterError{err: err, retryAfter: retryAfter}
+}
+
+// Error は、errorインターフェースを実装します
+func (e *RetryAfterError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap は、元のエラーを返します
+func (e *RetryAfterError) Unwrap() error {
+	return e.err
+}
+
+// RetryAfter は、再試行できるようになるまでの時間を返します
+func (e *RetryAfterError) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
+// ユースケースが返すエラーです
+var (
+	ErrUserNotFound             = newError(ErrNotFound, "user_not_found", "user not found")
+	ErrEmailAlreadyExists       = newError(ErrConflict, "email_already_exists", "email already exists")
+	ErrUnsupportedLanguage      = newError(ErrValidation, "unsupported_language", "unsupported language")
+	ErrInvalidEmailOrPassword   = newError(ErrInvalidCredentials, "invalid_credentials", "invalid email or password")
+	ErrEmailNotVerified         = newError(ErrInvalidCredentials, "email_not_verified", "email address is not verified")
+	ErrInvalidRefreshToken      = newError(ErrInvalidCredentials, "invalid_refresh_token", "invalid refresh token")
+	ErrRefreshTokenReused       = newError(ErrInvalidCredentials, "refresh_token_reused", "refresh token reuse detected")
+	ErrRefreshTokenExpired      = newError(ErrExpired, "refresh_token_expired", "refresh token has expired")
+	ErrInvalidResetToken        = newError(ErrValidation, "invalid_reset_token", "invalid or expired reset token")
+	ErrResetTokenExpired        = newError(ErrExpired, "reset_token_expired", "reset token has expired")
+	ErrInvalidVerificationToken = newError(ErrValidation, "invalid_verification_token", "invalid or expired verification token")
+	ErrVerificationTokenExpired = newError(ErrExpired, "verification_token_expired", "verification token has expired")
+	ErrInvalidSort              = newError(ErrValidation, "invalid_sort", "unsupported sort field or order")
+	ErrInvalidPageLimit         = newError(ErrValidation, "invalid_page_limit", "limit must be between 1 and 100")
+	ErrInvalidCursor            = newError(ErrValidation, "invalid_cursor", "invalid cursor")
+	ErrInvalidRoleFilter        = newError(ErrValidation, "invalid_role_filter", "unsupported role")
+	ErrInvalidCreatedRange      = newError(ErrValidation, "invalid_created_range", "created_from must be before created_to")
+	ErrTooManyLoginAttempts     = newError(ErrTooManyRequests, "too_many_login_attempts", "too many login attempts, please try again later")
+	ErrAccountLocked            = newError(ErrTooManyRequests, "account_locked", "account is temporarily locked due to too many failed login attempts")
+	ErrTooManyEmailRequests     = newError(ErrTooManyRequests, "too_many_email_requests", "too many email requests, please try again later")
+	ErrInvalidUnlockToken       = newError(ErrValidation, "invalid_unlock_token", "invalid or expired unlock token")
+	ErrUnlockTokenExpired       = newError(ErrExpired, "unlock_token_expired", "unlock token has expired")
+	ErrInvalidMFAToken          = newError(ErrInvalidCredentials, "invalid_mfa_token", "invalid or expired MFA token")
+	ErrInvalidMFACode           = newError(ErrInvalidCredentials, "invalid_mfa_code", "invalid authentication code")
+	ErrMFACodeMismatch          = newError(ErrValidation, "mfa_code_mismatch", "authentication code does not match")
+	ErrMFAAlreadyEnabled        = newError(ErrConflict, "mfa_already_enabled", "two-factor authentication is already enabled")
+	ErrMFANotEnabled            = newError(ErrConflict, "mfa_not_enabled", "two-factor authentication is not enabled")
+	ErrMFAEnrollmentNotStarted  = newError(ErrConflict, "mfa_enrollment_not_started", "two-factor authentication enrollment has not been started")
+	ErrUnknownOIDCProvider      = newError(ErrNotFound, "oidc_provider_not_found", "unsupported login provider")
+	ErrInvalidOIDCState         = newError(ErrValidation, "invalid_oidc_state", "invalid or expired authorization state")
+	ErrOIDCLoginFailed          = newError(ErrInvalidCredentials, "oidc_login_failed", "failed to verify the login with the provider")
+	ErrOIDCEmailRequired        = newError(ErrValidation, "oidc_email_required", "the provider did not share an email address")
+	ErrOIDCEmailAlreadyExists   = newError(ErrConflict, "oidc_email_already_exists", "an account with this email already exists; log in and link the provider from your account settings")
+	ErrIdentityAlreadyLinked    = newError(ErrConflict, "identity_already_linked", "this provider account is already linked")
+	ErrIdentityNotFound         = newError(ErrNotFound, "identity_not_found", "linked account not found")
+	ErrLastLoginMethod          = newError(ErrConflict, "last_login_method", "cannot unlink the only way to log in; set a password first")
+	ErrSessionNotFound          = newError(ErrNotFound, "session_not_found", "session not found")
+)
@@ -0,0 +1,222 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+	"voice-link/domain/model"
+)
+
+// unlockTokenTTL は、ロック解除トークンの有効期間です
+const unlockTokenTTL = 24 * time.Hour
+
+// LockoutPolicy は、ログインの失敗が続いた場合に次の試行まで待機を求め、アカウントをロックする条件です
+// ゼロ値の項目は既定値を使用します
+type LockoutPolicy struct {
+	DelayAfter   int           // この回数連続で失敗すると、次の試行まで待機を求める（既定は3回）
+	BaseDelay    time.Duration // 最初の待機時間。以降は失敗するたびに倍にする（既定は1秒）
+	MaxDelay     time.Duration // 待機時間の上限（既定は1分）
+	LockAfter    int           // この回数連続で失敗すると、アカウントをロックしてロック解除のメールを送信する（既定は10回）
+	LockDuration time.Duration // ロックする期間。この期間失敗がなければ失敗回数を数え直す（既定は30分）
+}
+
+// withDefaults は、ゼロ値の項目に既定値を設定したポリシーを返します
+func (p LockoutPolicy) withDefaults() LockoutPolicy {
+	if p.DelayAfter <= 0 {
+		p.DelayAfter = 3
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = time.Second
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = time.Minute
+	}
+	if p.LockAfter <= 0 {
+		p.LockAfter = 10
+	}
+	if p.LockDuration <= 0 {
+		p.LockDuration = 30 * time.Minute
+	}
+	return p
+}
+
+// consecutiveFailures は、nowの時点で連続した失敗として数えるログインの失敗回数を返します
+// 最後の失敗からLockDurationが過ぎている場合は0を返します
+func (p LockoutPolicy) consecutiveFailures(user *model.User, now time.Time) int {
+	if user.LastFailedLoginAt == nil || user.LastFailedLoginAt.Before(now.Add(-p.LockDuration)) {
+		return 0
+	}
+	return user.FailedLoginAttempts
+}
+
+// delay は、failures回連続で失敗した後に次の試行まで待機する時間を返します
+func (p LockoutPolicy) delay(failures int) time.Duration {
+	if failures < p.DelayAfter {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := p.DelayAfter; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, p.MaxDelay)
+}
+
+// rateLimitKey は、メールアドレスごとの回数を数えるキーを作成します
+// 大文字と小文字を変えて制限を回避できないよう、小文字に揃えます
+func rateLimitKey(action, email string) string {
+	return action + ":" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// checkRateLimit は、キーの試行を記録し、limitを超えた場合はlimitedを再試行までの時間とともに返します
+// 回数の記録先に問題がある場合は、利用者が操作できなくならないようログに記録して許可します
+func (u *userUseCase) checkRateLimit(ctx context.Context, key string, limit model.RateLimit, limited *Error) error {
+	if u.config.RateLimiter == nil || limit.Limit <= 0 {
+		return nil
+	}
+
+	result, err := u.config.RateLimiter.Allow(ctx, key, limit)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to check rate limit; allowing request", "error", err)
+		return nil
+	}
+	if !result.Allowed {
+		return WithRetryAfter(limited, result.RetryAfter)
+	}
+	return nil
+}
+
+// checkLoginAllowed は、ロック中または待機が必要な期間中のユーザーのログインを拒否します
+// パスワードを試せないよう、パスワードの検証より前に確認します
+func (u *userUseCase) checkLoginAllowed(user *model.User, now time.Time) error {
+	if user.IsLocked(now) {
+		return WithRetryAfter(ErrAccountLocked, user.LockedUntil.Sub(now))
+	}
+
+	failures := u.config.Lockout.consecutiveFailures(user, now)
+	if failures < u.config.Lockout.DelayAfter {
+		return nil
+	}
+	if next := user.LastFailedLoginAt.Add(u.config.Lockout.delay(failures)); now.Before(next) {
+		return WithRetryAfter(ErrTooManyLoginAttempts, next.Sub(now))
+	}
+	return nil
+}
+
+// recordLoginFailure は、パスワードまたは2段階認証のコードの誤りを記録します
+// 連続した失敗が上限に達した場合はアカウントをロックしてロック解除のメールを送信し、ErrAccountLockedを返します
+// それ以外の場合はfailedを返します
+func (u *userUseCase) recordLoginFailure(ctx context.Context, user *model.User, now time.Time, failed *Error) error {
+	policy := u.config.Lockout
+
+	var (
+		lockedUntil time.Time
+		unlockToken string
+	)
+	err := u.transactions.WithinTransaction(ctx, func(ctx context.Context) error {
+		failures, err := u.userRepo.RecordLoginFailure(ctx, user.ID, now, now.Add(-policy.LockDuration))
+		if err != nil {
+			return err
+		}
+		if failures < policy.LockAfter {
+			return nil
+		}
+
+		token, err := generateSecureToken(32)
+		if err != nil {
+			return err
+		}
+		if err := u.userRepo.Lock(ctx, user.ID, now.Add(policy.LockDuration), token, now.Add(unlockTokenTTL)); err != nil {
+			return err
+		}
+		lockedUntil, unlockToken = now.Add(policy.LockDuration), token
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if unlockToken == "" {
+		return failed
+	}
+
+	slog.WarnContext(ctx, "account locked after repeated login failures", "user_id", user.ID, "locked_until", lockedUntil)
+	// 退会済みのユーザーにはメールを送信しない
+	if !user.IsDeleted() {
+		u.sendUnlockEmail(ctx, user, unlockToken)
+	}
+
+	return WithRetryAfter(ErrAccountLocked, lockedUntil.Sub(now))
+}
+
+// resetLoginFailures は、ログインに成功したユーザーのログインの失敗回数をリセットします
+// 失敗が記録されていない場合は何もしません
+func (u *userUseCase) resetLoginFailures(ctx context.Context, user *model.User) error {
+	if user.FailedLoginAttempts == 0 && user.LockedUntil == nil {
+		return nil
+	}
+
+	if err := u.userRepo.ResetLoginFailures(ctx, user.ID); err != nil && !errors.Is(err, model.ErrNotFound) {
+		return err
+	}
+
+	user.FailedLoginAttempts = 0
+	user.LastFailedLoginAt = nil
+	user.LockedUntil = nil
+	user.UnlockToken = nil
+	user.UnlockTokenExpires = nil
+	return nil
+}
+
+// sendUnlockEmail は、アカウントをロックしたことと、ロックを解除するリンクを記載したメールを送信します
+// 送信に失敗した場合でもロックは期限が過ぎると解除されるため、ログに記録するのみとします
+func (u *userUseCase) sendUnlockEmail(ctx context.Context, user *model.User, token string) {
+	unlockURL, err := buildFrontendURL(u.config.FrontendURL, "/unlock-account", token)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to build unlock url", "user_id", user.ID, "error", err)
+		return
+	}
+
+	mail, err := renderEmail(user.Email, "account_locked", user.PreferredLanguage, map[string]interface{}{
+		"Name":             user.Name,
+		"UnlockURL":        unlockURL,
+		"LockedForMinutes": int(u.config.Lockout.LockDuration.Minutes()),
+		"ExpiresInHours":   int(unlockTokenTTL.Hours()),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to render unlock email", "user_id", user.ID, "error", err)
+		return
+	}
+
+	if err := u.mailer.Send(ctx, mail); err != nil {
+		slog.ErrorContext(ctx, "failed to send unlock email", "user_id", user.ID, "error", err)
+	}
+}
+
+// UnlockAccount は、ロック解除トークンを使用してアカウントのロックを解除し、ログインの失敗回数をリセットします
+func (u *userUseCase) UnlockAccount(ctx context.Context, token string) (err error) {
+	ctx, span := startSpan(ctx, "UserUseCase.UnlockAccount")
+	defer func() { endSpan(span, err) }()
+
+	return u.transactions.WithinTransaction(ctx, func(ctx context.Context) error {
+		user, err := u.userRepo.FindByUnlockToken(ctx, token)
+		if errors.Is(err, model.ErrNotFound) {
+			return ErrInvalidUnlockToken
+		}
+		if err != nil {
+			return err
+		}
+
+		if user.UnlockTokenExpires == nil || u.config.Clock.Now().After(*user.UnlockTokenExpires) {
+			return ErrUnlockTokenExpired
+		}
+
+		if err := u.userRepo.ResetLoginFailures(ctx, user.ID); err != nil {
+			return err
+		}
+
+		slog.InfoContext(ctx, "account unlocked", "user_id", user.ID)
+		return nil
+	})
+}
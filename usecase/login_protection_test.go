@@ -0,0 +1,328 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"voice-link/domain/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// fakeClock は、テストで任意の時刻を返すClockです
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+// stubRateLimiter は、キーごとの試行の回数をlimitと比較するテスト用のRateLimiterです
+type stubRateLimiter struct {
+	counts map[string]int
+	err    error
+}
+
+func newStubRateLimiter() *stubRateLimiter {
+	return &stubRateLimiter{counts: make(map[string]int)}
+}
+
+func (l *stubRateLimiter) Allow(ctx context.Context, key string, limit model.RateLimit) (model.RateLimitResult, error) {
+	if l.err != nil {
+		return model.RateLimitResult{}, l.err
+	}
+	l.counts[key]++
+	if l.counts[key] > limit.Limit {
+		return model.RateLimitResult{Allowed: false, RetryAfter: limit.Window}, nil
+	}
+	return model.RateLimitResult{Allowed: true}, nil
+}
+
+// testLockoutPolicy は、テストで使用するログインの失敗の待機とロックの条件です
+var testLockoutPolicy = LockoutPolicy{
+	DelayAfter:   3,
+	BaseDelay:    time.Second,
+	MaxDelay:     8 * time.Second,
+	LockAfter:    5,
+	LockDuration: 30 * time.Minute,
+}
+
+func TestLockoutPolicy_Delay(t *testing.T) {
+	tests := []struct {
+		failures int
+		expected time.Duration
+	}{
+		{2, 0},
+		{3, time.Second},
+		{4, 2 * time.Second},
+		{5, 4 * time.Second},
+		{6, 8 * time.Second},
+		// 上限を超えない
+		{7, 8 * time.Second},
+		{100, 8 * time.Second},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, testLockoutPolicy.delay(tt.failures), "failures=%d", tt.failures)
+	}
+}
+
+func TestUserUseCase_LoginLockout(t *testing.T) {
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	config := testUserUseCaseConfig
+	config.Lockout = testLockoutPolicy
+	config.Clock = &fakeClock{now: now}
+
+	// userWithFailures は、最後の失敗からsinceだけ経過したfailures回連続で失敗しているユーザーを返します
+	userWithFailures := func(failures int, since time.Duration) *model.User {
+		lastFailedAt := now.Add(-since)
+		return &model.User{
+			ID:                  1,
+			Name:                "テストユーザー",
+			Email:               "test@example.com",
+			Password:            string(hashedPassword),
+			FailedLoginAttempts: failures,
+			LastFailedLoginAt:   &lastFailedAt,
+		}
+	}
+
+	t.Run("待機が必要な期間中はパスワードを検証せずに拒否する", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(userWithFailures(4, 500*time.Millisecond), nil)
+		useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, config)
+
+		_, err := useCase.Login(context.Background(), "test@example.com", "password123", ClientInfo{})
+
+		// 4回連続で失敗した後は2秒待つ必要がある
+		assert.ErrorIs(t, err, ErrTooManyLoginAttempts)
+		assert.ErrorIs(t, err, ErrTooManyRequests)
+		var retryAfter *RetryAfterError
+		if assert.ErrorAs(t, err, &retryAfter) {
+			assert.Equal(t, 1500*time.Millisecond, retryAfter.RetryAfter())
+		}
+		mockRepo.AssertNotCalled(t, "RecordLoginFailure", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("待機した後はパスワードの誤りを記録する", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(userWithFailures(4, 2*time.Second), nil)
+		mockRepo.On("RecordLoginFailure", mock.Anything, uint(1), now, now.Add(-testLockoutPolicy.LockDuration)).Return(4, nil)
+		useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, config)
+
+		_, err := useCase.Login(context.Background(), "test@example.com", "wrongpassword", ClientInfo{})
+
+		assert.ErrorIs(t, err, ErrInvalidEmailOrPassword)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("前回の失敗から時間が経過している場合は待機を求めない", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockTokenRepo := new(MockRefreshTokenRepository)
+		mockRevocations := new(MockTokenRevocationStore)
+		mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(userWithFailures(4, time.Hour), nil)
+		mockRepo.On("ResetLoginFailures", mock.Anything, uint(1)).Return(nil)
+		mockRevocations.On("GetUserTokenVersion", mock.Anything, uint(1)).Return(uint(0), nil)
+		mockSessions := new(MockSessionRepository)
+		mockSessions.On("Create", mock.Anything, mock.AnythingOfType("*model.Session")).Return(nil)
+		mockTokenRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.RefreshToken")).Return(nil)
+		useCase := NewUserUseCase(mockRepo, mockTokenRepo, mockSessions, new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), mockRevocations, new(stubTransactionManager), new(MockMailer), nil, config)
+
+		_, err := useCase.Login(context.Background(), "test@example.com", "password123", ClientInfo{})
+
+		assert.NoError(t, err)
+		// ログインに成功した場合は失敗の記録を破棄する
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("失敗が上限に達した場合はロックしてメールを送信する", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockMailer := new(MockMailer)
+		mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(userWithFailures(4, time.Minute), nil)
+		mockRepo.On("RecordLoginFailure", mock.Anything, uint(1), now, mock.Anything).Return(testLockoutPolicy.LockAfter, nil)
+		var unlockToken string
+		mockRepo.On("Lock", mock.Anything, uint(1), now.Add(testLockoutPolicy.LockDuration), mock.AnythingOfType("string"), now.Add(unlockTokenTTL)).
+			Run(func(args mock.Arguments) { unlockToken = args.String(3) }).
+			Return(nil)
+		mockMailer.On("Send", mock.Anything, mock.MatchedBy(func(mail *model.Mail) bool {
+			return mail.To == "test@example.com" && strings.Contains(mail.TextBody, "/unlock-account?token="+unlockToken)
+		})).Return(nil)
+		transactions := new(stubTransactionManager)
+		useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), transactions, mockMailer, nil, config)
+
+		_, err := useCase.Login(context.Background(), "test@example.com", "wrongpassword", ClientInfo{})
+
+		assert.ErrorIs(t, err, ErrAccountLocked)
+		var retryAfter *RetryAfterError
+		if assert.ErrorAs(t, err, &retryAfter) {
+			assert.Equal(t, testLockoutPolicy.LockDuration, retryAfter.RetryAfter())
+		}
+		assert.NotEmpty(t, unlockToken)
+		// 失敗の記録とロックを1つのトランザクションで行う
+		assert.Equal(t, 1, transactions.calls)
+		mockRepo.AssertExpectations(t)
+		mockMailer.AssertExpectations(t)
+	})
+
+	t.Run("ロック中は正しいパスワードでもログインできない", func(t *testing.T) {
+		user := userWithFailures(testLockoutPolicy.LockAfter, time.Minute)
+		lockedUntil := now.Add(10 * time.Minute)
+		user.LockedUntil = &lockedUntil
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(user, nil)
+		useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, config)
+
+		_, err := useCase.Login(context.Background(), "test@example.com", "password123", ClientInfo{})
+
+		assert.ErrorIs(t, err, ErrAccountLocked)
+		var retryAfter *RetryAfterError
+		if assert.ErrorAs(t, err, &retryAfter) {
+			assert.Equal(t, 10*time.Minute, retryAfter.RetryAfter())
+		}
+	})
+
+	t.Run("ロックの期限が過ぎた場合はログインできる", func(t *testing.T) {
+		user := userWithFailures(testLockoutPolicy.LockAfter, testLockoutPolicy.LockDuration)
+		lockedUntil := now
+		user.LockedUntil = &lockedUntil
+		mockRepo := new(MockUserRepository)
+		mockTokenRepo := new(MockRefreshTokenRepository)
+		mockRevocations := new(MockTokenRevocationStore)
+		mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(user, nil)
+		mockRepo.On("ResetLoginFailures", mock.Anything, uint(1)).Return(nil)
+		mockRevocations.On("GetUserTokenVersion", mock.Anything, uint(1)).Return(uint(0), nil)
+		mockSessions := new(MockSessionRepository)
+		mockSessions.On("Create", mock.Anything, mock.AnythingOfType("*model.Session")).Return(nil)
+		mockTokenRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.RefreshToken")).Return(nil)
+		useCase := NewUserUseCase(mockRepo, mockTokenRepo, mockSessions, new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), mockRevocations, new(stubTransactionManager), new(MockMailer), nil, config)
+
+		_, err := useCase.Login(context.Background(), "test@example.com", "password123", ClientInfo{})
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestUserUseCase_LoginRateLimit(t *testing.T) {
+	config := testUserUseCaseConfig
+	config.LoginRateLimit = model.RateLimit{Limit: 2, Window: time.Minute}
+
+	t.Run("メールアドレスごとの上限を超えた場合はユーザーを検索せずに拒否する", func(t *testing.T) {
+		limiter := newStubRateLimiter()
+		config := config
+		config.RateLimiter = limiter
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("FindByEmail", mock.Anything, mock.Anything).Return(nil, model.ErrNotFound)
+		useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, config)
+
+		// 存在しないメールアドレスにも適用し、大文字と小文字は区別しない
+		for _, email := range []string{"nobody@example.com", "Nobody@Example.com"} {
+			_, err := useCase.Login(context.Background(), email, "password123", ClientInfo{})
+			assert.ErrorIs(t, err, ErrInvalidEmailOrPassword)
+		}
+		_, err := useCase.Login(context.Background(), "NOBODY@example.com", "password123", ClientInfo{})
+
+		assert.ErrorIs(t, err, ErrTooManyLoginAttempts)
+		var retryAfter *RetryAfterError
+		if assert.ErrorAs(t, err, &retryAfter) {
+			assert.Equal(t, time.Minute, retryAfter.RetryAfter())
+		}
+		mockRepo.AssertNumberOfCalls(t, "FindByEmail", 2)
+	})
+
+	t.Run("回数の記録先が利用できない場合は許可する", func(t *testing.T) {
+		limiter := newStubRateLimiter()
+		limiter.err = errors.New("connection refused")
+		config := config
+		config.RateLimiter = limiter
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(nil, model.ErrNotFound)
+		useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, config)
+
+		_, err := useCase.Login(context.Background(), "test@example.com", "password123", ClientInfo{})
+
+		assert.ErrorIs(t, err, ErrInvalidEmailOrPassword)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestUserUseCase_EmailRateLimit(t *testing.T) {
+	config := testUserUseCaseConfig
+	config.EmailRateLimit = model.RateLimit{Limit: 1, Window: time.Hour}
+	config.RateLimiter = newStubRateLimiter()
+
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("FindByEmail", mock.Anything, "nobody@example.com").Return(nil, model.ErrNotFound)
+	useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, config)
+	ctx := context.Background()
+
+	// パスワードリセットと確認メールの再送信は別々に数える
+	assert.NoError(t, useCase.RequestPasswordReset(ctx, "nobody@example.com"))
+	assert.NoError(t, useCase.ResendVerificationEmail(ctx, "nobody@example.com"))
+
+	assert.ErrorIs(t, useCase.RequestPasswordReset(ctx, "nobody@example.com"), ErrTooManyEmailRequests)
+	assert.ErrorIs(t, useCase.ResendVerificationEmail(ctx, "nobody@example.com"), ErrTooManyEmailRequests)
+	mockRepo.AssertNumberOfCalls(t, "FindByEmail", 2)
+}
+
+func TestUserUseCase_UnlockAccount(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	config := testUserUseCaseConfig
+	config.Clock = &fakeClock{now: now}
+
+	// lockedUser は、ロック解除トークンの有効期限がexpiresのユーザーを返します
+	lockedUser := func(expires time.Time) *model.User {
+		token := "unlock-token"
+		return &model.User{ID: 1, UnlockToken: &token, UnlockTokenExpires: &expires}
+	}
+
+	tests := []struct {
+		name          string
+		mockSetup     func(*MockUserRepository)
+		expectedError error
+	}{
+		{
+			name: "ロックを解除する",
+			mockSetup: func(mockRepo *MockUserRepository) {
+				mockRepo.On("FindByUnlockToken", mock.Anything, "unlock-token").Return(lockedUser(now.Add(time.Hour)), nil)
+				mockRepo.On("ResetLoginFailures", mock.Anything, uint(1)).Return(nil)
+			},
+		},
+		{
+			name: "トークンが存在しない",
+			mockSetup: func(mockRepo *MockUserRepository) {
+				mockRepo.On("FindByUnlockToken", mock.Anything, "unlock-token").Return(nil, model.ErrNotFound)
+			},
+			expectedError: ErrInvalidUnlockToken,
+		},
+		{
+			name: "トークンの有効期限が切れている",
+			mockSetup: func(mockRepo *MockUserRepository) {
+				mockRepo.On("FindByUnlockToken", mock.Anything, "unlock-token").Return(lockedUser(now.Add(-time.Second)), nil)
+			},
+			expectedError: ErrUnlockTokenExpired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			tt.mockSetup(mockRepo)
+			useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, config)
+
+			err := useCase.UnlockAccount(context.Background(), "unlock-token")
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				mockRepo.AssertNotCalled(t, "ResetLoginFailures", mock.Anything, mock.Anything)
+			} else {
+				assert.NoError(t, err)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package usecase
+
+import "errors"
+
+// Metrics は、認証に関するイベントの件数を記録する先です
+// メトリクスの系列が増え続けないよう、ユーザーIDやメールアドレスなどの値は渡しません
+type Metrics interface {
+	// LoginSucceeded は、ログインの成功を記録します
+	LoginSucceeded()
+	// LoginFailed は、ログインの失敗を記録します。reasonにはエラーコードを指定します
+	LoginFailed(reason string)
+	// UserRegistered は、ユーザーの登録を記録します
+	UserRegistered()
+	// PasswordResetRequested は、パスワードリセットのリクエストを記録します
+	PasswordResetRequested()
+	// PasswordResetCompleted は、パスワードリセットの完了を記録します
+	PasswordResetCompleted()
+}
+
+// nopMetrics は、何も記録しないMetricsです
+type nopMetrics struct{}
+
+func (nopMetrics) LoginSucceeded()         {}
+func (nopMetrics) LoginFailed(string)      {}
+func (nopMetrics) UserRegistered()         {}
+func (nopMetrics) PasswordResetRequested() {}
+func (nopMetrics) PasswordResetCompleted() {}
+
+// loginFailureReasonOf は、ログインのエラーからメトリクスに記録する失敗の理由を返します
+func loginFailureReasonOf(err error) string {
+	var useCaseErr *Error
+	if errors.As(err, &useCaseErr) {
+		return useCaseErr.Code()
+	}
+	return "internal_error"
+}
@@ -0,0 +1,79 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"voice-link/domain/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// stubMetrics は、記録されたイベントを保持するテスト用のMetricsです
+type stubMetrics struct {
+	events []string
+}
+
+func (s *stubMetrics) LoginSucceeded()           { s.events = append(s.events, "login_succeeded") }
+func (s *stubMetrics) LoginFailed(reason string) { s.events = append(s.events, "login_failed:"+reason) }
+func (s *stubMetrics) UserRegistered()           { s.events = append(s.events, "user_registered") }
+func (s *stubMetrics) PasswordResetRequested() {
+	s.events = append(s.events, "password_reset_requested")
+}
+func (s *stubMetrics) PasswordResetCompleted() {
+	s.events = append(s.events, "password_reset_completed")
+}
+
+func TestUserUseCase_LoginMetrics(t *testing.T) {
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	user := &model.User{ID: 1, Email: "test@example.com", Password: string(hashedPassword)}
+
+	tests := []struct {
+		name      string
+		email     string
+		password  string
+		mockSetup func(*MockUserRepository, *MockRefreshTokenRepository, *MockTokenRevocationStore)
+		expected  []string
+	}{
+		{
+			name:     "ログインの成功を記録する",
+			email:    "test@example.com",
+			password: "password123",
+			mockSetup: func(mockRepo *MockUserRepository, mockTokenRepo *MockRefreshTokenRepository, mockRevocations *MockTokenRevocationStore) {
+				mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(user, nil)
+				mockRevocations.On("GetUserTokenVersion", mock.Anything, uint(1)).Return(uint(0), nil)
+				mockTokenRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.RefreshToken")).Return(nil)
+			},
+			expected: []string{"login_succeeded"},
+		},
+		{
+			name:     "ログインの失敗をエラーコードで記録する",
+			email:    "test@example.com",
+			password: "wrongpassword",
+			mockSetup: func(mockRepo *MockUserRepository, mockTokenRepo *MockRefreshTokenRepository, mockRevocations *MockTokenRevocationStore) {
+				mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(user, nil)
+				mockRepo.On("RecordLoginFailure", mock.Anything, uint(1), mock.Anything, mock.Anything).Return(1, nil)
+			},
+			expected: []string{"login_failed:invalid_credentials"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			mockTokenRepo := new(MockRefreshTokenRepository)
+			mockRevocations := new(MockTokenRevocationStore)
+			tt.mockSetup(mockRepo, mockTokenRepo, mockRevocations)
+			mockSessions := new(MockSessionRepository)
+			mockSessions.On("Create", mock.Anything, mock.AnythingOfType("*model.Session")).Return(nil)
+
+			metrics := &stubMetrics{}
+			useCase := NewUserUseCase(mockRepo, mockTokenRepo, mockSessions, new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), mockRevocations, new(stubTransactionManager), new(MockMailer), metrics, testUserUseCaseConfig)
+
+			_, _ = useCase.Login(context.Background(), tt.email, tt.password, ClientInfo{})
+
+			assert.Equal(t, tt.expected, metrics.events)
+		})
+	}
+}
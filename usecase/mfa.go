@@ -0,0 +1,425 @@
+package usecase
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+	"voice-link/domain/model"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// mfaChallengeTTL は、パスワードの確認後に2段階認証のコードを入力できる期間です
+const mfaChallengeTTL = 5 * time.Minute
+
+// mfaChallengePurpose は、2段階認証のチャレンジトークンであることを示すpurposeクレームの値です
+const mfaChallengePurpose = "mfa_challenge"
+
+// mfaSecretPurpose は、2段階認証の秘密鍵の暗号化に使用する鍵を導出するときの用途です
+const mfaSecretPurpose = "mfa_secret"
+
+// defaultMFAIssuer は、認証アプリに表示するサービス名の既定値です
+const defaultMFAIssuer = "Voice Link"
+
+// MFAChallenge は、パスワードを確認した2段階認証の利用者に発行する、コードの確認までの短期間のトークンです
+type MFAChallenge struct {
+	Token     string
+	ExpiresIn int64 // トークンの有効期間（秒）
+}
+
+// MFAEnrollment は、2段階認証の登録を開始したときに認証アプリへ登録する情報です
+type MFAEnrollment struct {
+	Secret string // Base32形式の秘密鍵。QRコードを読み取れない場合に手入力する
+	URI    string // QRコードにするotpauth URI
+}
+
+// mfaChallengeClaims は、チャレンジトークンのクレームです
+// SubjectにはユーザーのIDを設定します
+type mfaChallengeClaims struct {
+	Purpose string `json:"purpose"`
+	Email   string `json:"email"`
+	jwt.RegisteredClaims
+}
+
+// mfaChallengeKey は、チャレンジトークンの署名に使用する鍵を返します
+// アクセストークンとして受け付けられないよう、アクセストークンの秘密鍵から別の鍵を導出します
+func (u *userUseCase) mfaChallengeKey() []byte {
+	mac := hmac.New(sha256.New, []byte(u.config.JWTSecret))
+	mac.Write([]byte(mfaChallengePurpose))
+	return mac.Sum(nil)
+}
+
+// mfaSecretKey は、2段階認証の秘密鍵の暗号化に使用するAES-256の鍵を返します
+// MFAEncryptionKeyが空の場合は、アクセストークンの秘密鍵から別の鍵を導出します
+func (u *userUseCase) mfaSecretKey() []byte {
+	secret := u.config.MFAEncryptionKey
+	if secret == "" {
+		secret = u.config.JWTSecret
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(mfaSecretPurpose))
+	return mac.Sum(nil)
+}
+
+// mfaSecretAEAD は、2段階認証の秘密鍵の暗号化に使用するAES-GCMを作成します
+func (u *userUseCase) mfaSecretAEAD() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(u.mfaSecretKey())
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealMFASecret は、データベースに保存する2段階認証の秘密鍵をAES-GCMで暗号化します
+// 別のユーザーの行に複製しても復号できないよう、ユーザーのIDを追加データとして認証します
+func (u *userUseCase) sealMFASecret(userID uint, secret string) (string, error) {
+	aead, err := u.mfaSecretAEAD()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(secret), mfaSecretAdditionalData(userID))
+	return base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// openMFASecret は、データベースに保存された2段階認証の秘密鍵を復号します
+func (u *userUseCase) openMFASecret(userID uint, sealed string) (string, error) {
+	aead, err := u.mfaSecretAEAD()
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.RawStdEncoding.DecodeString(sealed)
+	if err != nil || len(data) < aead.NonceSize() {
+		return "", fmt.Errorf("decrypt mfa secret of user %d: malformed ciphertext", userID)
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	secret, err := aead.Open(nil, nonce, ciphertext, mfaSecretAdditionalData(userID))
+	if err != nil {
+		return "", fmt.Errorf("decrypt mfa secret of user %d: %w", userID, err)
+	}
+	return string(secret), nil
+}
+
+// mfaSecretAdditionalData は、2段階認証の秘密鍵の暗号化で認証する追加データです
+func mfaSecretAdditionalData(userID uint) []byte {
+	return []byte(strconv.FormatUint(uint64(userID), 10))
+}
+
+// issueMFAChallenge は、パスワードを確認したユーザーのチャレンジトークンを発行します
+func (u *userUseCase) issueMFAChallenge(user *model.User, now time.Time) (*MFAChallenge, error) {
+	jti, err := generateSecureToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, mfaChallengeClaims{
+		Purpose: mfaChallengePurpose,
+		Email:   user.Email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatUint(uint64(user.ID), 10),
+			ExpiresAt: jwt.NewNumericDate(now.Add(mfaChallengeTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        jti,
+		},
+	})
+	signed, err := token.SignedString(u.mfaChallengeKey())
+	if err != nil {
+		return nil, err
+	}
+
+	return &MFAChallenge{Token: signed, ExpiresIn: int64(mfaChallengeTTL.Seconds())}, nil
+}
+
+// parseMFAChallenge は、チャレンジトークンを検証してクレームを返します
+func (u *userUseCase) parseMFAChallenge(tokenString string) (*mfaChallengeClaims, error) {
+	var claims mfaChallengeClaims
+	_, err := jwt.ParseWithClaims(tokenString, &claims, func(*jwt.Token) (interface{}, error) {
+		return u.mfaChallengeKey(), nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithTimeFunc(u.config.Clock.Now),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil || claims.Purpose != mfaChallengePurpose {
+		return nil, ErrInvalidMFAToken
+	}
+	return &claims, nil
+}
+
+// VerifyMFA は、チャレンジトークンと2段階認証のコードを検証してトークンを発行し、結果をメトリクスに記録します
+func (u *userUseCase) VerifyMFA(ctx context.Context, mfaToken, code string, client ClientInfo) (_ *TokenPair, err error) {
+	ctx, span := startSpan(ctx, "UserUseCase.VerifyMFA")
+	defer func() { endSpan(span, err) }()
+
+	tokens, err := u.verifyMFA(ctx, mfaToken, code, client)
+	if err != nil {
+		u.metrics.LoginFailed(loginFailureReasonOf(err))
+		return nil, err
+	}
+
+	u.metrics.LoginSucceeded()
+	return tokens, nil
+}
+
+// verifyMFA は、チャレンジトークンと、認証アプリのコードまたはリカバリーコードを検証してトークンを発行します
+// コードの誤りはパスワードの誤りと同じく数え、続いた場合は待機を求めてアカウントをロックします
+func (u *userUseCase) verifyMFA(ctx context.Context, mfaToken, code string, client ClientInfo) (*TokenPair, error) {
+	claims, err := u.parseMFAChallenge(mfaToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// 退会後の猶予期間中のユーザーも検索できるよう、ログインと同じくメールアドレスで検索する
+	user, err := u.userRepo.FindByEmail(ctx, claims.Email)
+	if errors.Is(err, model.ErrNotFound) {
+		return nil, ErrInvalidMFAToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	// チャレンジの発行後にメールアドレスの変更や2段階認証の解除があった場合は、ログインをやり直させる
+	if strconv.FormatUint(uint64(user.ID), 10) != claims.Subject || !user.IsMFAEnabled() {
+		return nil, ErrInvalidMFAToken
+	}
+
+	now := u.config.Clock.Now()
+	if err := u.checkLoginAllowed(user, now); err != nil {
+		return nil, err
+	}
+
+	used, err := u.useMFACode(ctx, user, code, now)
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		return nil, u.recordLoginFailure(ctx, user, now, ErrInvalidMFACode)
+	}
+
+	// チャレンジの発行後に猶予期間が過ぎた場合
+	if user.IsDeleted() && !u.isRestorable(user) {
+		return nil, ErrInvalidMFAToken
+	}
+
+	if err := u.resetLoginFailures(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return u.completeLogin(ctx, user, client)
+}
+
+// useMFACode は、認証アプリのコードまたはリカバリーコードを検証し、再び使用できないよう使用済みにします
+// 6桁の数字は認証アプリのコード、それ以外はリカバリーコードとして扱います
+func (u *userUseCase) useMFACode(ctx context.Context, user *model.User, code string, now time.Time) (bool, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return false, nil
+	}
+
+	if isTOTPCode(code) {
+		secret, err := u.openMFASecret(user.ID, *user.MFASecret)
+		if err != nil {
+			return false, err
+		}
+		step, ok := verifyTOTP(secret, code, now)
+		if !ok {
+			return false, nil
+		}
+		// 同じコードを有効期間内に再び使用できないよう、使用した時間ステップを記録する
+		return u.userRepo.UseMFAStep(ctx, user.ID, step)
+	}
+
+	return u.recoveryCodeRepo.Use(ctx, user.ID, hashRecoveryCode(code), now)
+}
+
+// BeginMFAEnrollment は、2段階認証の登録を開始し、認証アプリに登録する秘密鍵を発行します
+// 登録はConfirmMFAEnrollmentで認証アプリのコードを確認するまで有効になりません
+// 確認前に再び呼び出した場合は、秘密鍵を発行し直します
+func (u *userUseCase) BeginMFAEnrollment(ctx context.Context, userID uint) (_ *MFAEnrollment, err error) {
+	ctx, span := startSpan(ctx, "UserUseCase.BeginMFAEnrollment")
+	defer func() { endSpan(span, err) }()
+
+	user, err := u.userRepo.FindByID(ctx, userID)
+	if errors.Is(err, model.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.IsMFAEnabled() {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := u.sealMFASecret(user.ID, secret)
+	if err != nil {
+		return nil, err
+	}
+	// 同時に登録を完了した場合は更新されない
+	if err := u.userRepo.SetMFASecret(ctx, user.ID, sealed); err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			return nil, ErrMFAAlreadyEnabled
+		}
+		return nil, err
+	}
+
+	return &MFAEnrollment{
+		Secret: secret,
+		URI:    totpURI(u.config.MFAIssuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmMFAEnrollment は、認証アプリのコードを確認して2段階認証を有効にし、リカバリーコードを発行します
+// リカバリーコードはハッシュ値のみ保存するため、平文を返すのはこのときだけです
+func (u *userUseCase) ConfirmMFAEnrollment(ctx context.Context, userID uint, code string) (_ []string, err error) {
+	ctx, span := startSpan(ctx, "UserUseCase.ConfirmMFAEnrollment")
+	defer func() { endSpan(span, err) }()
+
+	// 6桁のコードを総当たりで確認できないよう、試行の回数を制限する
+	if err := u.checkRateLimit(ctx, mfaRateLimitKey(userID), u.config.LoginRateLimit, ErrTooManyLoginAttempts); err != nil {
+		return nil, err
+	}
+
+	codes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, c := range codes {
+		hashes[i] = hashRecoveryCode(c)
+	}
+
+	err = u.transactions.WithinTransaction(ctx, func(ctx context.Context) error {
+		user, err := u.userRepo.FindByID(ctx, userID)
+		if errors.Is(err, model.ErrNotFound) {
+			return ErrUserNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if user.IsMFAEnabled() {
+			return ErrMFAAlreadyEnabled
+		}
+		if user.MFASecret == nil {
+			return ErrMFAEnrollmentNotStarted
+		}
+
+		secret, err := u.openMFASecret(user.ID, *user.MFASecret)
+		if err != nil {
+			return err
+		}
+		now := u.config.Clock.Now()
+		step, ok := verifyTOTP(secret, strings.TrimSpace(code), now)
+		if !ok {
+			return ErrMFACodeMismatch
+		}
+
+		if err := u.userRepo.EnableMFA(ctx, user.ID, now, step); err != nil {
+			if errors.Is(err, model.ErrNotFound) {
+				return ErrMFAAlreadyEnabled
+			}
+			return err
+		}
+		return u.recoveryCodeRepo.ReplaceAll(ctx, user.ID, hashes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "mfa enabled", "user_id", userID)
+	return codes, nil
+}
+
+// DisableMFA は、本人が認証アプリのコードまたはリカバリーコードを入力して2段階認証を解除します
+func (u *userUseCase) DisableMFA(ctx context.Context, userID uint, code string) (err error) {
+	ctx, span := startSpan(ctx, "UserUseCase.DisableMFA")
+	defer func() { endSpan(span, err) }()
+
+	if err := u.checkRateLimit(ctx, mfaRateLimitKey(userID), u.config.LoginRateLimit, ErrTooManyLoginAttempts); err != nil {
+		return err
+	}
+
+	user, err := u.userRepo.FindByID(ctx, userID)
+	if errors.Is(err, model.ErrNotFound) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if !user.IsMFAEnabled() {
+		return ErrMFANotEnabled
+	}
+
+	used, err := u.useMFACode(ctx, user, code, u.config.Clock.Now())
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrMFACodeMismatch
+	}
+
+	if err := u.resetMFA(ctx, user.ID); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "mfa disabled", "user_id", user.ID)
+	return nil
+}
+
+// ResetMFA は、認証アプリとリカバリーコードをすべて失った利用者のために、管理者が2段階認証を解除します
+// 登録の途中で確認されていない秘密鍵も破棄します
+func (u *userUseCase) ResetMFA(ctx context.Context, userID uint) (err error) {
+	ctx, span := startSpan(ctx, "UserUseCase.ResetMFA")
+	defer func() { endSpan(span, err) }()
+
+	user, err := u.userRepo.FindByID(ctx, userID)
+	if errors.Is(err, model.ErrNotFound) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if !user.IsMFAEnabled() && user.MFASecret == nil {
+		return ErrMFANotEnabled
+	}
+
+	if err := u.resetMFA(ctx, user.ID); err != nil {
+		return err
+	}
+
+	slog.WarnContext(ctx, "mfa reset by administrator", "user_id", user.ID)
+	return nil
+}
+
+// resetMFA は、2段階認証の秘密鍵とリカバリーコードを削除します
+func (u *userUseCase) resetMFA(ctx context.Context, userID uint) error {
+	return u.transactions.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := u.userRepo.DisableMFA(ctx, userID); err != nil {
+			if errors.Is(err, model.ErrNotFound) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+		return u.recoveryCodeRepo.DeleteAllByUserID(ctx, userID)
+	})
+}
+
+// mfaRateLimitKey は、ユーザーごとに2段階認証のコードの確認の回数を数えるキーを作成します
+func mfaRateLimitKey(userID uint) string {
+	return "mfa:" + strconv.FormatUint(uint64(userID), 10)
+}
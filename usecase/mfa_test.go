@@ -0,0 +1,377 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+	"voice-link/domain/model"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestUserUseCase_LoginWithMFA(t *testing.T) {
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	now := time.Unix(1111111111, 0)
+	clock := &fakeClock{now: now}
+	config := testUserUseCaseConfig
+	config.Lockout = testLockoutPolicy
+	config.Clock = clock
+	key, _ := base32NoPadding.DecodeString(rfc6238Secret)
+
+	// mfaUser は、2段階認証を有効にしているユーザーを返します
+	mfaUser := func() *model.User {
+		secret := *sealedMFASecret(t, config, 1)
+		enabledAt := now.Add(-24 * time.Hour)
+		return &model.User{
+			ID:           1,
+			Name:         "テストユーザー",
+			Email:        "test@example.com",
+			Password:     string(hashedPassword),
+			MFASecret:    &secret,
+			MFAEnabledAt: &enabledAt,
+		}
+	}
+
+	// login は、パスワードを確認してチャレンジトークンを取得します
+	login := func(t *testing.T, useCase UserUseCase) string {
+		result, err := useCase.Login(context.Background(), "test@example.com", "password123", ClientInfo{})
+		assert.NoError(t, err)
+		assert.Nil(t, result.Tokens)
+		assert.NotNil(t, result.MFAChallenge)
+		assert.Equal(t, int64(mfaChallengeTTL.Seconds()), result.MFAChallenge.ExpiresIn)
+		return result.MFAChallenge.Token
+	}
+
+	t.Run("パスワードが正しい場合はトークンの代わりにチャレンジを返す", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockTokenRepo := new(MockRefreshTokenRepository)
+		user := mfaUser()
+		// コードの失敗回数はコードを確認するまでリセットしない
+		user.FailedLoginAttempts = 1
+		mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(user, nil)
+		useCase := NewUserUseCase(mockRepo, mockTokenRepo, new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, config)
+
+		login(t, useCase)
+
+		mockRepo.AssertNotCalled(t, "ResetLoginFailures", mock.Anything, mock.Anything)
+		mockTokenRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("認証アプリのコードでトークンを発行する", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockTokenRepo := new(MockRefreshTokenRepository)
+		mockRevocations := new(MockTokenRevocationStore)
+		user := mfaUser()
+		user.FailedLoginAttempts = 2
+		mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(user, nil)
+		mockRepo.On("UseMFAStep", mock.Anything, uint(1), totpStep(now)).Return(true, nil)
+		mockRepo.On("ResetLoginFailures", mock.Anything, uint(1)).Return(nil)
+		mockRevocations.On("GetUserTokenVersion", mock.Anything, uint(1)).Return(uint(1), nil)
+		mockSessions := new(MockSessionRepository)
+		mockSessions.On("Create", mock.Anything, mock.AnythingOfType("*model.Session")).Return(nil)
+		mockTokenRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.RefreshToken")).Return(nil)
+		useCase := NewUserUseCase(mockRepo, mockTokenRepo, mockSessions, new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), mockRevocations, new(stubTransactionManager), new(MockMailer), nil, config)
+
+		tokens, err := useCase.VerifyMFA(context.Background(), login(t, useCase), "050471", ClientInfo{})
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, tokens.AccessToken)
+		assert.NotEmpty(t, tokens.RefreshToken)
+		mockRepo.AssertExpectations(t)
+		mockTokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("使用済みのコードは拒否して失敗を記録する", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(mfaUser(), nil)
+		mockRepo.On("UseMFAStep", mock.Anything, uint(1), totpStep(now)).Return(false, nil)
+		mockRepo.On("RecordLoginFailure", mock.Anything, uint(1), now, mock.Anything).Return(1, nil)
+		useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, config)
+
+		_, err := useCase.VerifyMFA(context.Background(), login(t, useCase), "050471", ClientInfo{})
+
+		assert.ErrorIs(t, err, ErrInvalidMFACode)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("誤ったコードが続いた場合はアカウントをロックする", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockMailer := new(MockMailer)
+		mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(mfaUser(), nil)
+		mockRepo.On("RecordLoginFailure", mock.Anything, uint(1), now, mock.Anything).Return(testLockoutPolicy.LockAfter, nil)
+		mockRepo.On("Lock", mock.Anything, uint(1), now.Add(testLockoutPolicy.LockDuration), mock.AnythingOfType("string"), mock.Anything).Return(nil)
+		mockMailer.On("Send", mock.Anything, mock.Anything).Return(nil)
+		useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), mockMailer, nil, config)
+
+		// 前後1ステップの範囲外のコード
+		_, err := useCase.VerifyMFA(context.Background(), login(t, useCase), totpCode(key, totpStep(now)+5), ClientInfo{})
+
+		assert.ErrorIs(t, err, ErrAccountLocked)
+		mockRepo.AssertExpectations(t)
+		mockMailer.AssertExpectations(t)
+	})
+
+	t.Run("リカバリーコードでトークンを発行する", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockTokenRepo := new(MockRefreshTokenRepository)
+		mockRecoveryCodeRepo := new(MockRecoveryCodeRepository)
+		mockRevocations := new(MockTokenRevocationStore)
+		mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(mfaUser(), nil)
+		mockRecoveryCodeRepo.On("Use", mock.Anything, uint(1), hashRecoveryCode("abcd-efgh-ijkl-mnop"), now).Return(true, nil)
+		mockRevocations.On("GetUserTokenVersion", mock.Anything, uint(1)).Return(uint(1), nil)
+		mockSessions := new(MockSessionRepository)
+		mockSessions.On("Create", mock.Anything, mock.AnythingOfType("*model.Session")).Return(nil)
+		mockTokenRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.RefreshToken")).Return(nil)
+		useCase := NewUserUseCase(mockRepo, mockTokenRepo, mockSessions, mockRecoveryCodeRepo, new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), mockRevocations, new(stubTransactionManager), new(MockMailer), nil, config)
+
+		tokens, err := useCase.VerifyMFA(context.Background(), login(t, useCase), "ABCD-EFGH-IJKL-MNOP", ClientInfo{})
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, tokens.AccessToken)
+		mockRecoveryCodeRepo.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "UseMFAStep", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("期限切れのチャレンジは拒否する", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(mfaUser(), nil)
+		expiredClock := &fakeClock{now: now}
+		expiredConfig := config
+		expiredConfig.Clock = expiredClock
+		useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, expiredConfig)
+		token := login(t, useCase)
+
+		expiredClock.now = now.Add(mfaChallengeTTL + time.Second)
+		_, err := useCase.VerifyMFA(context.Background(), token, "050471", ClientInfo{})
+
+		assert.ErrorIs(t, err, ErrInvalidMFAToken)
+		mockRepo.AssertNotCalled(t, "UseMFAStep", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("アクセストークンの秘密鍵で署名したトークンはチャレンジとして受け付けない", func(t *testing.T) {
+		forged, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, mfaChallengeClaims{
+			Purpose: mfaChallengePurpose,
+			Email:   "test@example.com",
+			RegisteredClaims: jwt.RegisteredClaims{
+				Subject:   "1",
+				ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+			},
+		}).SignedString([]byte(config.JWTSecret))
+		useCase := NewUserUseCase(new(MockUserRepository), new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, config)
+
+		_, err := useCase.VerifyMFA(context.Background(), forged, "050471", ClientInfo{})
+
+		assert.ErrorIs(t, err, ErrInvalidMFAToken)
+	})
+
+	t.Run("チャレンジの発行後に2段階認証が解除された場合は拒否する", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(mfaUser(), nil).Once()
+		disabled := mfaUser()
+		disabled.MFAEnabledAt = nil
+		disabled.MFASecret = nil
+		mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(disabled, nil).Once()
+		useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, config)
+
+		_, err := useCase.VerifyMFA(context.Background(), login(t, useCase), "050471", ClientInfo{})
+
+		assert.ErrorIs(t, err, ErrInvalidMFAToken)
+	})
+}
+
+func TestUserUseCase_MFAEnrollment(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	config := testUserUseCaseConfig
+	config.Clock = &fakeClock{now: now}
+
+	t.Run("秘密鍵を発行してotpauth URIを返す", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("FindByID", mock.Anything, uint(1)).Return(&model.User{ID: 1, Email: "test@example.com"}, nil)
+		mockRepo.On("SetMFASecret", mock.Anything, uint(1), mock.AnythingOfType("string")).Return(nil)
+		useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, config)
+
+		enrollment, err := useCase.BeginMFAEnrollment(context.Background(), 1)
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, enrollment.Secret)
+		assert.Contains(t, enrollment.URI, "otpauth://totp/Voice%20Link:test@example.com?")
+		assert.Contains(t, enrollment.URI, "secret="+enrollment.Secret)
+		// 秘密鍵は暗号化して保存し、ユーザーのIDを変えると復号できない
+		sealed := mockRepo.Calls[1].Arguments.String(2)
+		assert.NotContains(t, sealed, enrollment.Secret)
+		useCaseImpl := useCase.(*userUseCase)
+		opened, err := useCaseImpl.openMFASecret(1, sealed)
+		assert.NoError(t, err)
+		assert.Equal(t, enrollment.Secret, opened)
+		_, err = useCaseImpl.openMFASecret(2, sealed)
+		assert.Error(t, err)
+	})
+
+	t.Run("有効にしている場合は登録を開始しない", func(t *testing.T) {
+		secret := *sealedMFASecret(t, config, 1)
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("FindByID", mock.Anything, uint(1)).Return(&model.User{ID: 1, MFASecret: &secret, MFAEnabledAt: &now}, nil)
+		useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, config)
+
+		_, err := useCase.BeginMFAEnrollment(context.Background(), 1)
+
+		assert.ErrorIs(t, err, ErrMFAAlreadyEnabled)
+		mockRepo.AssertNotCalled(t, "SetMFASecret", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("コードを確認して有効にし、リカバリーコードを発行する", func(t *testing.T) {
+		secret := *sealedMFASecret(t, config, 1)
+		mockRepo := new(MockUserRepository)
+		mockRecoveryCodeRepo := new(MockRecoveryCodeRepository)
+		mockRepo.On("FindByID", mock.Anything, uint(1)).Return(&model.User{ID: 1, MFASecret: &secret}, nil)
+		mockRepo.On("EnableMFA", mock.Anything, uint(1), now, totpStep(now)).Return(nil)
+		mockRecoveryCodeRepo.On("ReplaceAll", mock.Anything, uint(1), mock.Anything).Return(nil)
+		useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), mockRecoveryCodeRepo, new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, config)
+
+		codes, err := useCase.ConfirmMFAEnrollment(context.Background(), 1, "050471")
+
+		assert.NoError(t, err)
+		assert.Len(t, codes, recoveryCodeCount)
+		// 保存するのはハッシュ値のみ
+		hashes := mockRecoveryCodeRepo.Calls[0].Arguments.Get(2).([]string)
+		assert.Len(t, hashes, recoveryCodeCount)
+		assert.Equal(t, hashRecoveryCode(codes[0]), hashes[0])
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("コードが一致しない場合は有効にしない", func(t *testing.T) {
+		secret := *sealedMFASecret(t, config, 1)
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("FindByID", mock.Anything, uint(1)).Return(&model.User{ID: 1, MFASecret: &secret}, nil)
+		useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, config)
+
+		_, err := useCase.ConfirmMFAEnrollment(context.Background(), 1, "000000")
+
+		assert.ErrorIs(t, err, ErrMFACodeMismatch)
+		mockRepo.AssertNotCalled(t, "EnableMFA", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("登録を開始していない場合は確認できない", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("FindByID", mock.Anything, uint(1)).Return(&model.User{ID: 1}, nil)
+		useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, config)
+
+		_, err := useCase.ConfirmMFAEnrollment(context.Background(), 1, "050471")
+
+		assert.ErrorIs(t, err, ErrMFAEnrollmentNotStarted)
+	})
+
+	t.Run("確認の試行の回数を制限する", func(t *testing.T) {
+		limitedConfig := config
+		limitedConfig.RateLimiter = newStubRateLimiter()
+		limitedConfig.LoginRateLimit = model.RateLimit{Limit: 1, Window: time.Minute}
+		secret := *sealedMFASecret(t, config, 1)
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("FindByID", mock.Anything, uint(1)).Return(&model.User{ID: 1, MFASecret: &secret}, nil)
+		useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, limitedConfig)
+
+		_, err := useCase.ConfirmMFAEnrollment(context.Background(), 1, "000000")
+		assert.ErrorIs(t, err, ErrMFACodeMismatch)
+		_, err = useCase.ConfirmMFAEnrollment(context.Background(), 1, "050471")
+
+		assert.ErrorIs(t, err, ErrTooManyLoginAttempts)
+	})
+}
+
+func TestUserUseCase_DisableMFA(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	config := testUserUseCaseConfig
+	config.Clock = &fakeClock{now: now}
+	secret := *sealedMFASecret(t, config, 1)
+
+	t.Run("コードを確認して解除する", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockRecoveryCodeRepo := new(MockRecoveryCodeRepository)
+		mockRepo.On("FindByID", mock.Anything, uint(1)).Return(&model.User{ID: 1, MFASecret: &secret, MFAEnabledAt: &now}, nil)
+		mockRepo.On("UseMFAStep", mock.Anything, uint(1), totpStep(now)).Return(true, nil)
+		mockRepo.On("DisableMFA", mock.Anything, uint(1)).Return(nil)
+		mockRecoveryCodeRepo.On("DeleteAllByUserID", mock.Anything, uint(1)).Return(nil)
+		useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), mockRecoveryCodeRepo, new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, config)
+
+		err := useCase.DisableMFA(context.Background(), 1, "050471")
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+		mockRecoveryCodeRepo.AssertExpectations(t)
+	})
+
+	t.Run("コードが一致しない場合は解除しない", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockRecoveryCodeRepo := new(MockRecoveryCodeRepository)
+		mockRepo.On("FindByID", mock.Anything, uint(1)).Return(&model.User{ID: 1, MFASecret: &secret, MFAEnabledAt: &now}, nil)
+		mockRecoveryCodeRepo.On("Use", mock.Anything, uint(1), hashRecoveryCode("wrong-code"), now).Return(false, nil)
+		useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), mockRecoveryCodeRepo, new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, config)
+
+		err := useCase.DisableMFA(context.Background(), 1, "wrong-code")
+
+		assert.ErrorIs(t, err, ErrMFACodeMismatch)
+		mockRepo.AssertNotCalled(t, "DisableMFA", mock.Anything, mock.Anything)
+	})
+
+	t.Run("有効にしていない場合", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("FindByID", mock.Anything, uint(1)).Return(&model.User{ID: 1}, nil)
+		useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, config)
+
+		err := useCase.DisableMFA(context.Background(), 1, "050471")
+
+		assert.ErrorIs(t, err, ErrMFANotEnabled)
+	})
+}
+
+func TestUserUseCase_ResetMFA(t *testing.T) {
+	now := time.Now()
+	secret := rfc6238Secret
+
+	t.Run("管理者はコードなしで解除できる", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockRecoveryCodeRepo := new(MockRecoveryCodeRepository)
+		mockRepo.On("FindByID", mock.Anything, uint(2)).Return(&model.User{ID: 2, MFASecret: &secret, MFAEnabledAt: &now}, nil)
+		mockRepo.On("DisableMFA", mock.Anything, uint(2)).Return(nil)
+		mockRecoveryCodeRepo.On("DeleteAllByUserID", mock.Anything, uint(2)).Return(nil)
+		transactions := new(stubTransactionManager)
+		useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), mockRecoveryCodeRepo, new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), transactions, new(MockMailer), nil, testUserUseCaseConfig)
+
+		err := useCase.ResetMFA(context.Background(), 2)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, transactions.calls)
+		mockRepo.AssertExpectations(t)
+		mockRecoveryCodeRepo.AssertExpectations(t)
+	})
+
+	t.Run("有効にしていない場合", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("FindByID", mock.Anything, uint(2)).Return(&model.User{ID: 2}, nil)
+		useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, testUserUseCaseConfig)
+
+		err := useCase.ResetMFA(context.Background(), 2)
+
+		assert.ErrorIs(t, err, ErrMFANotEnabled)
+	})
+
+	t.Run("ユーザーが見つからない場合", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("FindByID", mock.Anything, uint(2)).Return(nil, model.ErrNotFound)
+		useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, testUserUseCaseConfig)
+
+		err := useCase.ResetMFA(context.Background(), 2)
+
+		assert.ErrorIs(t, err, ErrUserNotFound)
+	})
+}
+
+// sealedMFASecret は、RFC 6238のテストの秘密鍵をconfigの鍵で暗号化した、保存される値を返します
+func sealedMFASecret(t *testing.T, config UserUseCaseConfig, userID uint) *string {
+	sealed, err := (&userUseCase{config: config}).sealMFASecret(userID, rfc6238Secret)
+	assert.NoError(t, err)
+	return &sealed
+}
@@ -0,0 +1,365 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+	"voice-link/domain/model"
+)
+
+// oidcAuthRequestTTL は、認可画面へ移動してからコールバックを受け取るまでの期限です
+const oidcAuthRequestTTL = 10 * time.Minute
+
+// OIDCAuthorization は、プロバイダーの認可画面へ移動するための情報です
+// クライアントはStateを保存しておき、コールバックで受け取ったstateと一致することを確認してから送信します
+type OIDCAuthorization struct {
+	URL       string // 認可画面のURL
+	State     string // 認可リクエストを識別するstate
+	ExpiresIn int64  // コールバックを受け付ける期間（秒）
+}
+
+// oidcCodeChallenge は、PKCEのcode_verifierからS256のcode_challengeを作成します
+func oidcCodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// oidcProvider は、名前に対応するプロバイダーを返します
+func (u *userUseCase) oidcProvider(name string) (model.OIDCProvider, error) {
+	provider, ok := u.config.OIDCProviders[name]
+	if !ok {
+		return nil, ErrUnknownOIDCProvider
+	}
+	return provider, nil
+}
+
+// beginOIDC は、認可リクエストを保存し、プロバイダーの認可画面のURLを作成します
+// アカウントの連携の場合はuserIDに連携先のユーザーを指定します
+func (u *userUseCase) beginOIDC(ctx context.Context, providerName string, userID *uint) (*OIDCAuthorization, error) {
+	provider, err := u.oidcProvider(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	// code_verifierはRFC 7636の長さ（43〜128文字）を満たす
+	state, err := generateSecureToken(32)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := generateSecureToken(32)
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := generateSecureToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, oidcCodeChallenge(verifier))
+	if err != nil {
+		return nil, err
+	}
+
+	now := u.config.Clock.Now()
+	err = u.oidcAuthRequestRepo.Create(ctx, &model.OIDCAuthRequest{
+		StateHash:    hashToken(state),
+		Provider:     providerName,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		UserID:       userID,
+		ExpiresAt:    now.Add(oidcAuthRequestTTL),
+	}, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OIDCAuthorization{URL: authURL, State: state, ExpiresIn: int64(oidcAuthRequestTTL.Seconds())}, nil
+}
+
+// completeOIDC は、stateに対応する認可リクエストを使用済みにし、認可コードを交換してアカウントの情報を返します
+// 認可リクエストを開始したプロバイダーやユーザーと一致しない場合は、stateが無効なものとして拒否します
+func (u *userUseCase) completeOIDC(ctx context.Context, providerName, code, state string, userID *uint) (*model.ExternalIdentity, error) {
+	provider, err := u.oidcProvider(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := u.oidcAuthRequestRepo.Consume(ctx, hashToken(state))
+	if errors.Is(err, model.ErrNotFound) {
+		return nil, ErrInvalidOIDCState
+	}
+	if err != nil {
+		return nil, err
+	}
+	if request.Provider != providerName || u.config.Clock.Now().After(request.ExpiresAt) || !sameUserID(request.UserID, userID) {
+		return nil, ErrInvalidOIDCState
+	}
+
+	external, err := provider.Exchange(ctx, code, request.CodeVerifier, request.Nonce)
+	if errors.Is(err, model.ErrOIDCRejected) {
+		slog.WarnContext(ctx, "oidc authorization rejected", "provider", providerName, "error", err)
+		return nil, ErrOIDCLoginFailed
+	}
+	if err != nil {
+		return nil, err
+	}
+	return external, nil
+}
+
+// sameUserID は、認可リクエストを開始したユーザーとコールバックを送信したユーザーが一致するかどうかを判定します
+// ログインの認可リクエストはどちらもnilです
+func sameUserID(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// BeginOIDCLogin は、プロバイダーのアカウントでのログインを開始し、認可画面へ移動するための情報を返します
+func (u *userUseCase) BeginOIDCLogin(ctx context.Context, provider string) (_ *OIDCAuthorization, err error) {
+	ctx, span := startSpan(ctx, "UserUseCase.BeginOIDCLogin")
+	defer func() { endSpan(span, err) }()
+
+	return u.beginOIDC(ctx, provider, nil)
+}
+
+// CompleteOIDCLogin は、プロバイダーから受け取った認可コードでログインし、結果をメトリクスに記録します
+// 連携したアカウントがない場合は新しいユーザーを登録します。languageは登録するユーザーの言語です
+func (u *userUseCase) CompleteOIDCLogin(ctx context.Context, provider, code, state string, language model.Language, client ClientInfo) (_ *LoginResult, err error) {
+	ctx, span := startSpan(ctx, "UserUseCase.CompleteOIDCLogin")
+	defer func() { endSpan(span, err) }()
+
+	result, err := u.completeOIDCLogin(ctx, provider, code, state, language, client)
+	if err != nil {
+		u.metrics.LoginFailed(loginFailureReasonOf(err))
+		return nil, err
+	}
+
+	if result.Tokens != nil {
+		u.metrics.LoginSucceeded()
+	}
+	return result, nil
+}
+
+// completeOIDCLogin は、連携したアカウントのユーザーにトークンを発行します
+// パスワードでのログインと同様に、ロック中のアカウントは拒否し、2段階認証を有効にしている場合はチャレンジを発行します
+func (u *userUseCase) completeOIDCLogin(ctx context.Context, providerName, code, state string, language model.Language, client ClientInfo) (*LoginResult, error) {
+	external, err := u.completeOIDC(ctx, providerName, code, state, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var user *model.User
+	identity, err := u.identityRepo.FindByProviderSubject(ctx, providerName, external.Subject)
+	switch {
+	case err == nil:
+		if user, err = u.userRepo.FindByIDIncludingDeleted(ctx, identity.UserID); err != nil {
+			return nil, err
+		}
+	case errors.Is(err, model.ErrNotFound):
+		if user, err = u.registerOIDCUser(ctx, providerName, external, language); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	now := u.config.Clock.Now()
+	if user.IsLocked(now) {
+		return nil, WithRetryAfter(ErrAccountLocked, user.LockedUntil.Sub(now))
+	}
+	// 猶予期間を過ぎた退会済みのユーザーは削除を待っているだけのため、ログインできない
+	if user.IsDeleted() && !u.isRestorable(user) {
+		return nil, ErrOIDCLoginFailed
+	}
+	if u.config.RequireEmailVerification && user.EmailVerifiedAt == nil {
+		return nil, ErrEmailNotVerified
+	}
+
+	if user.IsMFAEnabled() {
+		challenge, err := u.issueMFAChallenge(user, now)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{MFAChallenge: challenge}, nil
+	}
+
+	tokens, err := u.completeLogin(ctx, user, client)
+	if errors.Is(err, ErrInvalidEmailOrPassword) {
+		return nil, ErrOIDCLoginFailed
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{Tokens: tokens}, nil
+}
+
+// registerOIDCUser は、プロバイダーのアカウントを連携した新しいユーザーを登録します
+// 既存のユーザーと同じメールアドレスの場合は、アカウントを乗っ取られないよう自動的には連携せず、
+// ログインしてから連携するよう求めます
+// パスワードは設定せず、プロバイダーが確認していないメールアドレスの場合は確認メールを送信します
+func (u *userUseCase) registerOIDCUser(ctx context.Context, providerName string, external *model.ExternalIdentity, language model.Language) (*model.User, error) {
+	if external.Email == "" {
+		return nil, ErrOIDCEmailRequired
+	}
+
+	name := strings.TrimSpace(external.Name)
+	if name == "" {
+		name, _, _ = strings.Cut(external.Email, "@")
+	}
+	user := &model.User{
+		Name:              name,
+		Email:             external.Email,
+		Role:              model.RoleUser,
+		PreferredLanguage: language.OrDefault(),
+	}
+
+	var verificationToken string
+	if external.EmailVerified {
+		verifiedAt := u.config.Clock.Now()
+		user.EmailVerifiedAt = &verifiedAt
+	} else {
+		token, err := u.prepareEmailVerification(user)
+		if err != nil {
+			return nil, err
+		}
+		verificationToken = token
+	}
+
+	err := u.transactions.WithinTransaction(ctx, func(ctx context.Context) error {
+		_, err := u.userRepo.FindByEmail(ctx, external.Email)
+		if err == nil {
+			return ErrOIDCEmailAlreadyExists
+		}
+		if !errors.Is(err, model.ErrNotFound) {
+			return err
+		}
+
+		if err := u.userRepo.Create(ctx, user); err != nil {
+			if errors.Is(err, model.ErrDuplicateEmail) {
+				return ErrOIDCEmailAlreadyExists
+			}
+			return err
+		}
+
+		// 同時に同じアカウントでログインした場合は、一意制約の違反として検出される
+		err = u.identityRepo.Create(ctx, &model.Identity{
+			UserID:   user.ID,
+			Provider: providerName,
+			Subject:  external.Subject,
+			Email:    external.Email,
+		})
+		if errors.Is(err, model.ErrDuplicateIdentity) {
+			return ErrIdentityAlreadyLinked
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	u.metrics.UserRegistered()
+	slog.InfoContext(ctx, "registered user with linked identity", "user_id", user.ID, "provider", providerName)
+
+	if verificationToken != "" {
+		u.sendVerificationEmail(ctx, user, user.Email, verificationToken)
+	}
+	return user, nil
+}
+
+// BeginOIDCLink は、ログイン中のユーザーにプロバイダーのアカウントを連携するための認可を開始します
+func (u *userUseCase) BeginOIDCLink(ctx context.Context, userID uint, provider string) (_ *OIDCAuthorization, err error) {
+	ctx, span := startSpan(ctx, "UserUseCase.BeginOIDCLink")
+	defer func() { endSpan(span, err) }()
+
+	return u.beginOIDC(ctx, provider, &userID)
+}
+
+// CompleteOIDCLink は、プロバイダーから受け取った認可コードで確認したアカウントをユーザーに連携します
+// 他のユーザーに連携済みのアカウントや、ユーザーが連携済みのプロバイダーのアカウントは連携できません
+func (u *userUseCase) CompleteOIDCLink(ctx context.Context, userID uint, provider, code, state string) (_ *model.Identity, err error) {
+	ctx, span := startSpan(ctx, "UserUseCase.CompleteOIDCLink")
+	defer func() { endSpan(span, err) }()
+
+	external, err := u.completeOIDC(ctx, provider, code, state, &userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := u.GetByID(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	identity := &model.Identity{
+		UserID:   userID,
+		Provider: provider,
+		Subject:  external.Subject,
+		Email:    external.Email,
+	}
+	err = u.identityRepo.Create(ctx, identity)
+	if errors.Is(err, model.ErrDuplicateIdentity) {
+		return nil, ErrIdentityAlreadyLinked
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "identity linked", "user_id", userID, "provider", provider)
+	return identity, nil
+}
+
+// ListIdentities は、ユーザーに連携したアカウントを連携した順に返します
+func (u *userUseCase) ListIdentities(ctx context.Context, userID uint) (_ []model.Identity, err error) {
+	ctx, span := startSpan(ctx, "UserUseCase.ListIdentities")
+	defer func() { endSpan(span, err) }()
+
+	return u.identityRepo.FindByUserID(ctx, userID)
+}
+
+// UnlinkIdentity は、ユーザーに連携したプロバイダーのアカウントの連携を解除します
+// パスワードを設定していないユーザーの最後の連携は、ログインできなくなるため解除できません
+func (u *userUseCase) UnlinkIdentity(ctx context.Context, userID uint, provider string) (err error) {
+	ctx, span := startSpan(ctx, "UserUseCase.UnlinkIdentity")
+	defer func() { endSpan(span, err) }()
+
+	err = u.transactions.WithinTransaction(ctx, func(ctx context.Context) error {
+		user, err := u.GetByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		identities, err := u.identityRepo.FindByUserID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		linked := false
+		for _, identity := range identities {
+			if identity.Provider == provider {
+				linked = true
+				break
+			}
+		}
+		if !linked {
+			return ErrIdentityNotFound
+		}
+		if user.Password == "" && len(identities) == 1 {
+			return ErrLastLoginMethod
+		}
+
+		err = u.identityRepo.Delete(ctx, userID, provider)
+		if errors.Is(err, model.ErrNotFound) {
+			return ErrIdentityNotFound
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "identity unlinked", "user_id", userID, "provider", provider)
+	return nil
+}
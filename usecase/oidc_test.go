@@ -0,0 +1,437 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+	"voice-link/domain/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// stubOIDCProvider は、設定したアカウントの情報を返すテスト用のOIDCProviderです
+type stubOIDCProvider struct {
+	identity *model.ExternalIdentity
+	err      error
+
+	codeChallenge string // AuthCodeURLに渡されたcode_challenge
+	codeVerifier  string // Exchangeに渡されたcode_verifier
+	nonce         string // Exchangeに渡されたnonce
+}
+
+func (p *stubOIDCProvider) AuthCodeURL(_ context.Context, state, nonce, codeChallenge string) (string, error) {
+	p.codeChallenge = codeChallenge
+	return "https://provider.example.com/authorize?state=" + state, nil
+}
+
+func (p *stubOIDCProvider) Exchange(_ context.Context, code, codeVerifier, nonce string) (*model.ExternalIdentity, error) {
+	p.codeVerifier, p.nonce = codeVerifier, nonce
+	return p.identity, p.err
+}
+
+// oidcTestConfig は、stubOIDCProviderを"mock"プロバイダーとして登録した設定を返します
+func oidcTestConfig(provider *stubOIDCProvider, now time.Time) UserUseCaseConfig {
+	config := testUserUseCaseConfig
+	config.Clock = &fakeClock{now: now}
+	config.OIDCProviders = map[string]model.OIDCProvider{"mock": provider}
+	return config
+}
+
+// oidcAuthRequest は、stateを"state"として保存された認可リクエストを返します
+func oidcAuthRequest(now time.Time, userID *uint) *model.OIDCAuthRequest {
+	return &model.OIDCAuthRequest{
+		StateHash:    hashToken("state"),
+		Provider:     "mock",
+		Nonce:        "nonce",
+		CodeVerifier: "verifier",
+		UserID:       userID,
+		ExpiresAt:    now.Add(5 * time.Minute),
+	}
+}
+
+func TestUserUseCase_BeginOIDCLogin(t *testing.T) {
+	now := time.Now()
+
+	t.Run("認可リクエストを保存して認可画面のURLを返す", func(t *testing.T) {
+		provider := &stubOIDCProvider{}
+		mockRequestRepo := new(MockOIDCAuthRequestRepository)
+		var saved *model.OIDCAuthRequest
+		mockRequestRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.OIDCAuthRequest"), now).Run(func(args mock.Arguments) {
+			saved = args.Get(1).(*model.OIDCAuthRequest)
+		}).Return(nil)
+		useCase := NewUserUseCase(new(MockUserRepository), new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), mockRequestRepo, new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, oidcTestConfig(provider, now))
+
+		authorization, err := useCase.BeginOIDCLogin(context.Background(), "mock")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "https://provider.example.com/authorize?state="+authorization.State, authorization.URL)
+		assert.Equal(t, int64(oidcAuthRequestTTL.Seconds()), authorization.ExpiresIn)
+		if assert.NotNil(t, saved) {
+			// stateはハッシュ化して保存し、code_challengeは保存したcode_verifierから作成する
+			assert.Equal(t, hashToken(authorization.State), saved.StateHash)
+			assert.Equal(t, "mock", saved.Provider)
+			assert.Nil(t, saved.UserID)
+			assert.NotEmpty(t, saved.Nonce)
+			assert.GreaterOrEqual(t, len(saved.CodeVerifier), 43)
+			assert.Equal(t, oidcCodeChallenge(saved.CodeVerifier), provider.codeChallenge)
+			assert.Equal(t, now.Add(oidcAuthRequestTTL), saved.ExpiresAt)
+		}
+	})
+
+	t.Run("設定していないプロバイダーはエラー", func(t *testing.T) {
+		useCase := NewUserUseCase(new(MockUserRepository), new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, oidcTestConfig(&stubOIDCProvider{}, now))
+
+		_, err := useCase.BeginOIDCLogin(context.Background(), "unknown")
+
+		assert.ErrorIs(t, err, ErrUnknownOIDCProvider)
+	})
+}
+
+func TestUserUseCase_CompleteOIDCLogin(t *testing.T) {
+	now := time.Now()
+	external := &model.ExternalIdentity{Subject: "subject-1", Email: "test@example.com", EmailVerified: true, Name: "テストユーザー"}
+	linkedUser := func() *model.User {
+		return &model.User{ID: 1, Name: "テストユーザー", Email: "test@example.com", EmailVerifiedAt: &now}
+	}
+
+	t.Run("連携したアカウントのユーザーにトークンを発行する", func(t *testing.T) {
+		provider := &stubOIDCProvider{identity: external}
+		mockRepo := new(MockUserRepository)
+		mockTokenRepo := new(MockRefreshTokenRepository)
+		mockIdentityRepo := new(MockIdentityRepository)
+		mockRequestRepo := new(MockOIDCAuthRequestRepository)
+		mockRevocations := new(MockTokenRevocationStore)
+		mockRequestRepo.On("Consume", mock.Anything, hashToken("state")).Return(oidcAuthRequest(now, nil), nil)
+		mockIdentityRepo.On("FindByProviderSubject", mock.Anything, "mock", "subject-1").Return(&model.Identity{UserID: 1, Provider: "mock", Subject: "subject-1"}, nil)
+		mockRepo.On("FindByIDIncludingDeleted", mock.Anything, uint(1)).Return(linkedUser(), nil)
+		mockRevocations.On("GetUserTokenVersion", mock.Anything, uint(1)).Return(uint(1), nil)
+		mockSessions := new(MockSessionRepository)
+		mockSessions.On("Create", mock.Anything, mock.AnythingOfType("*model.Session")).Return(nil)
+		mockTokenRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.RefreshToken")).Return(nil)
+		useCase := NewUserUseCase(mockRepo, mockTokenRepo, mockSessions, new(MockRecoveryCodeRepository), mockIdentityRepo, mockRequestRepo, mockRevocations, new(stubTransactionManager), new(MockMailer), nil, oidcTestConfig(provider, now))
+
+		result, err := useCase.CompleteOIDCLogin(context.Background(), "mock", "code", "state", model.LanguageJapanese, ClientInfo{})
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, result.Tokens.AccessToken)
+		// 認可リクエストのcode_verifierとnonceで交換する
+		assert.Equal(t, "verifier", provider.codeVerifier)
+		assert.Equal(t, "nonce", provider.nonce)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("連携したアカウントがない場合はパスワードなしのユーザーを登録する", func(t *testing.T) {
+		provider := &stubOIDCProvider{identity: external}
+		mockRepo := new(MockUserRepository)
+		mockTokenRepo := new(MockRefreshTokenRepository)
+		mockIdentityRepo := new(MockIdentityRepository)
+		mockRequestRepo := new(MockOIDCAuthRequestRepository)
+		mockRevocations := new(MockTokenRevocationStore)
+		mockMailer := new(MockMailer)
+		mockRequestRepo.On("Consume", mock.Anything, hashToken("state")).Return(oidcAuthRequest(now, nil), nil)
+		mockIdentityRepo.On("FindByProviderSubject", mock.Anything, "mock", "subject-1").Return(nil, model.ErrNotFound)
+		mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(nil, model.ErrNotFound)
+		var created *model.User
+		mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.User")).Run(func(args mock.Arguments) {
+			created = args.Get(1).(*model.User)
+			created.ID = 5
+		}).Return(nil)
+		mockIdentityRepo.On("Create", mock.Anything, &model.Identity{UserID: 5, Provider: "mock", Subject: "subject-1", Email: "test@example.com"}).Return(nil)
+		mockRevocations.On("GetUserTokenVersion", mock.Anything, uint(5)).Return(uint(1), nil)
+		mockSessions := new(MockSessionRepository)
+		mockSessions.On("Create", mock.Anything, mock.AnythingOfType("*model.Session")).Return(nil)
+		mockTokenRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.RefreshToken")).Return(nil)
+		transactions := new(stubTransactionManager)
+		useCase := NewUserUseCase(mockRepo, mockTokenRepo, mockSessions, new(MockRecoveryCodeRepository), mockIdentityRepo, mockRequestRepo, mockRevocations, transactions, mockMailer, nil, oidcTestConfig(provider, now))
+
+		result, err := useCase.CompleteOIDCLogin(context.Background(), "mock", "code", "state", model.LanguageEnglish, ClientInfo{})
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, result.Tokens.AccessToken)
+		if assert.NotNil(t, created) {
+			assert.Equal(t, "テストユーザー", created.Name)
+			assert.Empty(t, created.Password)
+			assert.Equal(t, model.RoleUser, created.Role)
+			assert.Equal(t, model.LanguageEnglish, created.PreferredLanguage)
+			// プロバイダーが確認済みのメールアドレスは確認済みとして登録する
+			assert.Equal(t, &now, created.EmailVerifiedAt)
+		}
+		assert.Equal(t, 1, transactions.calls)
+		mockIdentityRepo.AssertExpectations(t)
+		mockMailer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+
+	t.Run("確認していないメールアドレスの場合は確認メールを送信し、確認が必要な設定ではログインを拒否する", func(t *testing.T) {
+		provider := &stubOIDCProvider{identity: &model.ExternalIdentity{Subject: "subject-1", Email: "test@example.com"}}
+		mockRepo := new(MockUserRepository)
+		mockIdentityRepo := new(MockIdentityRepository)
+		mockRequestRepo := new(MockOIDCAuthRequestRepository)
+		mockMailer := new(MockMailer)
+		mockRequestRepo.On("Consume", mock.Anything, hashToken("state")).Return(oidcAuthRequest(now, nil), nil)
+		mockIdentityRepo.On("FindByProviderSubject", mock.Anything, "mock", "subject-1").Return(nil, model.ErrNotFound)
+		mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(nil, model.ErrNotFound)
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(user *model.User) bool {
+			// 名前がない場合はメールアドレスのローカル部を使用する
+			return user.Name == "test" && user.EmailVerifiedAt == nil && user.EmailVerificationToken != nil
+		})).Return(nil)
+		mockIdentityRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.Identity")).Return(nil)
+		mockMailer.On("Send", mock.Anything, mock.MatchedBy(func(mail *model.Mail) bool { return mail.To == "test@example.com" })).Return(nil)
+		config := oidcTestConfig(provider, now)
+		config.RequireEmailVerification = true
+		useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), mockIdentityRepo, mockRequestRepo, new(MockTokenRevocationStore), new(stubTransactionManager), mockMailer, nil, config)
+
+		_, err := useCase.CompleteOIDCLogin(context.Background(), "mock", "code", "state", "", ClientInfo{})
+
+		assert.ErrorIs(t, err, ErrEmailNotVerified)
+		mockRepo.AssertExpectations(t)
+		mockMailer.AssertExpectations(t)
+	})
+
+	t.Run("同じメールアドレスのユーザーが存在する場合は自動的に連携しない", func(t *testing.T) {
+		provider := &stubOIDCProvider{identity: external}
+		mockRepo := new(MockUserRepository)
+		mockIdentityRepo := new(MockIdentityRepository)
+		mockRequestRepo := new(MockOIDCAuthRequestRepository)
+		mockRequestRepo.On("Consume", mock.Anything, hashToken("state")).Return(oidcAuthRequest(now, nil), nil)
+		mockIdentityRepo.On("FindByProviderSubject", mock.Anything, "mock", "subject-1").Return(nil, model.ErrNotFound)
+		mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(linkedUser(), nil)
+		useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), mockIdentityRepo, mockRequestRepo, new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, oidcTestConfig(provider, now))
+
+		_, err := useCase.CompleteOIDCLogin(context.Background(), "mock", "code", "state", model.LanguageJapanese, ClientInfo{})
+
+		assert.ErrorIs(t, err, ErrOIDCEmailAlreadyExists)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		mockIdentityRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("メールアドレスを取得できない場合は登録しない", func(t *testing.T) {
+		provider := &stubOIDCProvider{identity: &model.ExternalIdentity{Subject: "subject-1"}}
+		mockIdentityRepo := new(MockIdentityRepository)
+		mockRequestRepo := new(MockOIDCAuthRequestRepository)
+		mockRequestRepo.On("Consume", mock.Anything, hashToken("state")).Return(oidcAuthRequest(now, nil), nil)
+		mockIdentityRepo.On("FindByProviderSubject", mock.Anything, "mock", "subject-1").Return(nil, model.ErrNotFound)
+		useCase := NewUserUseCase(new(MockUserRepository), new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), mockIdentityRepo, mockRequestRepo, new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, oidcTestConfig(provider, now))
+
+		_, err := useCase.CompleteOIDCLogin(context.Background(), "mock", "code", "state", model.LanguageJapanese, ClientInfo{})
+
+		assert.ErrorIs(t, err, ErrOIDCEmailRequired)
+	})
+
+	t.Run("2段階認証を有効にしている場合はチャレンジを返す", func(t *testing.T) {
+		provider := &stubOIDCProvider{identity: external}
+		mockRepo := new(MockUserRepository)
+		mockIdentityRepo := new(MockIdentityRepository)
+		mockRequestRepo := new(MockOIDCAuthRequestRepository)
+		mockTokenRepo := new(MockRefreshTokenRepository)
+		user := linkedUser()
+		secret := rfc6238Secret
+		user.MFASecret, user.MFAEnabledAt = &secret, &now
+		mockRequestRepo.On("Consume", mock.Anything, hashToken("state")).Return(oidcAuthRequest(now, nil), nil)
+		mockIdentityRepo.On("FindByProviderSubject", mock.Anything, "mock", "subject-1").Return(&model.Identity{UserID: 1}, nil)
+		mockRepo.On("FindByIDIncludingDeleted", mock.Anything, uint(1)).Return(user, nil)
+		useCase := NewUserUseCase(mockRepo, mockTokenRepo, new(MockSessionRepository), new(MockRecoveryCodeRepository), mockIdentityRepo, mockRequestRepo, new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, oidcTestConfig(provider, now))
+
+		result, err := useCase.CompleteOIDCLogin(context.Background(), "mock", "code", "state", model.LanguageJapanese, ClientInfo{})
+
+		assert.NoError(t, err)
+		assert.Nil(t, result.Tokens)
+		assert.NotNil(t, result.MFAChallenge)
+		mockTokenRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("ロック中のアカウントにはログインできない", func(t *testing.T) {
+		provider := &stubOIDCProvider{identity: external}
+		mockRepo := new(MockUserRepository)
+		mockIdentityRepo := new(MockIdentityRepository)
+		mockRequestRepo := new(MockOIDCAuthRequestRepository)
+		user := linkedUser()
+		lockedUntil := now.Add(10 * time.Minute)
+		user.LockedUntil = &lockedUntil
+		mockRequestRepo.On("Consume", mock.Anything, hashToken("state")).Return(oidcAuthRequest(now, nil), nil)
+		mockIdentityRepo.On("FindByProviderSubject", mock.Anything, "mock", "subject-1").Return(&model.Identity{UserID: 1}, nil)
+		mockRepo.On("FindByIDIncludingDeleted", mock.Anything, uint(1)).Return(user, nil)
+		useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), mockIdentityRepo, mockRequestRepo, new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, oidcTestConfig(provider, now))
+
+		_, err := useCase.CompleteOIDCLogin(context.Background(), "mock", "code", "state", model.LanguageJapanese, ClientInfo{})
+
+		assert.ErrorIs(t, err, ErrAccountLocked)
+	})
+
+	t.Run("猶予期間を過ぎた退会済みのユーザーはログインできない", func(t *testing.T) {
+		provider := &stubOIDCProvider{identity: external}
+		mockRepo := new(MockUserRepository)
+		mockIdentityRepo := new(MockIdentityRepository)
+		mockRequestRepo := new(MockOIDCAuthRequestRepository)
+		user := linkedUser()
+		deletedAt := time.Now().Add(-defaultDeletionGracePeriod - time.Hour)
+		user.DeletedAt = &deletedAt
+		mockRequestRepo.On("Consume", mock.Anything, hashToken("state")).Return(oidcAuthRequest(now, nil), nil)
+		mockIdentityRepo.On("FindByProviderSubject", mock.Anything, "mock", "subject-1").Return(&model.Identity{UserID: 1}, nil)
+		mockRepo.On("FindByIDIncludingDeleted", mock.Anything, uint(1)).Return(user, nil)
+		useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), mockIdentityRepo, mockRequestRepo, new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, oidcTestConfig(provider, now))
+
+		_, err := useCase.CompleteOIDCLogin(context.Background(), "mock", "code", "state", model.LanguageJapanese, ClientInfo{})
+
+		assert.ErrorIs(t, err, ErrOIDCLoginFailed)
+		mockRepo.AssertNotCalled(t, "Restore", mock.Anything, mock.Anything)
+	})
+
+	userID := uint(1)
+	invalidStates := []struct {
+		name    string
+		request *model.OIDCAuthRequest
+		err     error
+	}{
+		{name: "存在しないまたは使用済みのstate", err: model.ErrNotFound},
+		{name: "他のプロバイダーのstate", request: &model.OIDCAuthRequest{Provider: "other", ExpiresAt: now.Add(time.Minute)}},
+		{name: "期限切れのstate", request: &model.OIDCAuthRequest{Provider: "mock", ExpiresAt: now.Add(-time.Second)}},
+		{name: "アカウントの連携のstate", request: oidcAuthRequest(now, &userID)},
+	}
+	for _, tt := range invalidStates {
+		t.Run(tt.name+"は拒否する", func(t *testing.T) {
+			provider := &stubOIDCProvider{identity: external}
+			mockRequestRepo := new(MockOIDCAuthRequestRepository)
+			if tt.request != nil {
+				mockRequestRepo.On("Consume", mock.Anything, hashToken("state")).Return(tt.request, nil)
+			} else {
+				mockRequestRepo.On("Consume", mock.Anything, hashToken("state")).Return(nil, tt.err)
+			}
+			useCase := NewUserUseCase(new(MockUserRepository), new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), mockRequestRepo, new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, oidcTestConfig(provider, now))
+
+			_, err := useCase.CompleteOIDCLogin(context.Background(), "mock", "code", "state", model.LanguageJapanese, ClientInfo{})
+
+			assert.ErrorIs(t, err, ErrInvalidOIDCState)
+			// 認可コードは交換しない
+			assert.Empty(t, provider.codeVerifier)
+		})
+	}
+
+	t.Run("プロバイダーに拒否された場合はログインに失敗する", func(t *testing.T) {
+		provider := &stubOIDCProvider{err: fmt.Errorf("%w: invalid_grant", model.ErrOIDCRejected)}
+		mockRequestRepo := new(MockOIDCAuthRequestRepository)
+		mockRequestRepo.On("Consume", mock.Anything, hashToken("state")).Return(oidcAuthRequest(now, nil), nil)
+		useCase := NewUserUseCase(new(MockUserRepository), new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), mockRequestRepo, new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, oidcTestConfig(provider, now))
+
+		_, err := useCase.CompleteOIDCLogin(context.Background(), "mock", "code", "state", model.LanguageJapanese, ClientInfo{})
+
+		assert.ErrorIs(t, err, ErrOIDCLoginFailed)
+	})
+
+	t.Run("プロバイダーとの通信に失敗した場合はそのまま返す", func(t *testing.T) {
+		errNetwork := errors.New("connection refused")
+		provider := &stubOIDCProvider{err: errNetwork}
+		mockRequestRepo := new(MockOIDCAuthRequestRepository)
+		mockRequestRepo.On("Consume", mock.Anything, hashToken("state")).Return(oidcAuthRequest(now, nil), nil)
+		useCase := NewUserUseCase(new(MockUserRepository), new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), mockRequestRepo, new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, oidcTestConfig(provider, now))
+
+		_, err := useCase.CompleteOIDCLogin(context.Background(), "mock", "code", "state", model.LanguageJapanese, ClientInfo{})
+
+		assert.ErrorIs(t, err, errNetwork)
+	})
+}
+
+func TestUserUseCase_CompleteOIDCLink(t *testing.T) {
+	now := time.Now()
+	userID := uint(1)
+	external := &model.ExternalIdentity{Subject: "subject-1", Email: "other@example.com", EmailVerified: true}
+
+	t.Run("ログイン中のユーザーにアカウントを連携する", func(t *testing.T) {
+		provider := &stubOIDCProvider{identity: external}
+		mockRepo := new(MockUserRepository)
+		mockIdentityRepo := new(MockIdentityRepository)
+		mockRequestRepo := new(MockOIDCAuthRequestRepository)
+		mockRequestRepo.On("Consume", mock.Anything, hashToken("state")).Return(oidcAuthRequest(now, &userID), nil)
+		mockRepo.On("FindByID", mock.Anything, userID).Return(&model.User{ID: userID, Email: "test@example.com"}, nil)
+		mockIdentityRepo.On("Create", mock.Anything, &model.Identity{UserID: userID, Provider: "mock", Subject: "subject-1", Email: "other@example.com"}).Return(nil)
+		useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), mockIdentityRepo, mockRequestRepo, new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, oidcTestConfig(provider, now))
+
+		identity, err := useCase.CompleteOIDCLink(context.Background(), userID, "mock", "code", "state")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "mock", identity.Provider)
+		mockIdentityRepo.AssertExpectations(t)
+	})
+
+	t.Run("連携済みのアカウントは連携できない", func(t *testing.T) {
+		provider := &stubOIDCProvider{identity: external}
+		mockRepo := new(MockUserRepository)
+		mockIdentityRepo := new(MockIdentityRepository)
+		mockRequestRepo := new(MockOIDCAuthRequestRepository)
+		mockRequestRepo.On("Consume", mock.Anything, hashToken("state")).Return(oidcAuthRequest(now, &userID), nil)
+		mockRepo.On("FindByID", mock.Anything, userID).Return(&model.User{ID: userID}, nil)
+		mockIdentityRepo.On("Create", mock.Anything, mock.Anything).Return(model.ErrDuplicateIdentity)
+		useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), mockIdentityRepo, mockRequestRepo, new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, oidcTestConfig(provider, now))
+
+		_, err := useCase.CompleteOIDCLink(context.Background(), userID, "mock", "code", "state")
+
+		assert.ErrorIs(t, err, ErrIdentityAlreadyLinked)
+	})
+
+	t.Run("他のユーザーが開始した連携は完了できない", func(t *testing.T) {
+		provider := &stubOIDCProvider{identity: external}
+		otherUserID := uint(2)
+		mockRequestRepo := new(MockOIDCAuthRequestRepository)
+		mockRequestRepo.On("Consume", mock.Anything, hashToken("state")).Return(oidcAuthRequest(now, &otherUserID), nil)
+		useCase := NewUserUseCase(new(MockUserRepository), new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), mockRequestRepo, new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, oidcTestConfig(provider, now))
+
+		_, err := useCase.CompleteOIDCLink(context.Background(), userID, "mock", "code", "state")
+
+		assert.ErrorIs(t, err, ErrInvalidOIDCState)
+	})
+}
+
+func TestUserUseCase_UnlinkIdentity(t *testing.T) {
+	tests := []struct {
+		name       string
+		password   string
+		identities []model.Identity
+		expected   error
+	}{
+		{
+			name:       "パスワードを設定しているユーザーは最後の連携も解除できる",
+			password:   "hashed",
+			identities: []model.Identity{{Provider: "google"}},
+		},
+		{
+			name:       "他の連携があれば解除できる",
+			identities: []model.Identity{{Provider: "google"}, {Provider: "apple"}},
+		},
+		{
+			name:       "パスワードがない場合は最後の連携を解除できない",
+			identities: []model.Identity{{Provider: "google"}},
+			expected:   ErrLastLoginMethod,
+		},
+		{
+			name:       "連携していないプロバイダー",
+			password:   "hashed",
+			identities: []model.Identity{{Provider: "apple"}},
+			expected:   ErrIdentityNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			mockIdentityRepo := new(MockIdentityRepository)
+			mockRepo.On("FindByID", mock.Anything, uint(1)).Return(&model.User{ID: 1, Password: tt.password}, nil)
+			mockIdentityRepo.On("FindByUserID", mock.Anything, uint(1)).Return(tt.identities, nil)
+			mockIdentityRepo.On("Delete", mock.Anything, uint(1), "google").Return(nil)
+			transactions := new(stubTransactionManager)
+			useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), mockIdentityRepo, new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), transactions, new(MockMailer), nil, testUserUseCaseConfig)
+
+			err := useCase.UnlinkIdentity(context.Background(), 1, "google")
+
+			if tt.expected != nil {
+				assert.ErrorIs(t, err, tt.expected)
+				mockIdentityRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, 1, transactions.calls)
+			mockIdentityRepo.AssertCalled(t, "Delete", mock.Anything, uint(1), "google")
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"voice-link/domain/model"
+)
+
+// maxUserAgentLength は、セッションに記録するUser-Agentの最大の文字数です
+const maxUserAgentLength = 512
+
+// ClientInfo は、ログインやトークンの更新を行った端末の情報です
+type ClientInfo struct {
+	DeviceName string // クライアントが指定した端末の名前。ログイン時のみ記録します
+	UserAgent  string
+	IPAddress  string
+}
+
+// userAgent は、記録する長さに切り詰めたUser-Agentを返します
+func (c ClientInfo) userAgent() string {
+	runes := []rune(c.UserAgent)
+	if len(runes) > maxUserAgentLength {
+		return string(runes[:maxUserAgentLength])
+	}
+	return c.UserAgent
+}
+
+// startSession は、ログインした端末の新しいセッションを記録します
+// セッションのIDは、アクセストークンのsidとリフレッシュトークンのファミリーIDに使用します
+func (u *userUseCase) startSession(ctx context.Context, user *model.User, client ClientInfo) (*model.Session, error) {
+	id, err := generateSecureToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	now := u.config.Clock.Now()
+	session := &model.Session{
+		ID:         id,
+		UserID:     user.ID,
+		DeviceName: client.DeviceName,
+		UserAgent:  client.userAgent(),
+		IPAddress:  client.IPAddress,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(refreshTokenTTL),
+	}
+	if err := u.sessionRepo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// touchSession は、トークンを更新した端末の情報を記録し、セッションの期限をリフレッシュトークンに合わせて延長します
+func (u *userUseCase) touchSession(ctx context.Context, sessionID string, client ClientInfo) error {
+	now := u.config.Clock.Now()
+	return u.sessionRepo.Touch(ctx, sessionID, client.userAgent(), client.IPAddress, now, now.Add(refreshTokenTTL))
+}
+
+// revokeSession は、セッションと、そのセッションで発行したアクセストークンとリフレッシュトークンを失効させます
+func (u *userUseCase) revokeSession(ctx context.Context, sessionID string) error {
+	if err := u.tokenRevocations.RevokeSession(ctx, sessionID); err != nil {
+		return err
+	}
+	return u.refreshTokenRepo.RevokeFamily(ctx, sessionID)
+}
+
+// ListSessions は、ユーザーがログインしている端末のセッションを最近使用した順に返します
+func (u *userUseCase) ListSessions(ctx context.Context, userID uint) (_ []model.Session, err error) {
+	ctx, span := startSpan(ctx, "UserUseCase.ListSessions")
+	defer func() { endSpan(span, err) }()
+
+	return u.sessionRepo.FindActiveByUserID(ctx, userID, u.config.Clock.Now())
+}
+
+// RevokeSession は、ユーザーのセッションを失効させ、その端末をログアウトさせます
+// 他のユーザーのセッションや、既に失効または期限切れのセッションの場合はErrSessionNotFoundを返します
+func (u *userUseCase) RevokeSession(ctx context.Context, userID uint, sessionID string) (err error) {
+	ctx, span := startSpan(ctx, "UserUseCase.RevokeSession")
+	defer func() { endSpan(span, err) }()
+
+	session, err := u.sessionRepo.FindByID(ctx, sessionID)
+	if errors.Is(err, model.ErrNotFound) {
+		return ErrSessionNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if session.UserID != userID || session.RevokedAt != nil || !session.ExpiresAt.After(u.config.Clock.Now()) {
+		return ErrSessionNotFound
+	}
+
+	return u.revokeSession(ctx, session.ID)
+}
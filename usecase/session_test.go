@@ -0,0 +1,160 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"voice-link/domain/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestUserUseCase_ListSessions(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	config := testUserUseCaseConfig
+	config.Clock = &fakeClock{now: now}
+
+	sessions := []model.Session{
+		{ID: "session-1", UserID: 1, DeviceName: "iPhone"},
+		{ID: "session-2", UserID: 1, DeviceName: "MacBook"},
+	}
+	// 期限切れのセッションを除くため、現在の時刻で検索する
+	mockSessions := new(MockSessionRepository)
+	mockSessions.On("FindActiveByUserID", mock.Anything, uint(1), now).Return(sessions, nil)
+	useCase := NewUserUseCase(new(MockUserRepository), new(MockRefreshTokenRepository), mockSessions, new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, config)
+
+	result, err := useCase.ListSessions(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, sessions, result)
+	mockSessions.AssertExpectations(t)
+}
+
+func TestUserUseCase_RevokeSession(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	config := testUserUseCaseConfig
+	config.Clock = &fakeClock{now: now}
+	revokedAt := now.Add(-time.Minute)
+
+	tests := []struct {
+		name          string
+		sessionInput  string
+		mockSetup     func(*MockSessionRepository, *MockRefreshTokenRepository, *MockTokenRevocationStore)
+		expectedError error
+	}{
+		{
+			name:         "セッションを失効させる",
+			sessionInput: "session-1",
+			mockSetup: func(mockSessions *MockSessionRepository, mockTokenRepo *MockRefreshTokenRepository, mockRevocations *MockTokenRevocationStore) {
+				mockSessions.On("FindByID", mock.Anything, "session-1").Return(&model.Session{ID: "session-1", UserID: 1, ExpiresAt: now.Add(time.Hour)}, nil)
+				// アクセストークンとリフレッシュトークンをどちらも使用できなくする
+				mockRevocations.On("RevokeSession", mock.Anything, "session-1").Return(nil)
+				mockTokenRepo.On("RevokeFamily", mock.Anything, "session-1").Return(nil)
+			},
+		},
+		{
+			name:         "存在しないセッション",
+			sessionInput: "unknown",
+			mockSetup: func(mockSessions *MockSessionRepository, mockTokenRepo *MockRefreshTokenRepository, mockRevocations *MockTokenRevocationStore) {
+				mockSessions.On("FindByID", mock.Anything, "unknown").Return(nil, model.ErrNotFound)
+			},
+			expectedError: ErrSessionNotFound,
+		},
+		{
+			name:         "他のユーザーのセッション",
+			sessionInput: "session-2",
+			mockSetup: func(mockSessions *MockSessionRepository, mockTokenRepo *MockRefreshTokenRepository, mockRevocations *MockTokenRevocationStore) {
+				mockSessions.On("FindByID", mock.Anything, "session-2").Return(&model.Session{ID: "session-2", UserID: 2, ExpiresAt: now.Add(time.Hour)}, nil)
+			},
+			expectedError: ErrSessionNotFound,
+		},
+		{
+			name:         "失効済みのセッション",
+			sessionInput: "session-3",
+			mockSetup: func(mockSessions *MockSessionRepository, mockTokenRepo *MockRefreshTokenRepository, mockRevocations *MockTokenRevocationStore) {
+				mockSessions.On("FindByID", mock.Anything, "session-3").Return(&model.Session{ID: "session-3", UserID: 1, ExpiresAt: now.Add(time.Hour), RevokedAt: &revokedAt}, nil)
+			},
+			expectedError: ErrSessionNotFound,
+		},
+		{
+			name:         "期限切れのセッション",
+			sessionInput: "session-4",
+			mockSetup: func(mockSessions *MockSessionRepository, mockTokenRepo *MockRefreshTokenRepository, mockRevocations *MockTokenRevocationStore) {
+				mockSessions.On("FindByID", mock.Anything, "session-4").Return(&model.Session{ID: "session-4", UserID: 1, ExpiresAt: now.Add(-time.Hour)}, nil)
+			},
+			expectedError: ErrSessionNotFound,
+		},
+		{
+			name:         "現在の時刻に期限を迎えたセッション",
+			sessionInput: "session-6",
+			mockSetup: func(mockSessions *MockSessionRepository, mockTokenRepo *MockRefreshTokenRepository, mockRevocations *MockTokenRevocationStore) {
+				mockSessions.On("FindByID", mock.Anything, "session-6").Return(&model.Session{ID: "session-6", UserID: 1, ExpiresAt: now}, nil)
+			},
+			expectedError: ErrSessionNotFound,
+		},
+		{
+			name:         "失効の保存に失敗",
+			sessionInput: "session-5",
+			mockSetup: func(mockSessions *MockSessionRepository, mockTokenRepo *MockRefreshTokenRepository, mockRevocations *MockTokenRevocationStore) {
+				mockSessions.On("FindByID", mock.Anything, "session-5").Return(&model.Session{ID: "session-5", UserID: 1, ExpiresAt: now.Add(time.Hour)}, nil)
+				mockRevocations.On("RevokeSession", mock.Anything, "session-5").Return(errors.New("database error"))
+			},
+			expectedError: errors.New("database error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSessions := new(MockSessionRepository)
+			mockTokenRepo := new(MockRefreshTokenRepository)
+			mockRevocations := new(MockTokenRevocationStore)
+			tt.mockSetup(mockSessions, mockTokenRepo, mockRevocations)
+			useCase := NewUserUseCase(new(MockUserRepository), mockTokenRepo, mockSessions, new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), mockRevocations, new(stubTransactionManager), new(MockMailer), nil, config)
+
+			err := useCase.RevokeSession(context.Background(), 1, tt.sessionInput)
+
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			mockSessions.AssertExpectations(t)
+			mockTokenRepo.AssertExpectations(t)
+			mockRevocations.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserUseCase_SessionTimestamps(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	config := testUserUseCaseConfig
+	config.Clock = &fakeClock{now: now}
+	client := ClientInfo{DeviceName: "iPhone", UserAgent: "VoiceLink/1.0", IPAddress: "192.0.2.1"}
+
+	// ログイン日時、最終利用日時、期限は注入した時計の時刻から決める
+	mockSessions := new(MockSessionRepository)
+	mockSessions.On("Create", mock.Anything, mock.MatchedBy(func(session *model.Session) bool {
+		return session.LastSeenAt.Equal(now) && session.ExpiresAt.Equal(now.Add(refreshTokenTTL))
+	})).Return(nil)
+	mockSessions.On("Touch", mock.Anything, "session-1", "VoiceLink/1.0", "192.0.2.1", now, now.Add(refreshTokenTTL)).Return(nil)
+	useCase := NewUserUseCase(new(MockUserRepository), new(MockRefreshTokenRepository), mockSessions, new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, config).(*userUseCase)
+
+	session, err := useCase.startSession(context.Background(), &model.User{ID: 1}, client)
+	assert.NoError(t, err)
+	assert.Equal(t, "iPhone", session.DeviceName)
+	assert.NoError(t, useCase.touchSession(context.Background(), "session-1", client))
+	mockSessions.AssertExpectations(t)
+}
+
+func TestClientInfo_UserAgent(t *testing.T) {
+	// 長いUser-Agentは文字単位で切り詰めて記録する
+	long := ClientInfo{UserAgent: strings.Repeat("あ", maxUserAgentLength+10)}
+	assert.Equal(t, strings.Repeat("あ", maxUserAgentLength), long.userAgent())
+
+	short := ClientInfo{UserAgent: "Mozilla/5.0"}
+	assert.Equal(t, "Mozilla/5.0", short.userAgent())
+}
@@ -0,0 +1,226 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+	"voice-link/domain/model"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// accessTokenTTL は、アクセストークンの有効期間です
+	accessTokenTTL = 15 * time.Minute
+	// refreshTokenTTL は、リフレッシュトークンの有効期間です
+	refreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// TokenPair は、ログインおよびトークン更新時に発行されるトークンの組です
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    int64 // アクセストークンの有効期間（秒）
+}
+
+// generateSecureToken は、暗号学的に安全なランダム文字列を生成します
+func generateSecureToken(size int) (string, error) {
+	bytes := make([]byte, size)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// hashToken は、トークンをデータベースに保存するためにSHA-256でハッシュ化します
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// issueAccessToken は、ユーザーのアクセストークン（JWT）を生成します
+// sessionIDには、同じログインから発行されたトークンを識別するリフレッシュトークンのファミリーIDを設定します
+func (u *userUseCase) issueAccessToken(ctx context.Context, user *model.User, sessionID string) (string, error) {
+	// 一括失効の判定に使用するトークン世代を取得
+	version, err := u.tokenRevocations.GetUserTokenVersion(ctx, user.ID)
+	if err != nil {
+		return "", err
+	}
+
+	jti, err := generateSecureToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	now := u.config.Clock.Now()
+	claims := jwt.MapClaims{
+		"user_id":       user.ID,
+		"role":          user.Role,
+		"sid":           sessionID,
+		"token_version": version,
+		"lang":          user.PreferredLanguage,
+		"jti":           jti,
+		"exp":           now.Add(accessTokenTTL).Unix(),
+		"iat":           now.Unix(),
+	}
+	if u.config.TokenIssuer != "" {
+		claims["iss"] = u.config.TokenIssuer
+	}
+	if len(u.config.TokenAudience) > 0 {
+		claims["aud"] = u.config.TokenAudience
+	}
+
+	// トークンの署名
+	// 検証する側が鍵を選べるよう、非対称鍵の場合はkidを付ける
+	key := u.config.TokenKeys.SigningKey()
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
+	return token.SignedString(key.PrivateKey)
+}
+
+// issueTokens は、アクセストークンと指定されたファミリーのリフレッシュトークンを発行します
+func (u *userUseCase) issueTokens(ctx context.Context, user *model.User, familyID string) (*TokenPair, error) {
+	accessToken, err := u.issueAccessToken(ctx, user, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := generateSecureToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	// リフレッシュトークンはハッシュ値のみ保存する
+	if err := u.refreshTokenRepo.Create(ctx, &model.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(refreshToken),
+		FamilyID:  familyID,
+		ExpiresAt: u.config.Clock.Now().Add(refreshTokenTTL),
+	}); err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(accessTokenTTL.Seconds()),
+	}, nil
+}
+
+// RefreshToken は、リフレッシュトークンをローテーションして新しいトークンの組を発行します
+// 使用済みのリフレッシュトークンが再利用された場合は、同じファミリーのトークンをすべて失効させます
+// clientには、トークンを更新した端末の情報を指定し、セッションの最終利用日時とともに記録します
+func (u *userUseCase) RefreshToken(ctx context.Context, refreshToken string, client ClientInfo) (_ *TokenPair, err error) {
+	ctx, span := startSpan(ctx, "UserUseCase.RefreshToken")
+	defer func() { endSpan(span, err) }()
+
+	stored, err := u.refreshTokenRepo.FindByTokenHash(ctx, hashToken(refreshToken))
+	if errors.Is(err, model.ErrNotFound) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 使用済みまたは失効済みのトークンが提示された場合は漏洩の可能性があるため、セッションごと失効させる
+	if stored.UsedAt != nil || stored.RevokedAt != nil {
+		if err := u.revokeSession(ctx, stored.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	if u.config.Clock.Now().After(stored.ExpiresAt) {
+		return nil, ErrRefreshTokenExpired
+	}
+
+	// 同時に同じトークンで更新された場合も再利用として扱う
+	marked, err := u.refreshTokenRepo.MarkUsed(ctx, stored.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !marked {
+		if err := u.revokeSession(ctx, stored.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	user, err := u.userRepo.FindByID(ctx, stored.UserID)
+	if errors.Is(err, model.ErrNotFound) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.touchSession(ctx, stored.FamilyID, client); err != nil {
+		return nil, err
+	}
+
+	return u.issueTokens(ctx, user, stored.FamilyID)
+}
+
+// Logout は、現在のセッションからログアウトします
+// 使用中のアクセストークンと、同じセッションのアクセストークンとリフレッシュトークンを失効させます
+func (u *userUseCase) Logout(ctx context.Context, userID uint, jti, sessionID string, expiresAt time.Time) (err error) {
+	ctx, span := startSpan(ctx, "UserUseCase.Logout")
+	defer func() { endSpan(span, err) }()
+
+	if jti != "" {
+		if err := u.tokenRevocations.RevokeToken(ctx, jti, userID, expiresAt); err != nil {
+			return err
+		}
+	}
+
+	if sessionID != "" {
+		if err := u.revokeSession(ctx, sessionID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LogoutAll は、ユーザーのすべてのセッションからログアウトします
+// 発行済みのアクセストークンとリフレッシュトークンをすべて失効させます
+func (u *userUseCase) LogoutAll(ctx context.Context, userID uint) (err error) {
+	ctx, span := startSpan(ctx, "UserUseCase.LogoutAll")
+	defer func() { endSpan(span, err) }()
+
+	if err := u.tokenRevocations.RevokeAllUserTokens(ctx, userID); err != nil {
+		return err
+	}
+	if err := u.sessionRepo.RevokeAllByUserID(ctx, userID); err != nil {
+		return err
+	}
+
+	return u.refreshTokenRepo.RevokeAllByUserID(ctx, userID)
+}
+
+// PurgeExpiredTokens は、有効期限を過ぎた失効済みのアクセストークン、リフレッシュトークン、セッションを削除し、削除した件数を返します
+// 期限切れのトークンは署名または有効期限の検証で拒否されるため、失効の判定には影響しません
+func (u *userUseCase) PurgeExpiredTokens(ctx context.Context) (purged int64, err error) {
+	ctx, span := startSpan(ctx, "UserUseCase.PurgeExpiredTokens")
+	defer func() { endSpan(span, err) }()
+
+	now := u.config.Clock.Now()
+	for _, deleteExpired := range []func(context.Context, time.Time) (int64, error){
+		u.tokenRevocations.DeleteExpired,
+		u.refreshTokenRepo.DeleteExpired,
+		u.sessionRepo.DeleteExpired,
+	} {
+		deleted, err := deleteExpired(ctx, now)
+		purged += deleted
+		if err != nil {
+			return purged, err
+		}
+	}
+
+	return purged, nil
+}
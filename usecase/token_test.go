@@ -0,0 +1,366 @@
+package usecase
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+	"voice-link/domain/model"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestUserUseCase_RefreshToken(t *testing.T) {
+	// 期限はシステムの時刻ではなく、時刻の取得元の時刻で判定する
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	config := testUserUseCaseConfig
+	config.Clock = &fakeClock{now: now}
+	user := &model.User{
+		ID:    1,
+		Name:  "テストユーザー",
+		Email: "test@example.com",
+		Role:  model.RoleUser,
+	}
+
+	tests := []struct {
+		name          string
+		tokenInput    string
+		mockSetup     func(*MockUserRepository, *MockRefreshTokenRepository, *MockSessionRepository, *MockTokenRevocationStore)
+		expectedError error
+	}{
+		{
+			name:       "正常なトークン更新",
+			tokenInput: "valid-refresh-token",
+			mockSetup: func(mockRepo *MockUserRepository, mockTokenRepo *MockRefreshTokenRepository, mockSessions *MockSessionRepository, mockRevocations *MockTokenRevocationStore) {
+				stored := &model.RefreshToken{
+					ID:        10,
+					UserID:    1,
+					FamilyID:  "family-1",
+					ExpiresAt: now.Add(time.Hour),
+				}
+				mockTokenRepo.On("FindByTokenHash", mock.Anything, hashToken("valid-refresh-token")).Return(stored, nil)
+				mockTokenRepo.On("MarkUsed", mock.Anything, uint(10)).Return(true, nil)
+				mockRepo.On("FindByID", mock.Anything, uint(1)).Return(user, nil)
+				mockRevocations.On("GetUserTokenVersion", mock.Anything, uint(1)).Return(uint(0), nil)
+				// 更新した端末の情報が記録され、セッションの期限が延長されること
+				mockSessions.On("Touch", mock.Anything, "family-1", "VoiceLink/1.0 (Android)", "203.0.113.5", mock.Anything, mock.MatchedBy(func(expiresAt time.Time) bool {
+					return expiresAt.After(now.Add(refreshTokenTTL - time.Minute))
+				})).Return(nil)
+				// 同じファミリーで新しいリフレッシュトークンが作成されること
+				mockTokenRepo.On("Create", mock.Anything, mock.MatchedBy(func(token *model.RefreshToken) bool {
+					return token.FamilyID == "family-1" && token.UserID == 1 && token.ExpiresAt.Equal(now.Add(refreshTokenTTL))
+				})).Return(nil)
+			},
+			expectedError: nil,
+		},
+		{
+			name:       "存在しないトークン",
+			tokenInput: "unknown-token",
+			mockSetup: func(mockRepo *MockUserRepository, mockTokenRepo *MockRefreshTokenRepository, mockSessions *MockSessionRepository, mockRevocations *MockTokenRevocationStore) {
+				mockTokenRepo.On("FindByTokenHash", mock.Anything, hashToken("unknown-token")).Return(nil, model.ErrNotFound)
+			},
+			expectedError: errors.New("invalid refresh token"),
+		},
+		{
+			name:       "使用済みトークンの再利用",
+			tokenInput: "used-token",
+			mockSetup: func(mockRepo *MockUserRepository, mockTokenRepo *MockRefreshTokenRepository, mockSessions *MockSessionRepository, mockRevocations *MockTokenRevocationStore) {
+				usedAt := now.Add(-time.Minute)
+				stored := &model.RefreshToken{
+					ID:        11,
+					UserID:    1,
+					FamilyID:  "family-2",
+					ExpiresAt: now.Add(time.Hour),
+					UsedAt:    &usedAt,
+				}
+				mockTokenRepo.On("FindByTokenHash", mock.Anything, hashToken("used-token")).Return(stored, nil)
+				// セッションとファミリー全体が失効されること
+				mockTokenRepo.On("RevokeFamily", mock.Anything, "family-2").Return(nil)
+				mockRevocations.On("RevokeSession", mock.Anything, "family-2").Return(nil)
+			},
+			expectedError: errors.New("refresh token reuse detected"),
+		},
+		{
+			name:       "同時更新による再利用",
+			tokenInput: "raced-token",
+			mockSetup: func(mockRepo *MockUserRepository, mockTokenRepo *MockRefreshTokenRepository, mockSessions *MockSessionRepository, mockRevocations *MockTokenRevocationStore) {
+				stored := &model.RefreshToken{
+					ID:        12,
+					UserID:    1,
+					FamilyID:  "family-3",
+					ExpiresAt: now.Add(time.Hour),
+				}
+				mockTokenRepo.On("FindByTokenHash", mock.Anything, hashToken("raced-token")).Return(stored, nil)
+				mockTokenRepo.On("MarkUsed", mock.Anything, uint(12)).Return(false, nil)
+				mockTokenRepo.On("RevokeFamily", mock.Anything, "family-3").Return(nil)
+				mockRevocations.On("RevokeSession", mock.Anything, "family-3").Return(nil)
+			},
+			expectedError: errors.New("refresh token reuse detected"),
+		},
+		{
+			name:       "期限切れトークン",
+			tokenInput: "expired-token",
+			mockSetup: func(mockRepo *MockUserRepository, mockTokenRepo *MockRefreshTokenRepository, mockSessions *MockSessionRepository, mockRevocations *MockTokenRevocationStore) {
+				stored := &model.RefreshToken{
+					ID:        13,
+					UserID:    1,
+					FamilyID:  "family-4",
+					ExpiresAt: now.Add(-time.Hour),
+				}
+				mockTokenRepo.On("FindByTokenHash", mock.Anything, hashToken("expired-token")).Return(stored, nil)
+			},
+			expectedError: errors.New("refresh token has expired"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// モックの設定
+			mockRepo := new(MockUserRepository)
+			mockTokenRepo := new(MockRefreshTokenRepository)
+			mockSessions := new(MockSessionRepository)
+			mockRevocations := new(MockTokenRevocationStore)
+			tt.mockSetup(mockRepo, mockTokenRepo, mockSessions, mockRevocations)
+
+			// ユースケースの作成
+			useCase := NewUserUseCase(mockRepo, mockTokenRepo, mockSessions, new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), mockRevocations, new(stubTransactionManager), new(MockMailer), nil, config)
+
+			// テスト実行
+			tokens, err := useCase.RefreshToken(context.Background(), tt.tokenInput, ClientInfo{UserAgent: "VoiceLink/1.0 (Android)", IPAddress: "203.0.113.5"})
+
+			// アサーション
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				assert.Nil(t, tokens)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, tokens)
+				assert.Contains(t, tokens.AccessToken, ".")
+				assert.NotEmpty(t, tokens.RefreshToken)
+				assert.NotEqual(t, tt.tokenInput, tokens.RefreshToken)
+			}
+
+			mockRepo.AssertExpectations(t)
+			mockTokenRepo.AssertExpectations(t)
+			mockSessions.AssertExpectations(t)
+			mockRevocations.AssertExpectations(t)
+		})
+	}
+}
+
+// stubTokenKeySet は、1つの非対称鍵で署名と検証を行うTokenKeySetです
+type stubTokenKeySet struct {
+	key *model.TokenKey
+}
+
+func (s *stubTokenKeySet) SigningKey() *model.TokenKey { return s.key }
+
+func (s *stubTokenKeySet) VerificationKey(kid string) (*model.TokenKey, bool) {
+	return s.key, kid == s.key.ID
+}
+
+func (s *stubTokenKeySet) PublicKeys() []*model.TokenKey { return []*model.TokenKey{s.key} }
+
+func TestUserUseCase_IssueAccessToken(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	asymmetric := &stubTokenKeySet{key: &model.TokenKey{
+		ID:         "key-1",
+		Algorithm:  model.TokenAlgorithmEdDSA,
+		PrivateKey: privateKey,
+		PublicKey:  publicKey,
+	}}
+	user := &model.User{ID: 1, Role: model.RoleUser}
+	// iatとexpには時刻の取得元の時刻を使用する
+	now := time.Now().Add(-time.Minute).Truncate(time.Second)
+
+	tests := []struct {
+		name        string
+		config      UserUseCaseConfig
+		verifyKey   interface{}
+		expectedAlg string
+		expectedKid interface{}
+		expectedIss interface{}
+		expectedAud interface{}
+	}{
+		{
+			name:        "共有の秘密鍵",
+			config:      testUserUseCaseConfig,
+			verifyKey:   []byte(testUserUseCaseConfig.JWTSecret),
+			expectedAlg: "HS256",
+		},
+		{
+			name: "非対称鍵とissとaud",
+			config: UserUseCaseConfig{
+				JWTSecret:     "test-secret",
+				TokenKeys:     asymmetric,
+				TokenIssuer:   "voice-link",
+				TokenAudience: []string{"voice-link", "media-relay"},
+			},
+			verifyKey:   publicKey,
+			expectedAlg: "EdDSA",
+			expectedKid: "key-1",
+			expectedIss: "voice-link",
+			expectedAud: []interface{}{"voice-link", "media-relay"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Clock = &fakeClock{now: now}
+			mockRevocations := new(MockTokenRevocationStore)
+			mockRevocations.On("GetUserTokenVersion", mock.Anything, uint(1)).Return(uint(0), nil)
+			useCase := NewUserUseCase(new(MockUserRepository), new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), mockRevocations, new(stubTransactionManager), new(MockMailer), nil, tt.config).(*userUseCase)
+
+			accessToken, err := useCase.issueAccessToken(context.Background(), user, "session-1")
+			assert.NoError(t, err)
+
+			claims := jwt.MapClaims{}
+			token, err := jwt.ParseWithClaims(accessToken, claims, func(token *jwt.Token) (interface{}, error) {
+				return tt.verifyKey, nil
+			}, jwt.WithValidMethods([]string{tt.expectedAlg}))
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.expectedKid, token.Header["kid"])
+			assert.Equal(t, tt.expectedIss, claims["iss"])
+			assert.Equal(t, tt.expectedAud, claims["aud"])
+			assert.Equal(t, "session-1", claims["sid"])
+			assert.Equal(t, float64(now.Unix()), claims["iat"])
+			assert.Equal(t, float64(now.Add(accessTokenTTL).Unix()), claims["exp"])
+		})
+	}
+}
+
+func TestUserUseCase_Logout(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name           string
+		jtiInput       string
+		sessionIDInput string
+		mockSetup      func(*MockRefreshTokenRepository, *MockTokenRevocationStore)
+		expectedError  error
+	}{
+		{
+			name:           "正常なログアウト",
+			jtiInput:       "jti-1",
+			sessionIDInput: "family-1",
+			mockSetup: func(mockTokenRepo *MockRefreshTokenRepository, mockRevocations *MockTokenRevocationStore) {
+				mockRevocations.On("RevokeToken", mock.Anything, "jti-1", uint(1), expiresAt).Return(nil)
+				// 同じセッションのアクセストークンとリフレッシュトークンも失効されること
+				mockRevocations.On("RevokeSession", mock.Anything, "family-1").Return(nil)
+				mockTokenRepo.On("RevokeFamily", mock.Anything, "family-1").Return(nil)
+			},
+			expectedError: nil,
+		},
+		{
+			name:           "セッションIDを持たないトークン",
+			jtiInput:       "jti-2",
+			sessionIDInput: "",
+			mockSetup: func(mockTokenRepo *MockRefreshTokenRepository, mockRevocations *MockTokenRevocationStore) {
+				mockRevocations.On("RevokeToken", mock.Anything, "jti-2", uint(1), expiresAt).Return(nil)
+			},
+			expectedError: nil,
+		},
+		{
+			name:           "失効の保存に失敗",
+			jtiInput:       "jti-3",
+			sessionIDInput: "family-3",
+			mockSetup: func(mockTokenRepo *MockRefreshTokenRepository, mockRevocations *MockTokenRevocationStore) {
+				mockRevocations.On("RevokeToken", mock.Anything, "jti-3", uint(1), expiresAt).Return(errors.New("database error"))
+			},
+			expectedError: errors.New("database error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// モックの設定
+			mockTokenRepo := new(MockRefreshTokenRepository)
+			mockRevocations := new(MockTokenRevocationStore)
+			tt.mockSetup(mockTokenRepo, mockRevocations)
+
+			// ユースケースの作成
+			useCase := NewUserUseCase(new(MockUserRepository), mockTokenRepo, new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), mockRevocations, new(stubTransactionManager), new(MockMailer), nil, testUserUseCaseConfig)
+
+			// テスト実行
+			err := useCase.Logout(context.Background(), 1, tt.jtiInput, tt.sessionIDInput, expiresAt)
+
+			// アサーション
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockTokenRepo.AssertExpectations(t)
+			mockRevocations.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserUseCase_LogoutAll(t *testing.T) {
+	// モックの設定
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockRevocations := new(MockTokenRevocationStore)
+	mockRevocations.On("RevokeAllUserTokens", mock.Anything, uint(1)).Return(nil)
+	mockTokenRepo.On("RevokeAllByUserID", mock.Anything, uint(1)).Return(nil)
+	mockSessions := new(MockSessionRepository)
+	mockSessions.On("RevokeAllByUserID", mock.Anything, uint(1)).Return(nil)
+
+	// ユースケースの作成
+	useCase := NewUserUseCase(new(MockUserRepository), mockTokenRepo, mockSessions, new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), mockRevocations, new(stubTransactionManager), new(MockMailer), nil, testUserUseCaseConfig)
+
+	// テスト実行とアサーション
+	assert.NoError(t, useCase.LogoutAll(context.Background(), 1))
+	mockTokenRepo.AssertExpectations(t)
+	mockSessions.AssertExpectations(t)
+	mockRevocations.AssertExpectations(t)
+}
+
+func TestUserUseCase_PurgeExpiredTokens(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	config := testUserUseCaseConfig
+	config.Clock = &fakeClock{now: now}
+
+	t.Run("期限切れの失効情報、リフレッシュトークン、セッションを削除する", func(t *testing.T) {
+		mockRevocations := new(MockTokenRevocationStore)
+		mockRevocations.On("DeleteExpired", mock.Anything, now).Return(int64(3), nil)
+		mockTokenRepo := new(MockRefreshTokenRepository)
+		mockTokenRepo.On("DeleteExpired", mock.Anything, now).Return(int64(5), nil)
+		mockSessions := new(MockSessionRepository)
+		mockSessions.On("DeleteExpired", mock.Anything, now).Return(int64(2), nil)
+		useCase := NewUserUseCase(new(MockUserRepository), mockTokenRepo, mockSessions, new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), mockRevocations, new(stubTransactionManager), new(MockMailer), nil, config)
+
+		purged, err := useCase.PurgeExpiredTokens(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(10), purged)
+		mockRevocations.AssertExpectations(t)
+		mockTokenRepo.AssertExpectations(t)
+		mockSessions.AssertExpectations(t)
+	})
+
+	t.Run("削除に失敗した場合はそれまでの件数とエラーを返す", func(t *testing.T) {
+		errDatabase := errors.New("database error")
+		mockRevocations := new(MockTokenRevocationStore)
+		mockRevocations.On("DeleteExpired", mock.Anything, now).Return(int64(3), nil)
+		mockTokenRepo := new(MockRefreshTokenRepository)
+		mockTokenRepo.On("DeleteExpired", mock.Anything, now).Return(int64(0), errDatabase)
+		mockSessions := new(MockSessionRepository)
+		useCase := NewUserUseCase(new(MockUserRepository), mockTokenRepo, mockSessions, new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), mockRevocations, new(stubTransactionManager), new(MockMailer), nil, config)
+
+		purged, err := useCase.PurgeExpiredTokens(context.Background())
+
+		assert.ErrorIs(t, err, errDatabase)
+		assert.Equal(t, int64(3), purged)
+		mockSessions.AssertNotCalled(t, "DeleteExpired", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,146 @@
+package usecase
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// RFC 6238のTOTPのパラメーターです
+// 多くの認証アプリが対応している既定値（HMAC-SHA1、6桁、30秒）を使用します
+const (
+	totpPeriod     = 30 * time.Second
+	totpDigits     = 6
+	totpSecretSize = 20 // 秘密鍵のバイト数（RFC 4226で推奨される160ビット）
+	// totpSkew は、端末の時刻のずれを考慮して前後に許容する時間ステップの数です
+	totpSkew = 1
+)
+
+// recoveryCodeCount は、2段階認証を有効にしたときに発行するリカバリーコードの数です
+const recoveryCodeCount = 10
+
+// recoveryCodeSize は、リカバリーコードのバイト数です。Base32で16文字になります
+const recoveryCodeSize = 10
+
+// base32NoPadding は、秘密鍵とリカバリーコードの表記に使用するパディングなしのBase32です
+var base32NoPadding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// generateTOTPSecret は、認証アプリに登録するBase32形式の秘密鍵を生成します
+func generateTOTPSecret() (string, error) {
+	secret := make([]byte, totpSecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return base32NoPadding.EncodeToString(secret), nil
+}
+
+// totpStep は、時刻tのTOTPの時間ステップを返します
+func totpStep(t time.Time) int64 {
+	return t.Unix() / int64(totpPeriod/time.Second)
+}
+
+// totpCode は、秘密鍵と時間ステップからRFC 4226のHOTPの値を計算します
+func totpCode(secret []byte, step int64) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	// 動的切り捨て（RFC 4226 5.3）
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, value%modulo)
+}
+
+// verifyTOTP は、コードがnowの時点で有効かどうかを検証し、一致した時間ステップを返します
+// 端末の時刻のずれを考慮して、前後totpSkewステップのコードも受け付けます
+func verifyTOTP(secret, code string, now time.Time) (int64, bool) {
+	key, err := base32NoPadding.DecodeString(secret)
+	if err != nil || len(code) != totpDigits {
+		return 0, false
+	}
+
+	current := totpStep(now)
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// isTOTPCode は、入力がTOTPのコードの形式（数字のみの6桁）かどうかを判定します
+// それ以外の入力はリカバリーコードとして扱います
+func isTOTPCode(code string) bool {
+	if len(code) != totpDigits {
+		return false
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// totpURI は、認証アプリに秘密鍵を登録するためのotpauth URIを作成します
+// QRコードにしてアプリで読み取るか、秘密鍵を直接入力して登録します
+func totpURI(issuer, account, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(int(totpPeriod.Seconds())))
+
+	label := url.PathEscape(issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// generateRecoveryCodes は、表示用のリカバリーコードを生成します
+// コードは読み間違えにくいよう、4文字ごとにハイフンで区切った小文字で表記します
+func generateRecoveryCodes() ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		raw := make([]byte, recoveryCodeSize)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, err
+		}
+		encoded := strings.ToLower(base32NoPadding.EncodeToString(raw))
+
+		var code strings.Builder
+		for j := 0; j < len(encoded); j += 4 {
+			if j > 0 {
+				code.WriteByte('-')
+			}
+			code.WriteString(encoded[j:min(j+4, len(encoded))])
+		}
+		codes[i] = code.String()
+	}
+	return codes, nil
+}
+
+// hashRecoveryCode は、表記の揺れ（大文字と小文字、ハイフンや空白）を取り除いてリカバリーコードをハッシュ化します
+func hashRecoveryCode(code string) string {
+	normalized := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(code))
+	return hashToken(normalized)
+}
@@ -0,0 +1,113 @@
+package usecase
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rfc6238Secret は、RFC 6238の付録Bのテストベクターで使用されるSHA1の秘密鍵です
+var rfc6238Secret = base32NoPadding.EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCode_RFC6238(t *testing.T) {
+	// 付録Bの8桁の値の下6桁
+	tests := []struct {
+		unix     int64
+		expected string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	key, _ := base32NoPadding.DecodeString(rfc6238Secret)
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, totpCode(key, totpStep(time.Unix(tt.unix, 0))), "T=%d", tt.unix)
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	key, _ := base32NoPadding.DecodeString(rfc6238Secret)
+	current := totpStep(now)
+
+	t.Run("現在のステップのコードを受け付ける", func(t *testing.T) {
+		step, ok := verifyTOTP(rfc6238Secret, "050471", now)
+		assert.True(t, ok)
+		assert.Equal(t, current, step)
+	})
+
+	t.Run("前後1ステップのずれを許容する", func(t *testing.T) {
+		step, ok := verifyTOTP(rfc6238Secret, totpCode(key, current-1), now)
+		assert.True(t, ok)
+		assert.Equal(t, current-1, step)
+
+		step, ok = verifyTOTP(rfc6238Secret, totpCode(key, current+1), now)
+		assert.True(t, ok)
+		assert.Equal(t, current+1, step)
+	})
+
+	t.Run("2ステップ以上ずれたコードは拒否する", func(t *testing.T) {
+		_, ok := verifyTOTP(rfc6238Secret, totpCode(key, current-2), now)
+		assert.False(t, ok)
+	})
+
+	t.Run("形式が異なるコードや不正な秘密鍵は拒否する", func(t *testing.T) {
+		_, ok := verifyTOTP(rfc6238Secret, "50471", now)
+		assert.False(t, ok)
+		_, ok = verifyTOTP("not base32!", "050471", now)
+		assert.False(t, ok)
+	})
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := generateTOTPSecret()
+
+	assert.NoError(t, err)
+	key, err := base32NoPadding.DecodeString(secret)
+	assert.NoError(t, err)
+	assert.Len(t, key, totpSecretSize)
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := totpURI("Voice Link", "test@example.com", "JBSWY3DPEHPK3PXP")
+
+	parsed, err := url.Parse(uri)
+	assert.NoError(t, err)
+	assert.Equal(t, "otpauth", parsed.Scheme)
+	assert.Equal(t, "totp", parsed.Host)
+	assert.Equal(t, "/Voice Link:test@example.com", parsed.Path)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", parsed.Query().Get("secret"))
+	assert.Equal(t, "Voice Link", parsed.Query().Get("issuer"))
+	assert.Equal(t, "6", parsed.Query().Get("digits"))
+	assert.Equal(t, "30", parsed.Query().Get("period"))
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, err := generateRecoveryCodes()
+
+	assert.NoError(t, err)
+	assert.Len(t, codes, recoveryCodeCount)
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		assert.Regexp(t, `^[a-z2-7]{4}-[a-z2-7]{4}-[a-z2-7]{4}-[a-z2-7]{4}$`, code)
+		assert.False(t, isTOTPCode(code))
+		assert.False(t, seen[code], "duplicated code: %s", code)
+		seen[code] = true
+	}
+}
+
+func TestHashRecoveryCode(t *testing.T) {
+	// 大文字と小文字、区切りの有無によらず同じハッシュになる
+	expected := hashRecoveryCode("abcd-efgh-ijkl-mnop")
+	assert.Equal(t, expected, hashRecoveryCode("ABCDEFGHIJKLMNOP"))
+	assert.Equal(t, expected, hashRecoveryCode("abcd efgh ijkl mnop"))
+	assert.NotEqual(t, expected, hashRecoveryCode("abcd-efgh-ijkl-mnoq"))
+	assert.False(t, strings.Contains(expected, "abcd"))
+}
@@ -0,0 +1,56 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// tracerName は、ユースケース層のスパンを記録するトレーサーの名前です
+const tracerName = "voice-link/usecase"
+
+// startSpan は、ユースケースの処理のスパンを開始します
+func startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name)
+}
+
+// endSpan は、エラーを記録してスパンを終了します
+// 認証失敗などの想定されたエラーはエラーコードのみ記録し、スパンをエラーとして扱いません
+func endSpan(span trace.Span, err error) {
+	var useCaseErr *Error
+	switch {
+	case errors.As(err, &useCaseErr):
+		span.SetAttributes(attribute.String("error.code", useCaseErr.Code()))
+	case err != nil:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// hashPassword は、パスワードをbcryptでハッシュ化します
+// 処理に時間がかかるため、独立したスパンとして記録します
+func hashPassword(ctx context.Context, password string) (string, error) {
+	_, span := startSpan(ctx, "bcrypt.GenerateFromPassword")
+	defer span.End()
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
+// comparePassword は、パスワードがbcryptのハッシュ値と一致するかを検証します
+// 処理に時間がかかるため、独立したスパンとして記録します
+func comparePassword(ctx context.Context, hashedPassword, password string) error {
+	_, span := startSpan(ctx, "bcrypt.CompareHashAndPassword")
+	defer span.End()
+
+	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+}
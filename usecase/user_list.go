@@ -0,0 +1,168 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+	"voice-link/domain/model"
+)
+
+const (
+	// defaultUserPageLimit は、件数が指定されていない場合のユーザー一覧の1ページの件数です
+	defaultUserPageLimit = 20
+	// maxUserPageLimit は、ユーザー一覧の1ページで取得できる最大件数です
+	maxUserPageLimit = 100
+)
+
+// ListUsersParams は、ユーザー一覧の取得条件です
+// SortByとOrderが空の場合は作成日時の新しい順に並べます
+type ListUsersParams struct {
+	Filter model.UserFilter
+	SortBy model.UserSortField
+	Order  model.SortOrder
+	Limit  int    // 1ページの件数（0の場合は既定の件数）
+	Cursor string // 前のページで返されたカーソル（最初のページの場合は空）
+}
+
+// UserPage は、ユーザー一覧の1ページ分の結果です
+type UserPage struct {
+	Users      []model.User
+	Limit      int    // 1ページの件数
+	NextCursor string // 次のページを取得するためのカーソル（最後のページの場合は空）
+}
+
+// HasMore は、次のページが存在するかどうかを返します
+func (p *UserPage) HasMore() bool {
+	return p.NextCursor != ""
+}
+
+// userCursor は、クライアントに返すカーソルの内容です
+// 別の並び順のカーソルが指定された場合に検出できるよう、並び順も含めます
+type userCursor struct {
+	SortBy model.UserSortField `json:"s"`
+	Order  model.SortOrder     `json:"o"`
+	ID     uint                `json:"id"`
+	Value  string              `json:"v,omitempty"` // 並び替えに使用している項目の値
+}
+
+// ListUsers は、条件に一致するユーザーの一覧をカーソルベースのページ単位で取得します
+func (u *userUseCase) ListUsers(ctx context.Context, params ListUsersParams) (_ *UserPage, err error) {
+	ctx, span := startSpan(ctx, "UserUseCase.ListUsers")
+	defer func() { endSpan(span, err) }()
+
+	query, err := buildUserListQuery(params)
+	if err != nil {
+		return nil, err
+	}
+
+	// 次のページの有無を判定するため、1件多く取得する
+	limit := query.Limit
+	query.Limit++
+	users, err := u.userRepo.FindAll(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &UserPage{Users: users, Limit: limit}
+	if len(users) > limit {
+		page.Users = users[:limit]
+		page.NextCursor = encodeUserCursor(query.SortBy, query.Order, &page.Users[limit-1])
+	}
+
+	return page, nil
+}
+
+// buildUserListQuery は、取得条件を検証してリポジトリの検索条件に変換します
+func buildUserListQuery(params ListUsersParams) (model.UserListQuery, error) {
+	query := model.UserListQuery{
+		Filter: params.Filter,
+		SortBy: params.SortBy,
+		Order:  params.Order,
+		Limit:  params.Limit,
+	}
+
+	if query.SortBy == "" {
+		query.SortBy = model.UserSortByCreatedAt
+	}
+	if query.Order == "" {
+		query.Order = model.SortDescending
+	}
+	if !query.SortBy.IsValid() || !query.Order.IsValid() {
+		return query, ErrInvalidSort
+	}
+
+	if query.Limit == 0 {
+		query.Limit = defaultUserPageLimit
+	}
+	if query.Limit < 0 || query.Limit > maxUserPageLimit {
+		return query, ErrInvalidPageLimit
+	}
+
+	filter := query.Filter
+	if filter.Role != "" && !filter.Role.IsValid() {
+		return query, ErrInvalidRoleFilter
+	}
+	if filter.CreatedFrom != nil && filter.CreatedTo != nil && !filter.CreatedFrom.Before(*filter.CreatedTo) {
+		return query, ErrInvalidCreatedRange
+	}
+
+	if params.Cursor != "" {
+		after, err := decodeUserCursor(params.Cursor, query.SortBy, query.Order)
+		if err != nil {
+			return query, err
+		}
+		query.After = after
+	}
+
+	return query, nil
+}
+
+// encodeUserCursor は、ページの最後のユーザーから次のページのカーソルを作成します
+func encodeUserCursor(sortBy model.UserSortField, order model.SortOrder, last *model.User) string {
+	cursor := userCursor{SortBy: sortBy, Order: order, ID: last.ID}
+	switch sortBy {
+	case model.UserSortByCreatedAt:
+		cursor.Value = last.CreatedAt.Format(time.RFC3339Nano)
+	case model.UserSortByEmail:
+		cursor.Value = last.Email
+	case model.UserSortByName:
+		cursor.Value = last.Name
+	}
+
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeUserCursor は、カーソルを検証してページの区切りとなるユーザーの並び替えキーに変換します
+// 形式が不正な場合や、指定された並び順と異なる並び順で作成されたカーソルの場合はErrInvalidCursorを返します
+func decodeUserCursor(encoded string, sortBy model.UserSortField, order model.SortOrder) (*model.UserCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor userCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.SortBy != sortBy || cursor.Order != order || cursor.ID == 0 {
+		return nil, ErrInvalidCursor
+	}
+
+	after := &model.UserCursor{ID: cursor.ID}
+	switch sortBy {
+	case model.UserSortByCreatedAt:
+		createdAt, err := time.Parse(time.RFC3339Nano, cursor.Value)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		after.CreatedAt = createdAt
+	case model.UserSortByEmail:
+		after.Email = cursor.Value
+	case model.UserSortByName:
+		after.Name = cursor.Value
+	}
+
+	return after, nil
+}
@@ -0,0 +1,130 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"voice-link/domain/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestUserUseCase_ListUsers(t *testing.T) {
+	createdAt := time.Date(2026, 1, 2, 3, 4, 5, 123456000, time.UTC)
+	users := []model.User{
+		{ID: 3, Name: "ユーザー3", Email: "user3@example.com", CreatedAt: createdAt},
+		{ID: 2, Name: "ユーザー2", Email: "user2@example.com", CreatedAt: createdAt},
+		{ID: 1, Name: "ユーザー1", Email: "user1@example.com", CreatedAt: createdAt.Add(-time.Hour)},
+	}
+	verified := true
+	from := createdAt.Add(-24 * time.Hour)
+
+	t.Run("既定の並び順と件数で取得する", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("FindAll", mock.Anything, model.UserListQuery{
+			SortBy: model.UserSortByCreatedAt,
+			Order:  model.SortDescending,
+			Limit:  defaultUserPageLimit + 1,
+		}).Return(users, nil)
+		useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, testUserUseCaseConfig)
+
+		page, err := useCase.ListUsers(context.Background(), ListUsersParams{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, users, page.Users)
+		assert.Equal(t, defaultUserPageLimit, page.Limit)
+		assert.False(t, page.HasMore())
+		assert.Empty(t, page.NextCursor)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("次のページのカーソルで続きを取得する", func(t *testing.T) {
+		filter := model.UserFilter{EmailPrefix: "user", CreatedFrom: &from, Role: model.RoleUser, Verified: &verified}
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("FindAll", mock.Anything, model.UserListQuery{
+			Filter: filter,
+			SortBy: model.UserSortByCreatedAt,
+			Order:  model.SortDescending,
+			Limit:  3,
+		}).Return(users, nil).Once()
+		// 1ページ目の最後のユーザー（ID: 2）より後を取得する
+		mockRepo.On("FindAll", mock.Anything, model.UserListQuery{
+			Filter: filter,
+			SortBy: model.UserSortByCreatedAt,
+			Order:  model.SortDescending,
+			After:  &model.UserCursor{ID: 2, CreatedAt: createdAt},
+			Limit:  3,
+		}).Return(users[2:], nil).Once()
+		useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, testUserUseCaseConfig)
+
+		page, err := useCase.ListUsers(context.Background(), ListUsersParams{Filter: filter, Limit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, users[:2], page.Users)
+		assert.True(t, page.HasMore())
+
+		page, err = useCase.ListUsers(context.Background(), ListUsersParams{Filter: filter, Limit: 2, Cursor: page.NextCursor})
+		assert.NoError(t, err)
+		assert.Equal(t, users[2:], page.Users)
+		assert.False(t, page.HasMore())
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("並び替えの項目ごとにカーソルに値を含める", func(t *testing.T) {
+		for sortBy, expected := range map[model.UserSortField]*model.UserCursor{
+			model.UserSortByID:    {ID: 3},
+			model.UserSortByEmail: {ID: 3, Email: "user3@example.com"},
+			model.UserSortByName:  {ID: 3, Name: "ユーザー3"},
+		} {
+			cursor := encodeUserCursor(sortBy, model.SortAscending, &users[0])
+			after, err := decodeUserCursor(cursor, sortBy, model.SortAscending)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, after)
+		}
+	})
+
+	t.Run("リポジトリのエラーをそのまま返す", func(t *testing.T) {
+		errDatabase := errors.New("database error")
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("FindAll", mock.Anything, mock.Anything).Return(nil, errDatabase)
+		useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, testUserUseCaseConfig)
+
+		_, err := useCase.ListUsers(context.Background(), ListUsersParams{})
+		assert.ErrorIs(t, err, errDatabase)
+	})
+
+	otherOrderCursor := encodeUserCursor(model.UserSortByCreatedAt, model.SortAscending, &users[0])
+	invalidTimeCursor := encodeUserCursor(model.UserSortByName, model.SortDescending, &users[0])
+	to := from.Add(-time.Hour)
+	invalidParams := []struct {
+		name          string
+		params        ListUsersParams
+		expectedError error
+	}{
+		{"対応していない並び替えの項目", ListUsersParams{SortBy: "password"}, ErrInvalidSort},
+		{"対応していない並び順", ListUsersParams{Order: "random"}, ErrInvalidSort},
+		{"件数が負の値", ListUsersParams{Limit: -1}, ErrInvalidPageLimit},
+		{"件数が上限を超える", ListUsersParams{Limit: maxUserPageLimit + 1}, ErrInvalidPageLimit},
+		{"対応していないロール", ListUsersParams{Filter: model.UserFilter{Role: "owner"}}, ErrInvalidRoleFilter},
+		{"作成日時の範囲が逆転している", ListUsersParams{Filter: model.UserFilter{CreatedFrom: &from, CreatedTo: &to}}, ErrInvalidCreatedRange},
+		{"カーソルの形式が不正", ListUsersParams{Cursor: "!!!"}, ErrInvalidCursor},
+		{"カーソルの内容が不正", ListUsersParams{Cursor: "bm90LWpzb24"}, ErrInvalidCursor},
+		{"別の並び順のカーソル", ListUsersParams{Cursor: otherOrderCursor}, ErrInvalidCursor},
+		{"別の並び替えの項目のカーソル", ListUsersParams{Cursor: invalidTimeCursor}, ErrInvalidCursor},
+	}
+	for _, tt := range invalidParams {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, testUserUseCaseConfig)
+
+			page, err := useCase.ListUsers(context.Background(), tt.params)
+
+			assert.ErrorIs(t, err, tt.expectedError)
+			assert.ErrorIs(t, err, ErrValidation)
+			assert.Nil(t, page)
+			// 検証に失敗した場合はデータベースを検索しない
+			mockRepo.AssertNotCalled(t, "FindAll", mock.Anything, mock.Anything)
+		})
+	}
+}
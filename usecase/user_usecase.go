@@ -1,46 +1,122 @@
 package usecase
 
 import (
-	"crypto/rand"
-	"encoding/hex"
+	"context"
 	"errors"
-	"os"
+	"log/slog"
 	"time"
 	"voice-link/domain/model"
-
-	"github.com/golang-jwt/jwt/v5"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type UserUseCase interface {
-	Register(name, email, password string) (*model.User, error)
-	Login(email, password string) (string, error)
-	GetByID(id uint) (*model.User, error)
-	UpdateUser(id uint, name, email string) (*model.User, error)
-	DeleteUser(id uint) error
-	RequestPasswordReset(email string) error
-	ResetPassword(token, newPassword string) error
+	Register(ctx context.Context, name, email, password string, language model.Language) (*model.User, error)
+	Login(ctx context.Context, email, password string, client ClientInfo) (*LoginResult, error)
+	VerifyMFA(ctx context.Context, mfaToken, code string, client ClientInfo) (*TokenPair, error)
+	RefreshToken(ctx context.Context, refreshToken string, client ClientInfo) (*TokenPair, error)
+	Logout(ctx context.Context, userID uint, jti, sessionID string, expiresAt time.Time) error
+	LogoutAll(ctx context.Context, userID uint) error
+	GetByID(ctx context.Context, id uint) (*model.User, error)
+	ListUsers(ctx context.Context, params ListUsersParams) (*UserPage, error)
+	UpdateUser(ctx context.Context, id uint, name, email string, language model.Language) (*model.User, error)
+	DeleteUser(ctx context.Context, id uint) error
+	PurgeDeletedUsers(ctx context.Context) (int, error)
+	PurgeExpiredTokens(ctx context.Context) (int64, error)
+	RequestPasswordReset(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, newPassword string) error
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerificationEmail(ctx context.Context, email string) error
+	UnlockAccount(ctx context.Context, token string) error
+	BeginMFAEnrollment(ctx context.Context, userID uint) (*MFAEnrollment, error)
+	ConfirmMFAEnrollment(ctx context.Context, userID uint, code string) ([]string, error)
+	DisableMFA(ctx context.Context, userID uint, code string) error
+	ResetMFA(ctx context.Context, userID uint) error
+	BeginOIDCLogin(ctx context.Context, provider string) (*OIDCAuthorization, error)
+	CompleteOIDCLogin(ctx context.Context, provider, code, state string, language model.Language, client ClientInfo) (*LoginResult, error)
+	BeginOIDCLink(ctx context.Context, userID uint, provider string) (*OIDCAuthorization, error)
+	CompleteOIDCLink(ctx context.Context, userID uint, provider, code, state string) (*model.Identity, error)
+	ListIdentities(ctx context.Context, userID uint) ([]model.Identity, error)
+	UnlinkIdentity(ctx context.Context, userID uint, provider string) error
+	ListSessions(ctx context.Context, userID uint) ([]model.Session, error)
+	RevokeSession(ctx context.Context, userID uint, sessionID string) error
 }
 
-type userUseCase struct {
-	userRepo model.UserRepository
+// passwordResetTTL は、パスワードリセットトークンの有効期間です
+const passwordResetTTL = time.Hour
+
+// UserUseCaseConfig は、ユーザーユースケースの動作設定を定義します
+type UserUseCaseConfig struct {
+	FrontendURL              string        // メール本文のリンク先となるフロントエンドのURL
+	RequireEmailVerification bool          // メールアドレスが未確認のユーザーのログインを拒否するかどうか
+	JWTSecret                string        // 2段階認証のチャレンジトークンの署名に使用する秘密鍵。TokenKeysがnilの場合はアクセストークンの署名にも使用します
+	DeletionGracePeriod      time.Duration // 退会後にログインで復元できる期間（0の場合は30日）
+	Lockout                  LockoutPolicy // ログインの失敗が続いた場合の待機とロックの条件
+	MFAIssuer                string        // 認証アプリに表示するサービス名（空の場合はVoice Link）
+	MFAEncryptionKey         string        // 2段階認証の秘密鍵を暗号化して保存する鍵の元になる秘密の値（空の場合はJWTSecretから導出）
+
+	// RateLimiter は、メールアドレスごとの試行の回数の記録先です。nilの場合は回数を制限しません
+	RateLimiter model.RateLimiter
+	// LoginRateLimit は、メールアドレスごとに許可するログインの試行の回数です
+	// 存在しないメールアドレスにも適用するため、パスワードの総当たりに加えて登録済みかどうかの探索も抑えます
+	LoginRateLimit model.RateLimit
+	// EmailRateLimit は、メールアドレスごとに許可するパスワードリセットと確認メールの再送信の回数です
+	EmailRateLimit model.RateLimit
+	// TokenKeys は、アクセストークンの署名に使用する鍵です。nilの場合はJWTSecretでHS256の署名を行います
+	TokenKeys model.TokenKeySet
+	// TokenIssuer は、アクセストークンのissです。空の場合は含めません
+	TokenIssuer string
+	// TokenAudience は、アクセストークンのaudです。トークンを検証するサービスを列挙し、空の場合は含めません
+	TokenAudience []string
+	// Clock は、トークン（アクセストークン、リフレッシュトークン、メールアドレス確認、パスワードリセット）とセッションの期限、ログインの失敗とロック、2段階認証のコード、退会の猶予期間の判定に使用する時刻の取得元です。nilの場合はシステムの時刻を使用します
+	Clock model.Clock
+	// OIDCProviders は、連携したアカウントでのログインに使用できるプロバイダーです。キーはURLに使用するプロバイダーの名前です
+	OIDCProviders map[string]model.OIDCProvider
 }
 
-func NewUserUseCase(userRepo model.UserRepository) UserUseCase {
-	return &userUseCase{userRepo}
+type userUseCase struct {
+	userRepo            model.UserRepository
+	refreshTokenRepo    model.RefreshTokenRepository
+	sessionRepo         model.SessionRepository
+	recoveryCodeRepo    model.RecoveryCodeRepository
+	identityRepo        model.IdentityRepository
+	oidcAuthRequestRepo model.OIDCAuthRequestRepository
+	tokenRevocations    model.TokenRevocationStore
+	transactions        model.TransactionManager
+	mailer              model.Mailer
+	metrics             Metrics
+	config              UserUseCaseConfig
 }
 
-func (u *userUseCase) Register(name, email, password string) (*model.User, error) {
-	// メールアドレスの重複チェック
-	existingUser, err := u.userRepo.FindByEmail(email)
-
-	// エラーがなく、既存のユーザーが存在する場合
-	if err == nil && existingUser != nil {
-		return nil, errors.New("email already exists")
+// NewUserUseCase は、UserUseCaseの新しいインスタンスを作成します
+// metricsがnilの場合はイベントを記録しません
+func NewUserUseCase(userRepo model.UserRepository, refreshTokenRepo model.RefreshTokenRepository, sessionRepo model.SessionRepository, recoveryCodeRepo model.RecoveryCodeRepository, identityRepo model.IdentityRepository, oidcAuthRequestRepo model.OIDCAuthRequestRepository, tokenRevocations model.TokenRevocationStore, transactions model.TransactionManager, mailer model.Mailer, metrics Metrics, config UserUseCaseConfig) UserUseCase {
+	if metrics == nil {
+		metrics = nopMetrics{}
+	}
+	if config.DeletionGracePeriod <= 0 {
+		config.DeletionGracePeriod = defaultDeletionGracePeriod
+	}
+	if config.Clock == nil {
+		config.Clock = model.SystemClock
 	}
+	if config.TokenKeys == nil {
+		config.TokenKeys = model.NewHMACTokenKeySet(config.JWTSecret)
+	}
+	if config.MFAIssuer == "" {
+		config.MFAIssuer = defaultMFAIssuer
+	}
+	config.Lockout = config.Lockout.withDefaults()
+	return &userUseCase{userRepo, refreshTokenRepo, sessionRepo, recoveryCodeRepo, identityRepo, oidcAuthRequestRepo, tokenRevocations, transactions, mailer, metrics, config}
+}
+
+// Register は、新しいユーザーを登録します
+// languageはメッセージやメールに使用する言語として保存され、対応していない場合は既定の言語を使用します
+func (u *userUseCase) Register(ctx context.Context, name, email, password string, language model.Language) (_ *model.User, err error) {
+	ctx, span := startSpan(ctx, "UserUseCase.Register")
+	defer func() { endSpan(span, err) }()
 
 	// パスワードのハッシュ化
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	// 時間のかかる処理の間トランザクションを保持しないよう、重複チェックの前に行う
+	hashedPassword, err := hashPassword(ctx, password)
 
 	// ハッシュ化に失敗した場合
 	if err != nil {
@@ -49,141 +125,371 @@ func (u *userUseCase) Register(name, email, password string) (*model.User, error
 
 	// 新しいユーザーを作成
 	user := &model.User{
-		Name:     name,
-		Email:    email,
-		Password: string(hashedPassword),
+		Name:              name,
+		Email:             email,
+		Password:          hashedPassword,
+		Role:              model.RoleUser,
+		PreferredLanguage: language.OrDefault(),
 	}
 
-	// ユーザーをデータベースに作成
-	if err := u.userRepo.Create(user); err != nil {
+	// メールアドレス確認用のトークンを設定
+	verificationToken, err := u.prepareEmailVerification(user)
+	if err != nil {
 		return nil, err
 	}
 
+	// メールアドレスの重複チェックとユーザーの作成を1つのトランザクションで行う
+	err = u.transactions.WithinTransaction(ctx, func(ctx context.Context) error {
+		existingUser, err := u.userRepo.FindByEmail(ctx, email)
+
+		// エラーがなく、既存のユーザーが存在する場合
+		if err == nil && existingUser != nil {
+			return ErrEmailAlreadyExists
+		}
+		if err != nil && !errors.Is(err, model.ErrNotFound) {
+			return err
+		}
+
+		// 同時に同じメールアドレスで登録された場合は、一意制約の違反として検出される
+		return translateDuplicateEmail(u.userRepo.Create(ctx, user))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	u.metrics.UserRegistered()
+
+	// 確認メールを送信
+	u.sendVerificationEmail(ctx, user, user.Email, verificationToken)
+
 	// 作成したユーザーを返す
 	return user, nil
 }
 
-func (u *userUseCase) Login(email, password string) (string, error) {
+// LoginResult は、ログインの結果です
+// 2段階認証を有効にしているユーザーの場合はトークンを発行せず、コードの確認に使用するチャレンジを返します
+type LoginResult struct {
+	Tokens       *TokenPair    // 発行したトークン。2段階認証が必要な場合はnil
+	MFAChallenge *MFAChallenge // 2段階認証が必要な場合のチャレンジ。不要な場合はnil
+}
+
+// Login は、メールアドレスとパスワードを検証してトークンを発行し、結果をメトリクスに記録します
+// 2段階認証を有効にしているユーザーの場合は、VerifyMFAでコードを確認した時点でログインの成功を記録します
+func (u *userUseCase) Login(ctx context.Context, email, password string, client ClientInfo) (_ *LoginResult, err error) {
+	ctx, span := startSpan(ctx, "UserUseCase.Login")
+	defer func() { endSpan(span, err) }()
+
+	result, err := u.login(ctx, email, password, client)
+	if err != nil {
+		u.metrics.LoginFailed(loginFailureReasonOf(err))
+		return nil, err
+	}
+
+	if result.Tokens != nil {
+		u.metrics.LoginSucceeded()
+	}
+	return result, nil
+}
+
+// login は、メールアドレスとパスワードを検証してトークンを発行します
+// パスワードの誤りが続いた場合は、次の試行まで待機を求め、上限に達するとアカウントをロックします
+// 2段階認証を有効にしているユーザーの場合は、トークンの代わりにチャレンジを発行します
+// 退会後の猶予期間中のユーザーの場合は、退会を取り消してからトークンを発行します
+func (u *userUseCase) login(ctx context.Context, email, password string, client ClientInfo) (*LoginResult, error) {
+	// メールアドレスごとの試行の回数を制限する
+	if err := u.checkRateLimit(ctx, rateLimitKey("login", email), u.config.LoginRateLimit, ErrTooManyLoginAttempts); err != nil {
+		return nil, err
+	}
+
 	// メールアドレスでユーザーを検索
-	user, err := u.userRepo.FindByEmail(email)
+	user, err := u.userRepo.FindByEmail(ctx, email)
+	if errors.Is(err, model.ErrNotFound) {
+		return nil, ErrInvalidEmailOrPassword
+	}
 	if err != nil {
-		return "", errors.New("invalid email or password")
+		return nil, err
+	}
+
+	// ロック中や待機が必要な期間中は、パスワードを検証せずに拒否する
+	now := u.config.Clock.Now()
+	if err := u.checkLoginAllowed(user, now); err != nil {
+		return nil, err
 	}
 
 	// パスワードの検証
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return "", errors.New("invalid email or password")
+	if err := comparePassword(ctx, user.Password, password); err != nil {
+		return nil, u.recordLoginFailure(ctx, user, now, ErrInvalidEmailOrPassword)
 	}
 
-	// JWTトークンの生成
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": user.ID,
-		"exp":     time.Now().Add(time.Hour * 24).Unix(), // 24時間有効
-		"iat":     time.Now().Unix(),
-	})
+	// 猶予期間を過ぎた退会済みのユーザーは削除を待っているだけのため、存在しないユーザーと同じ扱いにする
+	if user.IsDeleted() && !u.isRestorable(user) {
+		return nil, ErrInvalidEmailOrPassword
+	}
 
-	// トークンの署名
-	tokenString, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
-	if err != nil {
-		return "", err
+	// パスワードが正しいため、連続した失敗の記録を破棄する
+	// 2段階認証を有効にしている場合は、パスワードを知る者がログインをやり直してコードの失敗回数を
+	// リセットできないよう、コードを確認するまで記録を残す
+	if !user.IsMFAEnabled() {
+		if err := u.resetLoginFailures(ctx, user); err != nil {
+			return nil, err
+		}
 	}
 
-	return tokenString, nil
-}
+	// メールアドレス確認済みのユーザーのみログインを許可する設定の場合
+	if u.config.RequireEmailVerification && user.EmailVerifiedAt == nil {
+		return nil, ErrEmailNotVerified
+	}
 
-func (u *userUseCase) GetByID(id uint) (*model.User, error) {
-	return u.userRepo.FindByID(id)
-}
+	// 2段階認証を有効にしている場合は、コードを確認してからトークンを発行する
+	if user.IsMFAEnabled() {
+		challenge, err := u.issueMFAChallenge(user, now)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{MFAChallenge: challenge}, nil
+	}
 
-func (u *userUseCase) UpdateUser(id uint, name, email string) (*model.User, error) {
-	user, err := u.userRepo.FindByID(id)
+	tokens, err := u.completeLogin(ctx, user, client)
 	if err != nil {
 		return nil, err
 	}
+	return &LoginResult{Tokens: tokens}, nil
+}
 
-	user.Name = name
-	user.Email = email
+// completeLogin は、認証を終えたユーザーに新しいセッションのトークンを発行します
+// 猶予期間中の退会済みのユーザーは、ログインによって退会を取り消します
+func (u *userUseCase) completeLogin(ctx context.Context, user *model.User, client ClientInfo) (*TokenPair, error) {
+	if user.IsDeleted() {
+		if err := u.restoreUser(ctx, user); err != nil {
+			return nil, err
+		}
+	}
 
-	if err := u.userRepo.Update(user); err != nil {
+	// 新しいセッションとトークンファミリーを開始
+	session, err := u.startSession(ctx, user, client)
+	if err != nil {
 		return nil, err
 	}
 
-	return user, nil
+	// アクセストークンとリフレッシュトークンの発行
+	return u.issueTokens(ctx, user, session.ID)
 }
 
-func (u *userUseCase) DeleteUser(id uint) error {
-	return u.userRepo.Delete(id)
-}
+func (u *userUseCase) GetByID(ctx context.Context, id uint) (_ *model.User, err error) {
+	ctx, span := startSpan(ctx, "UserUseCase.GetByID")
+	defer func() { endSpan(span, err) }()
 
-// generateResetToken は、パスワードリセット用のトークンを生成します
-func (u *userUseCase) generateResetToken() (string, error) {
-	bytes := make([]byte, 32)
-	if _, err := rand.Read(bytes); err != nil {
-		return "", err
+	user, err := u.userRepo.FindByID(ctx, id)
+	if errors.Is(err, model.ErrNotFound) {
+		return nil, ErrUserNotFound
 	}
-	return hex.EncodeToString(bytes), nil
+	return user, err
 }
 
-// RequestPasswordReset は、パスワードリセットのリクエストを処理します
-func (u *userUseCase) RequestPasswordReset(email string) error {
-	// ユーザーが存在するかチェック
-	user, err := u.userRepo.FindByEmail(email)
-	if err != nil {
-		// セキュリティ上の理由で、ユーザーが存在しない場合でも成功を返す
-		return nil
+// UpdateUser は、ユーザーの名前、メールアドレス、言語を更新します
+// languageが空の場合は現在の言語を維持します
+func (u *userUseCase) UpdateUser(ctx context.Context, id uint, name, email string, language model.Language) (_ *model.User, err error) {
+	ctx, span := startSpan(ctx, "UserUseCase.UpdateUser")
+	defer func() { endSpan(span, err) }()
+
+	if language != "" && !language.IsValid() {
+		return nil, ErrUnsupportedLanguage
 	}
 
-	// リセットトークンを生成
-	token, err := u.generateResetToken()
+	var (
+		user              *model.User
+		verificationToken string
+	)
+	err = u.transactions.WithinTransaction(ctx, func(ctx context.Context) error {
+		found, err := u.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		user = found
+
+		user.Name = name
+		if language != "" {
+			user.PreferredLanguage = language
+		}
+
+		// メールアドレスの変更は、新しいアドレスの確認が完了するまで反映しない
+		if email != user.Email {
+			existingUser, err := u.userRepo.FindByEmail(ctx, email)
+			if err == nil && existingUser != nil {
+				return ErrEmailAlreadyExists
+			}
+			if err != nil && !errors.Is(err, model.ErrNotFound) {
+				return err
+			}
+
+			user.PendingEmail = &email
+			if verificationToken, err = u.prepareEmailVerification(user); err != nil {
+				return err
+			}
+		} else if user.PendingEmail != nil {
+			// 現在のアドレスが指定された場合は確認待ちの変更を取り消す
+			// 確認トークンは変更後のアドレスに送信されているため合わせて無効にする
+			user.PendingEmail = nil
+			user.EmailVerificationToken = nil
+			user.EmailVerificationExpires = nil
+		}
+
+		return u.userRepo.Update(ctx, user)
+	})
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if verificationToken != "" {
+		u.sendVerificationEmail(ctx, user, *user.PendingEmail, verificationToken)
 	}
 
-	// トークンの有効期限を設定（1時間）
-	expires := time.Now().Add(time.Hour)
+	return user, nil
+}
 
-	// ユーザー情報を更新
-	user.PasswordResetToken = &token
-	user.PasswordResetExpires = &expires
+// DeleteUser は、ユーザーを退会済みにし、発行済みのトークンをすべて失効させます
+// データは猶予期間の経過後にPurgeDeletedUsersで完全に削除され、それまではログインによって復元できます
+func (u *userUseCase) DeleteUser(ctx context.Context, id uint) (err error) {
+	ctx, span := startSpan(ctx, "UserUseCase.DeleteUser")
+	defer func() { endSpan(span, err) }()
 
-	if err := u.userRepo.Update(user); err != nil {
+	// 猶予期間は退会した日時から数えるため、判定と同じ時刻の取得元を使用する
+	err = u.userRepo.Delete(ctx, id, u.config.Clock.Now())
+	if errors.Is(err, model.ErrNotFound) {
+		return ErrUserNotFound
+	}
+	if err != nil {
 		return err
 	}
 
-	// TODO: 実際の実装では、ここでメール送信を行う
-	// 今回はログ出力のみ
-	// log.Printf("Password reset token for %s: %s", email, token)
-
-	return nil
+	return u.LogoutAll(ctx, id)
 }
 
-// ResetPassword は、パスワードリセットトークンを使用してパスワードをリセットします
-func (u *userUseCase) ResetPassword(token, newPassword string) error {
-	// トークンでユーザーを検索
-	user, err := u.userRepo.FindByPasswordResetToken(token)
+// RequestPasswordReset は、パスワードリセットのリクエストを処理します
+func (u *userUseCase) RequestPasswordReset(ctx context.Context, email string) (err error) {
+	ctx, span := startSpan(ctx, "UserUseCase.RequestPasswordReset")
+	defer func() { endSpan(span, err) }()
+
+	// 任意のアドレスに大量のメールを送信できないよう、メールアドレスごとの回数を制限する
+	// 登録済みかどうかが判別できないよう、存在しないメールアドレスにも適用する
+	if err := u.checkRateLimit(ctx, rateLimitKey("password_reset", email), u.config.EmailRateLimit, ErrTooManyEmailRequests); err != nil {
+		return err
+	}
+
+	var (
+		user  *model.User
+		token string
+	)
+	err = u.transactions.WithinTransaction(ctx, func(ctx context.Context) error {
+		// ユーザーが存在するかチェック
+		found, err := u.userRepo.FindByEmail(ctx, email)
+		if errors.Is(err, model.ErrNotFound) {
+			// セキュリティ上の理由で、ユーザーが存在しない場合でも成功を返す
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		// 退会済みのユーザーも存在しない場合と同じく成功を返す
+		if found.IsDeleted() {
+			return nil
+		}
+		user = found
+
+		// リセットトークンを生成
+		if token, err = generateSecureToken(32); err != nil {
+			return err
+		}
+
+		// トークンの有効期限を設定（1時間）
+		expires := u.config.Clock.Now().Add(passwordResetTTL)
+
+		// ユーザー情報を更新
+		user.PasswordResetToken = &token
+		user.PasswordResetExpires = &expires
+
+		return u.userRepo.Update(ctx, user)
+	})
 	if err != nil {
-		return errors.New("invalid or expired reset token")
+		return err
 	}
+	if user == nil {
+		return nil
+	}
+	u.metrics.PasswordResetRequested()
 
-	// トークンの有効期限をチェック
-	if user.PasswordResetExpires == nil || time.Now().After(*user.PasswordResetExpires) {
-		return errors.New("reset token has expired")
+	// パスワード再設定用のリンクを記載したメールを送信
+	resetURL, err := buildFrontendURL(u.config.FrontendURL, "/reset-password", token)
+	if err != nil {
+		return err
 	}
 
-	// 新しいパスワードをハッシュ化
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	mail, err := renderEmail(user.Email, "password_reset", user.PreferredLanguage, map[string]interface{}{
+		"Name":             user.Name,
+		"ResetURL":         resetURL,
+		"ExpiresInMinutes": int(passwordResetTTL.Minutes()),
+	})
 	if err != nil {
 		return err
 	}
 
-	// パスワードを更新し、リセットトークンをクリア
-	user.Password = string(hashedPassword)
-	user.PasswordResetToken = nil
-	user.PasswordResetExpires = nil
+	// 送信の失敗をレスポンスで返すとユーザーの存在が判別できてしまうため、ログに記録するのみとする
+	if err := u.mailer.Send(ctx, mail); err != nil {
+		slog.ErrorContext(ctx, "failed to send password reset email", "user_id", user.ID, "error", err)
+	}
+
+	return nil
+}
 
-	if err := u.userRepo.Update(user); err != nil {
+// ResetPassword は、パスワードリセットトークンを使用してパスワードをリセットします
+func (u *userUseCase) ResetPassword(ctx context.Context, token, newPassword string) (err error) {
+	ctx, span := startSpan(ctx, "UserUseCase.ResetPassword")
+	defer func() { endSpan(span, err) }()
+
+	var user *model.User
+	err = u.transactions.WithinTransaction(ctx, func(ctx context.Context) error {
+		// トークンでユーザーを検索
+		found, err := u.userRepo.FindByPasswordResetToken(ctx, token)
+		if errors.Is(err, model.ErrNotFound) {
+			return ErrInvalidResetToken
+		}
+		if err != nil {
+			return err
+		}
+		user = found
+
+		// トークンの有効期限をチェック
+		if user.PasswordResetExpires == nil || u.config.Clock.Now().After(*user.PasswordResetExpires) {
+			return ErrResetTokenExpired
+		}
+
+		// 新しいパスワードをハッシュ化
+		hashedPassword, err := hashPassword(ctx, newPassword)
+		if err != nil {
+			return err
+		}
+
+		// パスワードを更新し、リセットトークンをクリア
+		user.Password = hashedPassword
+		user.PasswordResetToken = nil
+		user.PasswordResetExpires = nil
+
+		return u.userRepo.Update(ctx, user)
+	})
+	if err != nil {
 		return err
 	}
+	u.metrics.PasswordResetCompleted()
 
-	return nil
+	// パスワード変更前に発行されたトークンをすべて失効させる
+	// 失効情報のキャッシュはコミットを待たずに破棄されるため、パスワードの更新をコミットしてから行う
+	return u.LogoutAll(ctx, user.ID)
+}
+
+// translateDuplicateEmail は、メールアドレスの一意制約の違反をユースケースのエラーに変換します
+func translateDuplicateEmail(err error) error {
+	if errors.Is(err, model.ErrDuplicateEmail) {
+		return ErrEmailAlreadyExists
+	}
+	return err
 }
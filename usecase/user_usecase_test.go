@@ -1,9 +1,11 @@
 package usecase
 
 import (
+	"context"
 	"errors"
-	"os"
+	"strings"
 	"testing"
+	"time"
 	"voice-link/domain/model"
 
 	"github.com/stretchr/testify/assert"
@@ -11,60 +13,376 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// testUserUseCaseConfig は、テストで使用するユーザーユースケースの設定です
+var testUserUseCaseConfig = UserUseCaseConfig{
+	FrontendURL: "http://localhost:3000",
+	JWTSecret:   "test-secret",
+}
+
 // MockUserRepository は、UserRepositoryのモック実装です
 type MockUserRepository struct {
 	mock.Mock
 }
 
-func (m *MockUserRepository) Create(user *model.User) error {
-	args := m.Called(user)
+func (m *MockUserRepository) Create(ctx context.Context, user *model.User) error {
+	args := m.Called(ctx, user)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) FindByID(id uint) (*model.User, error) {
-	args := m.Called(id)
+func (m *MockUserRepository) FindByID(ctx context.Context, id uint) (*model.User, error) {
+	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*model.User), args.Error(1)
 }
 
-func (m *MockUserRepository) FindByEmail(email string) (*model.User, error) {
-	args := m.Called(email)
+func (m *MockUserRepository) FindByIDIncludingDeleted(ctx context.Context, id uint) (*model.User, error) {
+	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*model.User), args.Error(1)
 }
 
-func (m *MockUserRepository) Update(user *model.User) error {
-	args := m.Called(user)
+func (m *MockUserRepository) FindByEmail(ctx context.Context, email string) (*model.User, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockUserRepository) FindAll(ctx context.Context, query model.UserListQuery) ([]model.User, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.User), args.Error(1)
+}
+
+func (m *MockUserRepository) Restore(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) FindDeletedBefore(ctx context.Context, deletedBefore time.Time, limit int) ([]uint, error) {
+	args := m.Called(ctx, deletedBefore, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (m *MockUserRepository) Purge(ctx context.Context, id uint, deletedBefore time.Time) error {
+	args := m.Called(ctx, id, deletedBefore)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) Update(ctx context.Context, user *model.User) error {
+	args := m.Called(ctx, user)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) FindByPasswordResetToken(token string) (*model.User, error) {
-	args := m.Called(token)
+func (m *MockUserRepository) FindByPasswordResetToken(ctx context.Context, token string) (*model.User, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockUserRepository) FindByEmailVerificationToken(ctx context.Context, token string) (*model.User, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockUserRepository) FindByUnlockToken(ctx context.Context, token string) (*model.User, error) {
+	args := m.Called(ctx, token)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*model.User), args.Error(1)
 }
 
-func (m *MockUserRepository) Delete(id uint) error {
-	args := m.Called(id)
+func (m *MockUserRepository) RecordLoginFailure(ctx context.Context, id uint, at, resetBefore time.Time) (int, error) {
+	args := m.Called(ctx, id, at, resetBefore)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockUserRepository) Lock(ctx context.Context, id uint, lockedUntil time.Time, unlockToken string, unlockTokenExpires time.Time) error {
+	args := m.Called(ctx, id, lockedUntil, unlockToken, unlockTokenExpires)
 	return args.Error(0)
 }
 
-func TestUserUseCase_Register(t *testing.T) {
-	// JWT_SECRETの設定
-	os.Setenv("JWT_SECRET", "test-secret")
+func (m *MockUserRepository) ResetLoginFailures(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) SetMFASecret(ctx context.Context, id uint, secret string) error {
+	args := m.Called(ctx, id, secret)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) EnableMFA(ctx context.Context, id uint, enabledAt time.Time, step int64) error {
+	args := m.Called(ctx, id, enabledAt, step)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UseMFAStep(ctx context.Context, id uint, step int64) (bool, error) {
+	args := m.Called(ctx, id, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) DisableMFA(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) Delete(ctx context.Context, id uint, deletedAt time.Time) error {
+	args := m.Called(ctx, id, deletedAt)
+	return args.Error(0)
+}
+
+// MockTokenRevocationStore は、TokenRevocationStoreのモック実装です
+type MockTokenRevocationStore struct {
+	mock.Mock
+}
+
+func (m *MockTokenRevocationStore) RevokeToken(ctx context.Context, jti string, userID uint, expiresAt time.Time) error {
+	args := m.Called(ctx, jti, userID, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockTokenRevocationStore) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	args := m.Called(ctx, jti)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTokenRevocationStore) RevokeAllUserTokens(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockTokenRevocationStore) GetUserTokenVersion(ctx context.Context, userID uint) (uint, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(uint), args.Error(1)
+}
+
+func (m *MockTokenRevocationStore) RevokeSession(ctx context.Context, sessionID string) error {
+	args := m.Called(ctx, sessionID)
+	return args.Error(0)
+}
+
+func (m *MockTokenRevocationStore) IsSessionRevoked(ctx context.Context, sessionID string) (bool, error) {
+	args := m.Called(ctx, sessionID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTokenRevocationStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockTokenRevocationStore) DeleteAllByUserID(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+// stubTransactionManager は、トランザクションを使用せずにfnを実行するTransactionManagerです
+// fnが呼び出された回数を記録します
+type stubTransactionManager struct {
+	calls int
+}
+
+func (s *stubTransactionManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	s.calls++
+	return fn(ctx)
+}
+
+// MockMailer は、Mailerのモック実装です
+type MockMailer struct {
+	mock.Mock
+}
+
+func (m *MockMailer) Send(ctx context.Context, mail *model.Mail) error {
+	args := m.Called(ctx, mail)
+	return args.Error(0)
+}
+
+// MockRefreshTokenRepository は、RefreshTokenRepositoryのモック実装です
+type MockRefreshTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockRefreshTokenRepository) Create(ctx context.Context, token *model.RefreshToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*model.RefreshToken, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) MarkUsed(ctx context.Context, id uint) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	args := m.Called(ctx, familyID)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) DeleteAllByUserID(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) RevokeAllByUserID(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+// MockSessionRepository は、SessionRepositoryのモック実装です
+type MockSessionRepository struct {
+	mock.Mock
+}
+
+func (m *MockSessionRepository) Create(ctx context.Context, session *model.Session) error {
+	args := m.Called(ctx, session)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) FindByID(ctx context.Context, id string) (*model.Session, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) FindActiveByUserID(ctx context.Context, userID uint, now time.Time) ([]model.Session, error) {
+	args := m.Called(ctx, userID, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) Touch(ctx context.Context, id, userAgent, ipAddress string, lastSeenAt, expiresAt time.Time) error {
+	args := m.Called(ctx, id, userAgent, ipAddress, lastSeenAt, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) RevokeAllByUserID(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) DeleteAllByUserID(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// MockRecoveryCodeRepository は、RecoveryCodeRepositoryのモック実装です
+type MockRecoveryCodeRepository struct {
+	mock.Mock
+}
+
+func (m *MockRecoveryCodeRepository) ReplaceAll(ctx context.Context, userID uint, codeHashes []string) error {
+	args := m.Called(ctx, userID, codeHashes)
+	return args.Error(0)
+}
+
+func (m *MockRecoveryCodeRepository) Use(ctx context.Context, userID uint, codeHash string, usedAt time.Time) (bool, error) {
+	args := m.Called(ctx, userID, codeHash, usedAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRecoveryCodeRepository) DeleteAllByUserID(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+type MockIdentityRepository struct {
+	mock.Mock
+}
+
+func (m *MockIdentityRepository) Create(ctx context.Context, identity *model.Identity) error {
+	args := m.Called(ctx, identity)
+	return args.Error(0)
+}
+
+func (m *MockIdentityRepository) FindByProviderSubject(ctx context.Context, provider, subject string) (*model.Identity, error) {
+	args := m.Called(ctx, provider, subject)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Identity), args.Error(1)
+}
+
+func (m *MockIdentityRepository) FindByUserID(ctx context.Context, userID uint) ([]model.Identity, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Identity), args.Error(1)
+}
+
+func (m *MockIdentityRepository) Delete(ctx context.Context, userID uint, provider string) error {
+	args := m.Called(ctx, userID, provider)
+	return args.Error(0)
+}
+
+func (m *MockIdentityRepository) DeleteAllByUserID(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+type MockOIDCAuthRequestRepository struct {
+	mock.Mock
+}
+
+func (m *MockOIDCAuthRequestRepository) Create(ctx context.Context, request *model.OIDCAuthRequest, now time.Time) error {
+	args := m.Called(ctx, request, now)
+	return args.Error(0)
+}
 
+func (m *MockOIDCAuthRequestRepository) Consume(ctx context.Context, stateHash string) (*model.OIDCAuthRequest, error) {
+	args := m.Called(ctx, stateHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.OIDCAuthRequest), args.Error(1)
+}
+
+func TestUserUseCase_Register(t *testing.T) {
 	tests := []struct {
 		name          string
 		nameInput     string
 		emailInput    string
 		passwordInput string
-		mockSetup     func(*MockUserRepository)
+		languageInput model.Language
+		mockSetup     func(*MockUserRepository, *MockMailer)
 		expectedUser  *model.User
 		expectedError error
 	}{
@@ -73,15 +391,43 @@ func TestUserUseCase_Register(t *testing.T) {
 			nameInput:     "テストユーザー",
 			emailInput:    "test@example.com",
 			passwordInput: "password123",
-			mockSetup: func(mockRepo *MockUserRepository) {
+			mockSetup: func(mockRepo *MockUserRepository, mockMailer *MockMailer) {
 				// FindByEmailでユーザーが見つからない場合
-				mockRepo.On("FindByEmail", "test@example.com").Return(nil, errors.New("user not found"))
-				// Createでユーザー作成成功
-				mockRepo.On("Create", mock.AnythingOfType("*model.User")).Return(nil)
+				mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(nil, model.ErrNotFound)
+				// Createでユーザー作成成功（確認トークンが設定されていること）
+				mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(u *model.User) bool {
+					return u.EmailVerificationToken != nil && u.EmailVerifiedAt == nil
+				})).Return(nil)
+				// 確認メールが送信されること
+				mockMailer.On("Send", mock.Anything, mock.MatchedBy(func(mail *model.Mail) bool {
+					return mail.To == "test@example.com" && strings.Contains(mail.TextBody, "http://localhost:3000/verify-email?token=")
+				})).Return(nil)
 			},
 			expectedUser: &model.User{
-				Name:  "テストユーザー",
-				Email: "test@example.com",
+				Name:              "テストユーザー",
+				Email:             "test@example.com",
+				PreferredLanguage: model.LanguageJapanese,
+			},
+			expectedError: nil,
+		},
+		{
+			name:          "英語を指定したユーザー登録",
+			nameInput:     "Test User",
+			emailInput:    "en@example.com",
+			passwordInput: "password123",
+			languageInput: model.LanguageEnglish,
+			mockSetup: func(mockRepo *MockUserRepository, mockMailer *MockMailer) {
+				mockRepo.On("FindByEmail", mock.Anything, "en@example.com").Return(nil, model.ErrNotFound)
+				mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.User")).Return(nil)
+				// 確認メールが英語で送信されること
+				mockMailer.On("Send", mock.Anything, mock.MatchedBy(func(mail *model.Mail) bool {
+					return mail.Subject == "[Voice Link] Confirm your email address"
+				})).Return(nil)
+			},
+			expectedUser: &model.User{
+				Name:              "Test User",
+				Email:             "en@example.com",
+				PreferredLanguage: model.LanguageEnglish,
 			},
 			expectedError: nil,
 		},
@@ -90,13 +436,26 @@ func TestUserUseCase_Register(t *testing.T) {
 			nameInput:     "テストユーザー",
 			emailInput:    "existing@example.com",
 			passwordInput: "password123",
-			mockSetup: func(mockRepo *MockUserRepository) {
+			mockSetup: func(mockRepo *MockUserRepository, mockMailer *MockMailer) {
 				existingUser := &model.User{
 					ID:    1,
 					Name:  "既存ユーザー",
 					Email: "existing@example.com",
 				}
-				mockRepo.On("FindByEmail", "existing@example.com").Return(existingUser, nil)
+				mockRepo.On("FindByEmail", mock.Anything, "existing@example.com").Return(existingUser, nil)
+			},
+			expectedUser:  nil,
+			expectedError: errors.New("email already exists"),
+		},
+		{
+			name:          "同時に登録されたメールアドレスの重複",
+			nameInput:     "テストユーザー",
+			emailInput:    "race@example.com",
+			passwordInput: "password123",
+			mockSetup: func(mockRepo *MockUserRepository, mockMailer *MockMailer) {
+				// 重複チェックの後に他のリクエストで登録され、一意制約の違反になった場合
+				mockRepo.On("FindByEmail", mock.Anything, "race@example.com").Return(nil, model.ErrNotFound)
+				mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.User")).Return(model.ErrDuplicateEmail)
 			},
 			expectedUser:  nil,
 			expectedError: errors.New("email already exists"),
@@ -107,13 +466,15 @@ func TestUserUseCase_Register(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// モックの設定
 			mockRepo := new(MockUserRepository)
-			tt.mockSetup(mockRepo)
+			mockMailer := new(MockMailer)
+			tt.mockSetup(mockRepo, mockMailer)
+			transactions := new(stubTransactionManager)
 
 			// ユースケースの作成
-			useCase := NewUserUseCase(mockRepo)
+			useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), transactions, mockMailer, nil, testUserUseCaseConfig)
 
 			// テスト実行
-			user, err := useCase.Register(tt.nameInput, tt.emailInput, tt.passwordInput)
+			user, err := useCase.Register(context.Background(), tt.nameInput, tt.emailInput, tt.passwordInput, tt.languageInput)
 
 			// アサーション
 			if tt.expectedError != nil {
@@ -126,22 +487,24 @@ func TestUserUseCase_Register(t *testing.T) {
 				assert.Equal(t, tt.expectedUser.Name, user.Name)
 				assert.Equal(t, tt.expectedUser.Email, user.Email)
 				assert.NotEmpty(t, user.Password) // パスワードがハッシュ化されていることを確認
+				assert.Equal(t, model.RoleUser, user.Role)
+				assert.Equal(t, tt.expectedUser.PreferredLanguage, user.PreferredLanguage)
 			}
+			// 重複チェックと作成は1つのトランザクションで行う
+			assert.Equal(t, 1, transactions.calls)
 
 			mockRepo.AssertExpectations(t)
+			mockMailer.AssertExpectations(t)
 		})
 	}
 }
 
 func TestUserUseCase_Login(t *testing.T) {
-	// JWT_SECRETの設定
-	os.Setenv("JWT_SECRET", "test-secret")
-
 	tests := []struct {
 		name          string
 		emailInput    string
 		passwordInput string
-		mockSetup     func(*MockUserRepository)
+		mockSetup     func(*MockUserRepository, *MockRefreshTokenRepository, *MockSessionRepository, *MockTokenRevocationStore)
 		expectedToken string
 		expectedError error
 	}{
@@ -149,7 +512,7 @@ func TestUserUseCase_Login(t *testing.T) {
 			name:          "正常なログイン",
 			emailInput:    "test@example.com",
 			passwordInput: "password123",
-			mockSetup: func(mockRepo *MockUserRepository) {
+			mockSetup: func(mockRepo *MockUserRepository, mockTokenRepo *MockRefreshTokenRepository, mockSessions *MockSessionRepository, mockRevocations *MockTokenRevocationStore) {
 				// ハッシュ化されたパスワードを作成
 				hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
 				user := &model.User{
@@ -158,7 +521,19 @@ func TestUserUseCase_Login(t *testing.T) {
 					Email:    "test@example.com",
 					Password: string(hashedPassword),
 				}
-				mockRepo.On("FindByEmail", "test@example.com").Return(user, nil)
+				mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(user, nil)
+				// ログインした端末のセッションを記録し、セッションのIDをトークンファミリーに使用する
+				var sessionID string
+				mockSessions.On("Create", mock.Anything, mock.MatchedBy(func(session *model.Session) bool {
+					sessionID = session.ID
+					return session.ID != "" && session.UserID == 1 && session.DeviceName == "Pixel 8" &&
+						session.UserAgent == "VoiceLink/1.0 (Android)" && session.IPAddress == "203.0.113.5"
+				})).Return(nil)
+				// トークン世代の取得とリフレッシュトークンの保存
+				mockRevocations.On("GetUserTokenVersion", mock.Anything, uint(1)).Return(uint(0), nil)
+				mockTokenRepo.On("Create", mock.Anything, mock.MatchedBy(func(token *model.RefreshToken) bool {
+					return token.FamilyID == sessionID
+				})).Return(nil)
 			},
 			expectedToken: "", // 実際のトークンは動的に生成されるため空文字
 			expectedError: nil,
@@ -167,8 +542,8 @@ func TestUserUseCase_Login(t *testing.T) {
 			name:          "ユーザーが見つからない",
 			emailInput:    "nonexistent@example.com",
 			passwordInput: "password123",
-			mockSetup: func(mockRepo *MockUserRepository) {
-				mockRepo.On("FindByEmail", "nonexistent@example.com").Return(nil, errors.New("user not found"))
+			mockSetup: func(mockRepo *MockUserRepository, mockTokenRepo *MockRefreshTokenRepository, mockSessions *MockSessionRepository, mockRevocations *MockTokenRevocationStore) {
+				mockRepo.On("FindByEmail", mock.Anything, "nonexistent@example.com").Return(nil, model.ErrNotFound)
 			},
 			expectedToken: "",
 			expectedError: errors.New("invalid email or password"),
@@ -177,7 +552,7 @@ func TestUserUseCase_Login(t *testing.T) {
 			name:          "パスワードが間違っている",
 			emailInput:    "test@example.com",
 			passwordInput: "wrongpassword",
-			mockSetup: func(mockRepo *MockUserRepository) {
+			mockSetup: func(mockRepo *MockUserRepository, mockTokenRepo *MockRefreshTokenRepository, mockSessions *MockSessionRepository, mockRevocations *MockTokenRevocationStore) {
 				// 正しいパスワードでハッシュ化
 				hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
 				user := &model.User{
@@ -186,7 +561,9 @@ func TestUserUseCase_Login(t *testing.T) {
 					Email:    "test@example.com",
 					Password: string(hashedPassword),
 				}
-				mockRepo.On("FindByEmail", "test@example.com").Return(user, nil)
+				mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(user, nil)
+				// 連続した失敗として記録する
+				mockRepo.On("RecordLoginFailure", mock.Anything, uint(1), mock.Anything, mock.Anything).Return(1, nil)
 			},
 			expectedToken: "",
 			expectedError: errors.New("invalid email or password"),
@@ -197,27 +574,37 @@ func TestUserUseCase_Login(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// モックの設定
 			mockRepo := new(MockUserRepository)
-			tt.mockSetup(mockRepo)
+			mockTokenRepo := new(MockRefreshTokenRepository)
+			mockSessions := new(MockSessionRepository)
+			mockRevocations := new(MockTokenRevocationStore)
+			tt.mockSetup(mockRepo, mockTokenRepo, mockSessions, mockRevocations)
 
 			// ユースケースの作成
-			useCase := NewUserUseCase(mockRepo)
+			useCase := NewUserUseCase(mockRepo, mockTokenRepo, mockSessions, new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), mockRevocations, new(stubTransactionManager), new(MockMailer), nil, testUserUseCaseConfig)
 
 			// テスト実行
-			token, err := useCase.Login(tt.emailInput, tt.passwordInput)
+			result, err := useCase.Login(context.Background(), tt.emailInput, tt.passwordInput, ClientInfo{DeviceName: "Pixel 8", UserAgent: "VoiceLink/1.0 (Android)", IPAddress: "203.0.113.5"})
 
 			// アサーション
 			if tt.expectedError != nil {
 				assert.Error(t, err)
 				assert.Equal(t, tt.expectedError.Error(), err.Error())
-				assert.Empty(t, token)
+				assert.Nil(t, result)
 			} else {
 				assert.NoError(t, err)
-				assert.NotEmpty(t, token)
+				assert.Nil(t, result.MFAChallenge)
+				tokens := result.Tokens
+				assert.NotNil(t, tokens)
 				// JWTトークンの形式を簡単にチェック（.で区切られている）
-				assert.Contains(t, token, ".")
+				assert.Contains(t, tokens.AccessToken, ".")
+				assert.NotEmpty(t, tokens.RefreshToken)
+				assert.Equal(t, int64(accessTokenTTL.Seconds()), tokens.ExpiresIn)
 			}
 
 			mockRepo.AssertExpectations(t)
+			mockTokenRepo.AssertExpectations(t)
+			mockSessions.AssertExpectations(t)
+			mockRevocations.AssertExpectations(t)
 		})
 	}
 }
@@ -239,7 +626,7 @@ func TestUserUseCase_GetByID(t *testing.T) {
 					Name:  "テストユーザー",
 					Email: "test@example.com",
 				}
-				mockRepo.On("FindByID", uint(1)).Return(user, nil)
+				mockRepo.On("FindByID", mock.Anything, uint(1)).Return(user, nil)
 			},
 			expectedUser: &model.User{
 				ID:    1,
@@ -252,10 +639,10 @@ func TestUserUseCase_GetByID(t *testing.T) {
 			name:    "ユーザーが見つからない",
 			idInput: 999,
 			mockSetup: func(mockRepo *MockUserRepository) {
-				mockRepo.On("FindByID", uint(999)).Return(nil, errors.New("user not found"))
+				mockRepo.On("FindByID", mock.Anything, uint(999)).Return(nil, model.ErrNotFound)
 			},
 			expectedUser:  nil,
-			expectedError: errors.New("user not found"),
+			expectedError: ErrUserNotFound,
 		},
 	}
 
@@ -266,10 +653,10 @@ func TestUserUseCase_GetByID(t *testing.T) {
 			tt.mockSetup(mockRepo)
 
 			// ユースケースの作成
-			useCase := NewUserUseCase(mockRepo)
+			useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), new(MockMailer), nil, testUserUseCaseConfig)
 
 			// テスト実行
-			user, err := useCase.GetByID(tt.idInput)
+			user, err := useCase.GetByID(context.Background(), tt.idInput)
 
 			// アサーション
 			if tt.expectedError != nil {
@@ -295,7 +682,8 @@ func TestUserUseCase_UpdateUser(t *testing.T) {
 		idInput       uint
 		nameInput     string
 		emailInput    string
-		mockSetup     func(*MockUserRepository)
+		languageInput model.Language
+		mockSetup     func(*MockUserRepository, *MockMailer)
 		expectedUser  *model.User
 		expectedError error
 	}{
@@ -303,33 +691,114 @@ func TestUserUseCase_UpdateUser(t *testing.T) {
 			name:       "正常なユーザー更新",
 			idInput:    1,
 			nameInput:  "更新されたユーザー",
-			emailInput: "updated@example.com",
-			mockSetup: func(mockRepo *MockUserRepository) {
+			emailInput: "original@example.com",
+			mockSetup: func(mockRepo *MockUserRepository, mockMailer *MockMailer) {
 				user := &model.User{
 					ID:    1,
 					Name:  "元のユーザー",
 					Email: "original@example.com",
 				}
-				mockRepo.On("FindByID", uint(1)).Return(user, nil)
-				mockRepo.On("Update", mock.AnythingOfType("*model.User")).Return(nil)
+				mockRepo.On("FindByID", mock.Anything, uint(1)).Return(user, nil)
+				mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*model.User")).Return(nil)
 			},
 			expectedUser: &model.User{
 				ID:    1,
 				Name:  "更新されたユーザー",
-				Email: "updated@example.com",
+				Email: "original@example.com",
+			},
+			expectedError: nil,
+		},
+		{
+			name:       "メールアドレスの変更は確認待ちになる",
+			idInput:    1,
+			nameInput:  "元のユーザー",
+			emailInput: "updated@example.com",
+			mockSetup: func(mockRepo *MockUserRepository, mockMailer *MockMailer) {
+				user := &model.User{
+					ID:    1,
+					Name:  "元のユーザー",
+					Email: "original@example.com",
+				}
+				mockRepo.On("FindByID", mock.Anything, uint(1)).Return(user, nil)
+				mockRepo.On("FindByEmail", mock.Anything, "updated@example.com").Return(nil, model.ErrNotFound)
+				mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(u *model.User) bool {
+					return u.PendingEmail != nil && *u.PendingEmail == "updated@example.com" && u.EmailVerificationToken != nil
+				})).Return(nil)
+				// 確認メールは変更後のアドレスに送信されること
+				mockMailer.On("Send", mock.Anything, mock.MatchedBy(func(mail *model.Mail) bool {
+					return mail.To == "updated@example.com"
+				})).Return(nil)
+			},
+			expectedUser: &model.User{
+				ID:    1,
+				Name:  "元のユーザー",
+				Email: "original@example.com",
+			},
+			expectedError: nil,
+		},
+		{
+			name:       "変更後のメールアドレスが既に使用されている",
+			idInput:    1,
+			nameInput:  "元のユーザー",
+			emailInput: "existing@example.com",
+			mockSetup: func(mockRepo *MockUserRepository, mockMailer *MockMailer) {
+				user := &model.User{
+					ID:    1,
+					Name:  "元のユーザー",
+					Email: "original@example.com",
+				}
+				mockRepo.On("FindByID", mock.Anything, uint(1)).Return(user, nil)
+				mockRepo.On("FindByEmail", mock.Anything, "existing@example.com").Return(&model.User{ID: 2, Email: "existing@example.com"}, nil)
+			},
+			expectedUser:  nil,
+			expectedError: errors.New("email already exists"),
+		},
+		{
+			name:          "言語の変更",
+			idInput:       1,
+			nameInput:     "元のユーザー",
+			emailInput:    "original@example.com",
+			languageInput: model.LanguageEnglish,
+			mockSetup: func(mockRepo *MockUserRepository, mockMailer *MockMailer) {
+				user := &model.User{
+					ID:                1,
+					Name:              "元のユーザー",
+					Email:             "original@example.com",
+					PreferredLanguage: model.LanguageJapanese,
+				}
+				mockRepo.On("FindByID", mock.Anything, uint(1)).Return(user, nil)
+				mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(u *model.User) bool {
+					return u.PreferredLanguage == model.LanguageEnglish
+				})).Return(nil)
+			},
+			expectedUser: &model.User{
+				ID:                1,
+				Name:              "元のユーザー",
+				Email:             "original@example.com",
+				PreferredLanguage: model.LanguageEnglish,
 			},
 			expectedError: nil,
 		},
+		{
+			name:          "対応していない言語",
+			idInput:       1,
+			nameInput:     "元のユーザー",
+			emailInput:    "original@example.com",
+			languageInput: "fr",
+			mockSetup:     func(mockRepo *MockUserRepository, mockMailer *MockMailer) {},
+			expectedUser:  nil,
+			expectedError: ErrUnsupportedLanguage,
+		},
 		{
 			name:       "ユーザーが見つからない",
 			idInput:    999,
 			nameInput:  "更新されたユーザー",
 			emailInput: "updated@example.com",
-			mockSetup: func(mockRepo *MockUserRepository) {
-				mockRepo.On("FindByID", uint(999)).Return(nil, errors.New("user not found"))
+			mockSetup: func(mockRepo *MockUserRepository, mockMailer *MockMailer) {
+				mockRepo.On("FindByID", mock.Anything, uint(999)).Return(nil, model.ErrNotFound)
 			},
 			expectedUser:  nil,
-			expectedError: errors.New("user not found"),
+			expectedError: ErrUserNotFound,
 		},
 	}
 
@@ -337,13 +806,14 @@ func TestUserUseCase_UpdateUser(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// モックの設定
 			mockRepo := new(MockUserRepository)
-			tt.mockSetup(mockRepo)
+			mockMailer := new(MockMailer)
+			tt.mockSetup(mockRepo, mockMailer)
 
 			// ユースケースの作成
-			useCase := NewUserUseCase(mockRepo)
+			useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), mockMailer, nil, testUserUseCaseConfig)
 
 			// テスト実行
-			user, err := useCase.UpdateUser(tt.idInput, tt.nameInput, tt.emailInput)
+			user, err := useCase.UpdateUser(context.Background(), tt.idInput, tt.nameInput, tt.emailInput, tt.languageInput)
 
 			// アサーション
 			if tt.expectedError != nil {
@@ -356,35 +826,47 @@ func TestUserUseCase_UpdateUser(t *testing.T) {
 				assert.Equal(t, tt.expectedUser.ID, user.ID)
 				assert.Equal(t, tt.expectedUser.Name, user.Name)
 				assert.Equal(t, tt.expectedUser.Email, user.Email)
+				if tt.expectedUser.PreferredLanguage != "" {
+					assert.Equal(t, tt.expectedUser.PreferredLanguage, user.PreferredLanguage)
+				}
 			}
 
 			mockRepo.AssertExpectations(t)
+			mockMailer.AssertExpectations(t)
 		})
 	}
 }
 
 func TestUserUseCase_DeleteUser(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	config := testUserUseCaseConfig
+	config.Clock = &fakeClock{now: now}
+
 	tests := []struct {
 		name          string
 		idInput       uint
-		mockSetup     func(*MockUserRepository)
+		mockSetup     func(*MockUserRepository, *MockRefreshTokenRepository, *MockTokenRevocationStore)
 		expectedError error
 	}{
 		{
 			name:    "正常なユーザー削除",
 			idInput: 1,
-			mockSetup: func(mockRepo *MockUserRepository) {
-				mockRepo.On("Delete", uint(1)).Return(nil)
+			mockSetup: func(mockRepo *MockUserRepository, mockTokenRepo *MockRefreshTokenRepository, mockRevocations *MockTokenRevocationStore) {
+				// 退会した日時には時刻の取得元の時刻を記録する
+				mockRepo.On("Delete", mock.Anything, uint(1), now).Return(nil)
+				// 退会したユーザーのトークンはすべて失効させる
+				mockRevocations.On("RevokeAllUserTokens", mock.Anything, uint(1)).Return(nil)
+				mockTokenRepo.On("RevokeAllByUserID", mock.Anything, uint(1)).Return(nil)
 			},
 			expectedError: nil,
 		},
 		{
 			name:    "ユーザーが見つからない",
 			idInput: 999,
-			mockSetup: func(mockRepo *MockUserRepository) {
-				mockRepo.On("Delete", uint(999)).Return(errors.New("user not found"))
+			mockSetup: func(mockRepo *MockUserRepository, mockTokenRepo *MockRefreshTokenRepository, mockRevocations *MockTokenRevocationStore) {
+				mockRepo.On("Delete", mock.Anything, uint(999), now).Return(model.ErrNotFound)
 			},
-			expectedError: errors.New("user not found"),
+			expectedError: ErrUserNotFound,
 		},
 	}
 
@@ -392,13 +874,201 @@ func TestUserUseCase_DeleteUser(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// モックの設定
 			mockRepo := new(MockUserRepository)
-			tt.mockSetup(mockRepo)
+			mockTokenRepo := new(MockRefreshTokenRepository)
+			mockRevocations := new(MockTokenRevocationStore)
+			tt.mockSetup(mockRepo, mockTokenRepo, mockRevocations)
+			// トークンと同時にセッションも失効させる
+			mockSessions := new(MockSessionRepository)
+			mockSessions.On("RevokeAllByUserID", mock.Anything, mock.Anything).Return(nil)
+
+			// ユースケースの作成
+			useCase := NewUserUseCase(mockRepo, mockTokenRepo, mockSessions, new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), mockRevocations, new(stubTransactionManager), new(MockMailer), nil, config)
+
+			// テスト実行
+			err := useCase.DeleteUser(context.Background(), tt.idInput)
+
+			// アサーション
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockRepo.AssertExpectations(t)
+			mockTokenRepo.AssertExpectations(t)
+			mockRevocations.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserUseCase_ResetPassword(t *testing.T) {
+	// 期限はシステムの時刻ではなく、時刻の取得元の時刻で判定する
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	config := testUserUseCaseConfig
+	config.Clock = &fakeClock{now: now}
+
+	tests := []struct {
+		name          string
+		tokenInput    string
+		mockSetup     func(*MockUserRepository, *MockRefreshTokenRepository, *MockTokenRevocationStore)
+		expectedError error
+	}{
+		{
+			name:       "正常なパスワードリセット",
+			tokenInput: "valid-token",
+			mockSetup: func(mockRepo *MockUserRepository, mockTokenRepo *MockRefreshTokenRepository, mockRevocations *MockTokenRevocationStore) {
+				expires := now.Add(time.Hour)
+				token := "valid-token"
+				user := &model.User{
+					ID:                   1,
+					Email:                "test@example.com",
+					PasswordResetToken:   &token,
+					PasswordResetExpires: &expires,
+				}
+				mockRepo.On("FindByPasswordResetToken", mock.Anything, "valid-token").Return(user, nil)
+				mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(u *model.User) bool {
+					return u.PasswordResetToken == nil && u.PasswordResetExpires == nil
+				})).Return(nil)
+				// 既存のトークンがすべて失効されること
+				mockRevocations.On("RevokeAllUserTokens", mock.Anything, uint(1)).Return(nil)
+				mockTokenRepo.On("RevokeAllByUserID", mock.Anything, uint(1)).Return(nil)
+			},
+			expectedError: nil,
+		},
+		{
+			name:       "無効なトークン",
+			tokenInput: "invalid-token",
+			mockSetup: func(mockRepo *MockUserRepository, mockTokenRepo *MockRefreshTokenRepository, mockRevocations *MockTokenRevocationStore) {
+				mockRepo.On("FindByPasswordResetToken", mock.Anything, "invalid-token").Return(nil, model.ErrNotFound)
+			},
+			expectedError: errors.New("invalid or expired reset token"),
+		},
+		{
+			name:       "期限切れトークン",
+			tokenInput: "expired-token",
+			mockSetup: func(mockRepo *MockUserRepository, mockTokenRepo *MockRefreshTokenRepository, mockRevocations *MockTokenRevocationStore) {
+				expires := now.Add(-time.Hour)
+				token := "expired-token"
+				user := &model.User{
+					ID:                   1,
+					PasswordResetToken:   &token,
+					PasswordResetExpires: &expires,
+				}
+				mockRepo.On("FindByPasswordResetToken", mock.Anything, "expired-token").Return(user, nil)
+			},
+			expectedError: errors.New("reset token has expired"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// モックの設定
+			mockRepo := new(MockUserRepository)
+			mockTokenRepo := new(MockRefreshTokenRepository)
+			mockRevocations := new(MockTokenRevocationStore)
+			tt.mockSetup(mockRepo, mockTokenRepo, mockRevocations)
+			// トークンと同時にセッションも失効させる
+			mockSessions := new(MockSessionRepository)
+			mockSessions.On("RevokeAllByUserID", mock.Anything, mock.Anything).Return(nil)
+
+			// ユースケースの作成
+			useCase := NewUserUseCase(mockRepo, mockTokenRepo, mockSessions, new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), mockRevocations, new(stubTransactionManager), new(MockMailer), nil, config)
+
+			// テスト実行
+			err := useCase.ResetPassword(context.Background(), tt.tokenInput, "newpassword123")
+
+			// アサーション
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockRepo.AssertExpectations(t)
+			mockTokenRepo.AssertExpectations(t)
+			mockRevocations.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserUseCase_RequestPasswordReset(t *testing.T) {
+	// 期限はシステムの時刻ではなく、時刻の取得元の時刻から設定する
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	config := testUserUseCaseConfig
+	config.Clock = &fakeClock{now: now}
+
+	tests := []struct {
+		name          string
+		emailInput    string
+		mockSetup     func(*MockUserRepository, *MockMailer)
+		expectedError error
+	}{
+		{
+			name:       "正常なパスワードリセットリクエスト",
+			emailInput: "test@example.com",
+			mockSetup: func(mockRepo *MockUserRepository, mockMailer *MockMailer) {
+				user := &model.User{
+					ID:    1,
+					Name:  "テストユーザー",
+					Email: "test@example.com",
+				}
+				mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(user, nil)
+				mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(u *model.User) bool {
+					return u.PasswordResetToken != nil && u.PasswordResetExpires != nil && u.PasswordResetExpires.Equal(now.Add(passwordResetTTL))
+				})).Return(nil)
+				// リセット用のリンクを含むメールが送信されること
+				mockMailer.On("Send", mock.Anything, mock.MatchedBy(func(mail *model.Mail) bool {
+					token := *user.PasswordResetToken
+					link := "http://localhost:3000/reset-password?token=" + token
+					return mail.To == "test@example.com" &&
+						strings.Contains(mail.TextBody, link) &&
+						strings.Contains(mail.HTMLBody, link) &&
+						strings.Contains(mail.TextBody, "テストユーザー")
+				})).Return(nil)
+			},
+			expectedError: nil,
+		},
+		{
+			name:       "存在しないユーザー",
+			emailInput: "nonexistent@example.com",
+			mockSetup: func(mockRepo *MockUserRepository, mockMailer *MockMailer) {
+				// ユーザーが存在しない場合もエラーを返さず、メールも送信しない
+				mockRepo.On("FindByEmail", mock.Anything, "nonexistent@example.com").Return(nil, model.ErrNotFound)
+			},
+			expectedError: nil,
+		},
+		{
+			name:       "メール送信エラー",
+			emailInput: "test@example.com",
+			mockSetup: func(mockRepo *MockUserRepository, mockMailer *MockMailer) {
+				user := &model.User{
+					ID:    1,
+					Name:  "テストユーザー",
+					Email: "test@example.com",
+				}
+				mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(user, nil)
+				mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*model.User")).Return(nil)
+				// 送信に失敗してもユーザーの存在を判別できないようエラーを返さない
+				mockMailer.On("Send", mock.Anything, mock.AnythingOfType("*model.Mail")).Return(errors.New("smtp error"))
+			},
+			expectedError: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// モックの設定
+			mockRepo := new(MockUserRepository)
+			mockMailer := new(MockMailer)
+			tt.mockSetup(mockRepo, mockMailer)
 
 			// ユースケースの作成
-			useCase := NewUserUseCase(mockRepo)
+			useCase := NewUserUseCase(mockRepo, new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockRecoveryCodeRepository), new(MockIdentityRepository), new(MockOIDCAuthRequestRepository), new(MockTokenRevocationStore), new(stubTransactionManager), mockMailer, nil, config)
 
 			// テスト実行
-			err := useCase.DeleteUser(tt.idInput)
+			err := useCase.RequestPasswordReset(context.Background(), tt.emailInput)
 
 			// アサーション
 			if tt.expectedError != nil {
@@ -409,6 +1079,7 @@ func TestUserUseCase_DeleteUser(t *testing.T) {
 			}
 
 			mockRepo.AssertExpectations(t)
+			mockMailer.AssertExpectations(t)
 		})
 	}
 }